@@ -18,13 +18,24 @@ import (
 
 	"github.com/joho/godotenv"
 	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
+	jwtaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/jwt_access"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/quota"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cmd"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/contextguard"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ledger"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redact"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsecache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/shadow"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sharedstate"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/store"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/tui"
@@ -73,6 +84,8 @@ func main() {
 	var tuiMode bool
 	var standalone bool
 	var localModel bool
+	var rotateAuthKey bool
+	var encryptAuthFiles bool
 
 	// Define command-line flags for different operation modes.
 	flag.BoolVar(&login, "login", false, "Login Google Account")
@@ -91,6 +104,8 @@ func main() {
 	flag.BoolVar(&tuiMode, "tui", false, "Start with terminal management UI")
 	flag.BoolVar(&standalone, "standalone", false, "In TUI mode, start an embedded local server")
 	flag.BoolVar(&localModel, "local-model", false, "Use embedded model catalog only, skip remote model fetching")
+	flag.BoolVar(&rotateAuthKey, "rotate-auth-key", false, "Rotate the auth encryption key and re-encrypt all auth files")
+	flag.BoolVar(&encryptAuthFiles, "encrypt-auth-files", false, "Encrypt plaintext auth files with the current auth encryption key")
 
 	flag.CommandLine.Usage = func() {
 		out := flag.CommandLine.Output()
@@ -131,6 +146,8 @@ func main() {
 		pgStoreDSN           string
 		pgStoreSchema        string
 		pgStoreLocalPath     string
+		pgStoreChannel       string
+		pgStoreSyncInterval  time.Duration
 		pgStoreInst          *store.PostgresStore
 		useGitStore          bool
 		gitStoreRemoteURL    string
@@ -140,6 +157,8 @@ func main() {
 		gitStoreLocalPath    string
 		gitStoreInst         *store.GitTokenStore
 		gitStoreRoot         string
+		gitStoreSyncInterval time.Duration
+		gitStoreSyncPolicy   string
 		useObjectStore       bool
 		objectStoreEndpoint  string
 		objectStoreAccess    string
@@ -147,6 +166,8 @@ func main() {
 		objectStoreBucket    string
 		objectStoreLocalPath string
 		objectStoreInst      *store.ObjectTokenStore
+		objectSyncInterval   time.Duration
+		objectSyncPolicy     string
 	)
 
 	wd, err := os.Getwd()
@@ -184,6 +205,16 @@ func main() {
 		if value, ok := lookupEnv("PGSTORE_LOCAL_PATH", "pgstore_local_path"); ok {
 			pgStoreLocalPath = value
 		}
+		if value, ok := lookupEnv("PGSTORE_NOTIFY_CHANNEL", "pgstore_notify_channel"); ok {
+			pgStoreChannel = value
+		}
+		if value, ok := lookupEnv("PGSTORE_SYNC_INTERVAL", "pgstore_sync_interval"); ok {
+			if parsed, errParse := time.ParseDuration(value); errParse == nil && parsed > 0 {
+				pgStoreSyncInterval = parsed
+			} else {
+				log.Warnf("invalid PGSTORE_SYNC_INTERVAL %q, using %s", value, store.DefaultPostgresSyncInterval)
+			}
+		}
 		if pgStoreLocalPath == "" {
 			if writableBase != "" {
 				pgStoreLocalPath = writableBase
@@ -225,6 +256,39 @@ func main() {
 	if value, ok := lookupEnv("OBJECTSTORE_LOCAL_PATH", "objectstore_local_path"); ok {
 		objectStoreLocalPath = value
 	}
+	// Remote pulls are off unless an interval is set; the policy also applies to manual pulls.
+	remoteSyncEnv := func(prefix string) (time.Duration, string) {
+		var interval time.Duration
+		if value, ok := lookupEnv(prefix+"_SYNC_INTERVAL", strings.ToLower(prefix)+"_sync_interval"); ok {
+			if parsed, errParse := time.ParseDuration(value); errParse == nil && parsed > 0 {
+				interval = parsed
+			} else {
+				log.Warnf("invalid %s_SYNC_INTERVAL %q, remote pull disabled", prefix, value)
+			}
+		}
+		raw, _ := lookupEnv(prefix+"_SYNC_POLICY", strings.ToLower(prefix)+"_sync_policy")
+		policy, errPolicy := store.ParseSyncPolicy(raw)
+		if errPolicy != nil {
+			log.Warnf("invalid %s_SYNC_POLICY: %v, using %s", prefix, errPolicy, store.SyncPolicyRemoteWins)
+			policy = store.SyncPolicyRemoteWins
+		}
+		return interval, policy
+	}
+	gitStoreSyncInterval, gitStoreSyncPolicy = remoteSyncEnv("GITSTORE")
+	objectSyncInterval, objectSyncPolicy = remoteSyncEnv("OBJECTSTORE")
+
+	// Encrypt auth records at rest when a master key is configured.
+	authEncryptionKey, _ := lookupEnv("AUTHSTORE_ENCRYPTION_KEY", "authstore_encryption_key")
+	authEncryptionKeyFile, _ := lookupEnv("AUTHSTORE_ENCRYPTION_KEY_FILE", "authstore_encryption_key_file")
+	keyring, errKeyring := authcrypt.NewKeyring(authEncryptionKey, authEncryptionKeyFile)
+	if errKeyring != nil {
+		log.Errorf("failed to load auth encryption key: %v", errKeyring)
+		return
+	}
+	if keyring != nil {
+		authcrypt.Configure(keyring)
+		log.Infof("auth encryption enabled (active key %s)", keyring.ActiveKeyID())
+	}
 
 	// Check for cloud deploy mode only on first execution
 	// Read env var name in uppercase: DEPLOY
@@ -243,9 +307,10 @@ func main() {
 		pgStoreLocalPath = filepath.Join(pgStoreLocalPath, "pgstore")
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		pgStoreInst, err = store.NewPostgresStore(ctx, store.PostgresStoreConfig{
-			DSN:      pgStoreDSN,
-			Schema:   pgStoreSchema,
-			SpoolDir: pgStoreLocalPath,
+			DSN:           pgStoreDSN,
+			Schema:        pgStoreSchema,
+			SpoolDir:      pgStoreLocalPath,
+			NotifyChannel: pgStoreChannel,
 		})
 		cancel()
 		if err != nil {
@@ -455,14 +520,144 @@ func main() {
 		sdkAuth.RegisterTokenStore(sdkAuth.NewFileTokenStore())
 	}
 
+	// Register the Responses API conversation store used for previous_response_id.
+	if cfg.ResponsesStore.Enable {
+		responsesTTL := responsestore.ParseTTL(cfg.ResponsesStore.TTL)
+		switch strings.ToLower(strings.TrimSpace(cfg.ResponsesStore.Backend)) {
+		case "postgres":
+			if usePostgresStore {
+				responsestore.RegisterStore(store.NewPostgresResponseStore(pgStoreInst, responsesTTL))
+			} else {
+				log.Warn("responses-store backend postgres requires PGSTORE_DSN; falling back to memory")
+				responsestore.RegisterStore(responsestore.NewMemoryStore(responsesTTL))
+			}
+		case "file":
+			responsesDir := strings.TrimSpace(cfg.ResponsesStore.Dir)
+			if responsesDir == "" {
+				responsesDir = util.ResolveDataDirectory("responses")
+			}
+			fileStore, errFileStore := responsestore.NewFileStore(responsesDir, responsesTTL)
+			if errFileStore != nil {
+				log.Errorf("failed to initialize responses store: %v", errFileStore)
+				return
+			}
+			responsestore.RegisterStore(fileStore)
+		default:
+			responsestore.RegisterStore(responsestore.NewMemoryStore(responsesTTL))
+		}
+	}
+
+	// Build the response cache used for opted-in requests.
+	if cfg.ResponseCache.Enable {
+		cacheTTL := responsecache.ParseTTL(cfg.ResponseCache.TTL)
+		var cacheBackend responsecache.Store
+		backendName := strings.ToLower(strings.TrimSpace(cfg.ResponseCache.Backend))
+		switch backendName {
+		case "postgres":
+			if usePostgresStore {
+				cacheBackend = store.NewPostgresResponseCacheStore(pgStoreInst)
+			} else {
+				log.Warn("response-cache backend postgres requires PGSTORE_DSN; falling back to memory")
+				backendName = "memory"
+			}
+		case "file":
+			cacheDir := strings.TrimSpace(cfg.ResponseCache.Dir)
+			if cacheDir == "" {
+				cacheDir = util.ResolveDataDirectory("response-cache")
+			}
+			fileStore, errFileStore := responsecache.NewFileStore(cacheDir)
+			if errFileStore != nil {
+				log.Errorf("failed to initialize response cache: %v", errFileStore)
+				return
+			}
+			cacheBackend = fileStore
+		default:
+			backendName = "memory"
+		}
+		responsecache.Register(responsecache.New(cacheTTL, cfg.ResponseCache.MaxEntries, cacheBackend, backendName))
+	}
+
+	// Attach the durable usage ledger.
+	if cfg.UsageLedger.Enable {
+		switch strings.ToLower(strings.TrimSpace(cfg.UsageLedger.Backend)) {
+		case "postgres":
+			if usePostgresStore {
+				ledger.Default().SetStore(store.NewPostgresLedgerStore(pgStoreInst))
+			} else {
+				log.Warn("usage-ledger backend postgres requires PGSTORE_DSN; ledger disabled")
+			}
+		default:
+			ledgerDir := strings.TrimSpace(cfg.UsageLedger.Dir)
+			if ledgerDir == "" {
+				ledgerDir = util.ResolveDataDirectory("usage-ledger")
+			}
+			fileStore, errFileStore := ledger.NewFileStore(ledgerDir)
+			if errFileStore != nil {
+				log.Errorf("failed to initialize usage ledger: %v", errFileStore)
+				return
+			}
+			ledger.Default().SetStore(fileStore)
+		}
+	}
+
+	// Record shadow traffic for offline comparison.
+	if cfg.Shadow.Enable {
+		shadowDir := strings.TrimSpace(cfg.Shadow.Dir)
+		if shadowDir == "" {
+			shadowDir = util.ResolveDataDirectory("shadow")
+		}
+		shadowRecorder, errShadow := shadow.NewFileRecorder(shadowDir)
+		if errShadow != nil {
+			log.Errorf("failed to initialize shadow recorder: %v", errShadow)
+			return
+		}
+		shadow.Register(shadowRecorder)
+	}
+
+	// Replicate runtime state between replicas sharing the Postgres store.
+	if strings.EqualFold(strings.TrimSpace(cfg.SharedState.Backend), "postgres") {
+		if usePostgresStore {
+			interval := sharedstate.ParseInterval(cfg.SharedState.SyncInterval)
+			replicator := sharedstate.NewReplicator(store.NewPostgresSharedStateBackend(pgStoreInst), sharedstate.NewOrigin(), interval)
+			sharedstate.Register(replicator)
+			log.Infof("shared state replication enabled (origin=%s, interval=%s)", replicator.Origin(), interval)
+		} else {
+			log.Warn("shared-state backend postgres requires PGSTORE_DSN; state stays process-local")
+		}
+	}
+
+	// Restore client quota counters so per-key limits survive restarts.
+	if strings.EqualFold(strings.TrimSpace(cfg.ClientQuotas.Store), "file") {
+		quotaDir := strings.TrimSpace(cfg.ClientQuotas.Dir)
+		if quotaDir == "" {
+			quotaDir = util.ResolveDataDirectory("quotas")
+		}
+		quotaStore, errQuotaStore := quota.NewFileStore(quotaDir)
+		if errQuotaStore != nil {
+			log.Errorf("failed to initialize client quota store: %v", errQuotaStore)
+			return
+		}
+		if errLoad := quota.Default().SetStore(context.Background(), quotaStore); errLoad != nil {
+			log.Warnf("failed to load client quota counters: %v", errLoad)
+		}
+	}
+
 	// Register built-in access providers before constructing services.
 	configaccess.Register(&cfg.SDKConfig)
+	jwtaccess.Register(&cfg.SDKConfig)
+	quota.Configure(&cfg.SDKConfig)
+	pricing.Configure(&cfg.SDKConfig)
+	redact.Configure(&cfg.SDKConfig)
+	contextguard.Configure(&cfg.SDKConfig)
 
 	// Handle different command modes based on the provided flags.
 
 	if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport, vertexImportPrefix)
+	} else if rotateAuthKey || encryptAuthFiles {
+		// Handle auth encryption key rotation and plaintext migration
+		cmd.DoReencryptAuths(cfg, rotateAuthKey)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
@@ -490,6 +685,19 @@ func main() {
 		if localModel && (!tuiMode || standalone) {
 			log.Info("Local model mode: using embedded model catalog, remote model updates disabled")
 		}
+		// Follow credential and config changes made by other replicas sharing the database.
+		if pgStoreInst != nil && (!tuiMode || standalone) {
+			pgStoreInst.StartSync(context.Background(), pgStoreSyncInterval)
+		}
+		// Pull changes pushed to the git remote or bucket by other instances.
+		if !tuiMode || standalone {
+			if gitStoreInst != nil {
+				gitStoreInst.StartRemoteSync(context.Background(), gitStoreSyncInterval, gitStoreSyncPolicy)
+			}
+			if objectStoreInst != nil {
+				objectStoreInst.StartRemoteSync(context.Background(), objectSyncInterval, objectSyncPolicy)
+			}
+		}
 		if tuiMode {
 			if standalone {
 				// Standalone mode: start an embedded local server and connect TUI client to it.
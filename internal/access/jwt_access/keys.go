@@ -0,0 +1,331 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// minRefreshInterval limits refetches triggered by tokens carrying an unknown key id, so
+// forged tokens cannot be used to hammer the JWKS endpoint.
+const minRefreshInterval = 30 * time.Second
+
+// maxKeyDocumentBytes bounds JWKS and discovery responses.
+const maxKeyDocumentBytes = 1 << 20
+
+var errKeyNotFound = errors.New("signing key not found")
+
+// publicKey is one verification key with the optional constraints from its JWK.
+type publicKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// keySet caches the verification keys of one provider. Keys are loaded lazily, reloaded once
+// the cache TTL has passed and refreshed early when a token names an unknown key id, which is
+// how identity providers roll keys. Loads run outside the lock, one at a time, and at most every
+// minRefreshInterval, so an unreachable key source does not stall authentication.
+type keySet struct {
+	load  func(ctx context.Context) ([]publicKey, error)
+	ttl   time.Duration
+	now   func() time.Time
+	group singleflight.Group
+
+	mu        sync.Mutex
+	keys      []publicKey
+	loadedAt  time.Time
+	attempted time.Time
+	lastErr   error
+}
+
+func newKeySet(ttl time.Duration, load func(ctx context.Context) ([]publicKey, error)) *keySet {
+	return &keySet{load: load, ttl: ttl, now: time.Now}
+}
+
+// candidates returns the keys that may have signed a token with the given key id and
+// algorithm. Without a key id every key compatible with the algorithm is tried. Stale keys keep
+// being served while a reload runs in the background or while reloads are backing off.
+func (ks *keySet) candidates(ctx context.Context, kid, alg string) ([]publicKey, error) {
+	ks.mu.Lock()
+	stale := ks.loadedAt.IsZero() || ks.now().Sub(ks.loadedAt) >= ks.ttl
+	matched := matchKeys(ks.keys, kid, alg)
+	ks.mu.Unlock()
+	if len(matched) > 0 {
+		if stale {
+			go ks.group.Do("load", func() (any, error) {
+				ks.reload(context.Background())
+				return nil, nil
+			})
+		}
+		return matched, nil
+	}
+
+	_, _, _ = ks.group.Do("load", func() (any, error) {
+		ks.reload(context.WithoutCancel(ctx))
+		return nil, nil
+	})
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if matched = matchKeys(ks.keys, kid, alg); len(matched) > 0 {
+		return matched, nil
+	}
+	if len(ks.keys) == 0 && ks.lastErr != nil {
+		return nil, ks.lastErr
+	}
+	return nil, errKeyNotFound
+}
+
+// reload fetches the keys unless the previous attempt, successful or not, was less than
+// minRefreshInterval ago. A failed load keeps the previous keys.
+func (ks *keySet) reload(ctx context.Context) {
+	ks.mu.Lock()
+	now := ks.now()
+	if !ks.attempted.IsZero() && now.Sub(ks.attempted) < minRefreshInterval {
+		ks.mu.Unlock()
+		return
+	}
+	ks.attempted = now
+	ks.mu.Unlock()
+
+	keys, err := ks.load(ctx)
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.lastErr = err
+	if err != nil {
+		return
+	}
+	ks.keys = keys
+	ks.loadedAt = now
+}
+
+func matchKeys(keys []publicKey, kid, alg string) []publicKey {
+	var out []publicKey
+	for _, k := range keys {
+		if kid != "" && k.kid != kid {
+			continue
+		}
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		out = append(out, k)
+	}
+	return out
+}
+
+// fetchJWKS loads a JSON Web Key Set over HTTP.
+func fetchJWKS(ctx context.Context, client *http.Client, url string) ([]publicKey, error) {
+	body, err := fetchDocument(ctx, client, url)
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(body)
+}
+
+// discoverJWKSURL reads jwks_uri from the issuer's OpenID provider configuration.
+func discoverJWKSURL(ctx context.Context, client *http.Client, issuer string) (string, error) {
+	body, err := fetchDocument(ctx, client, strings.TrimRight(issuer, "/")+"/.well-known/openid-configuration")
+	if err != nil {
+		return "", err
+	}
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err = json.Unmarshal(body, &doc); err != nil {
+		return "", fmt.Errorf("decode openid configuration: %w", err)
+	}
+	if doc.JWKSURI == "" {
+		return "", errors.New("openid configuration has no jwks_uri")
+	}
+	if doc.Issuer != "" && doc.Issuer != issuer {
+		return "", fmt.Errorf("openid configuration issuer %q does not match %q", doc.Issuer, issuer)
+	}
+	return doc.JWKSURI, nil
+}
+
+func fetchDocument(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: unexpected status %d", url, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxKeyDocumentBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", url, err)
+	}
+	return body, nil
+}
+
+// loadKeyFile reads verification keys from a JWKS document or from PEM encoded public keys
+// and certificates.
+func loadKeyFile(path string) ([]publicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		return parseJWKS(data)
+	}
+	var keys []publicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		var key crypto.PublicKey
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s in %s: %w", strings.ToLower(block.Type), path, err)
+		}
+		keys = append(keys, publicKey{key: key})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public keys found in %s", path)
+	}
+	return keys, nil
+}
+
+type jsonWebKey struct {
+	Kty string   `json:"kty"`
+	Kid string   `json:"kid"`
+	Use string   `json:"use"`
+	Alg string   `json:"alg"`
+	Crv string   `json:"crv"`
+	N   string   `json:"n"`
+	E   string   `json:"e"`
+	X   string   `json:"x"`
+	Y   string   `json:"y"`
+	X5c []string `json:"x5c"`
+}
+
+// parseJWKS decodes the signature keys of a JWKS document. Keys of unknown types and
+// encryption keys are skipped.
+func parseJWKS(data []byte) ([]publicKey, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+	keys := make([]publicKey, 0, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil || key == nil {
+			continue
+		}
+		keys = append(keys, publicKey{kid: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, errN := decodeBigInt(jwk.N)
+		e, errE := decodeBigInt(jwk.E)
+		if errN != nil || errE != nil || !e.IsInt64() {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+		y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("invalid EC key")
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC key")
+		}
+		uncompressed := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(curve, uncompressed)
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	if len(jwk.X5c) > 0 {
+		der, err := base64.StdEncoding.DecodeString(jwk.X5c[0])
+		if err != nil {
+			return nil, err
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(raw) == 0 {
+		return nil, errors.New("invalid integer")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
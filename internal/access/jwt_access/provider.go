@@ -0,0 +1,348 @@
+// Package jwtaccess provides the built-in "jwt" and "oidc" access providers, which authenticate
+// clients with signed JWT bearer tokens issued by a single sign-on provider instead of static
+// API keys. Signatures are checked against keys from a JWKS URL, the issuer's OpenID
+// configuration or a local key file; issuer, audience and expiry are validated, and the
+// token's subject becomes the request principal that usage records and per-key policies use.
+package jwtaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPrincipalClaim = "sub"
+	defaultGroupsClaim    = "groups"
+	defaultCacheTTL       = 10 * time.Minute
+	defaultClockSkew      = time.Minute
+	fetchTimeout          = 10 * time.Second
+)
+
+// options is the parsed provider configuration.
+type options struct {
+	issuer          string
+	audiences       []string
+	jwksURL         string
+	keyFile         string
+	principalClaim  string
+	principalPrefix string
+	groupsClaim     string
+	cacheTTL        time.Duration
+	clockSkew       time.Duration
+}
+
+var (
+	registeredMu sync.Mutex
+	// registered holds the configuration each registered provider was built from.
+	registered = make(map[string]sdkaccess.AccessProvider)
+)
+
+// Register makes the jwt and oidc providers configured under access.providers available to the
+// access manager. Providers whose configuration did not change keep their instance, and with
+// it their cached keys. Invalid entries are logged and skipped.
+func Register(cfg *sdkconfig.SDKConfig) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	seen := make(map[string]struct{})
+	if cfg != nil {
+		for _, entry := range cfg.Access.Providers {
+			typ := strings.ToLower(strings.TrimSpace(entry.Type))
+			if typ != sdkaccess.AccessProviderTypeJWT && typ != sdkaccess.AccessProviderTypeOIDC {
+				continue
+			}
+			name := strings.TrimSpace(entry.Name)
+			if name == "" {
+				name = typ
+			}
+			key := registryKey(name)
+			if _, dup := seen[key]; dup {
+				log.Warnf("access provider %q: duplicate name, ignoring", name)
+				continue
+			}
+			if existing, ok := registered[key]; ok && reflect.DeepEqual(existing, entry) {
+				seen[key] = struct{}{}
+				continue
+			}
+			opts, err := parseOptions(typ, entry.Config)
+			if err != nil {
+				log.Errorf("access provider %q: %v", name, err)
+				continue
+			}
+			sdkaccess.RegisterProvider(key, newProvider(name, opts))
+			registered[key] = entry
+			seen[key] = struct{}{}
+		}
+	}
+	for key := range registered {
+		if _, ok := seen[key]; !ok {
+			sdkaccess.UnregisterProvider(key)
+			delete(registered, key)
+		}
+	}
+}
+
+// registryKey keeps the providers apart from other types in the SDK registry, which holds one
+// provider per key.
+func registryKey(name string) string {
+	return sdkaccess.AccessProviderTypeJWT + ":" + name
+}
+
+type provider struct {
+	name string
+	opts *options
+	keys *keySet
+	now  func() time.Time
+}
+
+func newProvider(name string, opts *options) *provider {
+	p := &provider{name: name, opts: opts, now: time.Now}
+	client := &http.Client{Timeout: fetchTimeout}
+	var jwksMu sync.Mutex
+	jwksURL := opts.jwksURL
+	p.keys = newKeySet(opts.cacheTTL, func(ctx context.Context) ([]publicKey, error) {
+		if opts.keyFile != "" {
+			return loadKeyFile(opts.keyFile)
+		}
+		jwksMu.Lock()
+		url := jwksURL
+		jwksMu.Unlock()
+		if url == "" {
+			discovered, err := discoverJWKSURL(ctx, client, opts.issuer)
+			if err != nil {
+				return nil, err
+			}
+			jwksMu.Lock()
+			jwksURL = discovered
+			jwksMu.Unlock()
+			url = discovered
+		}
+		return fetchJWKS(ctx, client, url)
+	})
+	return p
+}
+
+func (p *provider) Identifier() string {
+	return p.name
+}
+
+func (p *provider) Authenticate(ctx context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	raw, source, present := extractToken(r)
+	if raw == "" {
+		if present {
+			return nil, sdkaccess.NewInvalidCredentialError()
+		}
+		return nil, sdkaccess.NewNoCredentialsError()
+	}
+	claims, err := p.verify(ctx, raw)
+	if err != nil {
+		log.Debugf("access provider %q: rejected token: %v", p.name, err)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+
+	principal := claimString(claims[p.opts.principalClaim])
+	if principal == "" {
+		log.Debugf("access provider %q: token has no %q claim", p.name, p.opts.principalClaim)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	metadata := map[string]string{
+		"source":  source,
+		"subject": claimString(claims["sub"]),
+	}
+	if iss := claimString(claims["iss"]); iss != "" {
+		metadata["issuer"] = iss
+	}
+	if email := claimString(claims["email"]); email != "" {
+		metadata["email"] = email
+	}
+	if groups := groupValues(claims[p.opts.groupsClaim]); len(groups) > 0 {
+		metadata["groups"] = strings.Join(groups, ",")
+	}
+	return &sdkaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: p.opts.principalPrefix + principal,
+		Metadata:  metadata,
+	}, nil
+}
+
+func (p *provider) verify(ctx context.Context, raw string) (map[string]any, error) {
+	token, err := parseToken(raw)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := algHash(token.header.Alg); !ok {
+		return nil, fmt.Errorf("%w %q", errUnsupportedAlg, token.header.Alg)
+	}
+	candidates, err := p.keys.candidates(ctx, token.header.Kid, token.header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	verified := false
+	for _, key := range candidates {
+		if errVerify := verifySignature(token, key.key); errVerify == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errInvalidSignature
+	}
+	if err = validateClaims(token.claims, p.opts, p.now()); err != nil {
+		return nil, err
+	}
+	return token.claims, nil
+}
+
+// extractToken returns the first JWT presented in the headers clients use for API keys.
+// present reports whether any credential was sent at all.
+func extractToken(r *http.Request) (token, source string, present bool) {
+	candidates := []struct {
+		value  string
+		source string
+	}{
+		{bearerToken(r.Header.Get("Authorization")), "authorization"},
+		{strings.TrimSpace(r.Header.Get("X-Api-Key")), "x-api-key"},
+		{strings.TrimSpace(r.Header.Get("X-Goog-Api-Key")), "x-goog-api-key"},
+	}
+	for _, candidate := range candidates {
+		if candidate.value == "" {
+			continue
+		}
+		present = true
+		if looksLikeJWT(candidate.value) {
+			return candidate.value, candidate.source, true
+		}
+	}
+	return "", "", present
+}
+
+func bearerToken(header string) string {
+	scheme, value, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return strings.TrimSpace(header)
+	}
+	return strings.TrimSpace(value)
+}
+
+func claimString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	}
+	return ""
+}
+
+// groupValues reads a groups claim given as an array or as a space or comma separated string.
+func groupValues(value any) []string {
+	if s, ok := value.(string); ok {
+		return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return stringValues(value)
+}
+
+func parseOptions(typ string, raw map[string]any) (*options, error) {
+	opts := &options{
+		principalClaim: defaultPrincipalClaim,
+		groupsClaim:    defaultGroupsClaim,
+		cacheTTL:       defaultCacheTTL,
+		clockSkew:      defaultClockSkew,
+	}
+	var err error
+	opts.issuer = stringOption(raw, "issuer")
+	opts.jwksURL = stringOption(raw, "jwks-url")
+	opts.keyFile = stringOption(raw, "key-file")
+	opts.principalPrefix = stringOption(raw, "principal-prefix")
+	if v := stringOption(raw, "principal-claim"); v != "" {
+		opts.principalClaim = v
+	}
+	if v := stringOption(raw, "groups-claim"); v != "" {
+		opts.groupsClaim = v
+	}
+	if opts.audiences, err = stringListOption(raw, "audience"); err != nil {
+		return nil, err
+	}
+	if opts.cacheTTL, err = durationOption(raw, "cache-ttl", defaultCacheTTL); err != nil {
+		return nil, err
+	}
+	if opts.clockSkew, err = durationOption(raw, "clock-skew", defaultClockSkew); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case opts.jwksURL != "" && opts.keyFile != "":
+		return nil, errors.New("set either jwks-url or key-file, not both")
+	case typ == sdkaccess.AccessProviderTypeOIDC && opts.issuer == "":
+		return nil, errors.New("oidc provider requires issuer")
+	case typ == sdkaccess.AccessProviderTypeJWT && opts.jwksURL == "" && opts.keyFile == "":
+		return nil, errors.New("jwt provider requires jwks-url or key-file")
+	case typ == sdkaccess.AccessProviderTypeJWT && len(opts.audiences) == 0:
+		// Without an audience any token signed by the same keys, e.g. one issued to another
+		// application of the same identity provider, would be accepted.
+		return nil, errors.New("jwt provider requires audience")
+	}
+	if opts.cacheTTL == 0 {
+		opts.cacheTTL = defaultCacheTTL
+	}
+	return opts, nil
+}
+
+func stringOption(raw map[string]any, key string) string {
+	if v, ok := raw[key].(string); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+func stringListOption(raw map[string]any, key string) ([]string, error) {
+	switch v := raw[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if s := strings.TrimSpace(v); s != "" {
+			return []string{s}, nil
+		}
+		return nil, nil
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected strings", key)
+			}
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out, nil
+	case []string:
+		return v, nil
+	}
+	return nil, fmt.Errorf("%s: expected a string or a list of strings", key)
+}
+
+func durationOption(raw map[string]any, key string, fallback time.Duration) (time.Duration, error) {
+	v := stringOption(raw, key)
+	if v == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s: invalid duration %q", key, v)
+	}
+	return d, nil
+}
@@ -0,0 +1,354 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+)
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	signingInput := encodeSegment(t, map[string]any{"alg": "RS256", "typ": "JWT", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+	signingInput := encodeSegment(t, map[string]any{"alg": "ES256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]any {
+	return map[string]any{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func bearerRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestProviderValidatesJWKSTokens(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches atomic.Int32
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []any{rsaJWK("k1", &key.PublicKey)}})
+	}))
+	defer jwks.Close()
+
+	opts, err := parseOptions(sdkaccess.AccessProviderTypeJWT, map[string]any{
+		"jwks-url": jwks.URL,
+		"issuer":   "https://sso.example.com",
+		"audience": []any{"cliproxy"},
+	})
+	if err != nil {
+		t.Fatalf("parseOptions: %v", err)
+	}
+	p := newProvider("corp-sso", opts)
+
+	valid := map[string]any{
+		"iss":    "https://sso.example.com",
+		"aud":    "cliproxy",
+		"sub":    "alice",
+		"email":  "alice@example.com",
+		"groups": []any{"eng", "ml"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+	result, authErr := p.Authenticate(t.Context(), bearerRequest(signRS256(t, key, "k1", valid)))
+	if authErr != nil {
+		t.Fatalf("Authenticate: %v", authErr)
+	}
+	if result.Principal != "alice" || result.Provider != "corp-sso" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Metadata["groups"] != "eng,ml" || result.Metadata["email"] != "alice@example.com" || result.Metadata["source"] != "authorization" {
+		t.Fatalf("unexpected metadata: %+v", result.Metadata)
+	}
+	if _, authErr = p.Authenticate(t.Context(), bearerRequest(signRS256(t, key, "k1", valid))); authErr != nil {
+		t.Fatalf("second Authenticate: %v", authErr)
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", got)
+	}
+
+	rejected := map[string]map[string]any{
+		"expired":      {"exp": time.Now().Add(-time.Hour).Unix()},
+		"no expiry":    {"exp": nil},
+		"wrong issuer": {"iss": "https://evil.example.com"},
+		"wrong aud":    {"aud": []any{"other"}},
+	}
+	for name, override := range rejected {
+		claims := make(map[string]any, len(valid))
+		for k, v := range valid {
+			claims[k] = v
+		}
+		for k, v := range override {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+		_, authErr = p.Authenticate(t.Context(), bearerRequest(signRS256(t, key, "k1", claims)))
+		if !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+			t.Errorf("%s: expected invalid credential, got %v", name, authErr)
+		}
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, authErr = p.Authenticate(t.Context(), bearerRequest(signRS256(t, other, "k1", valid)))
+	if !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+		t.Fatalf("forged signature: expected invalid credential, got %v", authErr)
+	}
+
+	_, authErr = p.Authenticate(t.Context(), bearerRequest("sk-static-key"))
+	if !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+		t.Fatalf("static key: expected invalid credential, got %v", authErr)
+	}
+	_, authErr = p.Authenticate(t.Context(), httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	if !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNoCredentials) {
+		t.Fatalf("no header: expected no credentials, got %v", authErr)
+	}
+}
+
+func TestProviderDiscoversKeysAndFollowsRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rotated atomic.Bool
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"issuer": srv.URL, "jwks_uri": srv.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		keys := []any{rsaJWK("old", &oldKey.PublicKey)}
+		if rotated.Load() {
+			keys = []any{rsaJWK("new", &newKey.PublicKey)}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	})
+
+	opts, err := parseOptions(sdkaccess.AccessProviderTypeOIDC, map[string]any{"issuer": srv.URL, "principal-prefix": "sso:"})
+	if err != nil {
+		t.Fatalf("parseOptions: %v", err)
+	}
+	p := newProvider("oidc", opts)
+	claims := map[string]any{"iss": srv.URL, "sub": "bob", "exp": time.Now().Add(time.Hour).Unix()}
+	result, authErr := p.Authenticate(t.Context(), bearerRequest(signRS256(t, oldKey, "old", claims)))
+	if authErr != nil {
+		t.Fatalf("Authenticate: %v", authErr)
+	}
+	if result.Principal != "sso:bob" {
+		t.Fatalf("principal = %q", result.Principal)
+	}
+
+	rotated.Store(true)
+	// Pretend the last fetch is old enough to allow a refresh for the unknown key id.
+	p.keys.attempted = time.Now().Add(-time.Hour)
+	if _, authErr = p.Authenticate(t.Context(), bearerRequest(signRS256(t, newKey, "new", claims))); authErr != nil {
+		t.Fatalf("Authenticate after rotation: %v", authErr)
+	}
+}
+
+func TestProviderKeyFile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "sso.pem")
+	if err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := parseOptions(sdkaccess.AccessProviderTypeJWT, map[string]any{"key-file": path, "audience": "cliproxy", "groups-claim": "roles"})
+	if err != nil {
+		t.Fatalf("parseOptions: %v", err)
+	}
+	p := newProvider("jwt", opts)
+	token := signES256(t, key, map[string]any{"aud": "cliproxy", "sub": "svc-batch", "roles": "admin reader", "exp": time.Now().Add(time.Minute).Unix()})
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	req.Header.Set("X-Api-Key", token)
+	result, authErr := p.Authenticate(t.Context(), req)
+	if authErr != nil {
+		t.Fatalf("Authenticate: %v", authErr)
+	}
+	if result.Principal != "svc-batch" || result.Metadata["groups"] != "admin,reader" || result.Metadata["source"] != "x-api-key" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestParseOptionsRequiresKeySource(t *testing.T) {
+	if _, err := parseOptions(sdkaccess.AccessProviderTypeJWT, map[string]any{"issuer": "https://sso.example.com"}); err == nil {
+		t.Fatal("expected error for jwt provider without keys")
+	}
+	if _, err := parseOptions(sdkaccess.AccessProviderTypeOIDC, nil); err == nil {
+		t.Fatal("expected error for oidc provider without issuer")
+	}
+	if _, err := parseOptions(sdkaccess.AccessProviderTypeJWT, map[string]any{"jwks-url": "https://x", "audience": "cliproxy", "cache-ttl": "soon"}); err == nil {
+		t.Fatal("expected error for invalid cache-ttl")
+	}
+}
+
+func TestParseOptionsRequiresAudienceForJWT(t *testing.T) {
+	if _, err := parseOptions(sdkaccess.AccessProviderTypeJWT, map[string]any{"jwks-url": "https://x"}); err == nil {
+		t.Fatal("expected error for jwt provider without audience")
+	}
+	if _, err := parseOptions(sdkaccess.AccessProviderTypeJWT, map[string]any{"jwks-url": "https://x", "issuer": "https://sso.example.com"}); err == nil {
+		t.Fatal("expected error for jwt provider with issuer but no audience")
+	}
+	if _, err := parseOptions(sdkaccess.AccessProviderTypeJWT, map[string]any{"jwks-url": "https://x", "audience": "cliproxy"}); err != nil {
+		t.Fatalf("parseOptions with audience: %v", err)
+	}
+}
+
+func TestVerifySignatureRejectsMismatchedCurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingInput := encodeSegment(t, map[string]any{"alg": "ES256", "typ": "JWT"}) + "." + encodeSegment(t, map[string]any{"sub": "svc"})
+	digest := sha256.Sum256([]byte(signingInput))
+	r, sv, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 96)
+	r.FillBytes(sig[:48])
+	sv.FillBytes(sig[48:])
+	token, err := parseToken(signingInput + "." + base64.RawURLEncoding.EncodeToString(sig))
+	if err != nil {
+		t.Fatalf("parseToken: %v", err)
+	}
+	if err := verifySignature(token, &key.PublicKey); !errors.Is(err, errUnsupportedAlg) {
+		t.Fatalf("verifySignature() error = %v, want %v", err, errUnsupportedAlg)
+	}
+}
+
+func TestKeySetBacksOffWhileSourceFails(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		loads   atomic.Int32
+		failing atomic.Bool
+	)
+	release := make(chan struct{})
+	ks := newKeySet(time.Minute, func(context.Context) ([]publicKey, error) {
+		loads.Add(1)
+		if failing.Load() {
+			<-release
+			return nil, errors.New("jwks unavailable")
+		}
+		return []publicKey{{kid: "k1", key: &key.PublicKey}}, nil
+	})
+	clock := time.Now()
+	ks.now = func() time.Time { return clock }
+
+	if _, err = ks.candidates(t.Context(), "k1", "RS256"); err != nil {
+		t.Fatalf("initial load: %v", err)
+	}
+
+	// Past the TTL with the source hanging and then failing, concurrent callers keep getting the
+	// cached key without waiting for the fetch, and only one fetch runs.
+	failing.Store(true)
+	clock = clock.Add(2 * time.Minute)
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if matched, errCandidates := ks.candidates(t.Context(), "k1", "RS256"); errCandidates != nil || len(matched) != 1 {
+				t.Errorf("stale candidates: %v %v", matched, errCandidates)
+			}
+		}()
+	}
+	wg.Wait()
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for loads.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := loads.Load(); got != 2 {
+		t.Fatalf("loads = %d, want 2", got)
+	}
+
+	// Unknown key ids during the backoff do not refetch either.
+	for range 5 {
+		if _, err = ks.candidates(t.Context(), "k2", "RS256"); err == nil {
+			t.Fatal("expected an error for an unknown key id")
+		}
+	}
+	if got := loads.Load(); got != 2 {
+		t.Fatalf("loads during backoff = %d, want 2", got)
+	}
+	if matched, errCandidates := ks.candidates(t.Context(), "k1", "RS256"); errCandidates != nil || len(matched) != 1 {
+		t.Fatalf("stale key not served during backoff: %v", errCandidates)
+	}
+}
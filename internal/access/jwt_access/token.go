@@ -0,0 +1,220 @@
+package jwtaccess
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	errMalformedToken   = errors.New("malformed token")
+	errUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	errInvalidSignature = errors.New("invalid signature")
+)
+
+// tokenHeader is the JOSE header of a compact JWS.
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// parsedToken is a decoded but not yet verified JWT.
+type parsedToken struct {
+	header       tokenHeader
+	claims       map[string]any
+	signingInput []byte
+	signature    []byte
+}
+
+// looksLikeJWT reports whether value has the three dot-separated segments of a compact JWS.
+func looksLikeJWT(value string) bool {
+	return strings.Count(value, ".") == 2 && !strings.ContainsAny(value, " \t")
+}
+
+func parseToken(raw string) (*parsedToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %v", errMalformedToken, err)
+	}
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", errMalformedToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", errMalformedToken, err)
+	}
+	token := &parsedToken{signingInput: []byte(parts[0] + "." + parts[1]), signature: signature}
+	if err = json.Unmarshal(headerJSON, &token.header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", errMalformedToken, err)
+	}
+	if err = json.Unmarshal(payloadJSON, &token.claims); err != nil || token.claims == nil {
+		return nil, fmt.Errorf("%w: claims are not a JSON object", errMalformedToken)
+	}
+	return token, nil
+}
+
+// algHash maps a JWS algorithm to its digest. EdDSA signs the message itself.
+func algHash(alg string) (crypto.Hash, bool) {
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		return crypto.SHA256, true
+	case "RS384", "PS384", "ES384":
+		return crypto.SHA384, true
+	case "RS512", "PS512", "ES512":
+		return crypto.SHA512, true
+	case "EdDSA":
+		return 0, true
+	}
+	return 0, false
+}
+
+// esCurve returns the name of the curve an ES* algorithm is defined over (RFC 7518 3.4).
+func esCurve(alg string) string {
+	switch alg {
+	case "ES256":
+		return "P-256"
+	case "ES384":
+		return "P-384"
+	case "ES512":
+		return "P-521"
+	}
+	return ""
+}
+
+// verifySignature checks the token signature with key. Only asymmetric algorithms are
+// accepted, so a token cannot be signed with a public key used as an HMAC secret.
+func verifySignature(token *parsedToken, key crypto.PublicKey) error {
+	alg := token.header.Alg
+	hash, ok := algHash(alg)
+	if !ok {
+		return fmt.Errorf("%w %q", errUnsupportedAlg, alg)
+	}
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write(token.signingInput)
+		digest = h.Sum(nil)
+	}
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		var err error
+		switch alg[:2] {
+		case "RS":
+			err = rsa.VerifyPKCS1v15(k, hash, digest, token.signature)
+		case "PS":
+			err = rsa.VerifyPSS(k, hash, digest, token.signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		default:
+			return fmt.Errorf("%w %q for RSA key", errUnsupportedAlg, alg)
+		}
+		if err != nil {
+			return errInvalidSignature
+		}
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" {
+			return fmt.Errorf("%w %q for EC key", errUnsupportedAlg, alg)
+		}
+		if curve := k.Curve.Params().Name; curve != esCurve(alg) {
+			return fmt.Errorf("%w %q for %s key", errUnsupportedAlg, alg, curve)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(token.signature) != 2*size {
+			return errInvalidSignature
+		}
+		r := new(big.Int).SetBytes(token.signature[:size])
+		s := new(big.Int).SetBytes(token.signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if alg != "EdDSA" {
+			return fmt.Errorf("%w %q for Ed25519 key", errUnsupportedAlg, alg)
+		}
+		if !ed25519.Verify(k, token.signingInput, token.signature) {
+			return errInvalidSignature
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+	return nil
+}
+
+// validateClaims checks expiry, not-before, issuer and audience.
+func validateClaims(claims map[string]any, opts *options, now time.Time) error {
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if now.After(exp.Add(opts.clockSkew)) {
+		return errors.New("token expired")
+	}
+	if nbf, hasNbf := numericDate(claims["nbf"]); hasNbf && now.Add(opts.clockSkew).Before(nbf) {
+		return errors.New("token not yet valid")
+	}
+	if opts.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != opts.issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if len(opts.audiences) > 0 {
+		matched := false
+		for _, aud := range stringValues(claims["aud"]) {
+			for _, want := range opts.audiences {
+				if aud == want {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return errors.New("token audience not accepted")
+		}
+	}
+	return nil
+}
+
+func numericDate(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case float64:
+		sec, frac := int64(v), v-float64(int64(v))
+		return time.Unix(sec, int64(frac*1e9)), true
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return numericDate(f)
+		}
+	}
+	return time.Time{}, false
+}
+
+// stringValues reads a claim holding a string or an array of strings.
+func stringValues(value any) []string {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+)
+
+// LimitKind identifies which limit rejected a request.
+type LimitKind string
+
+const (
+	LimitRequestsPerMinute LimitKind = "requests_per_minute"
+	LimitTokensPerDay      LimitKind = "tokens_per_day"
+	LimitMonthlyBudget     LimitKind = "monthly_budget"
+	LimitModelNotAllowed   LimitKind = "model_not_allowed"
+)
+
+// LimitError reports a request rejected by a client key limit.
+// Error renders a JSON body in the client's protocol selected by Format.
+type LimitError struct {
+	Kind       LimitKind
+	Model      string
+	RetryAfter time.Duration
+	// Format is the handler type of the client request (e.g. "openai", "claude", "gemini").
+	Format string
+}
+
+// Message returns a human-readable description of the violated limit.
+func (e *LimitError) Message() string {
+	if e == nil {
+		return ""
+	}
+	switch e.Kind {
+	case LimitModelNotAllowed:
+		return fmt.Sprintf("API key is not allowed to use model %s", e.Model)
+	case LimitRequestsPerMinute:
+		return "API key exceeded its requests per minute limit"
+	case LimitTokensPerDay:
+		return "API key exceeded its daily token quota"
+	case LimitMonthlyBudget:
+		return "API key exceeded its monthly budget"
+	default:
+		return "API key exceeded its quota"
+	}
+}
+
+func (e *LimitError) Error() string {
+	if e == nil {
+		return ""
+	}
+	message := e.Message()
+	status := e.StatusCode()
+	var payload any
+	switch e.Format {
+	case constant.Claude:
+		errType := "rate_limit_error"
+		if status == http.StatusForbidden {
+			errType = "permission_error"
+		}
+		payload = map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": errType, "message": message},
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		statusText := "RESOURCE_EXHAUSTED"
+		if status == http.StatusForbidden {
+			statusText = "PERMISSION_DENIED"
+		}
+		payload = map[string]any{
+			"error": map[string]any{"code": status, "message": message, "status": statusText},
+		}
+	default:
+		errType := "rate_limit_error"
+		code := "rate_limit_exceeded"
+		switch {
+		case status == http.StatusForbidden:
+			errType = "permission_error"
+			code = "model_not_allowed"
+		case e.Kind == LimitTokensPerDay || e.Kind == LimitMonthlyBudget:
+			errType = "insufficient_quota"
+			code = "insufficient_quota"
+		}
+		payload = map[string]any{
+			"error": map[string]any{"message": message, "type": errType, "code": code},
+		}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return message
+	}
+	return string(data)
+}
+
+// StatusCode returns 403 for model allowlist violations and 429 for exhausted limits.
+func (e *LimitError) StatusCode() int {
+	if e != nil && e.Kind == LimitModelNotAllowed {
+		return http.StatusForbidden
+	}
+	return http.StatusTooManyRequests
+}
+
+// Headers returns Retry-After for exhausted limits, following the model cooldown convention.
+func (e *LimitError) Headers() http.Header {
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	if e == nil || e.Kind == LimitModelNotAllowed {
+		return headers
+	}
+	resetSeconds := int(math.Ceil(e.RetryAfter.Seconds()))
+	if resetSeconds < 0 {
+		resetSeconds = 0
+	}
+	headers.Set("Retry-After", strconv.Itoa(resetSeconds))
+	return headers
+}
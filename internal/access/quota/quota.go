@@ -0,0 +1,341 @@
+// Package quota enforces per-client-key limits after a request has been authenticated.
+// Each client API key may carry a requests-per-minute limit, a daily token quota, a monthly
+// spend cap and a model allowlist. Request counts are charged when a request is admitted;
+// token and spend counters are charged from usage records so streamed responses are
+// accounted once their final usage is known.
+package quota
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultKey is the api-key value of the entry applied to keys without a dedicated entry.
+const DefaultKey = "*"
+
+// persistDelay batches counter writes to the store.
+const persistDelay = 5 * time.Second
+
+// CostFunc returns the spend, in USD, attributed to a usage record.
+type CostFunc func(record coreusage.Record) float64
+
+// Limits holds the effective limits for one client key.
+type Limits struct {
+	RequestsPerMinute int
+	TokensPerDay      int64
+	MonthlyBudget     float64
+	AllowedModels     []string
+}
+
+func (l Limits) empty() bool {
+	return l.RequestsPerMinute <= 0 && l.TokensPerDay <= 0 && l.MonthlyBudget <= 0 && len(l.AllowedModels) == 0
+}
+
+// Counter tracks the usage windows of a client key.
+type Counter struct {
+	Minute         time.Time `json:"minute"`
+	MinuteRequests int       `json:"minute_requests"`
+	Day            string    `json:"day"`
+	DayTokens      int64     `json:"day_tokens"`
+	Month          string    `json:"month"`
+	MonthSpend     float64   `json:"month_spend"`
+}
+
+// roll resets any window that no longer covers now.
+func (c *Counter) roll(now time.Time) {
+	minute := now.Truncate(time.Minute)
+	if !c.Minute.Equal(minute) {
+		c.Minute = minute
+		c.MinuteRequests = 0
+	}
+	if day := now.Format("2006-01-02"); c.Day != day {
+		c.Day = day
+		c.DayTokens = 0
+	}
+	if month := now.Format("2006-01"); c.Month != month {
+		c.Month = month
+		c.MonthSpend = 0
+	}
+}
+
+// Enforcer evaluates client keys against their configured limits.
+type Enforcer struct {
+	mu       sync.Mutex
+	limits   map[string]Limits
+	counters map[string]*Counter
+	store    Store
+	cost     CostFunc
+	now      func() time.Time
+
+	persistPending bool
+}
+
+// NewEnforcer constructs an enforcer without limits backed by an in-memory store.
+func NewEnforcer() *Enforcer {
+	return &Enforcer{
+		limits:   make(map[string]Limits),
+		counters: make(map[string]*Counter),
+		store:    NewMemoryStore(),
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Configure replaces the configured limits.
+func (e *Enforcer) Configure(cfg *config.SDKConfig) {
+	if e == nil {
+		return
+	}
+	limits := make(map[string]Limits)
+	if cfg != nil {
+		for _, entry := range cfg.ClientQuotas.Keys {
+			key := strings.TrimSpace(entry.APIKey)
+			if key == "" {
+				continue
+			}
+			limit := Limits{
+				RequestsPerMinute: entry.RequestsPerMinute,
+				TokensPerDay:      entry.TokensPerDay,
+				MonthlyBudget:     entry.MonthlyBudget,
+			}
+			for _, model := range entry.AllowedModels {
+				if trimmed := strings.TrimSpace(model); trimmed != "" {
+					limit.AllowedModels = append(limit.AllowedModels, trimmed)
+				}
+			}
+			if limit.empty() {
+				continue
+			}
+			limits[key] = limit
+		}
+	}
+	e.mu.Lock()
+	e.limits = limits
+	e.mu.Unlock()
+}
+
+// SetStore replaces the counter store and loads any persisted counters from it.
+func (e *Enforcer) SetStore(ctx context.Context, store Store) error {
+	if e == nil || store == nil {
+		return nil
+	}
+	counters, err := store.Load(ctx)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.store = store
+	if err != nil {
+		return err
+	}
+	e.counters = make(map[string]*Counter, len(counters))
+	for key, counter := range counters {
+		c := counter
+		// Counters written before keys were hashed are migrated on load.
+		e.counters[util.HashAPIKey(key)] = &c
+	}
+	return nil
+}
+
+// SetCostFunc installs the function used to price usage records for monthly budgets.
+// Without a cost function, monthly budgets are not charged.
+func (e *Enforcer) SetCostFunc(fn CostFunc) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.cost = fn
+	e.mu.Unlock()
+}
+
+// Enabled reports whether any limits are configured.
+func (e *Enforcer) Enabled() bool {
+	if e == nil {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.limits) > 0
+}
+
+// Admit checks apiKey against its limits for model and, when allowed, charges one request
+// against the per-minute window. It returns nil when the request may proceed.
+func (e *Enforcer) Admit(apiKey, model string) *LimitError {
+	return e.check(apiKey, model, true)
+}
+
+// CheckModel verifies only the model allowlist for apiKey without charging any counter.
+func (e *Enforcer) CheckModel(apiKey, model string) *LimitError {
+	return e.check(apiKey, model, false)
+}
+
+func (e *Enforcer) check(apiKey, model string, charge bool) *LimitError {
+	if e == nil || apiKey == "" {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	limits, ok := e.limitsFor(apiKey)
+	if !ok {
+		return nil
+	}
+	if len(limits.AllowedModels) > 0 && !modelAllowed(limits.AllowedModels, model) {
+		return &LimitError{Kind: LimitModelNotAllowed, Model: model}
+	}
+	if !charge {
+		return nil
+	}
+
+	now := e.now()
+	counter := e.counterFor(apiKey)
+	counter.roll(now)
+	if limits.MonthlyBudget > 0 && counter.MonthSpend >= limits.MonthlyBudget {
+		return &LimitError{Kind: LimitMonthlyBudget, Model: model, RetryAfter: nextMonth(now).Sub(now)}
+	}
+	if limits.TokensPerDay > 0 && counter.DayTokens >= limits.TokensPerDay {
+		return &LimitError{Kind: LimitTokensPerDay, Model: model, RetryAfter: nextDay(now).Sub(now)}
+	}
+	if limits.RequestsPerMinute > 0 && counter.MinuteRequests >= limits.RequestsPerMinute {
+		return &LimitError{Kind: LimitRequestsPerMinute, Model: model, RetryAfter: counter.Minute.Add(time.Minute).Sub(now)}
+	}
+	counter.MinuteRequests++
+	return nil
+}
+
+// HandleUsage implements coreusage.Plugin by charging tokens and spend to the client key.
+func (e *Enforcer) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if e == nil || record.APIKey == "" {
+		return
+	}
+	e.mu.Lock()
+	if _, ok := e.limitsFor(record.APIKey); !ok {
+		e.mu.Unlock()
+		return
+	}
+	tokens := record.Detail.TotalTokens
+	if tokens == 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	var spend float64
+	if e.cost != nil {
+		spend = e.cost(record)
+	}
+	if tokens <= 0 && spend <= 0 {
+		e.mu.Unlock()
+		return
+	}
+	counter := e.counterFor(record.APIKey)
+	counter.roll(e.now())
+	counter.DayTokens += tokens
+	counter.MonthSpend += spend
+	e.schedulePersistLocked(ctx)
+	e.mu.Unlock()
+}
+
+// Snapshot returns a copy of the current counters keyed by util.HashAPIKey of the client key.
+func (e *Enforcer) Snapshot() map[string]Counter {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.snapshotLocked()
+}
+
+// Flush writes the current counters to the store.
+func (e *Enforcer) Flush(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	store := e.store
+	snapshot := e.snapshotLocked()
+	e.persistPending = false
+	e.mu.Unlock()
+	if store == nil {
+		return nil
+	}
+	return store.Save(ctx, snapshot)
+}
+
+func (e *Enforcer) schedulePersistLocked(ctx context.Context) {
+	if e.persistPending {
+		return
+	}
+	e.persistPending = true
+	persistCtx := context.WithoutCancel(ctx)
+	time.AfterFunc(persistDelay, func() {
+		if err := e.Flush(persistCtx); err != nil {
+			log.Warnf("client quota: failed to persist counters: %v", err)
+		}
+	})
+}
+
+func (e *Enforcer) snapshotLocked() map[string]Counter {
+	out := make(map[string]Counter, len(e.counters))
+	for key, counter := range e.counters {
+		out[key] = *counter
+	}
+	return out
+}
+
+func (e *Enforcer) limitsFor(apiKey string) (Limits, bool) {
+	if limits, ok := e.limits[apiKey]; ok {
+		return limits, true
+	}
+	limits, ok := e.limits[DefaultKey]
+	return limits, ok
+}
+
+// counterFor returns the counter of apiKey. Counters are keyed by the key's hash so the
+// persisted counters never contain client keys.
+func (e *Enforcer) counterFor(apiKey string) *Counter {
+	key := util.HashAPIKey(apiKey)
+	counter, ok := e.counters[key]
+	if !ok {
+		counter = &Counter{}
+		e.counters[key] = counter
+	}
+	return counter
+}
+
+func nextDay(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+func nextMonth(now time.Time) time.Time {
+	y, m, _ := now.Date()
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, now.Location())
+}
+
+// modelAllowed reports whether model matches one of the allowlist patterns.
+func modelAllowed(patterns []string, model string) bool {
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range patterns {
+		if util.MatchWildcard(strings.ToLower(pattern), model) {
+			return true
+		}
+	}
+	return false
+}
+
+var defaultEnforcer = NewEnforcer()
+
+func init() {
+	// Price budgets with the process-wide table so every entry point charges spend, not only
+	// services assembled through the SDK builder.
+	defaultEnforcer.SetCostFunc(pricing.Cost)
+	coreusage.RegisterPlugin(defaultEnforcer)
+}
+
+// Default returns the process-wide enforcer.
+func Default() *Enforcer { return defaultEnforcer }
+
+// Configure applies cfg to the process-wide enforcer.
+func Configure(cfg *config.SDKConfig) { defaultEnforcer.Configure(cfg) }
@@ -0,0 +1,151 @@
+package quota
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+func newTestEnforcer(t *testing.T, keys ...config.ClientKeyQuota) (*Enforcer, *time.Time) {
+	t.Helper()
+	e := NewEnforcer()
+	e.Configure(&config.SDKConfig{ClientQuotas: config.ClientQuotaConfig{Keys: keys}})
+	now := time.Date(2026, 3, 10, 12, 30, 15, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	return e, &now
+}
+
+func TestEnforcerRequestsPerMinute(t *testing.T) {
+	e, now := newTestEnforcer(t, config.ClientKeyQuota{APIKey: "k1", RequestsPerMinute: 2})
+
+	for i := 0; i < 2; i++ {
+		if err := e.Admit("k1", "gpt-5"); err != nil {
+			t.Fatalf("request %d rejected: %v", i, err)
+		}
+	}
+	err := e.Admit("k1", "gpt-5")
+	if err == nil || err.Kind != LimitRequestsPerMinute {
+		t.Fatalf("expected requests_per_minute violation, got %v", err)
+	}
+	if err.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", err.StatusCode())
+	}
+	if got := err.Headers().Get("Retry-After"); got != "45" {
+		t.Fatalf("Retry-After = %q, want 45", got)
+	}
+	if other := e.Admit("unlimited", "gpt-5"); other != nil {
+		t.Fatalf("key without limits rejected: %v", other)
+	}
+
+	*now = now.Add(time.Minute)
+	if err = e.Admit("k1", "gpt-5"); err != nil {
+		t.Fatalf("request after window reset rejected: %v", err)
+	}
+}
+
+func TestEnforcerTokensAndBudgetFromUsage(t *testing.T) {
+	e, now := newTestEnforcer(t, config.ClientKeyQuota{APIKey: "*", TokensPerDay: 100, MonthlyBudget: 1})
+	e.SetCostFunc(func(record coreusage.Record) float64 { return float64(record.Detail.OutputTokens) / 100 })
+
+	e.HandleUsage(context.Background(), coreusage.Record{APIKey: "team", Detail: coreusage.Detail{InputTokens: 60, OutputTokens: 50}})
+	err := e.Admit("team", "gemini-2.5-pro")
+	if err == nil || err.Kind != LimitTokensPerDay {
+		t.Fatalf("expected tokens_per_day violation, got %v", err)
+	}
+
+	*now = now.Add(24 * time.Hour)
+	if err = e.Admit("team", "gemini-2.5-pro"); err != nil {
+		t.Fatalf("request on next day rejected: %v", err)
+	}
+	e.HandleUsage(context.Background(), coreusage.Record{APIKey: "team", Detail: coreusage.Detail{OutputTokens: 60}})
+	err = e.Admit("team", "gemini-2.5-pro")
+	if err == nil || err.Kind != LimitMonthlyBudget {
+		t.Fatalf("expected monthly_budget violation, got %v", err)
+	}
+}
+
+func TestEnforcerAllowedModels(t *testing.T) {
+	e, _ := newTestEnforcer(t, config.ClientKeyQuota{APIKey: "k1", AllowedModels: []string{"gemini-*"}})
+
+	if err := e.CheckModel("k1", "gemini-2.5-flash"); err != nil {
+		t.Fatalf("allowed model rejected: %v", err)
+	}
+	err := e.Admit("k1", "claude-sonnet-4-5")
+	if err == nil || err.StatusCode() != http.StatusForbidden {
+		t.Fatalf("expected 403 model_not_allowed, got %v", err)
+	}
+	if err.Headers().Get("Retry-After") != "" {
+		t.Fatalf("model rejection should not carry Retry-After")
+	}
+}
+
+func TestLimitErrorFormats(t *testing.T) {
+	err := &LimitError{Kind: LimitRequestsPerMinute, RetryAfter: time.Second}
+
+	err.Format = "claude"
+	if got := gjson.Get(err.Error(), "error.type").String(); got != "rate_limit_error" {
+		t.Fatalf("claude error type = %q", got)
+	}
+	err.Format = "gemini"
+	if got := gjson.Get(err.Error(), "error.status").String(); got != "RESOURCE_EXHAUSTED" {
+		t.Fatalf("gemini error status = %q", got)
+	}
+	err.Format = "openai"
+	if got := gjson.Get(err.Error(), "error.code").String(); got != "rate_limit_exceeded" {
+		t.Fatalf("openai error code = %q", got)
+	}
+}
+
+func TestFileStorePersistsCounters(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	e, _ := newTestEnforcer(t, config.ClientKeyQuota{APIKey: "k1", TokensPerDay: 1000})
+	if err = e.SetStore(context.Background(), store); err != nil {
+		t.Fatalf("SetStore: %v", err)
+	}
+	e.HandleUsage(context.Background(), coreusage.Record{APIKey: "k1", Detail: coreusage.Detail{TotalTokens: 42}})
+	if err = e.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	restored, _ := newTestEnforcer(t, config.ClientKeyQuota{APIKey: "k1", TokensPerDay: 1000})
+	if err = restored.SetStore(context.Background(), store); err != nil {
+		t.Fatalf("SetStore restored: %v", err)
+	}
+	if got := restored.Snapshot()[util.HashAPIKey("k1")].DayTokens; got != 42 {
+		t.Fatalf("restored day tokens = %d, want 42", got)
+	}
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatalf("read counters: %v", err)
+	}
+	if strings.Contains(string(data), `"k1"`) {
+		t.Fatalf("persisted counters contain the client key: %s", data)
+	}
+}
+
+func TestSetStoreMigratesRawKeyCounters(t *testing.T) {
+	store := NewMemoryStore()
+	_ = store.Save(context.Background(), map[string]Counter{"k1": {DayTokens: 7}})
+	e, _ := newTestEnforcer(t, config.ClientKeyQuota{APIKey: "k1", TokensPerDay: 1000})
+	if err := e.SetStore(context.Background(), store); err != nil {
+		t.Fatalf("SetStore: %v", err)
+	}
+	snapshot := e.Snapshot()
+	if _, ok := snapshot["k1"]; ok {
+		t.Fatal("raw client key kept as counter key")
+	}
+	if got := snapshot[util.HashAPIKey("k1")].DayTokens; got != 7 {
+		t.Fatalf("migrated day tokens = %d, want 7", got)
+	}
+}
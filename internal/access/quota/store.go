@@ -0,0 +1,111 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store persists quota counters so limits survive restarts.
+type Store interface {
+	// Load returns all persisted counters keyed by the hash of the client API key.
+	Load(ctx context.Context) (map[string]Counter, error)
+	// Save replaces the persisted counters.
+	Save(ctx context.Context, counters map[string]Counter) error
+}
+
+// MemoryStore keeps counters in process memory only.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]Counter
+}
+
+// NewMemoryStore constructs an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]Counter)}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(context.Context) (map[string]Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]Counter, len(s.counters))
+	for key, counter := range s.counters {
+		out[key] = counter
+	}
+	return out, nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(_ context.Context, counters map[string]Counter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters = make(map[string]Counter, len(counters))
+	for key, counter := range counters {
+		s.counters[key] = counter
+	}
+	return nil
+}
+
+// FileStore persists counters as a single JSON document inside a directory.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore creates a file-backed store rooted at dir.
+func NewFileStore(dir string) (*FileStore, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("quota store: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("quota store: create directory: %w", err)
+	}
+	return &FileStore{path: filepath.Join(dir, "counters.json")}, nil
+}
+
+// Load implements Store.
+func (s *FileStore) Load(context.Context) (map[string]Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return map[string]Counter{}, nil
+		}
+		return nil, fmt.Errorf("quota store: read counters: %w", err)
+	}
+	counters := make(map[string]Counter)
+	if len(data) == 0 {
+		return counters, nil
+	}
+	if err = json.Unmarshal(data, &counters); err != nil {
+		return nil, fmt.Errorf("quota store: decode counters: %w", err)
+	}
+	return counters, nil
+}
+
+// Save implements Store.
+func (s *FileStore) Save(_ context.Context, counters map[string]Counter) error {
+	data, err := json.Marshal(counters)
+	if err != nil {
+		return fmt.Errorf("quota store: encode counters: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("quota store: write temp counters: %w", err)
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("quota store: rename counters: %w", err)
+	}
+	return nil
+}
@@ -7,7 +7,12 @@ import (
 	"strings"
 
 	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
+	jwtaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/jwt_access"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/quota"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/contextguard"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redact"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	log "github.com/sirupsen/logrus"
 )
@@ -86,6 +91,11 @@ func ApplyAccessProviders(manager *sdkaccess.Manager, oldCfg, newCfg *config.Con
 
 	existing := manager.Providers()
 	configaccess.Register(&newCfg.SDKConfig)
+	jwtaccess.Register(&newCfg.SDKConfig)
+	quota.Configure(&newCfg.SDKConfig)
+	pricing.Configure(&newCfg.SDKConfig)
+	redact.Configure(&newCfg.SDKConfig)
+	contextguard.Configure(&newCfg.SDKConfig)
 	providers, added, updated, removed, err := ReconcileProviders(oldCfg, newCfg, existing)
 	if err != nil {
 		log.Errorf("failed to reconcile request auth providers: %v", err)
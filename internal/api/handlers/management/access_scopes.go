@@ -0,0 +1,247 @@
+package management
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// Management token scopes. A ":write" scope also grants the matching ":read" scope.
+const (
+	scopeAll            = "*"
+	scopeConfigRead     = "config:read"
+	scopeConfigWrite    = "config:write"
+	scopeUsageRead      = "usage:read"
+	scopeUsageWrite     = "usage:write"
+	scopeLogsRead       = "logs:read"
+	scopeLogsWrite      = "logs:write"
+	scopeAuthRead       = "auth:read"
+	scopeAuthWrite      = "auth:write"
+	scopeSecretsRead    = "secrets:read"
+	scopeTokensDownload = "tokens:download"
+	scopeAuditRead      = "audit:read"
+)
+
+var knownScopes = []string{
+	scopeAll, scopeConfigRead, scopeConfigWrite, scopeSecretsRead, scopeUsageRead, scopeUsageWrite,
+	scopeLogsRead, scopeLogsWrite, scopeAuthRead, scopeAuthWrite, scopeTokensDownload, scopeAuditRead,
+}
+
+// secretRoutes return upstream or client API keys, or the whole config that holds them, in
+// clear text. Reading them needs secrets:read rather than config:read.
+var secretRoutes = map[string]struct{}{
+	"/config":                    {},
+	"/config.yaml":               {},
+	"/api-keys":                  {},
+	"/gemini-api-key":            {},
+	"/claude-api-key":            {},
+	"/codex-api-key":             {},
+	"/vertex-api-key":            {},
+	"/openai-compatibility":      {},
+	"/proxy-url":                 {},
+	"/ampcode":                   {},
+	"/ampcode/upstream-api-key":  {},
+	"/ampcode/upstream-api-keys": {},
+}
+
+const managementBasePath = "/v0/management"
+
+// managementActorKey is the gin context key holding the caller recorded in the audit log.
+const managementActorKey = "managementActor"
+
+// managementIdentity is the caller of a management request.
+type managementIdentity struct {
+	actor string
+	// scopes is nil for the secret key, the management password and the local password,
+	// which grant every scope.
+	scopes []string
+}
+
+func (id managementIdentity) allows(scope string) bool {
+	if id.scopes == nil {
+		return true
+	}
+	for _, granted := range id.scopes {
+		if granted == scopeAll || granted == scope {
+			return true
+		}
+		if base, ok := strings.CutSuffix(granted, ":write"); ok && scope == base+":read" {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredScope maps a management route (relative to /v0/management) to the scope it needs.
+// Routes that can grant further access, such as token management or replacing the whole
+// config file, require "*"; routes that reveal credentials require secrets:read.
+func requiredScope(method, route string) string {
+	read := method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+	pick := func(readScope, writeScope string) string {
+		if read {
+			return readScope
+		}
+		return writeScope
+	}
+	switch {
+	case route == "/management-tokens":
+		return pick(scopeAuditRead, scopeAll)
+	case route == "/audit-log":
+		return scopeAuditRead
+	case route == "/config.yaml":
+		return pick(scopeSecretsRead, scopeAll)
+	case route == "/auth-files/download":
+		return scopeTokensDownload
+	case strings.HasSuffix(route, "-auth-url"), route == "/get-auth-status", route == "/oauth-callback",
+		route == "/api-call", route == "/vertex/import":
+		// Login flows and upstream calls made with stored credentials.
+		return scopeAuthWrite
+	case strings.HasPrefix(route, "/auth-files"), strings.HasPrefix(route, "/auth-encryption"),
+		strings.HasPrefix(route, "/remote-sync"):
+		return pick(scopeAuthRead, scopeAuthWrite)
+	case route == "/usage", strings.HasPrefix(route, "/usage/"), route == "/routing/scores":
+		return pick(scopeUsageRead, scopeUsageWrite)
+	case route == "/logs", strings.HasPrefix(route, "/request-error-logs"), strings.HasPrefix(route, "/request-log-by-id"),
+		strings.HasPrefix(route, "/response-cache"):
+		return pick(scopeLogsRead, scopeLogsWrite)
+	}
+	if _, ok := secretRoutes[route]; ok && read {
+		return scopeSecretsRead
+	}
+	return pick(scopeConfigRead, scopeConfigWrite)
+}
+
+// matchManagementToken returns the configured token whose digest matches provided. Every
+// token is compared so the timing does not reveal which one matched.
+func matchManagementToken(tokens []config.ManagementToken, provided string) (config.ManagementToken, bool) {
+	digest := []byte(config.DigestManagementToken(provided))
+	var (
+		matched config.ManagementToken
+		found   bool
+	)
+	for _, token := range tokens {
+		if token.Token == "" {
+			continue
+		}
+		if subtle.ConstantTimeCompare(digest, []byte(token.Token)) == 1 && !found {
+			matched, found = token, true
+		}
+	}
+	return matched, found
+}
+
+// managementTokens returns a snapshot of the configured management tokens taken under h.mu,
+// which guards the changes made by PostManagementToken and DeleteManagementToken.
+func (h *Handler) managementTokens() []config.ManagementToken {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cfg == nil {
+		return nil
+	}
+	return slices.Clone(h.cfg.RemoteManagement.Tokens)
+}
+
+// GetManagementTokens lists the named management tokens and their scopes. Token values are
+// never returned.
+func (h *Handler) GetManagementTokens(c *gin.Context) {
+	type tokenView struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	out := []tokenView{}
+	if h.cfg != nil {
+		for _, token := range h.managementTokens() {
+			out = append(out, tokenView{Name: token.Name, Scopes: append([]string{}, token.Scopes...)})
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": out, "scopes": knownScopes})
+}
+
+// PostManagementToken creates a named token with the requested scopes. The generated value is
+// returned once; only its digest is stored.
+func (h *Handler) PostManagementToken(c *gin.Context) {
+	var body struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if len(body.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scopes are required"})
+		return
+	}
+	scopes := make([]string, 0, len(body.Scopes))
+	for _, scope := range body.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !slices.Contains(knownScopes, scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope " + scope})
+			return
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+	value := "cpm-" + hex.EncodeToString(raw)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, token := range h.cfg.RemoteManagement.Tokens {
+		if token.Name == name {
+			c.JSON(http.StatusConflict, gin.H{"error": "token name already exists"})
+			return
+		}
+	}
+	h.cfg.RemoteManagement.Tokens = append(h.cfg.RemoteManagement.Tokens, config.ManagementToken{
+		Name:   name,
+		Token:  config.HashManagementToken(value),
+		Scopes: scopes,
+	})
+	if err := config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
+		h.cfg.RemoteManagement.Tokens = h.cfg.RemoteManagement.Tokens[:len(h.cfg.RemoteManagement.Tokens)-1]
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save config: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"name": name, "scopes": scopes, "token": value})
+}
+
+// DeleteManagementToken revokes the token given by the name query parameter.
+func (h *Handler) DeleteManagementToken(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	tokens := h.cfg.RemoteManagement.Tokens
+	idx := slices.IndexFunc(tokens, func(token config.ManagementToken) bool { return token.Name == name })
+	if idx < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+		return
+	}
+	h.cfg.RemoteManagement.Tokens = slices.Delete(slices.Clone(tokens), idx, idx+1)
+	if err := config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
+		h.cfg.RemoteManagement.Tokens = tokens
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save config: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,192 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+func TestRequiredScope(t *testing.T) {
+	cases := []struct {
+		method, route, want string
+	}{
+		{http.MethodGet, "/usage", scopeUsageRead},
+		{http.MethodPost, "/usage/import", scopeUsageWrite},
+		{http.MethodGet, "/logs", scopeLogsRead},
+		{http.MethodDelete, "/logs", scopeLogsWrite},
+		{http.MethodGet, "/auth-files", scopeAuthRead},
+		{http.MethodPost, "/auth-files", scopeAuthWrite},
+		{http.MethodGet, "/auth-files/download", scopeTokensDownload},
+		{http.MethodGet, "/codex-auth-url", scopeAuthWrite},
+		{http.MethodGet, "/debug", scopeConfigRead},
+		{http.MethodGet, "/config", scopeSecretsRead},
+		{http.MethodGet, "/config.yaml", scopeSecretsRead},
+		{http.MethodGet, "/api-keys", scopeSecretsRead},
+		{http.MethodGet, "/gemini-api-key", scopeSecretsRead},
+		{http.MethodGet, "/claude-api-key", scopeSecretsRead},
+		{http.MethodGet, "/codex-api-key", scopeSecretsRead},
+		{http.MethodGet, "/vertex-api-key", scopeSecretsRead},
+		{http.MethodGet, "/openai-compatibility", scopeSecretsRead},
+		{http.MethodGet, "/ampcode/upstream-api-keys", scopeSecretsRead},
+		{http.MethodPut, "/api-keys", scopeConfigWrite},
+		{http.MethodPut, "/debug", scopeConfigWrite},
+		{http.MethodPut, "/config.yaml", scopeAll},
+		{http.MethodPost, "/management-tokens", scopeAll},
+		{http.MethodGet, "/audit-log", scopeAuditRead},
+	}
+	for _, tc := range cases {
+		if got := requiredScope(tc.method, tc.route); got != tc.want {
+			t.Errorf("requiredScope(%s %s) = %q, want %q", tc.method, tc.route, got, tc.want)
+		}
+	}
+}
+
+func TestManagementIdentityAllows(t *testing.T) {
+	id := managementIdentity{actor: "ops", scopes: []string{scopeAuthWrite, scopeUsageRead}}
+	for _, scope := range []string{scopeAuthWrite, scopeAuthRead, scopeUsageRead} {
+		if !id.allows(scope) {
+			t.Errorf("expected %s to be allowed", scope)
+		}
+	}
+	for _, scope := range []string{scopeUsageWrite, scopeConfigRead, scopeTokensDownload, scopeAll} {
+		if id.allows(scope) {
+			t.Errorf("expected %s to be denied", scope)
+		}
+	}
+	if !(managementIdentity{actor: "secret-key"}).allows(scopeAll) {
+		t.Fatal("secret key should grant every scope")
+	}
+	if (managementIdentity{actor: "empty", scopes: []string{}}).allows(scopeConfigRead) {
+		t.Fatal("token without scopes should grant nothing")
+	}
+}
+
+func TestMatchManagementTokenRejectsStoredDigest(t *testing.T) {
+	tokens := []config.ManagementToken{{Name: "ops", Token: config.HashManagementToken("secret-token")}}
+	if _, ok := matchManagementToken(tokens, "secret-token"); !ok {
+		t.Fatal("expected the token value to match")
+	}
+	if _, ok := matchManagementToken(tokens, tokens[0].Token); ok {
+		t.Fatal("stored digest must not authenticate")
+	}
+}
+
+func TestMiddlewareEnforcesTokenScopesAndAudits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authDir := t.TempDir()
+	t.Setenv("WRITABLE_PATH", t.TempDir())
+	h := &Handler{
+		cfg: &config.Config{
+			AuthDir: authDir,
+			RemoteManagement: config.RemoteManagement{
+				AllowRemote: true,
+				Tokens: []config.ManagementToken{
+					{Name: "grafana", Token: config.HashManagementToken("usage-token"), Scopes: []string{scopeUsageRead}},
+					{Name: "admin", Token: config.HashManagementToken("admin-token"), Scopes: []string{scopeAll}},
+				},
+			},
+		},
+		configFilePath: writeTestConfigFile(t),
+		failedAttempts: make(map[string]*attemptInfo),
+	}
+
+	r := gin.New()
+	mgmt := r.Group(managementBasePath)
+	mgmt.Use(h.Middleware())
+	mgmt.GET("/usage", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) })
+	mgmt.PUT("/debug", h.PutDebug)
+	mgmt.GET("/audit-log", h.GetAuditLog)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "10.0.0.8:1234"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/v0/management/usage", "usage-token", ""); rec.Code != http.StatusOK {
+		t.Fatalf("usage read: status %d body %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPut, "/v0/management/debug", "usage-token", `{"value":true}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("config write with usage token: status %d", rec.Code)
+	}
+	if h.cfg.Debug {
+		t.Fatal("debug changed by a token without config:write")
+	}
+	if rec := do(http.MethodGet, "/v0/management/usage", "wrong-token", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: status %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/v0/management/usage", config.HashManagementToken("usage-token"), ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("stored digest as token: status %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/v0/management/debug", "admin-token", `{"value":true}`); rec.Code != http.StatusOK {
+		t.Fatalf("config write with admin token: status %d body %s", rec.Code, rec.Body.String())
+	}
+	if !h.cfg.Debug {
+		t.Fatal("debug not updated")
+	}
+
+	if rec := do(http.MethodGet, "/v0/management/audit-log", "usage-token", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("audit read with usage token: status %d", rec.Code)
+	}
+	rec := do(http.MethodGet, "/v0/management/audit-log", "admin-token", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("audit read: status %d body %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Entries []auditRecord `json:"entries"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entries) != 2 {
+		t.Fatalf("audit entries = %d, want 2: %+v", len(resp.Entries), resp.Entries)
+	}
+	latest, denied := resp.Entries[0], resp.Entries[1]
+	if latest.Actor != "admin" || latest.Status != http.StatusOK || latest.SourceIP != "10.0.0.8" {
+		t.Fatalf("unexpected latest entry: %+v", latest)
+	}
+	if len(latest.Changes) != 1 || latest.Changes[0].Path != "debug" || latest.Changes[0].Before != "false" || latest.Changes[0].After != "true" {
+		t.Fatalf("unexpected changes: %+v", latest.Changes)
+	}
+	if denied.Actor != "grafana" || denied.Status != http.StatusForbidden || len(denied.Changes) != 0 {
+		t.Fatalf("unexpected denied entry: %+v", denied)
+	}
+
+	rec = do(http.MethodGet, "/v0/management/audit-log?actor=grafana&limit=5", "admin-token", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entries) != 1 || resp.Entries[0].Actor != "grafana" {
+		t.Fatalf("actor filter: %+v", resp.Entries)
+	}
+	if got := h.auditLogPath(); got != filepath.Join(util.ResolveDataDirectory("audit"), defaultAuditLogName) {
+		t.Fatalf("audit log path = %q", got)
+	}
+}
+
+func TestDiffAuditSnapshotsMasksSecrets(t *testing.T) {
+	before := auditSnapshot{config: map[string]string{}}
+	flattenAuditValue("", map[string]any{"api-keys": []any{"sk-old-secret-value"}, "debug": false}, before.config)
+	after := auditSnapshot{config: map[string]string{}}
+	flattenAuditValue("", map[string]any{"api-keys": []any{"sk-new-secret-value"}, "debug": false}, after.config)
+	changes := diffAuditSnapshots(before, after)
+	if len(changes) != 1 || changes[0].Path != "api-keys[0]" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	if strings.Contains(changes[0].Before, "old-secret") || strings.Contains(changes[0].After, "new-secret") {
+		t.Fatalf("secret not masked: %+v", changes[0])
+	}
+}
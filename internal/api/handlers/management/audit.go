@@ -0,0 +1,305 @@
+package management
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultAuditLogName is the audit log file created in the "audit" data directory.
+const defaultAuditLogName = "management-audit.jsonl"
+
+const (
+	defaultAuditQueryLimit = 100
+	maxAuditQueryLimit     = 1000
+)
+
+// auditRecord is one line of the management audit log.
+type auditRecord struct {
+	Time     time.Time     `json:"time"`
+	Actor    string        `json:"actor"`
+	SourceIP string        `json:"source-ip"`
+	Method   string        `json:"method"`
+	Path     string        `json:"path"`
+	Query    string        `json:"query,omitempty"`
+	Scope    string        `json:"scope"`
+	Status   int           `json:"status"`
+	Changes  []auditChange `json:"changes,omitempty"`
+}
+
+// auditChange is one changed config value or auth file. Secret values are masked and auth
+// files are identified by a digest of their content; an absent side means the value was added
+// or removed.
+type auditChange struct {
+	Path   string `json:"path"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// auditSnapshot captures the state a mutating call may change.
+type auditSnapshot struct {
+	config    map[string]string
+	authFiles map[string]string
+}
+
+var auditFileMu sync.Mutex
+
+// serveAuthorized enforces the route scope for the caller, then runs the handler. Mutating calls,
+// including those rejected for a missing scope, are written to the audit log.
+func (h *Handler) serveAuthorized(c *gin.Context, id managementIdentity) {
+	route := strings.TrimPrefix(c.FullPath(), managementBasePath)
+	scope := requiredScope(c.Request.Method, route)
+	c.Set(managementActorKey, id.actor)
+	method := c.Request.Method
+	mutating := method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+	auditPath := h.auditLogPath()
+	if !id.allows(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("management token %q lacks scope %s", id.actor, scope)})
+		if mutating && auditPath != "" {
+			h.writeAudit(auditPath, c, id, scope, nil)
+		}
+		return
+	}
+	if !mutating || auditPath == "" {
+		c.Next()
+		return
+	}
+	withAuth := strings.HasPrefix(scope, "auth:")
+	before := h.takeAuditSnapshot(withAuth)
+	c.Next()
+	after := h.takeAuditSnapshot(withAuth)
+	h.writeAudit(auditPath, c, id, scope, diffAuditSnapshots(before, after))
+}
+
+// auditLogPath returns the audit log location, or "" when the audit log is disabled.
+func (h *Handler) auditLogPath() string {
+	cfg := h.cfg
+	if cfg == nil || cfg.RemoteManagement.DisableAuditLog {
+		return ""
+	}
+	if path := strings.TrimSpace(cfg.RemoteManagement.AuditLogFile); path != "" {
+		return path
+	}
+	return filepath.Join(util.ResolveDataDirectory("audit"), defaultAuditLogName)
+}
+
+func (h *Handler) writeAudit(path string, c *gin.Context, id managementIdentity, scope string, changes []auditChange) {
+	record := auditRecord{
+		Time:     time.Now().UTC(),
+		Actor:    id.actor,
+		SourceIP: c.ClientIP(),
+		Method:   c.Request.Method,
+		Path:     c.Request.URL.Path,
+		Query:    util.MaskSensitiveQuery(c.Request.URL.RawQuery),
+		Scope:    scope,
+		Status:   c.Writer.Status(),
+		Changes:  changes,
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	auditFileMu.Lock()
+	defer auditFileMu.Unlock()
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		log.WithError(err).Warn("management audit: create directory")
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.WithError(err).Warn("management audit: open log")
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		log.WithError(err).Warn("management audit: write record")
+	}
+}
+
+// takeAuditSnapshot holds h.mu so the config is not marshalled while a handler saves it.
+func (h *Handler) takeAuditSnapshot(withAuthFiles bool) auditSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var snap auditSnapshot
+	cfg := h.cfg
+	if cfg == nil {
+		return snap
+	}
+	if data, err := yaml.Marshal(cfg); err == nil {
+		var tree any
+		if yaml.Unmarshal(data, &tree) == nil {
+			snap.config = make(map[string]string)
+			flattenAuditValue("", tree, snap.config)
+		}
+	}
+	if withAuthFiles && cfg.AuthDir != "" {
+		snap.authFiles = make(map[string]string)
+		entries, _ := os.ReadDir(cfg.AuthDir)
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(cfg.AuthDir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			sum := sha256.Sum256(data)
+			snap.authFiles[entry.Name()] = "sha256:" + hex.EncodeToString(sum[:8])
+		}
+	}
+	return snap
+}
+
+// flattenAuditValue turns a decoded YAML tree into dotted paths. Values under keys that look
+// like credentials are masked.
+func flattenAuditValue(prefix string, value any, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenAuditValue(path, child, out)
+		}
+	case []any:
+		for i, child := range v {
+			flattenAuditValue(fmt.Sprintf("%s[%d]", prefix, i), child, out)
+		}
+	case nil:
+	default:
+		text := fmt.Sprint(v)
+		if isSecretAuditPath(prefix) {
+			text = util.HideAPIKey(text)
+		}
+		out[prefix] = text
+	}
+}
+
+func isSecretAuditPath(path string) bool {
+	lower := strings.ToLower(path)
+	for _, marker := range []string{"key", "secret", "token", "password", "authorization", "cookie"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func diffAuditSnapshots(before, after auditSnapshot) []auditChange {
+	var changes []auditChange
+	diff := func(prefix string, a, b map[string]string) {
+		keys := make([]string, 0, len(a)+len(b))
+		for k := range a {
+			keys = append(keys, k)
+		}
+		for k := range b {
+			if _, ok := a[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			if a[k] != b[k] {
+				changes = append(changes, auditChange{Path: prefix + k, Before: a[k], After: b[k]})
+			}
+		}
+	}
+	diff("", before.config, after.config)
+	diff("auth-files/", before.authFiles, after.authFiles)
+	return changes
+}
+
+// GetAuditLog returns management audit records, newest first. Query parameters: limit (default
+// 100, at most 1000), actor, path (prefix of the request path) and since (RFC 3339).
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	path := h.auditLogPath()
+	if path == "" {
+		c.JSON(http.StatusOK, gin.H{"enabled": false, "entries": []auditRecord{}})
+		return
+	}
+	limit := defaultAuditQueryLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = min(n, maxAuditQueryLimit)
+	}
+	var since time.Time
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since"})
+			return
+		}
+		since = parsed
+	}
+	actor := c.Query("actor")
+	pathPrefix := c.Query("path")
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			c.JSON(http.StatusOK, gin.H{"enabled": true, "entries": []auditRecord{}})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to open audit log: %v", err)})
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	// Keep the last limit matches in a ring while scanning the file once.
+	ring := make([]auditRecord, 0, limit)
+	next := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record auditRecord
+		if json.Unmarshal(scanner.Bytes(), &record) != nil {
+			continue
+		}
+		if actor != "" && record.Actor != actor {
+			continue
+		}
+		if pathPrefix != "" && !strings.HasPrefix(record.Path, pathPrefix) && !strings.HasPrefix(strings.TrimPrefix(record.Path, managementBasePath), pathPrefix) {
+			continue
+		}
+		if !since.IsZero() && record.Time.Before(since) {
+			continue
+		}
+		if len(ring) < limit {
+			ring = append(ring, record)
+		} else {
+			ring[next] = record
+		}
+		next = (next + 1) % limit
+	}
+	if err = scanner.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read audit log: %v", err)})
+		return
+	}
+	entries := make([]auditRecord, 0, len(ring))
+	for i := range ring {
+		entries = append(entries, ring[(next-1-i+2*len(ring))%len(ring)])
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "entries": entries})
+}
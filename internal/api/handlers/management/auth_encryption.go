@@ -0,0 +1,94 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+)
+
+// GetAuthEncryption reports whether auth records are encrypted at rest, the configured key ids
+// and how many auth files are still plaintext or sealed with a retired key.
+func (h *Handler) GetAuthEncryption(c *gin.Context) {
+	keyring := authcrypt.Default()
+	active := keyring.ActiveKeyID()
+	plaintext, current, retired := 0, 0, 0
+	if h.cfg != nil && h.cfg.AuthDir != "" {
+		entries, err := os.ReadDir(h.cfg.AuthDir)
+		if err != nil && !os.IsNotExist(err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read auth dir: %v", err)})
+			return
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(strings.ToLower(name), ".json") {
+				continue
+			}
+			data, errRead := os.ReadFile(filepath.Join(h.cfg.AuthDir, name))
+			if errRead != nil || len(data) == 0 {
+				continue
+			}
+			switch kid := authcrypt.KeyIDOf(data); {
+			case kid == "":
+				plaintext++
+			case kid == active:
+				current++
+			default:
+				retired++
+			}
+		}
+	}
+	keyIDs := keyring.KeyIDs()
+	if keyIDs == nil {
+		keyIDs = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":       keyring != nil,
+		"active-key-id": active,
+		"key-ids":       keyIDs,
+		"files": gin.H{
+			"plaintext":   plaintext,
+			"current-key": current,
+			"retired-key": retired,
+		},
+	})
+}
+
+// RotateAuthEncryptionKey generates a new master key and re-encrypts every auth file with it.
+func (h *Handler) RotateAuthEncryptionKey(c *gin.Context) {
+	h.reencryptAuthFiles(c, true)
+}
+
+// ReencryptAuthFiles seals plaintext auth files and files sealed with a retired key using the
+// active master key.
+func (h *Handler) ReencryptAuthFiles(c *gin.Context) {
+	h.reencryptAuthFiles(c, false)
+}
+
+func (h *Handler) reencryptAuthFiles(c *gin.Context, rotate bool) {
+	keyring := authcrypt.Default()
+	if keyring == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "auth encryption is not configured"})
+		return
+	}
+	if h.cfg == nil || h.cfg.AuthDir == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth directory not configured"})
+		return
+	}
+	persister, _ := h.tokenStore.(authcrypt.Persister)
+	result, err := keyring.Reencrypt(c.Request.Context(), h.cfg.AuthDir, rotate, persister)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, authcrypt.ErrNotConfigured) || errors.Is(err, authcrypt.ErrNoKeyFile) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error(), "result": result})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
@@ -286,6 +287,10 @@ func normalizeRoutingStrategy(strategy string) (string, bool) {
 		return "round-robin", true
 	case "fill-first", "fillfirst", "ff":
 		return "fill-first", true
+	case "latency", "latency-aware", "ewma":
+		return "latency", true
+	case "least-inflight", "leastinflight", "li":
+		return "least-inflight", true
 	default:
 		return "", false
 	}
@@ -317,6 +322,27 @@ func (h *Handler) PutRoutingStrategy(c *gin.Context) {
 	h.persist(c)
 }
 
+// GetRoutingScores returns the live per-credential latency, TTFT, in-flight and error metrics
+// behind the latency and least-inflight strategies. Filter with ?provider= and ?model=.
+func (h *Handler) GetRoutingScores(c *gin.Context) {
+	strategy, _ := normalizeRoutingStrategy(h.cfg.Routing.Strategy)
+	scores := make([]coreauth.PerformanceScore, 0)
+	if h.authManager != nil {
+		provider := strings.TrimSpace(c.Query("provider"))
+		model := strings.TrimSpace(c.Query("model"))
+		for _, score := range h.authManager.PerformanceScores() {
+			if provider != "" && !strings.EqualFold(score.Provider, provider) {
+				continue
+			}
+			if model != "" && !strings.EqualFold(score.Model, model) {
+				continue
+			}
+			scores = append(scores, score)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"strategy": strategy, "scores": scores})
+}
+
 // Proxy URL
 func (h *Handler) GetProxyURL(c *gin.Context) { c.JSON(200, gin.H{"proxy-url": h.cfg.ProxyURL}) }
 func (h *Handler) PutProxyURL(c *gin.Context) {
@@ -137,6 +137,7 @@ func (h *Handler) SetPostAuthHook(hook coreauth.PostAuthHook) {
 // Middleware enforces access control for management endpoints.
 // All requests (local and remote) require a valid management key.
 // Additionally, remote access requires allow-remote-management=true.
+// Named management tokens are limited to their scopes, and mutating calls are audited.
 func (h *Handler) Middleware() gin.HandlerFunc {
 	const maxFailures = 5
 	const banDuration = 30 * time.Minute
@@ -202,7 +203,8 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 				h.attemptsMu.Unlock()
 			}
 		}
-		if secretHash == "" && envSecret == "" {
+		tokens := h.managementTokens()
+		if secretHash == "" && envSecret == "" && len(tokens) == 0 {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management key not set"})
 			return
 		}
@@ -232,13 +234,13 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		if localClient {
 			if lp := h.localPassword; lp != "" {
 				if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
-					c.Next()
+					h.serveAuthorized(c, managementIdentity{actor: "local-password"})
 					return
 				}
 			}
 		}
 
-		if envSecret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(envSecret)) == 1 {
+		succeed := func(id managementIdentity) {
 			if !localClient {
 				h.attemptsMu.Lock()
 				if ai := h.failedAttempts[clientIP]; ai != nil {
@@ -247,7 +249,16 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 				}
 				h.attemptsMu.Unlock()
 			}
-			c.Next()
+			h.serveAuthorized(c, id)
+		}
+
+		if envSecret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(envSecret)) == 1 {
+			succeed(managementIdentity{actor: "management-password"})
+			return
+		}
+
+		if token, ok := matchManagementToken(tokens, provided); ok {
+			succeed(managementIdentity{actor: token.Name, scopes: append([]string{}, token.Scopes...)})
 			return
 		}
 
@@ -259,16 +270,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			return
 		}
 
-		if !localClient {
-			h.attemptsMu.Lock()
-			if ai := h.failedAttempts[clientIP]; ai != nil {
-				ai.count = 0
-				ai.blockedUntil = time.Time{}
-			}
-			h.attemptsMu.Unlock()
-		}
-
-		c.Next()
+		succeed(managementIdentity{actor: "secret-key"})
 	}
 }
 
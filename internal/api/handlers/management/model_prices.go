@@ -0,0 +1,125 @@
+package management
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+)
+
+// model-prices: []ModelPrice
+//
+// GET returns both the configured overrides and the effective table (built-in defaults
+// merged with overrides) so clients can see which price a model is billed at.
+func (h *Handler) GetModelPrices(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"model-prices": h.cfg.ModelPrices,
+		"effective":    pricing.Default().Entries(),
+	})
+}
+
+func (h *Handler) PutModelPrices(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var entries []config.ModelPrice
+	if err = json.Unmarshal(data, &entries); err != nil {
+		var wrapper struct {
+			Items []config.ModelPrice `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &wrapper); err2 != nil {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		entries = wrapper.Items
+	}
+	normalized, ok := normalizeModelPrices(entries)
+	if !ok {
+		c.JSON(400, gin.H{"error": "invalid model price"})
+		return
+	}
+	h.cfg.ModelPrices = normalized
+	pricing.Configure(&h.cfg.SDKConfig)
+	h.persist(c)
+}
+
+// PatchModelPrices upserts a single price entry keyed by model.
+func (h *Handler) PatchModelPrices(c *gin.Context) {
+	var body config.ModelPrice
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	normalized, ok := normalizeModelPrices([]config.ModelPrice{body})
+	if !ok || len(normalized) != 1 {
+		c.JSON(400, gin.H{"error": "invalid model price"})
+		return
+	}
+	entry := normalized[0]
+	for i := range h.cfg.ModelPrices {
+		if strings.EqualFold(h.cfg.ModelPrices[i].Model, entry.Model) {
+			h.cfg.ModelPrices[i] = entry
+			pricing.Configure(&h.cfg.SDKConfig)
+			h.persist(c)
+			return
+		}
+	}
+	h.cfg.ModelPrices = append(h.cfg.ModelPrices, entry)
+	pricing.Configure(&h.cfg.SDKConfig)
+	h.persist(c)
+}
+
+func (h *Handler) DeleteModelPrices(c *gin.Context) {
+	model := strings.TrimSpace(c.Query("model"))
+	if model == "" {
+		c.JSON(400, gin.H{"error": "missing model"})
+		return
+	}
+	out := make([]config.ModelPrice, 0, len(h.cfg.ModelPrices))
+	for _, entry := range h.cfg.ModelPrices {
+		if !strings.EqualFold(entry.Model, model) {
+			out = append(out, entry)
+		}
+	}
+	if len(out) == len(h.cfg.ModelPrices) {
+		c.JSON(404, gin.H{"error": "model not found"})
+		return
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	h.cfg.ModelPrices = out
+	pricing.Configure(&h.cfg.SDKConfig)
+	h.persist(c)
+}
+
+// normalizeModelPrices trims model names, drops duplicates (last wins) and rejects
+// negative prices.
+func normalizeModelPrices(entries []config.ModelPrice) ([]config.ModelPrice, bool) {
+	out := make([]config.ModelPrice, 0, len(entries))
+	index := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		entry.Model = strings.TrimSpace(entry.Model)
+		if entry.Model == "" {
+			continue
+		}
+		if entry.Input < 0 || entry.Output < 0 || entry.CachedInput < 0 || entry.CacheWrite < 0 || entry.Reasoning < 0 {
+			return nil, false
+		}
+		key := strings.ToLower(entry.Model)
+		if i, exists := index[key]; exists {
+			out[i] = entry
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, entry)
+	}
+	if len(out) == 0 {
+		return nil, true
+	}
+	return out, true
+}
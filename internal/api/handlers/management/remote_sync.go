@@ -0,0 +1,42 @@
+package management
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/store"
+)
+
+// remoteSyncer is implemented by token stores that pull changes from a shared remote (the git
+// and object stores).
+type remoteSyncer interface {
+	RemoteSyncStatus() store.RemoteSyncStatus
+	PullRemote(ctx context.Context) (store.RemotePullResult, error)
+}
+
+// GetRemoteSync reports the periodic remote pull of the active token store: its interval and
+// conflict policy, the last attempt and success, and the last error.
+func (h *Handler) GetRemoteSync(c *gin.Context) {
+	syncer, ok := h.tokenStore.(remoteSyncer)
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, syncer.RemoteSyncStatus())
+}
+
+// PullRemoteSync pulls remote changes into the local workspace immediately.
+func (h *Handler) PullRemoteSync(c *gin.Context) {
+	syncer, ok := h.tokenStore.(remoteSyncer)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token store does not sync from a remote"})
+		return
+	}
+	result, err := syncer.PullRemote(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "result": result})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": result, "status": syncer.RemoteSyncStatus()})
+}
@@ -0,0 +1,78 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsecache"
+)
+
+// GetResponseCache returns hit-rate statistics and the live in-memory cache entries.
+func (h *Handler) GetResponseCache(c *gin.Context) {
+	cache := responsecache.Default()
+	enabled := h != nil && h.cfg != nil && h.cfg.ResponseCache.Enable
+	entries := cache.Entries()
+	if model := strings.TrimSpace(c.Query("model")); model != "" {
+		filtered := entries[:0]
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Model, model) {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": enabled,
+		"ttl":     cache.TTL().String(),
+		"stats":   cache.Stats(),
+		"entries": entries,
+	})
+}
+
+// GetResponseCacheEntry returns a single cached entry including its payload.
+func (h *Handler) GetResponseCacheEntry(c *gin.Context) {
+	key := strings.TrimSpace(c.Param("key"))
+	entry, err := responsecache.Default().Lookup(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, responsecache.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "cache entry not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to load cache entry: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteResponseCache purges the cache, or a single entry when ?key= is provided.
+// Passing ?reset-stats=true also zeroes the hit-rate counters.
+func (h *Handler) DeleteResponseCache(c *gin.Context) {
+	cache := responsecache.Default()
+	ctx := c.Request.Context()
+	removed := 0
+	if key := strings.TrimSpace(c.Query("key")); key != "" {
+		if err := cache.Delete(ctx, key); err != nil {
+			if errors.Is(err, responsecache.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "cache entry not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to delete cache entry: %v", err)})
+			return
+		}
+		removed = 1
+	} else {
+		count, err := cache.Purge(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to purge cache: %v", err)})
+			return
+		}
+		removed = count
+	}
+	if strings.EqualFold(strings.TrimSpace(c.Query("reset-stats")), "true") {
+		cache.ResetStats()
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
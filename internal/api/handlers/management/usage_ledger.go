@@ -0,0 +1,111 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ledger"
+)
+
+// GetUsageLedger aggregates ledger entries for a time range.
+//
+// Query parameters:
+//   - from, to: RFC3339 timestamps or YYYY-MM-DD dates (UTC). Defaults to the current month.
+//   - group-by: comma-separated list of api_key, auth_index, provider, model, day.
+//   - api-key, auth-index, provider, model: optional filters. api-key accepts a client key or
+//     the sha256: hash reported in api_key.
+func (h *Handler) GetUsageLedger(c *gin.Context) {
+	q, store, ok := h.usageLedgerQuery(c)
+	if !ok {
+		return
+	}
+	rows, err := store.Query(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to query usage ledger: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":     q.From.UTC(),
+		"to":       q.To.UTC(),
+		"group-by": q.GroupBy,
+		"rows":     rows,
+		"totals":   ledger.Totals(rows),
+	})
+}
+
+// ExportUsageLedger returns the same aggregation as GetUsageLedger as a CSV download.
+func (h *Handler) ExportUsageLedger(c *gin.Context) {
+	q, store, ok := h.usageLedgerQuery(c)
+	if !ok {
+		return
+	}
+	rows, err := store.Query(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to query usage ledger: %v", err)})
+		return
+	}
+	filename := fmt.Sprintf("usage-%s-%s.csv", q.From.UTC().Format("20060102"), q.To.UTC().Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	_ = ledger.WriteCSV(c.Writer, q.GroupBy, rows)
+}
+
+func (h *Handler) usageLedgerQuery(c *gin.Context) (ledger.Query, ledger.Store, bool) {
+	store := ledger.Default().Store()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage ledger disabled"})
+		return ledger.Query{}, nil, false
+	}
+	from, err := parseLedgerTime(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid from: %v", err)})
+		return ledger.Query{}, nil, false
+	}
+	to, err := parseLedgerTime(c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid to: %v", err)})
+		return ledger.Query{}, nil, false
+	}
+	var groups []string
+	if raw := strings.TrimSpace(c.Query("group-by")); raw != "" {
+		groups = strings.Split(raw, ",")
+	}
+	q, err := ledger.NormalizeQuery(ledger.Query{
+		From:      from,
+		To:        to,
+		GroupBy:   groups,
+		APIKey:    strings.TrimSpace(c.Query("api-key")),
+		AuthIndex: strings.TrimSpace(c.Query("auth-index")),
+		Provider:  strings.TrimSpace(c.Query("provider")),
+		Model:     strings.TrimSpace(c.Query("model")),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return ledger.Query{}, nil, false
+	}
+	return q, store, true
+}
+
+// parseLedgerTime accepts RFC3339 timestamps or UTC dates. A date used as the end of a
+// range is inclusive, so it is advanced to the start of the following day.
+func parseLedgerTime(raw string, end bool) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+		return ts, nil
+	}
+	day, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC3339 timestamp or YYYY-MM-DD date")
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
@@ -158,7 +158,7 @@ func shouldLogRequest(path string) bool {
 	}
 
 	if strings.HasPrefix(path, "/api") {
-		return strings.HasPrefix(path, "/api/provider")
+		return strings.HasPrefix(path, "/api/provider") || path == "/api/chat" || path == "/api/generate"
 	}
 
 	return true
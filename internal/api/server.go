@@ -24,16 +24,20 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	batchhandlers "github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/batch"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/claude"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/ollama"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -162,11 +166,17 @@ type Server struct {
 	// ampModule is the Amp routing module for model mapping hot-reload
 	ampModule *ampmodule.AmpModule
 
+	// batchHandlers serves the batch endpoints and runs queued batches when batch is enabled.
+	batchHandlers *batchhandlers.BatchAPIHandler
+
 	// managementRoutesRegistered tracks whether the management routes have been attached to the engine.
 	managementRoutesRegistered atomic.Bool
 	// managementRoutesEnabled controls whether management endpoints serve real handlers.
 	managementRoutesEnabled atomic.Bool
 
+	// metricsEnabled controls whether /metrics serves the Prometheus exposition.
+	metricsEnabled atomic.Bool
+
 	// envManagementSecret indicates whether MANAGEMENT_PASSWORD is configured.
 	envManagementSecret bool
 
@@ -254,6 +264,8 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		wsRoutes:            make(map[string]struct{}),
 	}
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
+	s.metricsEnabled.Store(cfg.Metrics.Enable)
+	metrics.SetEnabled(cfg.Metrics.Enable)
 	// Save initial YAML snapshot
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
 	s.applyAccessConfig(nil, cfg)
@@ -297,7 +309,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 
 	// Register management routes when configuration or environment secrets are available,
 	// or when a local management password is provided (e.g. TUI mode).
-	hasManagementSecret := cfg.RemoteManagement.SecretKey != "" || envManagementSecret || s.localPassword != ""
+	hasManagementSecret := cfg.RemoteManagement.SecretKey != "" || len(cfg.RemoteManagement.Tokens) > 0 || envManagementSecret || s.localPassword != ""
 	s.managementRoutesEnabled.Store(hasManagementSecret)
 	if hasManagementSecret {
 		s.registerManagementRoutes()
@@ -329,6 +341,7 @@ func (s *Server) setupRoutes() {
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	ollamaHandlers := ollama.NewOllamaAPIHandler(s.handlers)
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
@@ -340,9 +353,16 @@ func (s *Server) setupRoutes() {
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
 		v1.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
+		v1.POST("/images/generations", openaiHandlers.ImageGenerations)
+		v1.POST("/images/edits", openaiHandlers.ImageEdits)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		v1.GET("/responses/:id", openaiResponsesHandlers.GetResponse)
+		v1.GET("/responses/:id/input_items", openaiResponsesHandlers.GetResponseInputItems)
+		v1.DELETE("/responses/:id", openaiResponsesHandlers.DeleteResponse)
 	}
+	s.setupBatchRoutes(v1)
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
@@ -353,6 +373,16 @@ func (s *Server) setupRoutes() {
 		v1beta.GET("/models/*action", geminiHandlers.GeminiGetHandler)
 	}
 
+	// Ollama compatible API routes
+	ollamaAPI := s.engine.Group("/api")
+	ollamaAPI.Use(AuthMiddleware(s.accessManager))
+	{
+		ollamaAPI.GET("/tags", ollamaHandlers.Tags)
+		ollamaAPI.POST("/show", ollamaHandlers.Show)
+		ollamaAPI.POST("/chat", ollamaHandlers.Chat)
+		ollamaAPI.POST("/generate", ollamaHandlers.Generate)
+	}
+
 	// Root endpoint
 	s.engine.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -425,9 +455,51 @@ func (s *Server) setupRoutes() {
 		c.String(http.StatusOK, oauthCallbackSuccessHTML)
 	})
 
+	// Prometheus metrics; gated by metrics.enable and authenticated like the API routes.
+	s.engine.GET("/metrics", s.metricsAvailabilityMiddleware(), AuthMiddleware(s.accessManager), metrics.Handler(metrics.DefaultCollector(), func() *auth.Manager {
+		return s.handlers.AuthManager
+	}))
+
 	// Management routes are registered lazily by registerManagementRoutes when a secret is configured.
 }
 
+// setupBatchRoutes registers the OpenAI Batch and Anthropic Message Batches endpoints and
+// resumes batches left unfinished by a previous run. It is a no-op unless batch is enabled.
+func (s *Server) setupBatchRoutes(v1 *gin.RouterGroup) {
+	if s.cfg == nil || !s.cfg.Batch.Enable {
+		return
+	}
+	dir := strings.TrimSpace(s.cfg.Batch.Dir)
+	if dir == "" {
+		dir = util.ResolveDataDirectory("batches")
+	}
+	store, err := batch.NewStore(dir)
+	if err != nil {
+		log.Errorf("failed to initialize batch store: %v", err)
+		return
+	}
+	s.batchHandlers = batchhandlers.NewBatchAPIHandler(s.handlers, store, s.cfg.Batch.Concurrency)
+
+	v1.POST("/files", s.batchHandlers.UploadFile)
+	v1.GET("/files", s.batchHandlers.ListFiles)
+	v1.GET("/files/:id", s.batchHandlers.GetFile)
+	v1.GET("/files/:id/content", s.batchHandlers.FileContent)
+	v1.DELETE("/files/:id", s.batchHandlers.DeleteFile)
+	v1.POST("/batches", s.batchHandlers.CreateBatch)
+	v1.GET("/batches", s.batchHandlers.ListBatches)
+	v1.GET("/batches/:id", s.batchHandlers.GetBatch)
+	v1.POST("/batches/:id/cancel", s.batchHandlers.CancelBatch)
+	v1.POST("/messages/batches", s.batchHandlers.CreateMessageBatch)
+	v1.GET("/messages/batches", s.batchHandlers.ListMessageBatches)
+	v1.GET("/messages/batches/:id", s.batchHandlers.GetMessageBatch)
+	v1.POST("/messages/batches/:id/cancel", s.batchHandlers.CancelMessageBatch)
+	v1.GET("/messages/batches/:id/results", s.batchHandlers.MessageBatchResults)
+
+	if err = s.batchHandlers.Resume(); err != nil {
+		log.Errorf("failed to resume batches: %v", err)
+	}
+}
+
 // AttachWebsocketRoute registers a websocket upgrade handler on the primary Gin engine.
 // The handler is served as-is without additional middleware beyond the standard stack already configured.
 func (s *Server) AttachWebsocketRoute(path string, handler http.Handler) {
@@ -481,6 +553,15 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
+		mgmt.GET("/usage/ledger", s.mgmt.GetUsageLedger)
+		mgmt.GET("/usage/ledger/export", s.mgmt.ExportUsageLedger)
+		mgmt.GET("/response-cache", s.mgmt.GetResponseCache)
+		mgmt.GET("/response-cache/:key", s.mgmt.GetResponseCacheEntry)
+		mgmt.DELETE("/response-cache", s.mgmt.DeleteResponseCache)
+		mgmt.GET("/model-prices", s.mgmt.GetModelPrices)
+		mgmt.PUT("/model-prices", s.mgmt.PutModelPrices)
+		mgmt.PATCH("/model-prices", s.mgmt.PatchModelPrices)
+		mgmt.DELETE("/model-prices", s.mgmt.DeleteModelPrices)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
@@ -581,6 +662,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/routing/strategy", s.mgmt.GetRoutingStrategy)
 		mgmt.PUT("/routing/strategy", s.mgmt.PutRoutingStrategy)
 		mgmt.PATCH("/routing/strategy", s.mgmt.PutRoutingStrategy)
+		mgmt.GET("/routing/scores", s.mgmt.GetRoutingScores)
 
 		mgmt.GET("/claude-api-key", s.mgmt.GetClaudeKeys)
 		mgmt.PUT("/claude-api-key", s.mgmt.PutClaudeKeys)
@@ -621,6 +703,17 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
 		mgmt.PATCH("/auth-files/fields", s.mgmt.PatchAuthFileFields)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
+		mgmt.GET("/auth-encryption", s.mgmt.GetAuthEncryption)
+		mgmt.POST("/auth-encryption/rotate", s.mgmt.RotateAuthEncryptionKey)
+		mgmt.POST("/auth-encryption/reencrypt", s.mgmt.ReencryptAuthFiles)
+
+		mgmt.GET("/remote-sync", s.mgmt.GetRemoteSync)
+		mgmt.POST("/remote-sync/pull", s.mgmt.PullRemoteSync)
+
+		mgmt.GET("/management-tokens", s.mgmt.GetManagementTokens)
+		mgmt.POST("/management-tokens", s.mgmt.PostManagementToken)
+		mgmt.DELETE("/management-tokens", s.mgmt.DeleteManagementToken)
+		mgmt.GET("/audit-log", s.mgmt.GetAuditLog)
 
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
 		mgmt.GET("/codex-auth-url", s.mgmt.RequestCodexToken)
@@ -632,6 +725,16 @@ func (s *Server) registerManagementRoutes() {
 	}
 }
 
+func (s *Server) metricsAvailabilityMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.metricsEnabled.Load() {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		c.Next()
+	}
+}
+
 func (s *Server) managementAvailabilityMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !s.managementRoutesEnabled.Load() {
@@ -824,6 +927,11 @@ func (s *Server) Stop(ctx context.Context) error {
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
 
+	// Stop batch workers; unfinished batches resume on the next start.
+	if s.batchHandlers != nil {
+		s.batchHandlers.Stop()
+	}
+
 	log.Debug("API server stopped")
 	return nil
 }
@@ -916,9 +1024,9 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 
 	prevSecretEmpty := true
 	if oldCfg != nil {
-		prevSecretEmpty = oldCfg.RemoteManagement.SecretKey == ""
+		prevSecretEmpty = oldCfg.RemoteManagement.SecretKey == "" && len(oldCfg.RemoteManagement.Tokens) == 0
 	}
-	newSecretEmpty := cfg.RemoteManagement.SecretKey == ""
+	newSecretEmpty := cfg.RemoteManagement.SecretKey == "" && len(cfg.RemoteManagement.Tokens) == 0
 	if s.envManagementSecret {
 		s.registerManagementRoutes()
 		if s.managementRoutesEnabled.CompareAndSwap(false, true) {
@@ -949,6 +1057,8 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	s.applyAccessConfig(oldCfg, cfg)
 	s.cfg = cfg
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
+	s.metricsEnabled.Store(cfg.Metrics.Enable)
+	metrics.SetEnabled(cfg.Metrics.Enable)
 	if oldCfg != nil && s.wsAuthChanged != nil && oldCfg.WebsocketAuth != cfg.WebsocketAuth {
 		s.wsAuthChanged(oldCfg.WebsocketAuth, cfg.WebsocketAuth)
 	}
@@ -13,6 +13,7 @@ import (
 	gin "github.com/gin-gonic/gin"
 	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	internallogging "github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
@@ -20,6 +21,11 @@ import (
 
 func newTestServer(t *testing.T) *Server {
 	t.Helper()
+	return newTestServerWithConfig(t, nil)
+}
+
+func newTestServerWithConfig(t *testing.T, mutate func(cfg *proxyconfig.Config)) *Server {
+	t.Helper()
 
 	gin.SetMode(gin.TestMode)
 
@@ -40,6 +46,10 @@ func newTestServer(t *testing.T) *Server {
 		UsageStatisticsEnabled: false,
 	}
 
+	if mutate != nil {
+		mutate(cfg)
+	}
+
 	authManager := auth.NewManager(nil, nil, nil)
 	accessManager := sdkaccess.NewManager()
 
@@ -135,6 +145,30 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 	}
 }
 
+func TestBatchRoutesRegisteredWhenEnabled(t *testing.T) {
+	t.Setenv("WRITABLE_PATH", t.TempDir())
+	server := newTestServerWithConfig(t, func(cfg *proxyconfig.Config) {
+		cfg.Batch.Enable = true
+	})
+	t.Cleanup(server.batchHandlers.Stop)
+
+	for _, path := range []string{"/v1/batches", "/v1/messages/batches", "/v1/files"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"data"`) {
+			t.Fatalf("GET %s: status %d body=%s", path, rr.Code, rr.Body.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(util.ResolveDataDirectory("batches"), "batches")); err != nil {
+		t.Fatalf("batch store not created under the data directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(server.cfg.AuthDir, "batches")); err == nil {
+		t.Fatal("batch store created under the auth dir")
+	}
+}
+
 func TestDefaultRequestLoggerFactory_UsesResolvedLogDirectory(t *testing.T) {
 	t.Setenv("WRITABLE_PATH", "")
 	t.Setenv("writable_path", "")
@@ -0,0 +1,426 @@
+// Package authcrypt encrypts OAuth token records at rest. Records are sealed with envelope
+// encryption: each record gets a random data key that encrypts the JSON with AES-256-GCM, and
+// the data key is itself encrypted with the master key. The sealed record is a small JSON
+// document, so it still fits every token store (files, git, object storage and JSONB).
+//
+// Reads accept both sealed and plaintext records, so existing plaintext files keep working
+// while they are migrated. Without a configured key records are written in plaintext.
+package authcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// envelopeVersion identifies the sealed record layout.
+const envelopeVersion = 1
+
+// KeySize is the master key length in bytes (AES-256).
+const KeySize = 32
+
+// ErrNotConfigured is returned by operations that need a keyring when encryption is disabled.
+var ErrNotConfigured = errors.New("authcrypt: encryption is not configured")
+
+// ErrNoKey is returned when a sealed record is read without a matching master key.
+var ErrNoKey = errors.New("authcrypt: record is encrypted but no matching key is configured")
+
+// ErrNoKeyFile is returned by Rotate when the keyring has no key file to persist new keys in.
+var ErrNoKeyFile = errors.New("authcrypt: key rotation requires a key file")
+
+// envelope is the on-disk form of a sealed record.
+type envelope struct {
+	Version    int    `json:"cliproxy_encrypted"`
+	KeyID      string `json:"kid"`
+	WrappedKey string `json:"wrapped_key"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// Keyring holds the active master key and the retired keys still accepted for decryption.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    []masterKey
+	keyFile string
+}
+
+var (
+	defaultMu sync.RWMutex
+	active    *Keyring
+)
+
+// Configure installs the process-wide keyring. Passing nil disables encryption.
+func Configure(k *Keyring) {
+	defaultMu.Lock()
+	active = k
+	defaultMu.Unlock()
+}
+
+// Default returns the process-wide keyring, or nil when encryption is disabled.
+func Default() *Keyring {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return active
+}
+
+// Enabled reports whether new records are encrypted.
+func Enabled() bool {
+	return Default() != nil
+}
+
+// NewKeyring builds a keyring from keys given inline and from a key file. Inline keys are a
+// comma-separated list; the key file holds one key per line. Keys are base64 or hex encoded
+// 32-byte values. The first key found is the active key; the rest only decrypt, which is
+// what a rotation in progress needs. A key file without keys is initialized with a new random
+// key. It returns nil when no key is configured.
+func NewKeyring(inline, keyFile string) (*Keyring, error) {
+	k := &Keyring{keyFile: strings.TrimSpace(keyFile)}
+	var encoded []string
+	if k.keyFile != "" {
+		data, err := os.ReadFile(k.keyFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("authcrypt: read key file: %w", err)
+		}
+		encoded = append(encoded, strings.Split(string(data), "\n")...)
+	}
+	encoded = append(encoded, strings.Split(inline, ",")...)
+	for _, raw := range encoded {
+		raw = strings.TrimSpace(raw)
+		if raw == "" || strings.HasPrefix(raw, "#") {
+			continue
+		}
+		key, err := decodeKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		if err = k.add(key, false); err != nil {
+			return nil, err
+		}
+	}
+	if len(k.keys) == 0 {
+		if k.keyFile == "" {
+			return nil, nil
+		}
+		if _, err := k.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// ActiveKeyID returns the identifier of the key used for new records.
+func (k *Keyring) ActiveKeyID() string {
+	if k == nil {
+		return ""
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if len(k.keys) == 0 {
+		return ""
+	}
+	return k.keys[0].id
+}
+
+// KeyIDs lists the identifiers of all keys, active key first.
+func (k *Keyring) KeyIDs() []string {
+	if k == nil {
+		return nil
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	ids := make([]string, 0, len(k.keys))
+	for _, key := range k.keys {
+		ids = append(ids, key.id)
+	}
+	return ids
+}
+
+// Rotate generates a new active key and prepends it to the key file, keeping the previous
+// keys for decryption until every record has been re-encrypted. Rotation requires a key file
+// so the new key survives a restart.
+func (k *Keyring) Rotate() (string, error) {
+	if k == nil {
+		return "", ErrNotConfigured
+	}
+	if k.keyFile == "" {
+		return "", ErrNoKeyFile
+	}
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("authcrypt: generate key: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	existing, err := os.ReadFile(k.keyFile)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("authcrypt: read key file: %w", err)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(base64.StdEncoding.EncodeToString(key))
+	buf.WriteByte('\n')
+	buf.Write(existing)
+	if err = writeFileAtomic(k.keyFile, buf.Bytes(), 0o600); err != nil {
+		return "", fmt.Errorf("authcrypt: write key file: %w", err)
+	}
+	if err = k.addLocked(key, true); err != nil {
+		return "", err
+	}
+	return k.keys[0].id, nil
+}
+
+// Seal encrypts a plaintext record with the active key. It returns plain unchanged when the
+// keyring is nil, so callers can seal unconditionally.
+func (k *Keyring) Seal(plain []byte) ([]byte, error) {
+	if k == nil {
+		return plain, nil
+	}
+	k.mu.RLock()
+	if len(k.keys) == 0 {
+		k.mu.RUnlock()
+		return plain, nil
+	}
+	master := k.keys[0]
+	k.mu.RUnlock()
+
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	aad := []byte(master.id)
+	ciphertext, err := seal(dataAEAD, plain, aad)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(master.aead, dataKey, aad)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{
+		Version:    envelopeVersion,
+		KeyID:      master.id,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	})
+}
+
+// Open decrypts a sealed record. Plaintext records are returned unchanged.
+func (k *Keyring) Open(data []byte) ([]byte, error) {
+	env, ok := parseEnvelope(data)
+	if !ok {
+		return data, nil
+	}
+	if k == nil {
+		return nil, ErrNoKey
+	}
+	k.mu.RLock()
+	var master *masterKey
+	for i := range k.keys {
+		if k.keys[i].id == env.KeyID {
+			master = &k.keys[i]
+			break
+		}
+	}
+	k.mu.RUnlock()
+	if master == nil {
+		return nil, fmt.Errorf("%w (kid %s)", ErrNoKey, env.KeyID)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(env.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: decode wrapped key: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: decode ciphertext: %w", err)
+	}
+	aad := []byte(env.KeyID)
+	dataKey, err := open(master.aead, wrapped, aad)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: unwrap data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := open(dataAEAD, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: decrypt record: %w", err)
+	}
+	return plain, nil
+}
+
+// KeyIDOf returns the key identifier of a sealed record, or "" for plaintext.
+func KeyIDOf(data []byte) string {
+	if env, ok := parseEnvelope(data); ok {
+		return env.KeyID
+	}
+	return ""
+}
+
+// IsSealed reports whether data is a sealed record.
+func IsSealed(data []byte) bool {
+	_, ok := parseEnvelope(data)
+	return ok
+}
+
+// Seal encrypts plain with the process-wide keyring.
+func Seal(plain []byte) ([]byte, error) {
+	return Default().Seal(plain)
+}
+
+// Open decrypts data with the process-wide keyring.
+func Open(data []byte) ([]byte, error) {
+	return Default().Open(data)
+}
+
+// ReadFile reads a record from disk and decrypts it when sealed.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(data)
+}
+
+// WriteFile seals plain when encryption is enabled and writes it to path.
+func WriteFile(path string, plain []byte, perm os.FileMode) error {
+	data, err := Seal(plain)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+// EncodeJSON writes v to w like json.Encoder, indented when indent is set, and seals the
+// output when encryption is enabled. Token storages use it in place of json.Encoder.
+func EncodeJSON(w io.Writer, v any, indent string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if indent != "" {
+		enc.SetIndent("", indent)
+	}
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	data, err := Seal(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (k *Keyring) add(key []byte, front bool) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.addLocked(key, front)
+}
+
+func (k *Keyring) addLocked(key []byte, front bool) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(key)
+	id := hex.EncodeToString(sum[:8])
+	for _, existing := range k.keys {
+		if existing.id == id {
+			return nil
+		}
+	}
+	entry := masterKey{id: id, aead: aead}
+	if front {
+		k.keys = append([]masterKey{entry}, k.keys...)
+	} else {
+		k.keys = append(k.keys, entry)
+	}
+	return nil
+}
+
+func parseEnvelope(data []byte) (envelope, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' || !bytes.Contains(trimmed, []byte(`"cliproxy_encrypted"`)) {
+		return envelope{}, false
+	}
+	var env envelope
+	if err := json.Unmarshal(trimmed, &env); err != nil || env.Version != envelopeVersion || env.KeyID == "" {
+		return envelope{}, false
+	}
+	return env, true
+}
+
+func decodeKey(raw string) ([]byte, error) {
+	if len(raw) == hex.EncodedLen(KeySize) {
+		if key, err := hex.DecodeString(raw); err == nil {
+			return key, nil
+		}
+	}
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		key, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(raw, "="))
+	}
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("authcrypt: master key must be %d bytes encoded as base64 or hex", KeySize)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: init cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: init gcm: %w", err)
+	}
+	return aead, nil
+}
+
+// seal returns nonce || ciphertext.
+func seal(aead cipher.AEAD, plain, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plain, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,175 @@
+package authcrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, KeySize))
+}
+
+func TestSealOpen_RoundTrip(t *testing.T) {
+	k, err := NewKeyring(testKey(1), "")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	plain := []byte(`{"type":"claude","access_token":"secret"}`)
+
+	sealed, err := k.Seal(plain)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("secret")) {
+		t.Fatalf("sealed record leaks plaintext: %s", sealed)
+	}
+	if !IsSealed(sealed) || KeyIDOf(sealed) != k.ActiveKeyID() {
+		t.Fatalf("sealed record kid = %q, want %q", KeyIDOf(sealed), k.ActiveKeyID())
+	}
+	opened, err := k.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(opened, plain) {
+		t.Fatalf("Open = %s, want %s", opened, plain)
+	}
+
+	other, _ := NewKeyring(testKey(2), "")
+	if _, err = other.Open(sealed); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("Open with wrong key error = %v, want ErrNoKey", err)
+	}
+	var disabled *Keyring
+	if _, err = disabled.Open(sealed); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("Open without keyring error = %v, want ErrNoKey", err)
+	}
+}
+
+func TestOpen_PassesPlaintextThrough(t *testing.T) {
+	k, _ := NewKeyring(testKey(1), "")
+	plain := []byte(`{"type":"codex","email":"a@example.com"}`)
+	for _, keyring := range []*Keyring{k, nil} {
+		opened, err := keyring.Open(plain)
+		if err != nil || !bytes.Equal(opened, plain) {
+			t.Fatalf("Open(plaintext) = %s, %v; want passthrough", opened, err)
+		}
+	}
+	var disabled *Keyring
+	sealed, err := disabled.Seal(plain)
+	if err != nil || !bytes.Equal(sealed, plain) {
+		t.Fatalf("Seal without keyring = %s, %v; want passthrough", sealed, err)
+	}
+}
+
+func TestNewKeyring_RejectsInvalidKey(t *testing.T) {
+	if _, err := NewKeyring("not-a-key", ""); err == nil {
+		t.Fatal("NewKeyring(invalid) error = nil, want error")
+	}
+	k, err := NewKeyring(" ", "")
+	if err != nil || k != nil {
+		t.Fatalf("NewKeyring(empty) = %v, %v; want nil, nil", k, err)
+	}
+}
+
+func TestRotate_KeepsRetiredKeysForDecryption(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	k, err := NewKeyring("", keyFile)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	oldID := k.ActiveKeyID()
+	if oldID == "" {
+		t.Fatal("key file was not initialized")
+	}
+	sealed, _ := k.Seal([]byte(`{"type":"gemini"}`))
+
+	newID, err := k.Rotate()
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if newID == oldID || k.ActiveKeyID() != newID {
+		t.Fatalf("active key = %q after rotating from %q to %q", k.ActiveKeyID(), oldID, newID)
+	}
+	if _, err = k.Open(sealed); err != nil {
+		t.Fatalf("Open with retired key: %v", err)
+	}
+
+	reloaded, err := NewKeyring("", keyFile)
+	if err != nil {
+		t.Fatalf("reload key file: %v", err)
+	}
+	if got := strings.Join(reloaded.KeyIDs(), ","); got != newID+","+oldID {
+		t.Fatalf("reloaded key ids = %s, want %s,%s", got, newID, oldID)
+	}
+
+	inline, _ := NewKeyring(testKey(1), "")
+	if _, err = inline.Rotate(); !errors.Is(err, ErrNoKeyFile) {
+		t.Fatalf("Rotate without key file error = %v, want ErrNoKeyFile", err)
+	}
+}
+
+type recordingPersister struct {
+	paths []string
+}
+
+func (p *recordingPersister) PersistAuthFiles(_ context.Context, _ string, paths ...string) error {
+	p.paths = append(p.paths, paths...)
+	return nil
+}
+
+func TestReencrypt_MigratesAndRotates(t *testing.T) {
+	dir := t.TempDir()
+	k, err := NewKeyring("", filepath.Join(t.TempDir(), "keys"))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	plainPath := filepath.Join(dir, "plain.json")
+	if err = os.WriteFile(plainPath, []byte(`{"type":"claude"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sealed, _ := k.Seal([]byte(`{"type":"codex"}`))
+	if err = os.WriteFile(filepath.Join(dir, "sealed.json"), sealed, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	persister := &recordingPersister{}
+	result, err := k.Reencrypt(context.Background(), dir, false, persister)
+	if err != nil {
+		t.Fatalf("Reencrypt: %v", err)
+	}
+	if result.Scanned != 2 || len(result.Rewritten) != 1 || result.Rewritten[0] != plainPath {
+		t.Fatalf("Reencrypt result = %+v, want only plain.json rewritten", result)
+	}
+	if len(persister.paths) != 1 {
+		t.Fatalf("persisted %v, want plain.json", persister.paths)
+	}
+
+	persister.paths = nil
+	result, err = k.Reencrypt(context.Background(), dir, true, persister)
+	if err != nil {
+		t.Fatalf("Reencrypt(rotate): %v", err)
+	}
+	if len(result.Rewritten) != 2 || len(persister.paths) != 2 {
+		t.Fatalf("rotation rewrote %v, persisted %v; want both files", result.Rewritten, persister.paths)
+	}
+	for _, name := range []string{"plain.json", "sealed.json"} {
+		data, errRead := os.ReadFile(filepath.Join(dir, name))
+		if errRead != nil {
+			t.Fatal(errRead)
+		}
+		if KeyIDOf(data) != result.KeyID {
+			t.Fatalf("%s kid = %q, want %q", name, KeyIDOf(data), result.KeyID)
+		}
+		if _, errOpen := k.Open(data); errOpen != nil {
+			t.Fatalf("Open %s: %v", name, errOpen)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package authcrypt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReencryptResult summarizes a re-encryption pass over an auth directory.
+type ReencryptResult struct {
+	KeyID     string   `json:"kid"`
+	Scanned   int      `json:"scanned"`
+	Rewritten []string `json:"rewritten"`
+	Failed    []string `json:"failed,omitempty"`
+}
+
+// Persister mirrors rewritten auth files to a remote token store. The git, object storage
+// and Postgres stores implement it; the plain file store needs nothing further.
+type Persister interface {
+	PersistAuthFiles(ctx context.Context, message string, paths ...string) error
+}
+
+// Reencrypt optionally rotates to a fresh master key, re-seals every record in dir with the
+// active key and hands the rewritten files to persister. persister may be nil.
+func (k *Keyring) Reencrypt(ctx context.Context, dir string, rotate bool, persister Persister) (ReencryptResult, error) {
+	if k == nil {
+		return ReencryptResult{Rewritten: []string{}}, ErrNotConfigured
+	}
+	if rotate {
+		if _, err := k.Rotate(); err != nil {
+			return ReencryptResult{Rewritten: []string{}}, err
+		}
+	}
+	result, err := k.ReencryptDir(dir)
+	if err != nil {
+		return result, err
+	}
+	if persister != nil && len(result.Rewritten) > 0 {
+		message := fmt.Sprintf("Re-encrypt auth records with key %s", result.KeyID)
+		if err = persister.PersistAuthFiles(ctx, message, result.Rewritten...); err != nil {
+			return result, fmt.Errorf("authcrypt: persist re-encrypted records: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// ReencryptDir seals every auth record in dir with the active key. Plaintext records and
+// records sealed with a retired key are rewritten; records already sealed with the active key
+// are left alone. Like the watcher, only .json files directly inside dir are auth records.
+func (k *Keyring) ReencryptDir(dir string) (ReencryptResult, error) {
+	result := ReencryptResult{KeyID: k.ActiveKeyID(), Rewritten: []string{}}
+	if k == nil {
+		return result, ErrNotConfigured
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return result, fmt.Errorf("authcrypt: list auth directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(name), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		result.Scanned++
+		rewritten, errFile := k.reencryptFile(path, result.KeyID)
+		if errFile != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", name, errFile))
+			continue
+		}
+		if rewritten {
+			result.Rewritten = append(result.Rewritten, path)
+		}
+	}
+	return result, nil
+}
+
+func (k *Keyring) reencryptFile(path, activeKeyID string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if len(data) == 0 || KeyIDOf(data) == activeKeyID {
+		return false, nil
+	}
+	plain, err := k.Open(data)
+	if err != nil {
+		return false, err
+	}
+	sealed, err := k.Seal(plain)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if err = writeFileAtomic(path, sealed, info.Mode().Perm()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,151 @@
+// Package batch emulates the OpenAI Batch and Anthropic Message Batches APIs on top of the
+// regular request pipeline. Submitted requests are queued on disk, executed locally at a
+// bounded concurrency, and their results are appended to a per-batch journal so that
+// unfinished batches resume where they stopped after a restart.
+package batch
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Batch formats, selecting the API shape a batch was submitted through.
+const (
+	FormatOpenAI = "openai"
+	FormatClaude = "claude"
+)
+
+// Batch statuses, using the OpenAI vocabulary. Claude views map them to processing_status.
+const (
+	StatusInProgress = "in_progress"
+	StatusFinalizing = "finalizing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusCancelling = "cancelling"
+	StatusCancelled  = "cancelled"
+	StatusExpired    = "expired"
+)
+
+// File purposes.
+const (
+	PurposeBatch       = "batch"
+	PurposeBatchOutput = "batch_output"
+	// PurposeMessageBatch marks the input file synthesised for a Message Batches request.
+	PurposeMessageBatch = "message_batch"
+)
+
+// DefaultCompletionWindow is the only completion window accepted by the OpenAI Batch API.
+const DefaultCompletionWindow = 24 * time.Hour
+
+// ErrNotFound is returned when a file or batch ID is unknown.
+var ErrNotFound = errors.New("batch: not found")
+
+// File is an uploaded input file or a generated output file.
+type File struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"owner,omitempty"`
+	Purpose   string    `json:"purpose"`
+	Filename  string    `json:"filename"`
+	Bytes     int64     `json:"bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Item is a single queued request.
+type Item struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method,omitempty"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// Result records the outcome of one item. StatusCode is zero when the request could not be
+// executed at all, in which case Error describes why.
+type Result struct {
+	CustomID   string          `json:"custom_id"`
+	StatusCode int             `json:"status_code"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	FinishedAt time.Time       `json:"finished_at"`
+
+	// RetryAfter asks the runner to re-queue the item after the given delay instead of
+	// recording the result. It is set for cooldown responses and never persisted.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// Succeeded reports whether the upstream answered with a 2xx status.
+func (r Result) Succeeded() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Batch is the persisted state of a batch job.
+type Batch struct {
+	ID               string            `json:"id"`
+	Format           string            `json:"format"`
+	Owner            string            `json:"owner,omitempty"`
+	Endpoint         string            `json:"endpoint"`
+	InputFileID      string            `json:"input_file_id"`
+	OutputFileID     string            `json:"output_file_id,omitempty"`
+	ErrorFileID      string            `json:"error_file_id,omitempty"`
+	CompletionWindow string            `json:"completion_window,omitempty"`
+	Status           string            `json:"status"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+
+	CreatedAt    time.Time `json:"created_at"`
+	InProgressAt time.Time `json:"in_progress_at,omitempty"`
+	FinalizingAt time.Time `json:"finalizing_at,omitempty"`
+	CompletedAt  time.Time `json:"completed_at,omitempty"`
+	FailedAt     time.Time `json:"failed_at,omitempty"`
+	CancellingAt time.Time `json:"cancelling_at,omitempty"`
+	CancelledAt  time.Time `json:"cancelled_at,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	ExpiredAt    time.Time `json:"expired_at,omitempty"`
+	// EndedAt is when the batch reached a terminal status, whichever it was.
+	EndedAt time.Time `json:"ended_at,omitempty"`
+	// FailureReason explains a failed status.
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// Terminal reports whether the batch has stopped processing for good.
+func (b *Batch) Terminal() bool {
+	switch b.Status {
+	case StatusCompleted, StatusFailed, StatusCancelled, StatusExpired:
+		return true
+	}
+	return false
+}
+
+// Pending returns the number of items without a recorded result.
+func (b *Batch) Pending() int {
+	if pending := b.Total - b.Completed - b.Failed; pending > 0 {
+		return pending
+	}
+	return 0
+}
+
+func cloneBatch(b *Batch) *Batch {
+	if b == nil {
+		return nil
+	}
+	out := *b
+	if b.Metadata != nil {
+		out.Metadata = make(map[string]string, len(b.Metadata))
+		for k, v := range b.Metadata {
+			out.Metadata[k] = v
+		}
+	}
+	return &out
+}
+
+// NewID returns a random identifier with the given prefix, e.g. "batch_" or "file-".
+func NewID(prefix string) string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return prefix + hex.EncodeToString(buf)
+}
@@ -0,0 +1,70 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MaxItems caps the number of requests in one batch, matching the OpenAI limit.
+const MaxItems = 50000
+
+// ParseItems reads a JSONL batch input. Every line must carry a unique custom_id, a url and
+// a JSON object body.
+func ParseItems(r io.Reader) ([]Item, error) {
+	var items []Item
+	seen := make(map[string]struct{})
+	lineNo := 0
+	err := scanJSONL(r, func(line []byte) error {
+		lineNo++
+		var item Item
+		if err := json.Unmarshal(line, &item); err != nil {
+			return fmt.Errorf("line %d: invalid JSON: %w", lineNo, err)
+		}
+		item.CustomID = strings.TrimSpace(item.CustomID)
+		if item.CustomID == "" {
+			return fmt.Errorf("line %d: missing custom_id", lineNo)
+		}
+		if _, dup := seen[item.CustomID]; dup {
+			return fmt.Errorf("line %d: duplicate custom_id %q", lineNo, item.CustomID)
+		}
+		seen[item.CustomID] = struct{}{}
+		if method := strings.ToUpper(strings.TrimSpace(item.Method)); method != "" && method != "POST" {
+			return fmt.Errorf("line %d: unsupported method %q", lineNo, item.Method)
+		}
+		if strings.TrimSpace(item.URL) == "" {
+			return fmt.Errorf("line %d: missing url", lineNo)
+		}
+		if body := bytes.TrimSpace(item.Body); len(body) == 0 || body[0] != '{' {
+			return fmt.Errorf("line %d: body must be a JSON object", lineNo)
+		}
+		if len(items) >= MaxItems {
+			return fmt.Errorf("batch exceeds %d requests", MaxItems)
+		}
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("batch input contains no requests")
+	}
+	return items, nil
+}
+
+// EncodeItems renders items as a JSONL input file.
+func EncodeItems(items []Item) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, fmt.Errorf("encode item %q: %w", item.CustomID, err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,131 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/tidwall/gjson"
+)
+
+// RenderOpenAIOutput builds the output and error files of an OpenAI batch. Successful
+// responses go to the output file; upstream errors, and items left unprocessed by a cancelled
+// or expired batch, go to the error file.
+func RenderOpenAIOutput(items []Item, results []Result, status string) (output, errorsOut []byte) {
+	var out, errs bytes.Buffer
+	seen := make(map[string]struct{}, len(results))
+	for _, result := range results {
+		seen[result.CustomID] = struct{}{}
+		line := map[string]any{
+			"id":        NewID("batch_req_"),
+			"custom_id": result.CustomID,
+			"response":  nil,
+			"error":     nil,
+		}
+		if result.StatusCode > 0 {
+			line["response"] = map[string]any{
+				"status_code": result.StatusCode,
+				"request_id":  "",
+				"body":        rawOrNull(result.Body),
+			}
+		} else {
+			line["error"] = map[string]string{"code": "batch_request_failed", "message": result.Error}
+		}
+		if result.Succeeded() {
+			writeJSONLine(&out, line)
+		} else {
+			writeJSONLine(&errs, line)
+		}
+	}
+	if status == StatusCancelled || status == StatusExpired {
+		code, message := "batch_cancelled", "This request was not executed because the batch was cancelled."
+		if status == StatusExpired {
+			code, message = "batch_expired", "This request could not be executed before the completion window expired."
+		}
+		for _, item := range items {
+			if _, ok := seen[item.CustomID]; ok {
+				continue
+			}
+			writeJSONLine(&errs, map[string]any{
+				"id":        NewID("batch_req_"),
+				"custom_id": item.CustomID,
+				"response":  nil,
+				"error":     map[string]string{"code": code, "message": message},
+			})
+		}
+	}
+	return out.Bytes(), errs.Bytes()
+}
+
+// RenderClaudeResults builds the Message Batches results stream: one line per request, in
+// input order, with a succeeded, errored, canceled or expired result.
+func RenderClaudeResults(items []Item, results []Result, status string) []byte {
+	byID := make(map[string]Result, len(results))
+	for _, result := range results {
+		byID[result.CustomID] = result
+	}
+	var out bytes.Buffer
+	for _, item := range items {
+		result, ok := byID[item.CustomID]
+		var entry map[string]any
+		switch {
+		case ok && result.Succeeded():
+			entry = map[string]any{"type": "succeeded", "message": rawOrNull(result.Body)}
+		case ok:
+			entry = map[string]any{"type": "errored", "error": claudeErrorBody(result)}
+		case status == StatusExpired:
+			entry = map[string]any{"type": "expired"}
+		case status == StatusCancelled:
+			entry = map[string]any{"type": "canceled"}
+		default:
+			continue
+		}
+		writeJSONLine(&out, map[string]any{"custom_id": item.CustomID, "result": entry})
+	}
+	return out.Bytes()
+}
+
+// claudeErrorBody renders a failed result as an Anthropic error object, reusing the upstream
+// error when it is already in that shape.
+func claudeErrorBody(result Result) any {
+	body := gjson.ParseBytes(result.Body)
+	if body.Get("type").String() == "error" && body.Get("error").IsObject() {
+		return json.RawMessage(result.Body)
+	}
+	errType := body.Get("error.type").String()
+	message := body.Get("error.message").String()
+	if message == "" {
+		message = result.Error
+	}
+	if errType == "" {
+		errType = "api_error"
+		switch {
+		case result.StatusCode == 400 || result.StatusCode == 422:
+			errType = "invalid_request_error"
+		case result.StatusCode == 401:
+			errType = "authentication_error"
+		case result.StatusCode == 403:
+			errType = "permission_error"
+		case result.StatusCode == 404:
+			errType = "not_found_error"
+		case result.StatusCode == 429:
+			errType = "rate_limit_error"
+		}
+	}
+	return map[string]any{"type": "error", "error": map[string]string{"type": errType, "message": message}}
+}
+
+func rawOrNull(raw []byte) any {
+	if len(bytes.TrimSpace(raw)) == 0 || !json.Valid(raw) {
+		return nil
+	}
+	return json.RawMessage(raw)
+}
+
+func writeJSONLine(buf *bytes.Buffer, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	buf.Write(data)
+	buf.WriteByte('\n')
+}
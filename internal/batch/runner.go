@@ -0,0 +1,375 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultConcurrency is the number of items executed in parallel across all batches.
+	DefaultConcurrency = 4
+	// maxRetryDelay caps how long a cooled-down item waits before it is attempted again.
+	maxRetryDelay = 5 * time.Minute
+)
+
+var (
+	errCancelled = errors.New("batch cancelled")
+	errStopped   = errors.New("batch runner stopped")
+)
+
+// ExecuteFunc performs one item on behalf of a batch. Setting Result.RetryAfter re-queues the
+// item after the delay, which is how cooldowns of every credential for a model are honoured.
+type ExecuteFunc func(ctx context.Context, b *Batch, item Item) Result
+
+// Runner executes queued batches with a shared concurrency limit.
+type Runner struct {
+	store *Store
+	exec  ExecuteFunc
+	sem   chan struct{}
+
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]*activeBatch
+}
+
+// activeBatch is the in-memory state of a batch being processed. While a batch is active its
+// state is only modified through Runner.update so that progress and cancellation do not race.
+type activeBatch struct {
+	batch  *Batch
+	cancel context.CancelCauseFunc
+}
+
+// NewRunner creates a runner over store. A concurrency <= 0 uses DefaultConcurrency.
+func NewRunner(store *Store, exec ExecuteFunc, concurrency int) *Runner {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &Runner{
+		store:   store,
+		exec:    exec,
+		sem:     make(chan struct{}, concurrency),
+		ctx:     ctx,
+		stop:    stop,
+		running: make(map[string]*activeBatch),
+	}
+}
+
+// Store returns the backing store.
+func (r *Runner) Store() *Store {
+	return r.store
+}
+
+// Resume restarts every batch that had not finished when the process last stopped.
+func (r *Runner) Resume() error {
+	batches, err := r.store.ListBatches()
+	if err != nil {
+		return err
+	}
+	for _, b := range batches {
+		if b.Terminal() {
+			continue
+		}
+		if b.Status == StatusCancelling {
+			r.finish(b, StatusCancelled)
+			continue
+		}
+		log.Infof("batch: resuming %s (%d of %d requests pending)", b.ID, b.Pending(), b.Total)
+		r.start(b)
+	}
+	return nil
+}
+
+// Submit persists a new batch and starts processing it.
+func (r *Runner) Submit(b *Batch) error {
+	now := time.Now()
+	b.Status = StatusInProgress
+	if b.CreatedAt.IsZero() {
+		b.CreatedAt = now
+	}
+	b.InProgressAt = now
+	if b.ExpiresAt.IsZero() {
+		b.ExpiresAt = b.CreatedAt.Add(DefaultCompletionWindow)
+	}
+	if err := r.store.PutBatch(b); err != nil {
+		return err
+	}
+	r.start(cloneBatch(b))
+	return nil
+}
+
+// Get returns the current state of a batch.
+func (r *Runner) Get(id string) (*Batch, error) {
+	r.mu.Lock()
+	if active, ok := r.running[id]; ok {
+		b := cloneBatch(active.batch)
+		r.mu.Unlock()
+		return b, nil
+	}
+	r.mu.Unlock()
+	return r.store.GetBatch(id)
+}
+
+// List returns every batch, newest first, with live progress for active ones.
+func (r *Runner) List() ([]*Batch, error) {
+	batches, err := r.store.ListBatches()
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, b := range batches {
+		if active, ok := r.running[b.ID]; ok {
+			batches[i] = cloneBatch(active.batch)
+		}
+	}
+	return batches, nil
+}
+
+// Cancel stops a batch. Items already in flight are aborted and every item without a result
+// is reported as cancelled. Cancelling a finished batch returns it unchanged.
+func (r *Runner) Cancel(id string) (*Batch, error) {
+	r.mu.Lock()
+	active, ok := r.running[id]
+	if ok {
+		if active.batch.Status == StatusInProgress {
+			active.batch.Status = StatusCancelling
+			active.batch.CancellingAt = time.Now()
+			if err := r.store.PutBatch(active.batch); err != nil {
+				log.Warnf("batch: persist %s: %v", id, err)
+			}
+		}
+		b := cloneBatch(active.batch)
+		r.mu.Unlock()
+		active.cancel(errCancelled)
+		return b, nil
+	}
+	r.mu.Unlock()
+
+	b, err := r.store.GetBatch(id)
+	if err != nil {
+		return nil, err
+	}
+	if b.Terminal() {
+		return b, nil
+	}
+	b.CancellingAt = time.Now()
+	r.finish(b, StatusCancelled)
+	return r.store.GetBatch(id)
+}
+
+// Stop aborts in-flight items without changing batch statuses, so they resume on the next
+// start, and waits for the workers to exit.
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	for _, active := range r.running {
+		active.cancel(errStopped)
+	}
+	r.mu.Unlock()
+	r.stop()
+	r.wg.Wait()
+}
+
+func (r *Runner) start(b *Batch) {
+	ctx, cancel := context.WithCancelCause(r.ctx)
+	r.mu.Lock()
+	if _, exists := r.running[b.ID]; exists {
+		r.mu.Unlock()
+		cancel(nil)
+		return
+	}
+	r.running[b.ID] = &activeBatch{batch: b, cancel: cancel}
+	r.mu.Unlock()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel(nil)
+		r.process(ctx, b.ID)
+	}()
+}
+
+func (r *Runner) process(ctx context.Context, id string) {
+	snapshot, err := r.Get(id)
+	if err != nil {
+		r.update(id, func(b *Batch) { b.FailureReason = fmt.Sprintf("load batch: %v", err) })
+		r.release(id, StatusFailed)
+		return
+	}
+	items, err := r.store.Items(snapshot.InputFileID)
+	if err != nil {
+		r.update(id, func(b *Batch) { b.FailureReason = fmt.Sprintf("read input file: %v", err) })
+		r.release(id, StatusFailed)
+		return
+	}
+	results, err := r.store.Results(id)
+	if err != nil {
+		log.Warnf("batch: read results of %s: %v", id, err)
+	}
+	done := make(map[string]struct{}, len(results))
+	for _, result := range results {
+		done[result.CustomID] = struct{}{}
+	}
+
+	deadline, cancelDeadline := context.WithDeadline(ctx, snapshot.ExpiresAt)
+	defer cancelDeadline()
+
+	var wg sync.WaitGroup
+dispatch:
+	for _, item := range items {
+		if _, ok := done[item.CustomID]; ok {
+			continue
+		}
+		select {
+		case r.sem <- struct{}{}:
+		case <-deadline.Done():
+			break dispatch
+		}
+		wg.Add(1)
+		go func(item Item) {
+			defer wg.Done()
+			defer func() { <-r.sem }()
+			r.processItem(deadline, id, snapshot, item)
+		}(item)
+	}
+	wg.Wait()
+
+	current, _ := r.Get(id)
+	switch {
+	case current != nil && current.Pending() == 0:
+		r.release(id, StatusCompleted)
+	case errors.Is(context.Cause(ctx), errStopped):
+		r.mu.Lock()
+		delete(r.running, id)
+		r.mu.Unlock()
+	case errors.Is(context.Cause(ctx), errCancelled):
+		r.release(id, StatusCancelled)
+	case errors.Is(deadline.Err(), context.DeadlineExceeded):
+		r.release(id, StatusExpired)
+	default:
+		r.release(id, StatusCompleted)
+	}
+}
+
+func (r *Runner) processItem(ctx context.Context, id string, snapshot *Batch, item Item) {
+	for {
+		result := r.exec(ctx, snapshot, item)
+		if ctx.Err() != nil {
+			// Interrupted items are retried on resume or reported as cancelled/expired.
+			return
+		}
+		if result.RetryAfter > 0 {
+			delay := min(result.RetryAfter, maxRetryDelay)
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+				continue
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+		result.CustomID = item.CustomID
+		result.FinishedAt = time.Now()
+		if err := r.store.AppendResult(id, result); err != nil {
+			log.Errorf("batch: record result %s/%s: %v", id, item.CustomID, err)
+			return
+		}
+		r.update(id, func(b *Batch) {
+			if result.Succeeded() {
+				b.Completed++
+			} else {
+				b.Failed++
+			}
+		})
+		return
+	}
+}
+
+// update applies fn to the live state of an active batch and persists it.
+func (r *Runner) update(id string, fn func(b *Batch)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	active, ok := r.running[id]
+	if !ok {
+		return
+	}
+	fn(active.batch)
+	if err := r.store.PutBatch(active.batch); err != nil {
+		log.Warnf("batch: persist %s: %v", id, err)
+	}
+}
+
+// release finishes an active batch with status and stops tracking it.
+func (r *Runner) release(id, status string) {
+	r.mu.Lock()
+	active, ok := r.running[id]
+	delete(r.running, id)
+	r.mu.Unlock()
+	if ok {
+		r.finish(active.batch, status)
+	}
+}
+
+// finish moves b to a terminal status and, for OpenAI batches, writes the output and error files.
+func (r *Runner) finish(b *Batch, status string) {
+	now := time.Now()
+	if b.Format == FormatOpenAI {
+		b.FinalizingAt = now
+		if err := r.writeOpenAIOutputs(b, status); err != nil {
+			log.Errorf("batch: write outputs of %s: %v", b.ID, err)
+			status = StatusFailed
+			b.FailureReason = err.Error()
+		}
+	}
+	b.Status = status
+	b.EndedAt = now
+	switch status {
+	case StatusCompleted:
+		b.CompletedAt = now
+	case StatusFailed:
+		b.FailedAt = now
+	case StatusCancelled:
+		b.CancelledAt = now
+	case StatusExpired:
+		b.ExpiredAt = now
+	}
+	if err := r.store.PutBatch(b); err != nil {
+		log.Errorf("batch: persist %s: %v", b.ID, err)
+	}
+}
+
+func (r *Runner) writeOpenAIOutputs(b *Batch, status string) error {
+	items, err := r.store.Items(b.InputFileID)
+	if err != nil {
+		return err
+	}
+	results, err := r.store.Results(b.ID)
+	if err != nil {
+		return err
+	}
+	output, errorsOut := RenderOpenAIOutput(items, results, status)
+	if len(output) > 0 {
+		file := &File{ID: NewID("file-"), Owner: b.Owner, Purpose: PurposeBatchOutput, Filename: b.ID + "_output.jsonl", CreatedAt: time.Now()}
+		if err = r.store.PutFile(file, output); err != nil {
+			return err
+		}
+		b.OutputFileID = file.ID
+	}
+	if len(errorsOut) > 0 {
+		file := &File{ID: NewID("file-"), Owner: b.Owner, Purpose: PurposeBatchOutput, Filename: b.ID + "_error.jsonl", CreatedAt: time.Now()}
+		if err = r.store.PutFile(file, errorsOut); err != nil {
+			return err
+		}
+		b.ErrorFileID = file.ID
+	}
+	return nil
+}
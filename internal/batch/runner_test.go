@@ -0,0 +1,173 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+func newTestBatch(t *testing.T, store *Store, n int) *Batch {
+	t.Helper()
+	items := make([]Item, 0, n)
+	for i := 0; i < n; i++ {
+		items = append(items, Item{CustomID: fmt.Sprintf("req-%d", i), URL: "/v1/chat/completions", Body: []byte(`{"model":"m"}`)})
+	}
+	content, err := EncodeItems(items)
+	if err != nil {
+		t.Fatalf("EncodeItems: %v", err)
+	}
+	file := &File{ID: NewID("file-"), Purpose: PurposeBatch, CreatedAt: time.Now()}
+	if err = store.PutFile(file, content); err != nil {
+		t.Fatalf("PutFile: %v", err)
+	}
+	return &Batch{ID: NewID("batch_"), Format: FormatOpenAI, Endpoint: "/v1/chat/completions", InputFileID: file.ID, Total: n}
+}
+
+func waitForStatus(t *testing.T, runner *Runner, id string, terminal bool) *Batch {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		b, err := runner.Get(id)
+		if err == nil && b.Terminal() == terminal {
+			return b
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("batch %s did not reach terminal=%v", id, terminal)
+	return nil
+}
+
+func TestRunnerCompletesAndRetriesCooldowns(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	var cooled sync.Map
+	runner := NewRunner(store, func(ctx context.Context, b *Batch, item Item) Result {
+		if item.CustomID == "req-1" {
+			if _, seen := cooled.LoadOrStore(item.CustomID, true); !seen {
+				return Result{RetryAfter: 10 * time.Millisecond}
+			}
+		}
+		if item.CustomID == "req-2" {
+			return Result{StatusCode: 400, Body: []byte(`{"error":{"message":"bad","type":"invalid_request_error"}}`)}
+		}
+		return Result{StatusCode: 200, Body: []byte(`{"id":"` + item.CustomID + `"}`)}
+	}, 2)
+	defer runner.Stop()
+
+	b := newTestBatch(t, store, 3)
+	if err = runner.Submit(b); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	done := waitForStatus(t, runner, b.ID, true)
+	if done.Status != StatusCompleted || done.Completed != 2 || done.Failed != 1 {
+		t.Fatalf("unexpected batch: %+v", done)
+	}
+	if done.OutputFileID == "" || done.ErrorFileID == "" {
+		t.Fatalf("output files missing: %+v", done)
+	}
+	f, err := store.OpenFileContent(done.ErrorFileID)
+	if err != nil {
+		t.Fatalf("open error file: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	var lines []string
+	_ = scanJSONL(f, func(line []byte) error { lines = append(lines, string(line)); return nil })
+	if len(lines) != 1 || gjson.Get(lines[0], "custom_id").String() != "req-2" || gjson.Get(lines[0], "response.status_code").Int() != 400 {
+		t.Fatalf("unexpected error file: %v", lines)
+	}
+}
+
+func TestRunnerResumesWithoutRepeatingFinishedItems(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	release := make(chan struct{})
+	var firstRun atomic.Int32
+	runner := NewRunner(store, func(ctx context.Context, b *Batch, item Item) Result {
+		firstRun.Add(1)
+		if item.CustomID != "req-0" {
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return Result{Error: ctx.Err().Error()}
+			}
+		}
+		return Result{StatusCode: 200, Body: []byte(`{}`)}
+	}, 1)
+	b := newTestBatch(t, store, 3)
+	if err = runner.Submit(b); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		current, _ := runner.Get(b.ID)
+		if current.Completed == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("first item never completed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	runner.Stop()
+	if stored, _ := store.GetBatch(b.ID); stored.Status != StatusInProgress {
+		t.Fatalf("stopped batch status = %s, want in_progress", stored.Status)
+	}
+
+	var executed sync.Map
+	resumed := NewRunner(store, func(ctx context.Context, b *Batch, item Item) Result {
+		executed.Store(item.CustomID, true)
+		return Result{StatusCode: 200, Body: []byte(`{}`)}
+	}, 2)
+	defer resumed.Stop()
+	if err = resumed.Resume(); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	done := waitForStatus(t, resumed, b.ID, true)
+	if done.Status != StatusCompleted || done.Completed != 3 {
+		t.Fatalf("unexpected resumed batch: %+v", done)
+	}
+	if _, again := executed.Load("req-0"); again {
+		t.Fatalf("finished item was executed again after resume")
+	}
+	close(release)
+}
+
+func TestRunnerCancelReportsPendingItems(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	runner := NewRunner(store, func(ctx context.Context, b *Batch, item Item) Result {
+		<-ctx.Done()
+		return Result{Error: ctx.Err().Error()}
+	}, 1)
+	defer runner.Stop()
+
+	b := newTestBatch(t, store, 2)
+	if err = runner.Submit(b); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if _, err = runner.Cancel(b.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	done := waitForStatus(t, runner, b.ID, true)
+	if done.Status != StatusCancelled || done.Pending() != 2 {
+		t.Fatalf("unexpected cancelled batch: %+v", done)
+	}
+	items, _ := store.Items(b.InputFileID)
+	results := RenderClaudeResults(items, nil, done.Status)
+	if strings.Count(string(results), `"canceled"`) != 2 {
+		t.Fatalf("unexpected claude results: %s", results)
+	}
+}
@@ -0,0 +1,300 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxJSONLLine bounds a single line of an input or result file.
+const maxJSONLLine = 64 << 20
+
+// Store persists files, batches and result journals inside a directory:
+//
+//	files/<id>.json         file metadata
+//	files/<id>.jsonl        file content
+//	batches/<id>.json       batch state
+//	batches/<id>.results    append-only result journal (one Result per line)
+type Store struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewStore creates a store rooted at dir.
+func NewStore(dir string) (*Store, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("batch store: directory is required")
+	}
+	for _, sub := range []string{"files", "batches"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("batch store: create directory: %w", err)
+		}
+	}
+	return &Store{dir: dir}, nil
+}
+
+// PutFile stores content under file.ID, filling in Bytes.
+func (s *Store) PutFile(file *File, content []byte) error {
+	if !validID(file.ID) {
+		return fmt.Errorf("batch store: invalid file id %q", file.ID)
+	}
+	file.Bytes = int64(len(content))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := writeFileAtomic(s.filePath(file.ID, ".jsonl"), content); err != nil {
+		return err
+	}
+	return s.writeJSON(s.filePath(file.ID, ".json"), file)
+}
+
+// GetFile returns the metadata of a stored file.
+func (s *Store) GetFile(id string) (*File, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var file File
+	if err := s.readJSON(s.filePath(id, ".json"), &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// OpenFileContent opens the content of a stored file for reading.
+func (s *Store) OpenFileContent(id string) (*os.File, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(s.filePath(id, ".jsonl"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// DeleteFile removes a stored file.
+func (s *Store) DeleteFile(id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.filePath(id, ".json")); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("batch store: delete file: %w", err)
+	}
+	_ = os.Remove(s.filePath(id, ".jsonl"))
+	return nil
+}
+
+// ListFiles returns the files of owner, newest first.
+func (s *Store) ListFiles(owner string) ([]*File, error) {
+	var files []*File
+	err := s.listJSON("files", func(data []byte) {
+		var file File
+		if json.Unmarshal(data, &file) == nil && file.Owner == owner {
+			files = append(files, &file)
+		}
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.After(files[j].CreatedAt) })
+	return files, err
+}
+
+// PutBatch stores or replaces the state of a batch.
+func (s *Store) PutBatch(b *Batch) error {
+	if !validID(b.ID) {
+		return fmt.Errorf("batch store: invalid batch id %q", b.ID)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeJSON(s.batchPath(b.ID, ".json"), b)
+}
+
+// GetBatch returns the state of a batch.
+func (s *Store) GetBatch(id string) (*Batch, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var b Batch
+	if err := s.readJSON(s.batchPath(id, ".json"), &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListBatches returns every stored batch, newest first.
+func (s *Store) ListBatches() ([]*Batch, error) {
+	var batches []*Batch
+	err := s.listJSON("batches", func(data []byte) {
+		var b Batch
+		if json.Unmarshal(data, &b) == nil && b.ID != "" {
+			batches = append(batches, &b)
+		}
+	})
+	sort.Slice(batches, func(i, j int) bool { return batches[i].CreatedAt.After(batches[j].CreatedAt) })
+	return batches, err
+}
+
+// AppendResult appends a result to the journal of batch id.
+func (s *Store) AppendResult(id string, result Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("batch store: encode result: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.batchPath(id, ".results"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("batch store: open results: %w", err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return fmt.Errorf("batch store: write result: %w", err)
+	}
+	return nil
+}
+
+// Results returns the journal of batch id in completion order. A torn final line, left by a
+// crash in the middle of a write, is ignored so the item is simply executed again.
+func (s *Store) Results(id string) ([]Result, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(s.batchPath(id, ".results"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("batch store: open results: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	var results []Result
+	err = scanJSONL(f, func(line []byte) error {
+		var result Result
+		if json.Unmarshal(line, &result) == nil && result.CustomID != "" {
+			results = append(results, result)
+		}
+		return nil
+	})
+	return results, err
+}
+
+// Items parses the input file of a batch.
+func (s *Store) Items(fileID string) ([]Item, error) {
+	f, err := s.OpenFileContent(fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return ParseItems(f)
+}
+
+func (s *Store) filePath(id, ext string) string {
+	return filepath.Join(s.dir, "files", id+ext)
+}
+
+func (s *Store) batchPath(id, ext string) string {
+	return filepath.Join(s.dir, "batches", id+ext)
+}
+
+func (s *Store) writeJSON(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("batch store: encode: %w", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+func (s *Store) readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("batch store: read: %w", err)
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("batch store: decode %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func (s *Store) listJSON(sub string, fn func(data []byte)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(filepath.Join(s.dir, sub))
+	if err != nil {
+		return fmt.Errorf("batch store: list %s: %w", sub, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, errRead := os.ReadFile(filepath.Join(s.dir, sub, entry.Name()))
+		if errRead == nil {
+			fn(data)
+		}
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("batch store: write: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("batch store: write: %w", err)
+	}
+	return nil
+}
+
+// validID rejects identifiers that could escape the store directory.
+func validID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r == '-' || r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')) {
+			return false
+		}
+	}
+	return true
+}
+
+func scanJSONL(r io.Reader, fn func(line []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLine)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("batch store: read lines: %w", err)
+	}
+	return nil
+}
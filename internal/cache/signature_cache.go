@@ -3,11 +3,13 @@ package cache
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sharedstate"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -109,13 +111,41 @@ func CacheSignature(modelName, text, signature string) {
 	groupKey := GetModelGroup(modelName)
 	textHash := hashText(text)
 	sc := getOrCreateGroupCache(groupKey)
+	now := time.Now()
 	sc.mu.Lock()
-	defer sc.mu.Unlock()
+	sc.entries[textHash] = SignatureEntry{
+		Signature: signature,
+		Timestamp: now,
+	}
+	sc.mu.Unlock()
+	sharedstate.Publish(sharedstate.KindSignature, groupKey+":"+textHash, signature, now.Add(SignatureCacheTTL))
+}
 
+// applySharedSignature stores a signature replicated from another proxy replica. Keys are
+// "<model group>:<text hash>"; the hash never contains a colon.
+func applySharedSignature(entry sharedstate.Entry) {
+	sep := strings.LastIndex(entry.Key, ":")
+	if sep <= 0 {
+		return
+	}
+	groupKey, textHash := entry.Key[:sep], entry.Key[sep+1:]
+	sc := getOrCreateGroupCache(groupKey)
+	if entry.Deleted {
+		sc.mu.Lock()
+		delete(sc.entries, textHash)
+		sc.mu.Unlock()
+		return
+	}
+	var signature string
+	if err := json.Unmarshal(entry.Value, &signature); err != nil || len(signature) < MinValidSignatureLen {
+		return
+	}
+	sc.mu.Lock()
 	sc.entries[textHash] = SignatureEntry{
 		Signature: signature,
-		Timestamp: time.Now(),
+		Timestamp: entry.ExpiresAt.Add(-SignatureCacheTTL),
 	}
+	sc.mu.Unlock()
 }
 
 // GetCachedSignature retrieves a cached signature for a given model group and text.
@@ -203,6 +233,7 @@ var signatureBypassStrictMode atomic.Bool
 func init() {
 	signatureCacheEnabled.Store(true)
 	signatureBypassStrictMode.Store(false)
+	sharedstate.Subscribe(sharedstate.KindSignature, applySharedSignature)
 }
 
 // SetSignatureCacheEnabled switches Antigravity signature handling between cache mode and bypass mode.
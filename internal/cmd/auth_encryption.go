@@ -0,0 +1,44 @@
+// Package cmd contains CLI helpers. This file implements encrypting auth records at rest and
+// rotating the master key that protects them.
+package cmd
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoReencryptAuths seals every auth record in the auth directory with the active master key,
+// migrating plaintext records. With rotate set, a new master key is generated first and the
+// previous keys stay in the key file for decryption. Rewritten records are pushed to the
+// remote token store when one is configured.
+func DoReencryptAuths(cfg *config.Config, rotate bool) {
+	keyring := authcrypt.Default()
+	if keyring == nil {
+		log.Errorf("auth encryption: no master key configured (set AUTHSTORE_ENCRYPTION_KEY or AUTHSTORE_ENCRYPTION_KEY_FILE)")
+		return
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if resolved, errResolve := util.ResolveAuthDir(cfg.AuthDir); errResolve == nil {
+		cfg.AuthDir = resolved
+	}
+	persister, _ := sdkAuth.GetTokenStore().(authcrypt.Persister)
+	result, err := keyring.Reencrypt(context.Background(), cfg.AuthDir, rotate, persister)
+	if err != nil {
+		log.Errorf("auth encryption: %v", err)
+		return
+	}
+	for _, failure := range result.Failed {
+		log.Warnf("auth encryption: skipped %s", failure)
+	}
+	if rotate {
+		log.Infof("auth encryption: rotated master key to %s", result.KeyID)
+	}
+	log.Infof("auth encryption: %d of %d auth records re-encrypted with key %s", len(result.Rewritten), result.Scanned, result.KeyID)
+}
@@ -0,0 +1,104 @@
+package config
+
+import "strings"
+
+// DefaultAzureOpenAIAPIVersion is used when an Azure OpenAI entry does not set api-version.
+const DefaultAzureOpenAIAPIVersion = "2025-04-01-preview"
+
+// AzureOpenAIKey represents the configuration for an Azure OpenAI resource.
+// Requests are routed to /openai/deployments/{deployment} on the resource endpoint and
+// authenticated with the api-key header.
+type AzureOpenAIKey struct {
+	// APIKey is the resource key sent in the api-key header.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// BaseURL is the resource endpoint (e.g., "https://my-resource.openai.azure.com").
+	BaseURL string `yaml:"base-url" json:"base-url"`
+
+	// APIVersion is the api-version query parameter; defaults to DefaultAzureOpenAIAPIVersion.
+	APIVersion string `yaml:"api-version,omitempty" json:"api-version,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces model aliases for this credential (e.g., "teamA/gpt-4o").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL optionally overrides the global proxy for this credential.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Models maps deployment names to client-visible model aliases.
+	Models []AzureOpenAIModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+func (k AzureOpenAIKey) GetAPIKey() string  { return k.APIKey }
+func (k AzureOpenAIKey) GetBaseURL() string { return k.BaseURL }
+
+// EffectiveAPIVersion returns APIVersion or DefaultAzureOpenAIAPIVersion when unset.
+func (k AzureOpenAIKey) EffectiveAPIVersion() string {
+	if v := strings.TrimSpace(k.APIVersion); v != "" {
+		return v
+	}
+	return DefaultAzureOpenAIAPIVersion
+}
+
+// AzureOpenAIModel maps an Azure deployment to a client-visible model alias.
+type AzureOpenAIModel struct {
+	// Name is the deployment name on the Azure OpenAI resource.
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the model name clients use to reference this deployment.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m AzureOpenAIModel) GetName() string  { return m.Name }
+func (m AzureOpenAIModel) GetAlias() string { return m.Alias }
+
+// SanitizeAzureOpenAIKeys normalizes Azure OpenAI entries and drops those without an API
+// key or endpoint.
+func (cfg *Config) SanitizeAzureOpenAIKeys() {
+	if cfg == nil {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(cfg.AzureOpenAIKey))
+	out := cfg.AzureOpenAIKey[:0]
+	for i := range cfg.AzureOpenAIKey {
+		entry := cfg.AzureOpenAIKey[i]
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.BaseURL = strings.TrimSuffix(strings.TrimSpace(entry.BaseURL), "/")
+		if entry.APIKey == "" || entry.BaseURL == "" {
+			continue
+		}
+		entry.APIVersion = strings.TrimSpace(entry.APIVersion)
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		sanitizedModels := make([]AzureOpenAIModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" {
+				sanitizedModels = append(sanitizedModels, model)
+			}
+		}
+		entry.Models = sanitizedModels
+
+		uniqueKey := entry.APIKey + "|" + entry.BaseURL
+		if _, exists := seen[uniqueKey]; exists {
+			continue
+		}
+		seen[uniqueKey] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.AzureOpenAIKey = out
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultBedrockRegion is used when a Bedrock credential does not specify a region.
+const DefaultBedrockRegion = "us-east-1"
+
+// BedrockKey represents the configuration for an AWS Bedrock credential.
+// Requests are signed with SigV4 using either static access keys or a named
+// profile from an AWS shared credentials file.
+type BedrockKey struct {
+	// AccessKeyID is the AWS access key ID used for static credentials.
+	AccessKeyID string `yaml:"access-key-id,omitempty" json:"access-key-id,omitempty"`
+
+	// SecretAccessKey is the AWS secret access key paired with AccessKeyID.
+	SecretAccessKey string `yaml:"secret-access-key,omitempty" json:"secret-access-key,omitempty"`
+
+	// SessionToken is the optional session token for temporary credentials.
+	SessionToken string `yaml:"session-token,omitempty" json:"session-token,omitempty"`
+
+	// Profile names a profile in the shared credentials file. It is used when
+	// AccessKeyID is empty.
+	Profile string `yaml:"profile,omitempty" json:"profile,omitempty"`
+
+	// CredentialsFile overrides the shared credentials file path used with Profile.
+	// Defaults to $AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials.
+	CredentialsFile string `yaml:"credentials-file,omitempty" json:"credentials-file,omitempty"`
+
+	// Region is the AWS region hosting the models; defaults to us-east-1.
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces model aliases for this credential (e.g., "teamA/claude-sonnet").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// BaseURL optionally overrides the Bedrock runtime endpoint (e.g., a VPC endpoint).
+	// When empty, https://bedrock-runtime.{region}.amazonaws.com is used.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// ProxyURL optionally overrides the global proxy for this credential.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Models maps Bedrock model or inference profile IDs to client-visible aliases.
+	Models []BedrockModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// GetAPIKey returns the identity of the credential: the access key ID, or the
+// profile name for profile-based credentials.
+func (k BedrockKey) GetAPIKey() string {
+	if id := strings.TrimSpace(k.AccessKeyID); id != "" {
+		return id
+	}
+	if profile := strings.TrimSpace(k.Profile); profile != "" {
+		return "profile:" + profile
+	}
+	return ""
+}
+
+// GetBaseURL returns the effective Bedrock runtime endpoint.
+func (k BedrockKey) GetBaseURL() string {
+	if base := strings.TrimSpace(k.BaseURL); base != "" {
+		return strings.TrimSuffix(base, "/")
+	}
+	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", k.EffectiveRegion())
+}
+
+// EffectiveRegion returns Region or DefaultBedrockRegion when unset.
+func (k BedrockKey) EffectiveRegion() string {
+	if region := strings.TrimSpace(k.Region); region != "" {
+		return region
+	}
+	return DefaultBedrockRegion
+}
+
+// BedrockModel maps a Bedrock model ID to a client-visible alias.
+type BedrockModel struct {
+	// Name is the Bedrock model ID or inference profile ID
+	// (e.g., "us.anthropic.claude-sonnet-4-5-20250929-v1:0").
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the model name clients use to reference this model.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m BedrockModel) GetName() string  { return m.Name }
+func (m BedrockModel) GetAlias() string { return m.Alias }
+
+// SanitizeBedrockKeys normalizes Bedrock credentials and drops entries without
+// static keys or a profile.
+func (cfg *Config) SanitizeBedrockKeys() {
+	if cfg == nil {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(cfg.BedrockKey))
+	out := cfg.BedrockKey[:0]
+	for i := range cfg.BedrockKey {
+		entry := cfg.BedrockKey[i]
+		entry.AccessKeyID = strings.TrimSpace(entry.AccessKeyID)
+		entry.SecretAccessKey = strings.TrimSpace(entry.SecretAccessKey)
+		entry.SessionToken = strings.TrimSpace(entry.SessionToken)
+		entry.Profile = strings.TrimSpace(entry.Profile)
+		entry.CredentialsFile = strings.TrimSpace(entry.CredentialsFile)
+		entry.Region = strings.TrimSpace(entry.Region)
+		if entry.AccessKeyID == "" && entry.Profile == "" {
+			continue
+		}
+		if entry.AccessKeyID != "" && entry.SecretAccessKey == "" {
+			continue
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.BaseURL = strings.TrimSpace(entry.BaseURL)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		sanitizedModels := make([]BedrockModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" {
+				sanitizedModels = append(sanitizedModels, model)
+			}
+		}
+		entry.Models = sanitizedModels
+
+		uniqueKey := entry.GetAPIKey() + "|" + entry.GetBaseURL()
+		if _, exists := seen[uniqueKey]; exists {
+			continue
+		}
+		seen[uniqueKey] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.BedrockKey = out
+}
@@ -6,6 +6,8 @@ package config
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -48,6 +50,9 @@ type Config struct {
 	// Pprof config controls the optional pprof HTTP debug server.
 	Pprof PprofConfig `yaml:"pprof" json:"pprof"`
 
+	// Metrics config controls the Prometheus metrics endpoint.
+	Metrics MetricsConfig `yaml:"metrics" json:"metrics"`
+
 	// CommercialMode disables high-overhead HTTP middleware features to minimize per-request memory usage.
 	CommercialMode bool `yaml:"commercial-mode" json:"commercial-mode"`
 
@@ -65,6 +70,12 @@ type Config struct {
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsageLedger configures the durable usage ledger queried via the management API.
+	UsageLedger UsageLedgerConfig `yaml:"usage-ledger" json:"usage-ledger"`
+
+	// SharedState configures replication of runtime state between proxy replicas.
+	SharedState SharedStateConfig `yaml:"shared-state" json:"shared-state"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -86,6 +97,10 @@ type Config struct {
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
+	// ModelFallbacks defines cross-provider fallback chains tried when every credential for a
+	// model is cooling down or unavailable, or the upstream fails before the first byte.
+	ModelFallbacks []ModelFallback `yaml:"model-fallbacks,omitempty" json:"model-fallbacks,omitempty"`
+
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
@@ -120,6 +135,12 @@ type Config struct {
 	// Used for services that use Vertex AI-style paths but with simple API key authentication.
 	VertexCompatAPIKey []VertexCompatKey `yaml:"vertex-api-key" json:"vertex-api-key"`
 
+	// BedrockKey defines AWS Bedrock credentials signed with SigV4.
+	BedrockKey []BedrockKey `yaml:"bedrock-api-key" json:"bedrock-api-key"`
+
+	// AzureOpenAIKey defines Azure OpenAI resources routed by deployment name.
+	AzureOpenAIKey []AzureOpenAIKey `yaml:"azure-openai" json:"azure-openai"`
+
 	// AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.
 	AmpCode AmpCode `yaml:"ampcode" json:"ampcode"`
 
@@ -181,6 +202,39 @@ type PprofConfig struct {
 	Addr string `yaml:"addr" json:"addr"`
 }
 
+// MetricsConfig holds Prometheus metrics endpoint settings.
+type MetricsConfig struct {
+	// Enable exposes /metrics on the API server. Requests are authenticated with the
+	// same client API keys as the API routes.
+	Enable bool `yaml:"enable" json:"enable"`
+}
+
+// UsageLedgerConfig controls the append-only usage ledger. Unlike the in-memory usage
+// statistics, ledger entries survive restarts and can be queried by time range.
+type UsageLedgerConfig struct {
+	// Enable turns on ledger writes. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Backend selects the storage: "file" (default) or "postgres" (requires PGSTORE_DSN).
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// Dir is the directory used by the file backend. Defaults to data/usage-ledger under
+	// WRITABLE_PATH or the working directory.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+}
+
+// SharedStateConfig controls replication of session affinity bindings, thinking signatures,
+// Codex prompt cache keys and credential cooldowns between replicas that share a backend.
+type SharedStateConfig struct {
+	// Backend selects the storage: "memory" (default, state stays process-local) or "postgres"
+	// (requires PGSTORE_DSN).
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// SyncInterval is how often state is pushed and pulled, e.g. "1s". Replicas converge within
+	// about two intervals. Defaults to 1s.
+	SyncInterval string `yaml:"sync-interval,omitempty" json:"sync-interval,omitempty"`
+}
+
 // RemoteManagement holds management API configuration under 'remote-management'.
 type RemoteManagement struct {
 	// AllowRemote toggles remote (non-localhost) access to management API.
@@ -195,6 +249,46 @@ type RemoteManagement struct {
 	// PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset.
 	// Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.
 	PanelGitHubRepository string `yaml:"panel-github-repository"`
+	// Tokens lists named management tokens limited to a set of scopes. The secret key and
+	// MANAGEMENT_PASSWORD keep granting every scope.
+	Tokens []ManagementToken `yaml:"tokens"`
+	// AuditLogFile overrides where mutating management calls are recorded as JSON lines.
+	// Defaults to data/audit/management-audit.jsonl under WRITABLE_PATH or the working directory.
+	AuditLogFile string `yaml:"audit-log-file,omitempty"`
+	// DisableAuditLog turns off the management audit log.
+	DisableAuditLog bool `yaml:"disable-audit-log,omitempty"`
+}
+
+// ManagementToken is a named management API token.
+type ManagementToken struct {
+	// Name identifies the token in the audit log.
+	Name string `yaml:"name"`
+	// Token is the token value, either plaintext or its "sha256:" hex digest. Plaintext values
+	// are hashed when the config is loaded and stored hashed on the next save.
+	Token string `yaml:"token"`
+	// Scopes lists the granted scopes, e.g. "usage:read" or "auth:write". "*" grants all.
+	Scopes []string `yaml:"scopes"`
+}
+
+// managementTokenHashPrefix marks hashed management token values.
+const managementTokenHashPrefix = "sha256:"
+
+// HashManagementToken returns the stored form of a management token value. Already hashed
+// values are returned unchanged, so it must only be used to normalise configured tokens;
+// presented credentials go through DigestManagementToken.
+func HashManagementToken(token string) string {
+	token = strings.TrimSpace(token)
+	if token == "" || strings.HasPrefix(token, managementTokenHashPrefix) {
+		return token
+	}
+	return DigestManagementToken(token)
+}
+
+// DigestManagementToken hashes a presented management token unconditionally, so a stored
+// digest sent as the token never matches itself.
+func DigestManagementToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return managementTokenHashPrefix + hex.EncodeToString(sum[:])
 }
 
 // QuotaExceeded defines the behavior when API quota limits are exceeded.
@@ -214,7 +308,8 @@ type QuotaExceeded struct {
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first".
+	// Supported values: "round-robin" (default), "fill-first", "latency" (lowest EWMA
+	// latency/TTFT and error ratio), "least-inflight" (fewest requests in flight).
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
 
 	// ClaudeCodeSessionAffinity enables session-sticky routing for Claude Code clients.
@@ -234,6 +329,14 @@ type RoutingConfig struct {
 	SessionAffinityTTL string `yaml:"session-affinity-ttl,omitempty" json:"session-affinity-ttl,omitempty"`
 }
 
+// ModelFallback defines the ordered fallback models for a requested model.
+type ModelFallback struct {
+	// Model is the client-requested model name the chain applies to.
+	Model string `yaml:"model" json:"model"`
+	// Fallbacks lists the models tried in order, which may be served by other providers.
+	Fallbacks []string `yaml:"fallbacks" json:"fallbacks"`
+}
+
 // OAuthModelAlias defines a model ID alias for a specific channel.
 // It maps the upstream model name (Name) to the client-visible alias (Alias).
 // When Fork is true, the alias is added as an additional model in listings while
@@ -555,6 +658,11 @@ type OpenAICompatibilityModel struct {
 	// Thinking configures the thinking/reasoning capability for this model.
 	// If nil, the model defaults to level-based reasoning with levels ["low", "medium", "high"].
 	Thinking *registry.ThinkingSupport `yaml:"thinking,omitempty" json:"thinking,omitempty"`
+
+	// InputModalities lists the input modalities this upstream model accepts (e.g. TEXT, IMAGE,
+	// PDF, AUDIO, VIDEO). Requests carrying other modalities are routed to another upstream of
+	// the same alias. Empty means unknown and the model is never excluded.
+	InputModalities []string `yaml:"input-modalities,omitempty" json:"input-modalities,omitempty"`
 }
 
 func (m OpenAICompatibilityModel) GetName() string  { return m.Name }
@@ -646,6 +754,12 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
 	}
 
+	// Keep only digests of named management tokens in memory.
+	for i := range cfg.RemoteManagement.Tokens {
+		cfg.RemoteManagement.Tokens[i].Name = strings.TrimSpace(cfg.RemoteManagement.Tokens[i].Name)
+		cfg.RemoteManagement.Tokens[i].Token = HashManagementToken(cfg.RemoteManagement.Tokens[i].Token)
+	}
+
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
 	if cfg.RemoteManagement.PanelGitHubRepository == "" {
 		cfg.RemoteManagement.PanelGitHubRepository = DefaultPanelGitHubRepository
@@ -674,6 +788,12 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize Vertex-compatible API keys.
 	cfg.SanitizeVertexCompatKeys()
 
+	// Sanitize AWS Bedrock credentials.
+	cfg.SanitizeBedrockKeys()
+
+	// Sanitize Azure OpenAI resources: drop entries without api-key or base-url
+	cfg.SanitizeAzureOpenAIKeys()
+
 	// Sanitize Codex keys: drop entries without base-url
 	cfg.SanitizeCodexKeys()
 
@@ -4,6 +4,8 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -24,6 +26,10 @@ type SDKConfig struct {
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// Access lists additional client authentication providers, such as "jwt" or "oidc" validation
+	// of single sign-on tokens. They are consulted after the inline api-keys.
+	Access sdkaccess.AccessConfig `yaml:"access,omitempty" json:"access,omitempty"`
+
 	// PassthroughHeaders controls whether upstream response headers are forwarded to downstream clients.
 	// Default is false (disabled).
 	PassthroughHeaders bool `yaml:"passthrough-headers" json:"passthrough-headers"`
@@ -34,6 +40,267 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// ResponsesStore configures server-side conversation state for the HTTP Responses API.
+	ResponsesStore ResponsesStoreConfig `yaml:"responses-store" json:"responses-store"`
+
+	// ClientQuotas defines per-client-key rate limits, token quotas, budgets and model allowlists.
+	ClientQuotas ClientQuotaConfig `yaml:"client-quotas" json:"client-quotas"`
+
+	// ModelPrices overrides or extends the built-in per-model price table used for cost accounting.
+	ModelPrices []ModelPrice `yaml:"model-prices,omitempty" json:"model-prices,omitempty"`
+
+	// ResponseCache configures the exact-match response cache in front of the executors.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache" json:"response-cache"`
+
+	// Redaction configures detection of secrets and PII in prompts and request logs.
+	Redaction RedactionConfig `yaml:"redaction" json:"redaction"`
+
+	// ContextGuard configures preflight prompt token estimation against model context windows.
+	ContextGuard ContextGuardConfig `yaml:"context-guard" json:"context-guard"`
+
+	// Batch configures the local emulation of the OpenAI Batch and Anthropic Message Batches APIs.
+	Batch BatchConfig `yaml:"batch" json:"batch"`
+
+	// Shadow configures mirroring of sampled traffic to a secondary model for comparison.
+	Shadow ShadowConfig `yaml:"shadow" json:"shadow"`
+}
+
+// ShadowConfig controls shadow traffic. Mirrored calls never delay the client response and
+// run on dedicated credentials, so their failures do not cool down the primary credentials.
+type ShadowConfig struct {
+	// Enable turns on mirroring for the configured rules. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Dir is where records are written as daily JSON-lines files. Defaults to data/shadow under
+	// WRITABLE_PATH or the working directory. Changes take effect on restart.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// MaxConcurrent caps in-flight shadow calls; requests beyond it are not mirrored. Default is 16.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
+	// Timeout bounds a single shadow call, e.g. "2m". Default is 5m.
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Rules are evaluated in order; the first rule matching the client key and model applies.
+	Rules []ShadowRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// ShadowRule mirrors a percentage of matching requests to TargetModel.
+type ShadowRule struct {
+	// Name labels the records written for this rule.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// APIKeys restricts the rule to these client API keys. Empty or "*" matches every key.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// Models restricts the rule to requested models matching these patterns ('*' wildcards).
+	// Empty matches every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Percent is the share of matching requests mirrored, from 0 to 100.
+	Percent float64 `yaml:"percent" json:"percent"`
+
+	// TargetModel is the model the mirrored request is sent to.
+	TargetModel string `yaml:"target-model" json:"target-model"`
+
+	// AuthIDs lists the credentials reserved for shadow calls. Each call is pinned to one of
+	// them in turn. Required; keep them out of regular routing, for example with a prefix and
+	// force-model-prefix.
+	AuthIDs []string `yaml:"auth-ids" json:"auth-ids"`
+
+	// RecordText stores the request and both response bodies in the records.
+	RecordText bool `yaml:"record-text,omitempty" json:"record-text,omitempty"`
+}
+
+// BatchConfig controls the local batch subsystem behind /v1/files, /v1/batches and
+// /v1/messages/batches. Changes take effect on restart.
+type BatchConfig struct {
+	// Enable registers the batch endpoints and resumes unfinished batches at startup. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Dir is where input files, batch state and results are kept. Defaults to data/batches under
+	// WRITABLE_PATH or the working directory.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// Concurrency is the number of batch requests executed in parallel across all batches. Default is 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+}
+
+// RedactionConfig controls the secret/PII redaction engine. When enabled, request logs are
+// always masked, and outbound prompts are handled according to the per-key mode.
+type RedactionConfig struct {
+	// Enable turns on redaction. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Mode is the default action for prompts containing findings: "mask" (default) replaces
+	// matches before the request is sent upstream, "block" rejects the request and "allow"
+	// forwards it unchanged.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// Detectors selects the built-in detectors: aws-key, jwt, pem, email. Empty enables all.
+	Detectors []string `yaml:"detectors,omitempty" json:"detectors,omitempty"`
+
+	// Patterns adds custom detectors matched as regular expressions.
+	Patterns []RedactionPattern `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+
+	// APIKeys overrides Mode for individual client API keys.
+	APIKeys []RedactionKeyMode `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+}
+
+// RedactionPattern is a named custom detector.
+type RedactionPattern struct {
+	// Name labels findings of this pattern (e.g. "employee-id").
+	Name string `yaml:"name" json:"name"`
+
+	// Regex is an RE2 regular expression.
+	Regex string `yaml:"regex" json:"regex"`
+}
+
+// RedactionKeyMode overrides the redaction mode for a client API key.
+type RedactionKeyMode struct {
+	APIKey string `yaml:"api-key" json:"api-key"`
+	Mode   string `yaml:"mode" json:"mode"`
+}
+
+// ContextGuardConfig controls the preflight context-window guard. When enabled, prompt tokens
+// are estimated for every credential and model a request is dispatched to, fallbacks included,
+// and prompts that exceed the model's input limit are rejected or trimmed according to the
+// policy selected for the client key or model.
+type ContextGuardConfig struct {
+	// Enable turns on the guard. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Policy is the default action for oversized prompts: "reject" (default) returns a
+	// context-length error, "drop-oldest" removes the oldest conversation turns and
+	// "truncate-tool-results" shortens large tool results, oldest first.
+	Policy string `yaml:"policy,omitempty" json:"policy,omitempty"`
+
+	// ToolResultMaxTokens is the size tool results are truncated to by the
+	// truncate-tool-results policy. Default is 2048.
+	ToolResultMaxTokens int `yaml:"tool-result-max-tokens,omitempty" json:"tool-result-max-tokens,omitempty"`
+
+	// Models overrides the input limit or policy for a model ID or family prefix.
+	Models []ContextGuardModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// APIKeys overrides the policy for individual client API keys. Key entries take
+	// precedence over model entries; the "*" entry only applies when no model entry sets a
+	// policy.
+	APIKeys []ContextGuardKeyPolicy `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+}
+
+// ContextGuardModel overrides the context guard for a model ID or family prefix.
+type ContextGuardModel struct {
+	Model string `yaml:"model" json:"model"`
+
+	// Limit is the maximum number of prompt tokens. Zero uses the limit from the model registry.
+	Limit int64 `yaml:"limit,omitempty" json:"limit,omitempty"`
+
+	Policy string `yaml:"policy,omitempty" json:"policy,omitempty"`
+}
+
+// ContextGuardKeyPolicy overrides the context guard policy for a client API key.
+type ContextGuardKeyPolicy struct {
+	APIKey string `yaml:"api-key" json:"api-key"`
+	Policy string `yaml:"policy" json:"policy"`
+}
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	// Model is a model ID or family prefix (e.g. "claude-sonnet-4-5").
+	Model string `yaml:"model" json:"model"`
+
+	// Input is the price of uncached input tokens.
+	Input float64 `yaml:"input" json:"input"`
+
+	// Output is the price of output tokens.
+	Output float64 `yaml:"output" json:"output"`
+
+	// CachedInput is the price of cache-read input tokens. Zero bills them at the input price.
+	CachedInput float64 `yaml:"cached-input,omitempty" json:"cached-input,omitempty"`
+
+	// CacheWrite is the price of cache-creation input tokens. Zero bills them at the input price.
+	CacheWrite float64 `yaml:"cache-write,omitempty" json:"cache-write,omitempty"`
+
+	// Reasoning is the price of reasoning tokens. Zero bills them at the output price.
+	Reasoning float64 `yaml:"reasoning,omitempty" json:"reasoning,omitempty"`
+}
+
+// ResponseCacheConfig controls caching of completed responses for identical requests.
+// Caching is opt-in per request via the X-Response-Cache header or per client API key.
+type ResponseCacheConfig struct {
+	// Enable turns on the response cache. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Backend selects the persistent tier behind the in-memory LRU: "memory" (default, no
+	// persistence), "file", or "postgres". The postgres backend requires PGSTORE_DSN.
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// TTL is how long cached responses are served (e.g. "1h"). Default is 1h.
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+
+	// MaxEntries bounds the in-memory LRU. Default is 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// Dir is the directory used by the file backend. Defaults to data/response-cache under
+	// WRITABLE_PATH or the working directory.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// APIKeys lists client API keys whose requests are cached without the opt-in header.
+	// Use "*" to cache for every key. Clients can still send "X-Response-Cache: bypass".
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+}
+
+// ClientQuotaConfig groups the per-client-key limits enforced after authentication.
+type ClientQuotaConfig struct {
+	// Store selects where usage counters are persisted: "memory" (default) or "file".
+	Store string `yaml:"store,omitempty" json:"store,omitempty"`
+
+	// Dir is the directory used by the file store. Defaults to data/quotas under WRITABLE_PATH or
+	// the working directory.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// Keys lists the limits per client API key. An entry with api-key "*" applies to
+	// every authenticated key that has no dedicated entry.
+	Keys []ClientKeyQuota `yaml:"keys,omitempty" json:"keys,omitempty"`
+}
+
+// ClientKeyQuota describes the limits applied to a single client API key.
+// Zero values disable the corresponding limit.
+type ClientKeyQuota struct {
+	// APIKey is the client key these limits apply to, or "*" for the default entry.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// RequestsPerMinute caps the number of requests admitted per calendar minute.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+
+	// TokensPerDay caps the total tokens (input + output + reasoning) charged per UTC day.
+	TokensPerDay int64 `yaml:"tokens-per-day,omitempty" json:"tokens-per-day,omitempty"`
+
+	// MonthlyBudget caps the spend per UTC calendar month, in USD.
+	MonthlyBudget float64 `yaml:"monthly-budget,omitempty" json:"monthly-budget,omitempty"`
+
+	// AllowedModels restricts the models this key may use. Entries support "*" wildcards.
+	// Empty allows every model.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+}
+
+// ResponsesStoreConfig controls how completed /v1/responses results are retained
+// so that follow-up requests can reference them via previous_response_id.
+type ResponsesStoreConfig struct {
+	// Enable turns on previous_response_id expansion and response storage. Default is false.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Backend selects where responses are kept: "memory" (default), "file", or "postgres".
+	// The postgres backend is only available when the server runs with PGSTORE_DSN.
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// TTL is how long stored responses remain retrievable (e.g. "24h"). Default is 24h.
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+
+	// Dir is the directory used by the file backend. Defaults to data/responses under WRITABLE_PATH
+	// or the working directory.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
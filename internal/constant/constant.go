@@ -24,4 +24,7 @@ const (
 
 	// Antigravity represents the Antigravity response format identifier.
 	Antigravity = "antigravity"
+
+	// Ollama represents the Ollama API format identifier.
+	Ollama = "ollama"
 )
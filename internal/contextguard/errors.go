@@ -0,0 +1,36 @@
+package contextguard
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+// ExceededError reports a prompt that does not fit the model's input limit.
+// Error renders a JSON body in the client's protocol selected by Format.
+type ExceededError struct {
+	Estimated int64
+	Limit     int64
+	Model     string
+	// Format is the handler type of the client request (e.g. "openai", "claude", "gemini").
+	Format string
+}
+
+// Message returns a human-readable description with the estimated and allowed token counts.
+func (e *ExceededError) Message() string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf("prompt is too long: an estimated %d tokens exceeds the %d token input limit of model %s", e.Estimated, e.Limit, e.Model)
+}
+
+func (e *ExceededError) Error() string {
+	if e == nil {
+		return ""
+	}
+	return interfaces.InvalidRequestBody(e.Format, e.Message(), "context_length_exceeded")
+}
+
+// StatusCode returns 400.
+func (e *ExceededError) StatusCode() int { return http.StatusBadRequest }
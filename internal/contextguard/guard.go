@@ -0,0 +1,351 @@
+// Package contextguard estimates prompt tokens before a request is dispatched and enforces the
+// model's context window by rejecting the request or trimming the prompt, according to the
+// policy configured for the client key or model.
+package contextguard
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// Policy is the action taken on a prompt that exceeds the model's input limit.
+type Policy string
+
+const (
+	// PolicyReject returns a context-length error without contacting upstream.
+	PolicyReject Policy = "reject"
+	// PolicyDropOldest removes the oldest conversation turns, keeping system prompts and the
+	// latest turn.
+	PolicyDropOldest Policy = "drop-oldest"
+	// PolicyTruncateToolResults shortens large tool results, oldest first.
+	PolicyTruncateToolResults Policy = "truncate-tool-results"
+)
+
+// DefaultKey is the api-key value of the entry applied to keys without a dedicated entry.
+const DefaultKey = "*"
+
+// DefaultToolResultMaxTokens is the truncation size used when none is configured.
+const DefaultToolResultMaxTokens = 2048
+
+type modelRule struct {
+	limit  int64
+	policy Policy
+}
+
+// Result describes the outcome of a preflight check.
+type Result struct {
+	// Payload is the request body to dispatch, trimmed when the policy removed content.
+	Payload []byte
+	// Estimated is the estimated prompt size before trimming.
+	Estimated int64
+	// Trimmed is the estimated number of tokens removed by the policy.
+	Trimmed int64
+	// Limit is the input limit the prompt was checked against.
+	Limit int64
+	// Policy is the policy that was applied.
+	Policy Policy
+}
+
+// Guard holds the configured policies and limits.
+type Guard struct {
+	mu            sync.RWMutex
+	enabled       bool
+	policy        Policy
+	toolResultMax int
+	keyPolicies   map[string]Policy
+	models        map[string]modelRule
+}
+
+// NewGuard constructs a disabled guard.
+func NewGuard() *Guard {
+	return &Guard{
+		policy:        PolicyReject,
+		toolResultMax: DefaultToolResultMaxTokens,
+		keyPolicies:   make(map[string]Policy),
+		models:        make(map[string]modelRule),
+	}
+}
+
+// Configure applies cfg.ContextGuard. Entries with unknown policies are skipped with a warning.
+func (g *Guard) Configure(cfg *config.SDKConfig) {
+	if g == nil {
+		return
+	}
+	enabled := false
+	policy := PolicyReject
+	toolResultMax := DefaultToolResultMaxTokens
+	keyPolicies := make(map[string]Policy)
+	models := make(map[string]modelRule)
+	if cfg != nil && cfg.ContextGuard.Enable {
+		gc := cfg.ContextGuard
+		enabled = true
+		if parsed, ok := ParsePolicy(gc.Policy); ok {
+			policy = parsed
+		} else if strings.TrimSpace(gc.Policy) != "" {
+			log.Warnf("context guard: unknown policy %q, using %s", gc.Policy, PolicyReject)
+		}
+		if gc.ToolResultMaxTokens > 0 {
+			toolResultMax = gc.ToolResultMaxTokens
+		}
+		for _, entry := range gc.APIKeys {
+			key := strings.TrimSpace(entry.APIKey)
+			parsed, ok := ParsePolicy(entry.Policy)
+			if key == "" || !ok {
+				if key != "" {
+					log.Warnf("context guard: skipping api key entry with unknown policy %q", entry.Policy)
+				}
+				continue
+			}
+			keyPolicies[key] = parsed
+		}
+		for _, entry := range gc.Models {
+			model := normalizeModel(entry.Model)
+			if model == "" {
+				continue
+			}
+			rule := modelRule{limit: max(entry.Limit, 0)}
+			if strings.TrimSpace(entry.Policy) != "" {
+				parsed, ok := ParsePolicy(entry.Policy)
+				if !ok {
+					log.Warnf("context guard: unknown policy %q for model %s", entry.Policy, entry.Model)
+				}
+				rule.policy = parsed
+			}
+			models[model] = rule
+		}
+	}
+	g.mu.Lock()
+	g.enabled = enabled
+	g.policy = policy
+	g.toolResultMax = toolResultMax
+	g.keyPolicies = keyPolicies
+	g.models = models
+	g.mu.Unlock()
+}
+
+// ParsePolicy parses a policy name.
+func ParsePolicy(raw string) (Policy, bool) {
+	switch Policy(strings.ToLower(strings.TrimSpace(raw))) {
+	case PolicyReject:
+		return PolicyReject, true
+	case PolicyDropOldest:
+		return PolicyDropOldest, true
+	case PolicyTruncateToolResults:
+		return PolicyTruncateToolResults, true
+	default:
+		return "", false
+	}
+}
+
+// Enabled reports whether the guard is configured.
+func (g *Guard) Enabled() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.enabled
+}
+
+// PolicyFor returns the policy for a client API key and model. A dedicated key entry takes
+// precedence over model entries, which take precedence over the "*" key entry and then the
+// default policy.
+func (g *Guard) PolicyFor(apiKey, model string) Policy {
+	if g == nil {
+		return PolicyReject
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if policy, ok := g.keyPolicies[apiKey]; ok && apiKey != DefaultKey {
+		return policy
+	}
+	if rule, ok := g.lookupModelLocked(model); ok && rule.policy != "" {
+		return rule.policy
+	}
+	if policy, ok := g.keyPolicies[DefaultKey]; ok {
+		return policy
+	}
+	return g.policy
+}
+
+// LimitFor returns the input token limit of model as served by provider: the configured
+// override, else the registry's input limit, else the context length minus the output tokens
+// requested in payload. Zero means the limit is unknown and the prompt is not checked.
+func (g *Guard) LimitFor(model, provider string, payload []byte) int64 {
+	if g == nil {
+		return 0
+	}
+	g.mu.RLock()
+	rule, ok := g.lookupModelLocked(model)
+	g.mu.RUnlock()
+	if ok && rule.limit > 0 {
+		return rule.limit
+	}
+	info := registry.LookupModelInfo(model, provider)
+	if info == nil {
+		return 0
+	}
+	if info.InputTokenLimit > 0 {
+		return int64(info.InputTokenLimit)
+	}
+	if info.ContextLength <= 0 {
+		return 0
+	}
+	limit := int64(info.ContextLength) - requestedOutputTokens(payload)
+	return max(limit, 0)
+}
+
+func (g *Guard) lookupModelLocked(model string) (modelRule, bool) {
+	key := normalizeModel(model)
+	if key == "" {
+		return modelRule{}, false
+	}
+	if rule, ok := g.models[key]; ok {
+		return rule, true
+	}
+	bestLen := 0
+	var best modelRule
+	for prefix, rule := range g.models {
+		if len(prefix) > bestLen && strings.HasPrefix(key, prefix) {
+			best, bestLen = rule, len(prefix)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// Target identifies one execution candidate of a request.
+type Target struct {
+	// Model is the upstream model name.
+	Model string
+	// Provider is the provider key of the selected credential.
+	Provider string
+	// Format is the request format the executor translates the client payload into. Empty
+	// means the payload is sent in the client format.
+	Format string
+}
+
+// Check estimates the prompt size of payload, written in the client protocol format, once
+// translated for target, and enforces the input limit of the target model. Trimming is applied
+// to the client payload. When the prompt does not fit after the policy has been applied an
+// *ExceededError is returned. A zero Result.Limit means the model's limit is unknown and the
+// payload was returned unchanged.
+func (g *Guard) Check(format, apiKey string, target Target, payload []byte, stream bool) (Result, error) {
+	result := Result{Payload: payload}
+	if !g.Enabled() || len(payload) == 0 {
+		return result, nil
+	}
+	limit := g.LimitFor(target.Model, target.Provider, payload)
+	if limit <= 0 {
+		return result, nil
+	}
+	enc, err := helps.TokenizerForModel(target.Model)
+	if err != nil {
+		return result, err
+	}
+	estimate := func(body []byte) (int64, error) {
+		if target.Format == "" || target.Format == format {
+			return Estimate(enc, format, body)
+		}
+		translated := sdktranslator.TranslateRequest(sdktranslator.FromString(format), sdktranslator.FromString(target.Format), target.Model, body, stream)
+		return Estimate(enc, target.Format, translated)
+	}
+	estimated, err := estimate(payload)
+	if err != nil {
+		return result, err
+	}
+	policy := g.PolicyFor(apiKey, target.Model)
+	result.Estimated = estimated
+	result.Limit = limit
+	result.Policy = policy
+	if estimated <= limit {
+		return result, nil
+	}
+
+	trimmed := payload
+	switch policy {
+	case PolicyDropOldest:
+		trimmed = dropOldestTurns(enc, format, payload, estimated-limit)
+	case PolicyTruncateToolResults:
+		g.mu.RLock()
+		maxTokens := g.toolResultMax
+		g.mu.RUnlock()
+		trimmed = truncateToolResults(enc, format, payload, estimated-limit, maxTokens)
+	}
+	remaining := estimated
+	if !bytes.Equal(trimmed, payload) {
+		if remaining, err = estimate(trimmed); err != nil {
+			return result, err
+		}
+	}
+	if remaining > limit {
+		return result, &ExceededError{Estimated: remaining, Limit: limit, Model: target.Model, Format: format}
+	}
+	result.Payload = trimmed
+	result.Trimmed = estimated - remaining
+	return result, nil
+}
+
+// Estimate approximates the prompt tokens of payload in the given request format.
+func Estimate(enc tokenizer.Codec, format string, payload []byte) (int64, error) {
+	switch format {
+	case constant.Claude:
+		return helps.CountClaudeTokens(enc, payload)
+	case constant.Gemini, constant.GeminiCLI, constant.Antigravity:
+		return helps.CountGeminiTokens(enc, payload)
+	case constant.OpenaiResponse, constant.Codex:
+		return helps.CountOpenAIResponsesTokens(enc, payload)
+	default:
+		return helps.CountOpenAIChatTokens(enc, payload)
+	}
+}
+
+// FormatForProvider returns the request format the built-in executor of provider sends
+// upstream. Unknown providers are treated as OpenAI-compatible.
+func FormatForProvider(provider string) string {
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "claude", "bedrock":
+		return constant.Claude
+	case "gemini", "vertex", "aistudio":
+		return constant.Gemini
+	case "gemini-cli":
+		return constant.GeminiCLI
+	case "antigravity":
+		return constant.Antigravity
+	case "codex":
+		return constant.Codex
+	default:
+		return constant.OpenAI
+	}
+}
+
+func requestedOutputTokens(payload []byte) int64 {
+	root := gjson.ParseBytes(payload)
+	for _, path := range []string{"max_tokens", "max_completion_tokens", "max_output_tokens", "generationConfig.maxOutputTokens", "request.generationConfig.maxOutputTokens"} {
+		if v := root.Get(path); v.Exists() && v.Int() > 0 {
+			return v.Int()
+		}
+	}
+	return 0
+}
+
+func normalizeModel(model string) string {
+	return strings.ToLower(strings.TrimSpace(model))
+}
+
+var defaultGuard = NewGuard()
+
+// Default returns the process-wide guard.
+func Default() *Guard { return defaultGuard }
+
+// Configure applies cfg to the process-wide guard.
+func Configure(cfg *config.SDKConfig) { defaultGuard.Configure(cfg) }
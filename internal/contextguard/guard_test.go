@@ -0,0 +1,135 @@
+package contextguard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newTestGuard(gc config.ContextGuardConfig) *Guard {
+	gc.Enable = true
+	g := NewGuard()
+	g.Configure(&config.SDKConfig{ContextGuard: gc})
+	return g
+}
+
+func words(n int) string {
+	return strings.Repeat("lorem ", n)
+}
+
+func TestCheckRejectsOversizedPrompt(t *testing.T) {
+	g := newTestGuard(config.ContextGuardConfig{Models: []config.ContextGuardModel{{Model: "claude-", Limit: 50}}})
+	payload := []byte(`{"model":"claude-test","messages":[{"role":"user","content":"` + words(200) + `"}]}`)
+
+	_, err := g.Check("claude", "", Target{Model: "claude-test"}, payload, false)
+	exceeded, ok := errors.AsType[*ExceededError](err)
+	if !ok {
+		t.Fatalf("expected ExceededError, got %v", err)
+	}
+	if exceeded.Limit != 50 || exceeded.Estimated <= 50 || exceeded.StatusCode() != 400 {
+		t.Fatalf("unexpected error: %+v", exceeded)
+	}
+	if !strings.Contains(err.Error(), `"type":"invalid_request_error"`) {
+		t.Fatalf("unexpected claude error body: %s", err.Error())
+	}
+}
+
+func TestCheckDropOldestKeepsSystemAndLatestTurn(t *testing.T) {
+	g := newTestGuard(config.ContextGuardConfig{
+		Policy: "drop-oldest",
+		Models: []config.ContextGuardModel{{Model: "gpt-test", Limit: 150}},
+	})
+	payload := []byte(`{"messages":[` +
+		`{"role":"system","content":"be brief"},` +
+		`{"role":"user","content":"first ` + words(200) + `"},` +
+		`{"role":"assistant","content":"reply ` + words(50) + `"},` +
+		`{"role":"user","content":"second ` + words(20) + `"},` +
+		`{"role":"assistant","content":"ok"},` +
+		`{"role":"user","content":"latest question"}]}`)
+
+	result, err := g.Check("openai", "", Target{Model: "gpt-test"}, payload, false)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Trimmed <= 0 || result.Estimated <= 150 {
+		t.Fatalf("unexpected result: estimated=%d trimmed=%d", result.Estimated, result.Trimmed)
+	}
+	roles := gjson.GetBytes(result.Payload, "messages.#.role").String()
+	if roles != `["system","user","assistant","user"]` {
+		t.Fatalf("roles after trimming = %s", roles)
+	}
+	if got := gjson.GetBytes(result.Payload, "messages.3.content").String(); got != "latest question" {
+		t.Fatalf("latest turn lost: %s", result.Payload)
+	}
+}
+
+func TestCheckTruncatesClaudeToolResults(t *testing.T) {
+	g := newTestGuard(config.ContextGuardConfig{
+		Policy:              "truncate-tool-results",
+		ToolResultMaxTokens: 10,
+		Models:              []config.ContextGuardModel{{Model: "claude-test", Limit: 100}},
+	})
+	payload := []byte(`{"messages":[` +
+		`{"role":"user","content":"list files"},` +
+		`{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"ls","input":{}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"` + words(300) + `"}]}]}`)
+
+	result, err := g.Check("claude", "", Target{Model: "claude-test"}, payload, false)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	content := gjson.GetBytes(result.Payload, "messages.2.content.0.content").String()
+	if !strings.Contains(content, "[truncated ") || len(content) >= len(words(300)) {
+		t.Fatalf("tool result not truncated: %q", content)
+	}
+	if gjson.GetBytes(result.Payload, "messages.2.content.0.tool_use_id").String() != "t1" {
+		t.Fatalf("tool_use_id lost: %s", result.Payload)
+	}
+}
+
+func TestPolicyForPrecedence(t *testing.T) {
+	g := newTestGuard(config.ContextGuardConfig{
+		Policy:  "reject",
+		Models:  []config.ContextGuardModel{{Model: "gemini-", Policy: "drop-oldest"}},
+		APIKeys: []config.ContextGuardKeyPolicy{{APIKey: "agent", Policy: "truncate-tool-results"}, {APIKey: "bad", Policy: "nope"}, {APIKey: "*", Policy: "truncate-tool-results"}},
+	})
+	if got := g.PolicyFor("agent", "gemini-2.5-pro"); got != PolicyTruncateToolResults {
+		t.Fatalf("PolicyFor(agent) = %s", got)
+	}
+	if got := g.PolicyFor("bad", "gemini-2.5-pro"); got != PolicyDropOldest {
+		t.Fatalf("PolicyFor(bad, gemini) = %s, want model policy", got)
+	}
+	if got := g.PolicyFor("other", "gemini-2.5-pro"); got != PolicyDropOldest {
+		t.Fatalf("PolicyFor(other, gemini) = %s, want model policy over the * entry", got)
+	}
+	if got := g.PolicyFor("", "gpt-5"); got != PolicyTruncateToolResults {
+		t.Fatalf("PolicyFor(gpt-5) = %s, want the * entry", got)
+	}
+	if result, err := NewGuard().Check("openai", "", Target{Model: "gpt-5"}, []byte(`{"messages":[]}`), false); err != nil || result.Limit != 0 {
+		t.Fatalf("disabled guard must pass through, got %+v %v", result, err)
+	}
+}
+
+func TestCheckEstimatesTranslatedPayloadPerTarget(t *testing.T) {
+	g := newTestGuard(config.ContextGuardConfig{Models: []config.ContextGuardModel{{Model: "small-model", Limit: 50}, {Model: "large-model", Limit: 5000}}})
+	payload := []byte(`{"messages":[{"role":"system","content":"be brief"},{"role":"user","content":"` + words(200) + `"}]}`)
+
+	if _, err := g.Check("openai", "", Target{Model: "small-model", Provider: "claude", Format: "claude"}, payload, false); err == nil {
+		t.Fatal("expected the small model to reject the prompt")
+	}
+	result, err := g.Check("openai", "", Target{Model: "large-model", Provider: "gemini", Format: "gemini"}, payload, false)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	plain, err := g.Check("openai", "", Target{Model: "large-model"}, payload, false)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if result.Limit != 5000 || result.Estimated <= 200 || result.Estimated == plain.Estimated {
+		t.Fatalf("translated estimate = %d (untranslated %d), limit %d", result.Estimated, plain.Estimated, result.Limit)
+	}
+}
@@ -0,0 +1,257 @@
+package contextguard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// layout describes where a protocol keeps its conversation turns.
+type layout struct {
+	// path is the sjson path of the turn array.
+	path string
+	// key wraps a single item when it is estimated on its own.
+	key string
+	// pinned items (system prompts) are never dropped.
+	pinned func(item gjson.Result) bool
+	// turnStart reports whether item opens a new turn, i.e. it is a user message that is not
+	// a tool result. Dropping whole turns keeps tool calls paired with their results.
+	turnStart func(item gjson.Result) bool
+}
+
+func layoutFor(format string, payload []byte) layout {
+	switch format {
+	case constant.Claude:
+		return layout{
+			path:   "messages",
+			key:    "messages",
+			pinned: func(gjson.Result) bool { return false },
+			turnStart: func(item gjson.Result) bool {
+				if item.Get("role").String() != "user" {
+					return false
+				}
+				return !item.Get(`content.#(type=="tool_result")`).Exists()
+			},
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		path := "contents"
+		if gjson.GetBytes(payload, "request").IsObject() {
+			path = "request.contents"
+		}
+		return layout{
+			path:   path,
+			key:    "contents",
+			pinned: func(gjson.Result) bool { return false },
+			turnStart: func(item gjson.Result) bool {
+				role := item.Get("role").String()
+				if role != "" && role != "user" {
+					return false
+				}
+				return len(item.Get("parts.#.functionResponse").Array()) == 0
+			},
+		}
+	case constant.OpenaiResponse:
+		return layout{
+			path:   "input",
+			key:    "input",
+			pinned: isSystemRole,
+			turnStart: func(item gjson.Result) bool {
+				itemType := item.Get("type").String()
+				return (itemType == "" || itemType == "message") && item.Get("role").String() == "user"
+			},
+		}
+	default:
+		return layout{
+			path:   "messages",
+			key:    "messages",
+			pinned: isSystemRole,
+			turnStart: func(item gjson.Result) bool {
+				return item.Get("role").String() == "user"
+			},
+		}
+	}
+}
+
+func isSystemRole(item gjson.Result) bool {
+	role := item.Get("role").String()
+	return role == "system" || role == "developer"
+}
+
+// dropOldestTurns removes whole turns from the start of the conversation until at least excess
+// tokens have been removed. System prompts and the latest turn are always kept.
+func dropOldestTurns(enc tokenizer.Codec, format string, payload []byte, excess int64) []byte {
+	l := layoutFor(format, payload)
+	items := gjson.GetBytes(payload, l.path)
+	if !items.IsArray() {
+		return payload
+	}
+	elems := items.Array()
+	last := -1
+	for i := len(elems) - 1; i >= 0; i-- {
+		if !l.pinned(elems[i]) && l.turnStart(elems[i]) {
+			last = i
+			break
+		}
+	}
+	if last <= 0 {
+		return payload
+	}
+
+	dropped := make([]bool, len(elems))
+	var removed int64
+	for removed < excess {
+		start := -1
+		for i := 0; i < last; i++ {
+			if !dropped[i] && !l.pinned(elems[i]) {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			break
+		}
+		for i := start; i < last; i++ {
+			if i > start && l.turnStart(elems[i]) {
+				break
+			}
+			if l.pinned(elems[i]) {
+				continue
+			}
+			dropped[i] = true
+			removed += itemTokens(enc, format, l.key, elems[i].Raw)
+		}
+	}
+	if removed == 0 {
+		return payload
+	}
+
+	kept := make([]string, 0, len(elems))
+	for i, elem := range elems {
+		if !dropped[i] {
+			kept = append(kept, elem.Raw)
+		}
+	}
+	out, err := sjson.SetRawBytes(payload, l.path, []byte("["+strings.Join(kept, ",")+"]"))
+	if err != nil {
+		return payload
+	}
+	return out
+}
+
+func itemTokens(enc tokenizer.Codec, format, key, raw string) int64 {
+	count, err := Estimate(enc, format, []byte(`{"`+key+`":[`+raw+`]}`))
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// toolResult locates a tool result inside a payload.
+type toolResult struct {
+	path string
+	text string
+	// object results (Gemini functionResponse.response) are replaced by {"content": text}.
+	object bool
+}
+
+func collectToolResults(format string, payload []byte) []toolResult {
+	l := layoutFor(format, payload)
+	var results []toolResult
+	gjson.GetBytes(payload, l.path).ForEach(func(index, item gjson.Result) bool {
+		base := l.path + "." + strconv.Itoa(int(index.Int()))
+		switch format {
+		case constant.Claude:
+			item.Get("content").ForEach(func(blockIndex, block gjson.Result) bool {
+				if block.Get("type").String() == "tool_result" {
+					results = append(results, toolResult{
+						path: base + ".content." + strconv.Itoa(int(blockIndex.Int())) + ".content",
+						text: contentText(block.Get("content")),
+					})
+				}
+				return true
+			})
+		case constant.Gemini, constant.GeminiCLI:
+			item.Get("parts").ForEach(func(partIndex, part gjson.Result) bool {
+				if response := part.Get("functionResponse.response"); response.Exists() {
+					results = append(results, toolResult{
+						path:   base + ".parts." + strconv.Itoa(int(partIndex.Int())) + ".functionResponse.response",
+						text:   response.Raw,
+						object: true,
+					})
+				}
+				return true
+			})
+		case constant.OpenaiResponse:
+			switch item.Get("type").String() {
+			case "function_call_output", "custom_tool_call_output":
+				results = append(results, toolResult{path: base + ".output", text: contentText(item.Get("output"))})
+			}
+		default:
+			if item.Get("role").String() == "tool" {
+				results = append(results, toolResult{path: base + ".content", text: contentText(item.Get("content"))})
+			}
+		}
+		return true
+	})
+	return results
+}
+
+// contentText flattens a string or an array of text parts.
+func contentText(content gjson.Result) string {
+	if content.Type == gjson.String {
+		return content.String()
+	}
+	if !content.IsArray() {
+		return content.Raw
+	}
+	var parts []string
+	content.ForEach(func(_, part gjson.Result) bool {
+		if text := part.Get("text"); text.Exists() {
+			parts = append(parts, text.String())
+		}
+		return true
+	})
+	return strings.Join(parts, "\n")
+}
+
+// truncateToolResults shortens tool results larger than maxTokens to their leading maxTokens
+// tokens, oldest first, until at least excess tokens have been removed.
+func truncateToolResults(enc tokenizer.Codec, format string, payload []byte, excess int64, maxTokens int) []byte {
+	if maxTokens <= 0 {
+		maxTokens = DefaultToolResultMaxTokens
+	}
+	out := payload
+	var removed int64
+	for _, result := range collectToolResults(format, payload) {
+		if removed >= excess {
+			break
+		}
+		ids, _, err := enc.Encode(result.text)
+		if err != nil || len(ids) <= maxTokens {
+			continue
+		}
+		head, err := enc.Decode(ids[:maxTokens])
+		if err != nil {
+			continue
+		}
+		cut := len(ids) - maxTokens
+		text := head + fmt.Sprintf("\n[truncated %d tokens]", cut)
+		var updated []byte
+		if result.object {
+			updated, err = sjson.SetBytes(out, result.path, map[string]string{"content": text})
+		} else {
+			updated, err = sjson.SetBytes(out, result.path, text)
+		}
+		if err != nil {
+			continue
+		}
+		out = updated
+		removed += int64(cut)
+	}
+	return out
+}
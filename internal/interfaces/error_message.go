@@ -3,7 +3,12 @@
 // such as AI service clients, API handlers, and data models.
 package interfaces
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+)
 
 // ErrorMessage encapsulates an error with an associated HTTP status code.
 // This structure is used to provide detailed error information including
@@ -18,3 +23,30 @@ type ErrorMessage struct {
 	// Addon contains additional headers to be added to the response.
 	Addon http.Header
 }
+
+// InvalidRequestBody renders a 400 invalid-request error carrying message as a JSON body in
+// the protocol of format, the client's handler type: Claude, Gemini, or OpenAI for anything
+// else. code is the error code reported to OpenAI clients.
+func InvalidRequestBody(format, message, code string) string {
+	var payload any
+	switch format {
+	case constant.Claude:
+		payload = map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": "invalid_request_error", "message": message},
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		payload = map[string]any{
+			"error": map[string]any{"code": http.StatusBadRequest, "message": message, "status": "INVALID_ARGUMENT"},
+		}
+	default:
+		payload = map[string]any{
+			"error": map[string]any{"message": message, "type": "invalid_request_error", "code": code},
+		}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return message
+	}
+	return string(data)
+}
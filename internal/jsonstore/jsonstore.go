@@ -0,0 +1,181 @@
+// Package jsonstore holds the plumbing shared by the expiring JSON document stores (the
+// Responses API store and the response cache): TTL parsing and a directory of JSON files
+// addressed by name.
+package jsonstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval controls how often expired documents are removed from a Dir.
+const sweepInterval = 10 * time.Minute
+
+// Expirer is implemented by documents that carry an expiry.
+type Expirer interface {
+	Expired(now time.Time) bool
+}
+
+// ParseTTL converts a duration string into a TTL, falling back to fallback when raw is
+// empty, invalid or not positive.
+func ParseTTL(raw string, fallback time.Duration) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return fallback
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil || ttl <= 0 {
+		return fallback
+	}
+	return ttl
+}
+
+// Dir persists JSON documents as <name>.json files inside a directory. Callers are
+// responsible for choosing names that cannot escape the directory. Documents carrying an
+// "expires_at" field are removed lazily when read and periodically once the first document
+// has been saved.
+type Dir struct {
+	mu        sync.Mutex
+	path      string
+	label     string
+	noun      string
+	sweepOnce sync.Once
+}
+
+// OpenDir creates the directory when missing. label prefixes error messages (for example
+// "response store") and noun names one document in them (for example "record").
+func OpenDir(path, label, noun string) (*Dir, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("%s: directory is required", label)
+	}
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return nil, fmt.Errorf("%s: create directory: %w", label, err)
+	}
+	return &Dir{path: path, label: label, noun: noun}, nil
+}
+
+// Load decodes the document name into v. It reports false when the document is missing or
+// expired; expired documents are removed.
+func (d *Dir) Load(name string, v Expirer) (bool, error) {
+	path := d.file(name)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: read %s: %w", d.label, d.noun, err)
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("%s: decode %s: %w", d.label, d.noun, err)
+	}
+	if v.Expired(time.Now()) {
+		_ = os.Remove(path)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Save atomically writes v as the document name.
+func (d *Dir) Save(name string, v any) error {
+	d.sweepOnce.Do(d.startSweep)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("%s: encode %s: %w", d.label, d.noun, err)
+	}
+	path := d.file(name)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("%s: write temp %s: %w", d.label, d.noun, err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("%s: rename %s: %w", d.label, d.noun, err)
+	}
+	return nil
+}
+
+// Remove deletes the document name. It reports false when the document did not exist.
+func (d *Dir) Remove(name string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := os.Remove(d.file(name)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: delete %s: %w", d.label, d.noun, err)
+	}
+	return true, nil
+}
+
+// Purge removes every document and returns how many were removed.
+func (d *Dir) Purge() (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	matches, err := filepath.Glob(filepath.Join(d.path, "*.json"))
+	if err != nil {
+		return 0, fmt.Errorf("%s: list %ss: %w", d.label, d.noun, err)
+	}
+	removed := 0
+	for _, path := range matches {
+		if errRemove := os.Remove(path); errRemove == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// Sweep removes every document whose expires_at is at or before now and returns how many
+// were removed.
+func (d *Dir) Sweep(now time.Time) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	matches, err := filepath.Glob(filepath.Join(d.path, "*.json"))
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, path := range matches {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			continue
+		}
+		var doc struct {
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		if json.Unmarshal(data, &doc) != nil || doc.ExpiresAt.IsZero() || now.Before(doc.ExpiresAt) {
+			continue
+		}
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// startSweep launches a background goroutine that removes expired documents, including
+// those left by earlier runs, and repeats every sweepInterval.
+func (d *Dir) startSweep() {
+	go func() {
+		d.Sweep(time.Now())
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			d.Sweep(time.Now())
+		}
+	}()
+}
+
+func (d *Dir) file(name string) string {
+	return filepath.Join(d.path, name+".json")
+}
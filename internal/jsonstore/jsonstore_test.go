@@ -0,0 +1,70 @@
+package jsonstore
+
+import (
+	"testing"
+	"time"
+)
+
+type doc struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (d *doc) Expired(now time.Time) bool {
+	return !d.ExpiresAt.IsZero() && !now.Before(d.ExpiresAt)
+}
+
+func TestParseTTL(t *testing.T) {
+	for raw, want := range map[string]time.Duration{"": time.Hour, "bogus": time.Hour, "-1m": time.Hour, "90s": 90 * time.Second} {
+		if got := ParseTTL(raw, time.Hour); got != want {
+			t.Errorf("ParseTTL(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestDirRoundTripAndExpiry(t *testing.T) {
+	dir, err := OpenDir(t.TempDir(), "test store", "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dir.Save("live", &doc{Value: "a", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if err = dir.Save("stale", &doc{Value: "b", ExpiresAt: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	var got doc
+	if found, errLoad := dir.Load("live", &got); errLoad != nil || !found || got.Value != "a" {
+		t.Fatalf("Load(live) = %v, %v, %+v", found, errLoad, got)
+	}
+	if found, errLoad := dir.Load("stale", &got); errLoad != nil || found {
+		t.Fatalf("Load(stale) = %v, %v", found, errLoad)
+	}
+	if removed, _ := dir.Remove("stale"); removed {
+		t.Fatal("expired document was not removed on load")
+	}
+	if n, errPurge := dir.Purge(); errPurge != nil || n != 1 {
+		t.Fatalf("Purge() = %d, %v", n, errPurge)
+	}
+}
+
+func TestDirSweepRemovesExpired(t *testing.T) {
+	dir, err := OpenDir(t.TempDir(), "test store", "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keep the background sweeper out of the way so the count below is deterministic.
+	dir.sweepOnce.Do(func() {})
+	now := time.Now()
+	_ = dir.Save("live", &doc{ExpiresAt: now.Add(time.Hour)})
+	_ = dir.Save("stale", &doc{ExpiresAt: now.Add(-time.Second)})
+	_ = dir.Save("forever", &doc{})
+	if n := dir.Sweep(now); n != 1 {
+		t.Fatalf("Sweep() = %d, want 1", n)
+	}
+	for name, want := range map[string]bool{"live": true, "stale": false, "forever": true} {
+		if removed, _ := dir.Remove(name); removed != want {
+			t.Errorf("%s present = %v, want %v", name, removed, want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package ledger
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// WriteCSV renders rows as CSV with one column per grouping dimension followed by the counters.
+func WriteCSV(w io.Writer, groups []string, rows []Row) error {
+	writer := csv.NewWriter(w)
+	header := append(append([]string(nil), groups...),
+		"requests", "failures", "input_tokens", "output_tokens", "reasoning_tokens", "cached_tokens", "total_tokens", "latency_ms_total")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, 0, len(header))
+		for _, group := range groups {
+			record = append(record, row.dimension(group))
+		}
+		record = append(record,
+			strconv.FormatInt(row.Requests, 10),
+			strconv.FormatInt(row.Failures, 10),
+			strconv.FormatInt(row.InputTokens, 10),
+			strconv.FormatInt(row.OutputTokens, 10),
+			strconv.FormatInt(row.ReasoningTokens, 10),
+			strconv.FormatInt(row.CachedTokens, 10),
+			strconv.FormatInt(row.TotalTokens, 10),
+			strconv.FormatInt(row.LatencyMsTotal, 10),
+		)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r Row) dimension(group string) string {
+	switch group {
+	case GroupAPIKey:
+		return r.APIKey
+	case GroupAuthIndex:
+		return r.AuthIndex
+	case GroupProvider:
+		return r.Provider
+	case GroupModel:
+		return r.Model
+	case GroupDay:
+		return r.Day
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,142 @@
+package ledger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	filePrefix = "usage-"
+	fileSuffix = ".jsonl"
+)
+
+// FileStore appends entries to one JSON-lines file per UTC day inside a directory.
+// Queries only open the files whose day overlaps the requested range.
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileStore creates a file-backed ledger rooted at dir.
+func NewFileStore(dir string) (*FileStore, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("usage ledger: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("usage ledger: create directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Append implements Store.
+func (s *FileStore) Append(_ context.Context, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("usage ledger: encode entry: %w", err)
+	}
+	line = append(line, '\n')
+	path := filepath.Join(s.dir, filePrefix+entry.Timestamp.UTC().Format(dayLayout)+fileSuffix)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("usage ledger: open segment: %w", err)
+	}
+	if _, err = file.Write(line); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("usage ledger: write entry: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("usage ledger: close segment: %w", err)
+	}
+	return nil
+}
+
+// Query implements Store.
+func (s *FileStore) Query(ctx context.Context, q Query) ([]Row, error) {
+	days, err := s.segments(q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	agg := NewAggregator(q)
+	for _, path := range days {
+		if errCtx := ctx.Err(); errCtx != nil {
+			return nil, errCtx
+		}
+		if err = s.scan(path, q, agg); err != nil {
+			return nil, err
+		}
+	}
+	return agg.Rows(), nil
+}
+
+// segments lists the daily files overlapping [from, to) in chronological order.
+func (s *FileStore) segments(from, to time.Time) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("usage ledger: list segments: %w", err)
+	}
+	firstDay := from.UTC().Format(dayLayout)
+	lastDay := to.UTC().Add(-time.Nanosecond).Format(dayLayout)
+	out := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+		if day < firstDay || day > lastDay {
+			continue
+		}
+		out = append(out, filepath.Join(s.dir, name))
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func (s *FileStore) scan(path string, q Query, agg *Aggregator) error {
+	// Hold the lock so a concurrent append never exposes a partially written line.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("usage ledger: open segment: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if errDecode := json.Unmarshal(line, &entry); errDecode != nil {
+			continue
+		}
+		if q.Matches(entry) {
+			agg.Add(entry)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("usage ledger: read segment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+// Package ledger keeps a durable, append-only record of every usage record emitted by the
+// runtime so that usage can be queried over arbitrary time ranges after restarts. Entries
+// are written by a coreusage.Plugin into a Store (daily JSON-lines files or Postgres) and
+// aggregated on demand by API key, auth index, provider, model and day.
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// Grouping dimensions accepted by Query.GroupBy.
+const (
+	GroupAPIKey    = "api_key"
+	GroupAuthIndex = "auth_index"
+	GroupProvider  = "provider"
+	GroupModel     = "model"
+	GroupDay       = "day"
+)
+
+// dayLayout is the format of the day dimension. Days are always computed in UTC.
+const dayLayout = "2006-01-02"
+
+// Entry is one usage record as persisted in the ledger.
+type Entry struct {
+	Timestamp       time.Time `json:"timestamp"`
+	APIKey          string    `json:"api_key"`
+	AuthID          string    `json:"auth_id,omitempty"`
+	AuthIndex       string    `json:"auth_index"`
+	Provider        string    `json:"provider"`
+	Model           string    `json:"model"`
+	Source          string    `json:"source,omitempty"`
+	LatencyMs       int64     `json:"latency_ms"`
+	Failed          bool      `json:"failed"`
+	InputTokens     int64     `json:"input_tokens"`
+	OutputTokens    int64     `json:"output_tokens"`
+	ReasoningTokens int64     `json:"reasoning_tokens"`
+	CachedTokens    int64     `json:"cached_tokens"`
+	TotalTokens     int64     `json:"total_tokens"`
+}
+
+// Query selects ledger entries in the half-open range [From, To) and groups them.
+// Empty filters match everything.
+type Query struct {
+	From      time.Time
+	To        time.Time
+	GroupBy   []string
+	APIKey    string
+	AuthIndex string
+	Provider  string
+	Model     string
+}
+
+// Row is one aggregated group. Dimension fields not listed in GroupBy are empty.
+type Row struct {
+	APIKey          string `json:"api_key,omitempty"`
+	AuthIndex       string `json:"auth_index,omitempty"`
+	Provider        string `json:"provider,omitempty"`
+	Model           string `json:"model,omitempty"`
+	Day             string `json:"day,omitempty"`
+	Requests        int64  `json:"requests"`
+	Failures        int64  `json:"failures"`
+	InputTokens     int64  `json:"input_tokens"`
+	OutputTokens    int64  `json:"output_tokens"`
+	ReasoningTokens int64  `json:"reasoning_tokens"`
+	CachedTokens    int64  `json:"cached_tokens"`
+	TotalTokens     int64  `json:"total_tokens"`
+	LatencyMsTotal  int64  `json:"latency_ms_total"`
+}
+
+// Store persists ledger entries and answers aggregate queries.
+type Store interface {
+	// Append writes entry to the ledger.
+	Append(ctx context.Context, entry Entry) error
+	// Query aggregates the entries matching q. q has been validated by NormalizeQuery.
+	Query(ctx context.Context, q Query) ([]Row, error)
+}
+
+// NormalizeQuery validates the grouping dimensions, lower-cases them and removes duplicates.
+// A zero To defaults to now and a zero From defaults to the start of To's UTC month.
+func NormalizeQuery(q Query) (Query, error) {
+	if q.To.IsZero() {
+		q.To = time.Now().UTC()
+	}
+	if q.From.IsZero() {
+		to := q.To.UTC()
+		q.From = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	if !q.From.Before(q.To) {
+		return q, fmt.Errorf("from must be before to")
+	}
+	// Entries carry hashed client keys; a raw key filter is hashed to match them.
+	q.APIKey = util.HashAPIKey(q.APIKey)
+	seen := make(map[string]struct{}, len(q.GroupBy))
+	groups := make([]string, 0, len(q.GroupBy))
+	for _, group := range q.GroupBy {
+		group = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(group, "-", "_")))
+		if group == "" {
+			continue
+		}
+		switch group {
+		case GroupAPIKey, GroupAuthIndex, GroupProvider, GroupModel, GroupDay:
+		default:
+			return q, fmt.Errorf("unsupported group-by dimension %q", group)
+		}
+		if _, ok := seen[group]; ok {
+			continue
+		}
+		seen[group] = struct{}{}
+		groups = append(groups, group)
+	}
+	q.GroupBy = groups
+	return q, nil
+}
+
+// Matches reports whether entry falls inside the query range and filters.
+func (q Query) Matches(entry Entry) bool {
+	if entry.Timestamp.Before(q.From) || !entry.Timestamp.Before(q.To) {
+		return false
+	}
+	if q.APIKey != "" && entry.APIKey != q.APIKey {
+		return false
+	}
+	if q.AuthIndex != "" && entry.AuthIndex != q.AuthIndex {
+		return false
+	}
+	if q.Provider != "" && !strings.EqualFold(entry.Provider, q.Provider) {
+		return false
+	}
+	if q.Model != "" && !strings.EqualFold(entry.Model, q.Model) {
+		return false
+	}
+	return true
+}
+
+// Aggregator accumulates entries into rows keyed by the query's grouping dimensions.
+type Aggregator struct {
+	groups []string
+	rows   map[Row]*Row
+}
+
+// NewAggregator prepares an aggregator for the grouping dimensions of q.
+func NewAggregator(q Query) *Aggregator {
+	return &Aggregator{groups: q.GroupBy, rows: make(map[Row]*Row)}
+}
+
+// Add folds entry into its group.
+func (a *Aggregator) Add(entry Entry) {
+	var key Row
+	for _, group := range a.groups {
+		switch group {
+		case GroupAPIKey:
+			key.APIKey = entry.APIKey
+		case GroupAuthIndex:
+			key.AuthIndex = entry.AuthIndex
+		case GroupProvider:
+			key.Provider = entry.Provider
+		case GroupModel:
+			key.Model = entry.Model
+		case GroupDay:
+			key.Day = entry.Timestamp.UTC().Format(dayLayout)
+		}
+	}
+	row, ok := a.rows[key]
+	if !ok {
+		copied := key
+		row = &copied
+		a.rows[key] = row
+	}
+	row.Requests++
+	if entry.Failed {
+		row.Failures++
+	}
+	row.InputTokens += entry.InputTokens
+	row.OutputTokens += entry.OutputTokens
+	row.ReasoningTokens += entry.ReasoningTokens
+	row.CachedTokens += entry.CachedTokens
+	row.TotalTokens += entry.TotalTokens
+	row.LatencyMsTotal += entry.LatencyMs
+}
+
+// Rows returns the aggregated rows in a stable order.
+func (a *Aggregator) Rows() []Row {
+	out := make([]Row, 0, len(a.rows))
+	for _, row := range a.rows {
+		out = append(out, *row)
+	}
+	SortRows(out)
+	return out
+}
+
+// SortRows orders rows by day, API key, auth index, provider and model.
+func SortRows(rows []Row) {
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.APIKey != b.APIKey {
+			return a.APIKey < b.APIKey
+		}
+		if a.AuthIndex != b.AuthIndex {
+			return a.AuthIndex < b.AuthIndex
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Model < b.Model
+	})
+}
+
+// Totals sums every row into a single row without dimensions.
+func Totals(rows []Row) Row {
+	var total Row
+	for _, row := range rows {
+		total.Requests += row.Requests
+		total.Failures += row.Failures
+		total.InputTokens += row.InputTokens
+		total.OutputTokens += row.OutputTokens
+		total.ReasoningTokens += row.ReasoningTokens
+		total.CachedTokens += row.CachedTokens
+		total.TotalTokens += row.TotalTokens
+		total.LatencyMsTotal += row.LatencyMsTotal
+	}
+	return total
+}
+
+// EntryFromRecord converts a usage record into a ledger entry. The client key is stored as
+// util.HashAPIKey so the ledger never holds client credentials.
+func EntryFromRecord(record coreusage.Record) Entry {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	detail := record.Detail
+	total := detail.TotalTokens
+	if total == 0 {
+		total = detail.InputTokens + detail.OutputTokens + detail.ReasoningTokens
+	}
+	model := record.Model
+	if model == "" {
+		model = "unknown"
+	}
+	return Entry{
+		Timestamp:       timestamp.UTC(),
+		APIKey:          util.HashAPIKey(record.APIKey),
+		AuthID:          record.AuthID,
+		AuthIndex:       record.AuthIndex,
+		Provider:        record.Provider,
+		Model:           model,
+		Source:          record.Source,
+		LatencyMs:       record.Latency.Milliseconds(),
+		Failed:          record.Failed,
+		InputTokens:     detail.InputTokens,
+		OutputTokens:    detail.OutputTokens,
+		ReasoningTokens: detail.ReasoningTokens,
+		CachedTokens:    detail.CachedTokens,
+		TotalTokens:     total,
+	}
+}
+
+// Recorder writes usage records into the configured store. It implements coreusage.Plugin.
+type Recorder struct {
+	mu    sync.RWMutex
+	store Store
+}
+
+// SetStore attaches the ledger store. A nil store disables recording.
+func (r *Recorder) SetStore(store Store) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.store = store
+	r.mu.Unlock()
+}
+
+// Store returns the attached store, or nil when the ledger is disabled.
+func (r *Recorder) Store() Store {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.store
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (r *Recorder) HandleUsage(ctx context.Context, record coreusage.Record) {
+	store := r.Store()
+	if store == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := store.Append(context.WithoutCancel(ctx), EntryFromRecord(record)); err != nil {
+		log.Warnf("usage ledger: append entry: %v", err)
+	}
+}
+
+var defaultRecorder = &Recorder{}
+
+func init() {
+	coreusage.RegisterPlugin(defaultRecorder)
+}
+
+// Default returns the process-wide recorder fed by the usage pipeline.
+func Default() *Recorder { return defaultRecorder }
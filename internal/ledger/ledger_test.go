@@ -0,0 +1,112 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestFileStoreQueryGroupsByKeyModelAndDay(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	records := []coreusage.Record{
+		{APIKey: "team-a", Provider: "claude", Model: "claude-sonnet-4", AuthIndex: "1", RequestedAt: day1, Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 5}},
+		{APIKey: "team-a", Provider: "claude", Model: "claude-sonnet-4", AuthIndex: "2", RequestedAt: day1.Add(time.Hour), Failed: true, Detail: coreusage.Detail{InputTokens: 3}},
+		{APIKey: "team-b", Provider: "gemini", Model: "gemini-2.5-pro", AuthIndex: "3", RequestedAt: day2, Detail: coreusage.Detail{InputTokens: 7, OutputTokens: 1, TotalTokens: 8}},
+		{APIKey: "team-a", Provider: "claude", Model: "claude-sonnet-4", AuthIndex: "1", RequestedAt: day2.Add(48 * time.Hour), Detail: coreusage.Detail{InputTokens: 100}},
+	}
+	for _, record := range records {
+		if err = store.Append(ctx, EntryFromRecord(record)); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	q, err := NormalizeQuery(Query{From: day1.Add(-time.Hour), To: day2.Add(time.Hour), GroupBy: []string{"api-key", "day"}})
+	if err != nil {
+		t.Fatalf("NormalizeQuery() error = %v", err)
+	}
+	rows, err := store.Query(ctx, q)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", rows)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Day < rows[j].Day })
+	if rows[0].APIKey != util.HashAPIKey("team-a") || rows[0].Day != "2026-03-01" || rows[0].Requests != 2 || rows[0].Failures != 1 || rows[0].TotalTokens != 18 {
+		t.Fatalf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].APIKey != util.HashAPIKey("team-b") || rows[1].Day != "2026-03-02" || rows[1].TotalTokens != 8 {
+		t.Fatalf("unexpected second row: %+v", rows[1])
+	}
+	if rows[0].Model != "" {
+		t.Fatalf("ungrouped dimensions must stay empty: %+v", rows[0])
+	}
+
+	keyQuery, err := NormalizeQuery(Query{From: q.From, To: q.To, APIKey: "team-b"})
+	if err != nil {
+		t.Fatalf("NormalizeQuery() error = %v", err)
+	}
+	rows, err = store.Query(ctx, keyQuery)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Requests != 1 {
+		t.Fatalf("unexpected rows for raw key filter: %+v", rows)
+	}
+
+	q.Provider = "CLAUDE"
+	q.GroupBy = []string{GroupAuthIndex}
+	rows, err = store.Query(ctx, q)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(rows) != 2 || rows[0].AuthIndex != "1" || rows[1].AuthIndex != "2" {
+		t.Fatalf("unexpected filtered rows: %+v", rows)
+	}
+}
+
+func TestNormalizeQueryRejectsUnknownGroup(t *testing.T) {
+	if _, err := NormalizeQuery(Query{GroupBy: []string{"team"}}); err == nil {
+		t.Fatalf("expected error for unknown dimension")
+	}
+	q, err := NormalizeQuery(Query{GroupBy: []string{"model", "MODEL", " day "}})
+	if err != nil {
+		t.Fatalf("NormalizeQuery() error = %v", err)
+	}
+	if strings.Join(q.GroupBy, ",") != "model,day" {
+		t.Fatalf("GroupBy = %v", q.GroupBy)
+	}
+	if q.From.Day() != 1 || !q.From.Before(q.To) {
+		t.Fatalf("expected default range to start at the beginning of the month, got %v..%v", q.From, q.To)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	rows := []Row{{APIKey: "team-a", Model: "gpt-5", Requests: 2, TotalTokens: 30}}
+	if err := WriteCSV(&buf, []string{GroupAPIKey, GroupModel}, rows); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "api_key,model,requests,") {
+		t.Fatalf("unexpected header: %s", lines[0])
+	}
+	if lines[1] != "team-a,gpt-5,2,0,0,0,0,0,30,0" {
+		t.Fatalf("unexpected row: %s", lines[1])
+	}
+}
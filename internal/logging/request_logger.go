@@ -24,6 +24,7 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redact"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 )
 
@@ -231,6 +232,13 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 		return nil
 	}
 
+	// Mask secrets and PII before anything touches the disk.
+	body = redact.MaskLog(body)
+	websocketTimeline = redact.MaskLog(websocketTimeline)
+	apiRequest = redact.MaskLog(apiRequest)
+	apiResponse = redact.MaskLog(apiResponse)
+	apiWebsocketTimeline = redact.MaskLog(apiWebsocketTimeline)
+
 	// Ensure logs directory exists
 	if errEnsure := l.ensureLogsDir(); errEnsure != nil {
 		return fmt.Errorf("failed to create logs directory: %w", errEnsure)
@@ -260,6 +268,7 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 		// If decompression fails, continue with original response and annotate the log output.
 		responseToWrite = response
 	}
+	responseToWrite = redact.MaskLog(responseToWrite)
 
 	logFile, errOpen := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if errOpen != nil {
@@ -337,7 +346,7 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 		requestHeaders[key] = headerValues
 	}
 
-	requestBodyPath, errTemp := l.writeRequestBodyTempFile(body)
+	requestBodyPath, errTemp := l.writeRequestBodyTempFile(redact.MaskLog(body))
 	if errTemp != nil {
 		return nil, fmt.Errorf("failed to create request body temp file: %w", errTemp)
 	}
@@ -1270,7 +1279,7 @@ func (w *FileStreamingLogWriter) WriteAPIRequest(apiRequest []byte) error {
 	if len(apiRequest) == 0 {
 		return nil
 	}
-	w.apiRequest = bytes.Clone(apiRequest)
+	w.apiRequest = bytes.Clone(redact.MaskLog(apiRequest))
 	return nil
 }
 
@@ -1285,7 +1294,7 @@ func (w *FileStreamingLogWriter) WriteAPIResponse(apiResponse []byte) error {
 	if len(apiResponse) == 0 {
 		return nil
 	}
-	w.apiResponse = bytes.Clone(apiResponse)
+	w.apiResponse = bytes.Clone(redact.MaskLog(apiResponse))
 	return nil
 }
 
@@ -1300,7 +1309,7 @@ func (w *FileStreamingLogWriter) WriteAPIWebsocketTimeline(apiWebsocketTimeline
 	if len(apiWebsocketTimeline) == 0 {
 		return nil
 	}
-	w.apiWebsocketTimeline = bytes.Clone(apiWebsocketTimeline)
+	w.apiWebsocketTimeline = bytes.Clone(redact.MaskLog(apiWebsocketTimeline))
 	return nil
 }
 
@@ -1407,17 +1416,14 @@ func (w *FileStreamingLogWriter) writeFinalLog(logFile *os.File) error {
 		return errWrite
 	}
 
-	responseBodyFile, errOpen := os.Open(w.responseBodyPath)
-	if errOpen != nil {
-		return errOpen
+	// Chunks are spooled unmasked and the assembled body is masked here, so a secret split
+	// across chunk boundaries is still caught.
+	responseBody, errRead := os.ReadFile(w.responseBodyPath)
+	if errRead != nil {
+		return errRead
 	}
-	defer func() {
-		if errClose := responseBodyFile.Close(); errClose != nil {
-			log.WithError(errClose).Warn("failed to close response body temp file")
-		}
-	}()
 
-	return writeResponseSection(logFile, w.responseStatus, w.statusWritten, w.responseHeaders, responseBodyFile, nil, false)
+	return writeResponseSection(logFile, w.responseStatus, w.statusWritten, w.responseHeaders, bytes.NewReader(redact.MaskLog(responseBody)), nil, false)
 }
 
 func (w *FileStreamingLogWriter) cleanupTempFiles() {
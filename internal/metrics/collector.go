@@ -0,0 +1,146 @@
+// Package metrics exposes proxy runtime state in the Prometheus text exposition format.
+// Request, latency and token series are accumulated from usage records; credential,
+// scheduler and refresh series are read from the core auth manager at scrape time.
+package metrics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency histogram.
+var latencyBuckets = []float64{0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// seriesKey identifies one provider/model/credential combination.
+type seriesKey struct {
+	provider  string
+	model     string
+	authIndex string
+}
+
+type series struct {
+	success         int64
+	failure         int64
+	latencyCounts   []int64
+	latencySum      float64
+	latencyCount    int64
+	inputTokens     int64
+	outputTokens    int64
+	reasoningTokens int64
+	cachedTokens    int64
+}
+
+// Collector aggregates usage records into request, latency and token series.
+// It implements coreusage.Plugin.
+type Collector struct {
+	mu     sync.Mutex
+	series map[seriesKey]*series
+}
+
+// NewCollector constructs an empty collector.
+func NewCollector() *Collector {
+	return &Collector{series: make(map[seriesKey]*series)}
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (c *Collector) HandleUsage(_ context.Context, record coreusage.Record) {
+	if c == nil {
+		return
+	}
+	key := seriesKey{provider: record.Provider, model: record.Model, authIndex: record.AuthIndex}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &series{latencyCounts: make([]int64, len(latencyBuckets))}
+		c.series[key] = s
+	}
+	if record.Failed {
+		s.failure++
+	} else {
+		s.success++
+	}
+	if record.Latency > 0 {
+		seconds := record.Latency.Seconds()
+		for i, bound := range latencyBuckets {
+			if seconds <= bound {
+				s.latencyCounts[i]++
+			}
+		}
+		s.latencySum += seconds
+		s.latencyCount++
+	}
+	s.inputTokens += record.Detail.InputTokens
+	s.outputTokens += record.Detail.OutputTokens
+	s.reasoningTokens += record.Detail.ReasoningTokens
+	s.cachedTokens += record.Detail.CachedTokens
+}
+
+type seriesSnapshot struct {
+	key seriesKey
+	series
+}
+
+func (c *Collector) snapshot() []seriesSnapshot {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	out := make([]seriesSnapshot, 0, len(c.series))
+	for key, s := range c.series {
+		copied := *s
+		copied.latencyCounts = append([]int64(nil), s.latencyCounts...)
+		out = append(out, seriesSnapshot{key: key, series: copied})
+	}
+	c.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].key, out[j].key
+		if a.provider != b.provider {
+			return a.provider < b.provider
+		}
+		if a.model != b.model {
+			return a.model < b.model
+		}
+		return a.authIndex < b.authIndex
+	})
+	return out
+}
+
+var (
+	defaultCollector = NewCollector()
+	registerOnce     sync.Once
+	collecting       atomic.Bool
+)
+
+// SetEnabled starts or stops feeding the process-wide collector from the usage pipeline.
+// The collector is only registered as a usage plugin the first time metrics are enabled, so
+// a server with metrics disabled never aggregates records. Disabling later drops the
+// accumulated series.
+func SetEnabled(enabled bool) {
+	if enabled {
+		registerOnce.Do(func() { coreusage.RegisterPlugin(collectorPlugin{}) })
+		collecting.Store(true)
+		return
+	}
+	if collecting.Swap(false) {
+		defaultCollector.mu.Lock()
+		defaultCollector.series = make(map[seriesKey]*series)
+		defaultCollector.mu.Unlock()
+	}
+}
+
+// collectorPlugin forwards usage records to the process-wide collector while metrics are enabled.
+type collectorPlugin struct{}
+
+func (collectorPlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if collecting.Load() {
+		defaultCollector.HandleUsage(ctx, record)
+	}
+}
+
+// DefaultCollector returns the process-wide collector fed by the usage pipeline.
+func DefaultCollector() *Collector { return defaultCollector }
@@ -0,0 +1,196 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// ContentType is the Prometheus text exposition format content type.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const namespace = "cliproxy"
+
+// Handler returns a Gin handler serving the collector and auth manager state.
+// The manager may be nil, in which case only usage-derived series are written.
+func Handler(collector *Collector, manager func() *coreauth.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", ContentType)
+		c.Status(http.StatusOK)
+		var m *coreauth.Manager
+		if manager != nil {
+			m = manager()
+		}
+		_ = Write(c.Writer, collector, m)
+	}
+}
+
+// Write renders every metric family to w.
+func Write(w io.Writer, collector *Collector, manager *coreauth.Manager) error {
+	bw := bufio.NewWriter(w)
+	e := &encoder{w: bw}
+	e.writeUsage(collector.snapshot())
+	if manager != nil {
+		e.writeAuths(manager.List())
+		e.writeScheduler(manager.SchedulerStats())
+		e.writeRefresh(manager.RefreshStats())
+	}
+	return bw.Flush()
+}
+
+type encoder struct {
+	w *bufio.Writer
+}
+
+func (e *encoder) header(name, help, kind string) {
+	e.w.WriteString("# HELP " + name + " " + help + "\n")
+	e.w.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+func (e *encoder) sample(name string, labels []string, value float64) {
+	e.w.WriteString(name)
+	if len(labels) > 0 {
+		e.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+			e.w.WriteString(labels[i])
+			e.w.WriteString(`="`)
+			e.w.WriteString(escapeLabel(labels[i+1]))
+			e.w.WriteByte('"')
+		}
+		e.w.WriteByte('}')
+	}
+	e.w.WriteByte(' ')
+	e.w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	e.w.WriteByte('\n')
+}
+
+func (e *encoder) writeUsage(snapshot []seriesSnapshot) {
+	requests := namespace + "_requests_total"
+	e.header(requests, "Upstream requests by provider, model, credential and result.", "counter")
+	for _, s := range snapshot {
+		labels := usageLabels(s.key)
+		e.sample(requests, append(labels, "result", "success"), float64(s.success))
+		e.sample(requests, append(labels, "result", "failure"), float64(s.failure))
+	}
+
+	latency := namespace + "_request_duration_seconds"
+	e.header(latency, "Upstream request latency in seconds.", "histogram")
+	for _, s := range snapshot {
+		labels := usageLabels(s.key)
+		for i, bound := range latencyBuckets {
+			e.sample(latency+"_bucket", append(labels, "le", strconv.FormatFloat(bound, 'g', -1, 64)), float64(s.latencyCounts[i]))
+		}
+		e.sample(latency+"_bucket", append(labels, "le", "+Inf"), float64(s.latencyCount))
+		e.sample(latency+"_sum", labels, s.latencySum)
+		e.sample(latency+"_count", labels, float64(s.latencyCount))
+	}
+
+	tokens := namespace + "_tokens_total"
+	e.header(tokens, "Tokens consumed by provider, model, credential and token type.", "counter")
+	for _, s := range snapshot {
+		labels := usageLabels(s.key)
+		e.sample(tokens, append(labels, "type", "input"), float64(s.inputTokens))
+		e.sample(tokens, append(labels, "type", "output"), float64(s.outputTokens))
+		e.sample(tokens, append(labels, "type", "reasoning"), float64(s.reasoningTokens))
+		e.sample(tokens, append(labels, "type", "cached"), float64(s.cachedTokens))
+	}
+}
+
+func (e *encoder) writeAuths(auths []*coreauth.Auth) {
+	type authRow struct {
+		labels  []string
+		auth    *coreauth.Auth
+		blocked int
+	}
+	rows := make([]authRow, 0, len(auths))
+	for _, auth := range auths {
+		if auth == nil {
+			continue
+		}
+		blocked := 0
+		for _, state := range auth.ModelStates {
+			if state != nil && (state.Unavailable || state.Quota.Exceeded) {
+				blocked++
+			}
+		}
+		rows = append(rows, authRow{
+			labels:  []string{"provider", auth.Provider, "auth_index", auth.EnsureIndex(), "status", string(auth.Status)},
+			auth:    auth,
+			blocked: blocked,
+		})
+	}
+
+	unavailable := namespace + "_auth_unavailable"
+	e.header(unavailable, "Whether the credential is temporarily unavailable (1) or not (0).", "gauge")
+	for _, row := range rows {
+		e.sample(unavailable, row.labels, boolValue(row.auth.Unavailable))
+	}
+	quotaExceeded := namespace + "_auth_quota_exceeded"
+	e.header(quotaExceeded, "Whether the credential has exceeded its upstream quota (1) or not (0).", "gauge")
+	for _, row := range rows {
+		e.sample(quotaExceeded, row.labels, boolValue(row.auth.Quota.Exceeded))
+	}
+	backoff := namespace + "_auth_backoff_level"
+	e.header(backoff, "Progressive cooldown exponent of the credential.", "gauge")
+	for _, row := range rows {
+		e.sample(backoff, row.labels, float64(row.auth.Quota.BackoffLevel))
+	}
+	disabled := namespace + "_auth_disabled"
+	e.header(disabled, "Whether the credential is disabled (1) or not (0).", "gauge")
+	for _, row := range rows {
+		e.sample(disabled, row.labels, boolValue(row.auth.Disabled))
+	}
+	blockedModels := namespace + "_auth_blocked_models"
+	e.header(blockedModels, "Number of models on the credential that are unavailable or over quota.", "gauge")
+	for _, row := range rows {
+		e.sample(blockedModels, row.labels, float64(row.blocked))
+	}
+}
+
+func (e *encoder) writeScheduler(stats []coreauth.SchedulerStat) {
+	name := namespace + "_scheduler_credentials"
+	e.header(name, "Credentials tracked by the scheduler per provider, model and state.", "gauge")
+	for _, stat := range stats {
+		labels := []string{"provider", stat.Provider, "model", stat.Model}
+		e.sample(name, append(labels, "state", "ready"), float64(stat.Ready))
+		e.sample(name, append(labels, "state", "cooldown"), float64(stat.Cooldown))
+		e.sample(name, append(labels, "state", "blocked"), float64(stat.Blocked))
+		e.sample(name, append(labels, "state", "disabled"), float64(stat.Disabled))
+	}
+}
+
+func (e *encoder) writeRefresh(stats []coreauth.RefreshStat) {
+	name := namespace + "_auth_refresh_total"
+	e.header(name, "Credential refresh attempts by provider and result.", "counter")
+	for _, stat := range stats {
+		e.sample(name, []string{"provider", stat.Provider, "result", "success"}, float64(stat.Success))
+		e.sample(name, []string{"provider", stat.Provider, "result", "failure"}, float64(stat.Failure))
+	}
+}
+
+// usageLabels returns a fresh label slice so callers can append without aliasing.
+func usageLabels(key seriesKey) []string {
+	labels := make([]string, 0, 8)
+	return append(labels, "provider", key.provider, "model", key.model, "auth_index", key.authIndex)
+}
+
+func boolValue(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
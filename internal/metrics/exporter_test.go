@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestWriteUsageSeries(t *testing.T) {
+	collector := NewCollector()
+	collector.HandleUsage(context.Background(), coreusage.Record{
+		Provider:  "gemini",
+		Model:     "gemini-2.5-pro",
+		AuthIndex: "abc",
+		Latency:   750 * time.Millisecond,
+		Detail:    coreusage.Detail{InputTokens: 10, OutputTokens: 5, ReasoningTokens: 2, CachedTokens: 3},
+	})
+	collector.HandleUsage(context.Background(), coreusage.Record{
+		Provider:  "gemini",
+		Model:     "gemini-2.5-pro",
+		AuthIndex: "abc",
+		Failed:    true,
+	})
+
+	var buf bytes.Buffer
+	if err := Write(&buf, collector, nil); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`cliproxy_requests_total{provider="gemini",model="gemini-2.5-pro",auth_index="abc",result="success"} 1`,
+		`cliproxy_requests_total{provider="gemini",model="gemini-2.5-pro",auth_index="abc",result="failure"} 1`,
+		`cliproxy_request_duration_seconds_bucket{provider="gemini",model="gemini-2.5-pro",auth_index="abc",le="0.5"} 0`,
+		`cliproxy_request_duration_seconds_bucket{provider="gemini",model="gemini-2.5-pro",auth_index="abc",le="1"} 1`,
+		`cliproxy_request_duration_seconds_count{provider="gemini",model="gemini-2.5-pro",auth_index="abc"} 1`,
+		`cliproxy_tokens_total{provider="gemini",model="gemini-2.5-pro",auth_index="abc",type="reasoning"} 2`,
+		`cliproxy_tokens_total{provider="gemini",model="gemini-2.5-pro",auth_index="abc",type="cached"} 3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteAuthState(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	auth := &coreauth.Auth{ID: "a1", Provider: "claude", Status: coreauth.StatusActive, Unavailable: true}
+	auth.Quota.Exceeded = true
+	auth.Quota.BackoffLevel = 3
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, NewCollector(), manager); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`cliproxy_auth_unavailable{provider="claude",auth_index=`,
+		`cliproxy_auth_backoff_level{provider="claude"`,
+		"# TYPE cliproxy_scheduler_credentials gauge",
+		"# TYPE cliproxy_auth_refresh_total counter",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in output:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(out, `status="active"} 3`) {
+		t.Fatalf("expected backoff level 3 in output:\n%s", out)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Fatalf("escapeLabel = %q", got)
+	}
+}
+
+func TestCollectorPluginOnlyCollectsWhenEnabled(t *testing.T) {
+	record := coreusage.Record{Provider: "codex", Model: "gpt-5", AuthIndex: "gate"}
+	t.Cleanup(func() { SetEnabled(false) })
+
+	collectorPlugin{}.HandleUsage(context.Background(), record)
+	if len(DefaultCollector().snapshot()) != 0 {
+		t.Fatal("collector aggregated a record while metrics were disabled")
+	}
+	SetEnabled(true)
+	collectorPlugin{}.HandleUsage(context.Background(), record)
+	if len(DefaultCollector().snapshot()) != 1 {
+		t.Fatal("collector ignored a record while metrics were enabled")
+	}
+	SetEnabled(false)
+	if len(DefaultCollector().snapshot()) != 0 {
+		t.Fatal("disabling metrics kept the accumulated series")
+	}
+}
@@ -0,0 +1,233 @@
+// Package pricing converts usage records into spend using a per-model price table.
+// Built-in list prices ship with the model registry and can be overridden or extended
+// from configuration.
+package pricing
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// Source values reported by Entries.
+const (
+	SourceDefault = "default"
+	SourceConfig  = "config"
+)
+
+// Entry describes one effective price table row.
+type Entry struct {
+	Model       string  `json:"model"`
+	Input       float64 `json:"input"`
+	Output      float64 `json:"output"`
+	CachedInput float64 `json:"cached-input,omitempty"`
+	CacheWrite  float64 `json:"cache-write,omitempty"`
+	Reasoning   float64 `json:"reasoning,omitempty"`
+	Source      string  `json:"source"`
+}
+
+// Table resolves model prices. Configured prices take precedence over built-in defaults.
+type Table struct {
+	mu        sync.RWMutex
+	defaults  map[string]registry.ModelPrice
+	overrides map[string]registry.ModelPrice
+}
+
+// NewTable creates a table seeded with the registry's built-in prices.
+func NewTable() *Table {
+	defaults := make(map[string]registry.ModelPrice)
+	for model, price := range registry.DefaultModelPrices() {
+		defaults[strings.ToLower(model)] = price
+	}
+	return &Table{defaults: defaults, overrides: make(map[string]registry.ModelPrice)}
+}
+
+// Configure replaces the configured overrides with cfg.ModelPrices.
+func (t *Table) Configure(cfg *config.SDKConfig) {
+	if t == nil {
+		return
+	}
+	overrides := make(map[string]registry.ModelPrice)
+	if cfg != nil {
+		for _, entry := range cfg.ModelPrices {
+			model := strings.ToLower(strings.TrimSpace(entry.Model))
+			if model == "" {
+				continue
+			}
+			overrides[model] = registry.ModelPrice{
+				Input:       entry.Input,
+				Output:      entry.Output,
+				CachedInput: entry.CachedInput,
+				CacheWrite:  entry.CacheWrite,
+				Reasoning:   entry.Reasoning,
+			}
+		}
+	}
+	t.mu.Lock()
+	t.overrides = overrides
+	t.mu.Unlock()
+}
+
+// Lookup returns the price for model. Thinking suffixes such as "(high)" and route
+// prefixes such as "team/" are ignored. Exact matches win over the longest family prefix,
+// and configured prices win over defaults at the same specificity. A family prefix only
+// matches when the rest of the name is a snapshot suffix (see snapshotSuffix), so
+// "gpt-5-nano" does not fall back to the "gpt-5" price.
+func (t *Table) Lookup(model string) (registry.ModelPrice, bool) {
+	if t == nil {
+		return registry.ModelPrice{}, false
+	}
+	key := normalizeModel(model)
+	if key == "" {
+		return registry.ModelPrice{}, false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, table := range []map[string]registry.ModelPrice{t.overrides, t.defaults} {
+		if price, ok := table[key]; ok {
+			return price, true
+		}
+	}
+	bestLen := 0
+	var best registry.ModelPrice
+	for _, table := range []map[string]registry.ModelPrice{t.overrides, t.defaults} {
+		for prefix, price := range table {
+			if len(prefix) > bestLen && strings.HasPrefix(key, prefix) && snapshotSuffix(key[len(prefix):]) {
+				best, bestLen = price, len(prefix)
+			}
+		}
+	}
+	return best, bestLen > 0
+}
+
+// snapshotSuffix reports whether rest, the part of a model name after a price table key,
+// only names a dated or versioned snapshot of that model: "-20250929", "@20250929",
+// "-2025-08-07", "-preview-05-20", "-latest" or "-v2". Single digits and words name a
+// different model ("-1", "-nano", "-lite") and are rejected.
+func snapshotSuffix(rest string) bool {
+	if rest == "" || (rest[0] != '-' && rest[0] != '@') {
+		return false
+	}
+	for _, segment := range strings.FieldsFunc(rest, func(r rune) bool { return r == '-' || r == '@' }) {
+		switch {
+		case segment == "latest" || segment == "preview" || segment == "exp":
+		case len(segment) >= 2 && allDigits(segment):
+		case len(segment) >= 2 && segment[0] == 'v' && allDigits(segment[1:]):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Cost returns the spend in USD for record, or 0 when the model has no price.
+//
+// Response formats report tokens differently: OpenAI and Gemini include cache reads in the
+// input count while the Claude format reports them separately, and OpenAI includes reasoning
+// tokens in the output count while Gemini reports them on top. The usage parsers flag the
+// separate counts on the Detail, so Claude served through Vertex, Bedrock or Antigravity is
+// priced the same as Claude direct. Claude cache writes are reported on their own and billed
+// at the cache-write price. Each token is billed exactly once at its own rate.
+func (t *Table) Cost(record coreusage.Record) float64 {
+	price, ok := t.Lookup(record.Model)
+	if !ok {
+		return 0
+	}
+	detail := record.Detail
+	input := detail.InputTokens
+	cached := detail.CachedTokens
+	if cached > 0 && !detail.CachedSeparate && cached <= input {
+		input -= cached
+	}
+	output := detail.OutputTokens
+	reasoning := detail.ReasoningTokens
+	if reasoning > 0 && !detail.ReasoningSeparate && reasoning <= output {
+		output -= reasoning
+	}
+	cachedPrice := price.CachedInput
+	if cachedPrice <= 0 {
+		cachedPrice = price.Input
+	}
+	cacheWritePrice := price.CacheWrite
+	if cacheWritePrice <= 0 {
+		cacheWritePrice = price.Input
+	}
+	reasoningPrice := price.Reasoning
+	if reasoningPrice <= 0 {
+		reasoningPrice = price.Output
+	}
+	total := float64(input)*price.Input +
+		float64(cached)*cachedPrice +
+		float64(detail.CacheCreationTokens)*cacheWritePrice +
+		float64(output)*price.Output +
+		float64(reasoning)*reasoningPrice
+	return total / 1_000_000
+}
+
+// Entries lists the effective price table sorted by model.
+func (t *Table) Entries() []Entry {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]Entry, 0, len(t.defaults)+len(t.overrides))
+	for model, price := range t.defaults {
+		if _, overridden := t.overrides[model]; overridden {
+			continue
+		}
+		out = append(out, newEntry(model, price, SourceDefault))
+	}
+	for model, price := range t.overrides {
+		out = append(out, newEntry(model, price, SourceConfig))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out
+}
+
+func newEntry(model string, price registry.ModelPrice, source string) Entry {
+	return Entry{
+		Model:       model,
+		Input:       price.Input,
+		Output:      price.Output,
+		CachedInput: price.CachedInput,
+		CacheWrite:  price.CacheWrite,
+		Reasoning:   price.Reasoning,
+		Source:      source,
+	}
+}
+
+func normalizeModel(model string) string {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if idx := strings.Index(model, "("); idx > 0 && strings.HasSuffix(model, ")") {
+		model = strings.TrimSpace(model[:idx])
+	}
+	if idx := strings.LastIndex(model, "/"); idx >= 0 {
+		model = model[idx+1:]
+	}
+	return model
+}
+
+var defaultTable = NewTable()
+
+// Default returns the process-wide price table.
+func Default() *Table { return defaultTable }
+
+// Configure applies cfg to the process-wide price table.
+func Configure(cfg *config.SDKConfig) { defaultTable.Configure(cfg) }
+
+// Cost prices record with the process-wide table.
+func Cost(record coreusage.Record) float64 { return defaultTable.Cost(record) }
@@ -0,0 +1,114 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestLookupPrefersExactThenLongestPrefix(t *testing.T) {
+	table := NewTable()
+	table.Configure(&config.SDKConfig{ModelPrices: []config.ModelPrice{
+		{Model: "claude-sonnet-4-5-special", Input: 9, Output: 9},
+		{Model: "custom", Input: 1, Output: 2},
+	}})
+
+	price, ok := table.Lookup("claude-sonnet-4-5-20250929")
+	if !ok || price.Input != 3 {
+		t.Fatalf("expected family prefix price, got %+v (ok=%v)", price, ok)
+	}
+	price, ok = table.Lookup("team/Claude-Sonnet-4-5-Special(high)")
+	if !ok || price.Input != 9 {
+		t.Fatalf("expected configured override, got %+v (ok=%v)", price, ok)
+	}
+	price, ok = table.Lookup("custom-v2")
+	if !ok || price.Output != 2 {
+		t.Fatalf("expected configured prefix, got %+v (ok=%v)", price, ok)
+	}
+	price, ok = table.Lookup("claude-sonnet-4-5@20250929")
+	if !ok || price.Input != 3 {
+		t.Fatalf("expected vertex snapshot to use the family price, got %+v (ok=%v)", price, ok)
+	}
+	for _, model := range []string{"gpt-5-nano", "gemini-2.5-pro-lite", "claude-opus-4-8"} {
+		if price, ok = table.Lookup(model); ok {
+			t.Fatalf("expected %s to have no price, got %+v", model, price)
+		}
+	}
+	if _, ok = table.Lookup("unknown-model"); ok {
+		t.Fatalf("expected unknown model to have no price")
+	}
+}
+
+func TestCostNormalizesProviderTokenAccounting(t *testing.T) {
+	table := NewTable()
+	table.Configure(&config.SDKConfig{ModelPrices: []config.ModelPrice{
+		{Model: "m", Input: 10, Output: 20, CachedInput: 1},
+		{Model: "w", Input: 10, Output: 20, CachedInput: 1, CacheWrite: 12.5},
+	}})
+
+	cases := []struct {
+		name   string
+		record coreusage.Record
+		want   float64
+	}{
+		{
+			name:   "claude format reports cache reads separately",
+			record: coreusage.Record{Provider: "vertex", Model: "m", Detail: coreusage.Detail{InputTokens: 1000, CachedTokens: 500, OutputTokens: 100, CachedSeparate: true}},
+			want:   (1000*10 + 500*1 + 100*20) / 1e6,
+		},
+		{
+			name:   "openai includes cache reads in input",
+			record: coreusage.Record{Provider: "openai", Model: "m", Detail: coreusage.Detail{InputTokens: 1000, CachedTokens: 500, OutputTokens: 100}},
+			want:   (500*10 + 500*1 + 100*20) / 1e6,
+		},
+		{
+			name:   "reasoning included in output",
+			record: coreusage.Record{Provider: "openai", Model: "m", Detail: coreusage.Detail{InputTokens: 100, OutputTokens: 300, ReasoningTokens: 200}},
+			want:   (100*10 + 300*20) / 1e6,
+		},
+		{
+			name:   "reasoning reported on top of output",
+			record: coreusage.Record{Provider: "gemini", Model: "m", Detail: coreusage.Detail{InputTokens: 100, OutputTokens: 300, ReasoningTokens: 200, ReasoningSeparate: true}},
+			want:   (100*10 + 500*20) / 1e6,
+		},
+		{
+			name:   "claude cache writes without reads use the input price by default",
+			record: coreusage.Record{Provider: "claude", Model: "m", Detail: coreusage.Detail{InputTokens: 10, CacheCreationTokens: 3000, OutputTokens: 100, CachedSeparate: true}},
+			want:   (10*10 + 3000*10 + 100*20) / 1e6,
+		},
+		{
+			name:   "claude cache writes use the cache-write price",
+			record: coreusage.Record{Provider: "claude", Model: "w", Detail: coreusage.Detail{InputTokens: 10, CachedTokens: 200, CacheCreationTokens: 3000, OutputTokens: 100, CachedSeparate: true}},
+			want:   (10*10 + 200*1 + 3000*12.5 + 100*20) / 1e6,
+		},
+		{
+			name:   "unpriced model",
+			record: coreusage.Record{Model: "other", Detail: coreusage.Detail{InputTokens: 100}},
+			want:   0,
+		},
+	}
+	for _, tc := range cases {
+		if got := table.Cost(tc.record); math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("%s: Cost() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEntriesMarksOverrides(t *testing.T) {
+	table := NewTable()
+	table.Configure(&config.SDKConfig{ModelPrices: []config.ModelPrice{{Model: "gpt-5", Input: 99, Output: 99}}})
+	var found bool
+	for _, entry := range table.Entries() {
+		if entry.Model == "gpt-5" {
+			found = true
+			if entry.Source != SourceConfig || entry.Input != 99 {
+				t.Fatalf("unexpected entry: %+v", entry)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected gpt-5 entry")
+	}
+}
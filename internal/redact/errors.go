@@ -0,0 +1,35 @@
+package redact
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+// BlockedError reports a prompt rejected because it contained secrets or PII.
+// Error renders a JSON body in the client's protocol selected by Format.
+type BlockedError struct {
+	Detectors []string
+	// Format is the handler type of the client request (e.g. "openai", "claude", "gemini").
+	Format string
+}
+
+// Message returns a human-readable description naming the detectors that matched.
+func (e *BlockedError) Message() string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf("request blocked: prompt contains sensitive data (%s)", strings.Join(e.Detectors, ", "))
+}
+
+func (e *BlockedError) Error() string {
+	if e == nil {
+		return ""
+	}
+	return interfaces.InvalidRequestBody(e.Format, e.Message(), "sensitive_data_blocked")
+}
+
+// StatusCode returns 400.
+func (e *BlockedError) StatusCode() int { return http.StatusBadRequest }
@@ -0,0 +1,263 @@
+// Package redact detects secrets and personal data in request payloads. It masks matches in
+// request logs and applies a per-client-key block/mask/allow policy to outbound prompts.
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Mode is the action taken on an outbound prompt that contains findings.
+type Mode string
+
+const (
+	// ModeMask replaces findings before the request is sent upstream.
+	ModeMask Mode = "mask"
+	// ModeBlock rejects the request.
+	ModeBlock Mode = "block"
+	// ModeAllow forwards the request unchanged.
+	ModeAllow Mode = "allow"
+)
+
+// Built-in detector names.
+const (
+	DetectorAWSKey = "aws-key"
+	DetectorJWT    = "jwt"
+	DetectorPEM    = "pem"
+	DetectorEmail  = "email"
+)
+
+// DefaultKey is the api-key value of the entry applied to keys without a dedicated entry.
+const DefaultKey = "*"
+
+type detector struct {
+	name     string
+	patterns []*regexp.Regexp
+}
+
+var builtinDetectors = []detector{
+	{name: DetectorPEM, patterns: []*regexp.Regexp{
+		regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`),
+	}},
+	{name: DetectorAWSKey, patterns: []*regexp.Regexp{
+		regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`),
+		regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["'\s]*[:=]\s*["']?[A-Za-z0-9/+]{40}\b`),
+	}},
+	{name: DetectorJWT, patterns: []*regexp.Regexp{
+		regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`),
+	}},
+	{name: DetectorEmail, patterns: []*regexp.Regexp{
+		regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`),
+	}},
+}
+
+// Findings counts matches per detector name.
+type Findings map[string]int
+
+// Total returns the number of matches across all detectors.
+func (f Findings) Total() int {
+	total := 0
+	for _, n := range f {
+		total += n
+	}
+	return total
+}
+
+// Detectors returns the detector names with at least one match, sorted.
+func (f Findings) Detectors() []string {
+	names := make([]string, 0, len(f))
+	for name, n := range f {
+		if n > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Engine holds the compiled detectors and per-key modes.
+type Engine struct {
+	mu        sync.RWMutex
+	enabled   bool
+	mode      Mode
+	keyModes  map[string]Mode
+	detectors []detector
+}
+
+// NewEngine constructs a disabled engine.
+func NewEngine() *Engine {
+	return &Engine{mode: ModeMask, keyModes: make(map[string]Mode)}
+}
+
+// Configure compiles cfg.Redaction. Invalid custom patterns are skipped with a warning.
+func (e *Engine) Configure(cfg *config.SDKConfig) {
+	if e == nil {
+		return
+	}
+	enabled := false
+	mode := ModeMask
+	keyModes := make(map[string]Mode)
+	var detectors []detector
+	if cfg != nil && cfg.Redaction.Enable {
+		rc := cfg.Redaction
+		enabled = true
+		if parsed, ok := ParseMode(rc.Mode); ok {
+			mode = parsed
+		} else if strings.TrimSpace(rc.Mode) != "" {
+			log.Warnf("redaction: unknown mode %q, using %s", rc.Mode, ModeMask)
+		}
+		for _, entry := range rc.APIKeys {
+			key := strings.TrimSpace(entry.APIKey)
+			parsed, ok := ParseMode(entry.Mode)
+			if key == "" || !ok {
+				continue
+			}
+			keyModes[key] = parsed
+		}
+		detectors = selectBuiltinDetectors(rc.Detectors)
+		for _, pattern := range rc.Patterns {
+			name := strings.TrimSpace(pattern.Name)
+			if name == "" || strings.TrimSpace(pattern.Regex) == "" {
+				continue
+			}
+			re, err := regexp.Compile(pattern.Regex)
+			if err != nil {
+				log.Warnf("redaction: skipping pattern %s: %v", name, err)
+				continue
+			}
+			detectors = append(detectors, detector{name: name, patterns: []*regexp.Regexp{re}})
+		}
+	}
+	e.mu.Lock()
+	e.enabled = enabled
+	e.mode = mode
+	e.keyModes = keyModes
+	e.detectors = detectors
+	e.mu.Unlock()
+}
+
+func selectBuiltinDetectors(names []string) []detector {
+	if len(names) == 0 {
+		return append([]detector(nil), builtinDetectors...)
+	}
+	wanted := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		wanted[strings.ToLower(strings.TrimSpace(name))] = struct{}{}
+	}
+	var out []detector
+	for _, d := range builtinDetectors {
+		if _, ok := wanted[d.name]; ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// ParseMode parses a mode name.
+func ParseMode(raw string) (Mode, bool) {
+	switch Mode(strings.ToLower(strings.TrimSpace(raw))) {
+	case ModeMask:
+		return ModeMask, true
+	case ModeBlock:
+		return ModeBlock, true
+	case ModeAllow:
+		return ModeAllow, true
+	default:
+		return "", false
+	}
+}
+
+// Enabled reports whether redaction is configured.
+func (e *Engine) Enabled() bool {
+	if e == nil {
+		return false
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.enabled && len(e.detectors) > 0
+}
+
+// ModeFor returns the outbound mode for a client API key.
+func (e *Engine) ModeFor(apiKey string) Mode {
+	if e == nil {
+		return ModeAllow
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if mode, ok := e.keyModes[apiKey]; ok {
+		return mode
+	}
+	if mode, ok := e.keyModes[DefaultKey]; ok {
+		return mode
+	}
+	return e.mode
+}
+
+// Detect counts findings in data without modifying it.
+func (e *Engine) Detect(data []byte) Findings {
+	_, findings := e.scan(data, false)
+	return findings
+}
+
+// Mask replaces every finding with a "[REDACTED:<detector>]" marker. The input slice is
+// returned unchanged when nothing matches.
+func (e *Engine) Mask(data []byte) ([]byte, Findings) {
+	return e.scan(data, true)
+}
+
+func (e *Engine) scan(data []byte, mask bool) ([]byte, Findings) {
+	if e == nil || len(data) == 0 {
+		return data, nil
+	}
+	e.mu.RLock()
+	enabled := e.enabled
+	detectors := e.detectors
+	e.mu.RUnlock()
+	if !enabled {
+		return data, nil
+	}
+	var findings Findings
+	out := data
+	for _, d := range detectors {
+		marker := []byte("[REDACTED:" + d.name + "]")
+		for _, re := range d.patterns {
+			matches := re.FindAllIndex(out, -1)
+			if len(matches) == 0 {
+				continue
+			}
+			if findings == nil {
+				findings = make(Findings)
+			}
+			findings[d.name] += len(matches)
+			if mask {
+				out = re.ReplaceAllLiteral(out, marker)
+			}
+		}
+	}
+	return out, findings
+}
+
+var defaultEngine = NewEngine()
+
+// logEngine always masks the built-in detectors, whether or not outbound redaction is enabled.
+var logEngine = &Engine{enabled: true, mode: ModeMask, keyModes: map[string]Mode{}, detectors: builtinDetectors}
+
+// Default returns the process-wide engine.
+func Default() *Engine { return defaultEngine }
+
+// Configure applies cfg to the process-wide engine.
+func Configure(cfg *config.SDKConfig) { defaultEngine.Configure(cfg) }
+
+// MaskLog masks payloads destined for request logs. Every built-in detector is applied even
+// when redaction is disabled or configured with a subset of detectors; configured custom
+// patterns are applied on top.
+func MaskLog(data []byte) []byte {
+	out, _ := logEngine.Mask(data)
+	out, _ = defaultEngine.Mask(out)
+	return out
+}
@@ -76,6 +76,29 @@ func GetAntigravityModels() []*ModelInfo {
 	return cloneModelInfos(getModels().Antigravity)
 }
 
+// GetGeminiEmbeddingModels returns the Gemini embedding model definitions.
+// They are defined statically because the remote models catalog only tracks
+// generative models; Gemini API key and Vertex providers append them to their lists.
+// Their generation methods mark them for IsEmbeddingModel, which keeps them out of chat
+// listings and chat requests.
+func GetGeminiEmbeddingModels() []*ModelInfo {
+	return []*ModelInfo{
+		{
+			ID:                         "gemini-embedding-001",
+			Object:                     "model",
+			Created:                    1752537600,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			DisplayName:                "Gemini Embedding 001",
+			Name:                       "models/gemini-embedding-001",
+			Version:                    "001",
+			Description:                "Gemini text embedding model",
+			InputTokenLimit:            2048,
+			SupportedGenerationMethods: []string{"embedContent", "batchEmbedContents"},
+		},
+	}
+}
+
 // cloneModelInfos returns a shallow copy of the slice with each element deep-cloned.
 func cloneModelInfos(models []*ModelInfo) []*ModelInfo {
 	if len(models) == 0 {
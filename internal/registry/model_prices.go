@@ -0,0 +1,39 @@
+package registry
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+//go:embed models/prices.json
+var embeddedPricesJSON []byte
+
+// ModelPrice is the list price of a model in USD per million tokens.
+// Zero CachedInput bills cache reads and zero CacheWrite bills cache writes at the input
+// price; zero Reasoning bills reasoning tokens at the output price.
+type ModelPrice struct {
+	Input       float64 `json:"input"`
+	Output      float64 `json:"output"`
+	CachedInput float64 `json:"cached_input,omitempty"`
+	CacheWrite  float64 `json:"cache_write,omitempty"`
+	Reasoning   float64 `json:"reasoning,omitempty"`
+}
+
+var defaultModelPrices map[string]ModelPrice
+
+func init() {
+	if err := json.Unmarshal(embeddedPricesJSON, &defaultModelPrices); err != nil {
+		panic(fmt.Sprintf("registry: failed to parse embedded prices.json: %v", err))
+	}
+}
+
+// DefaultModelPrices returns a copy of the built-in price table. Keys are model IDs or
+// model family prefixes (e.g. "claude-sonnet-4-5" also prices "claude-sonnet-4-5-20250929").
+func DefaultModelPrices() map[string]ModelPrice {
+	out := make(map[string]ModelPrice, len(defaultModelPrices))
+	for model, price := range defaultModelPrices {
+		out[model] = price
+	}
+	return out
+}
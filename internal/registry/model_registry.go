@@ -5,7 +5,10 @@ package registry
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -51,6 +54,9 @@ type ModelInfo struct {
 	SupportedInputModalities []string `json:"supportedInputModalities,omitempty"`
 	// SupportedOutputModalities lists supported output modalities (e.g., TEXT, IMAGE)
 	SupportedOutputModalities []string `json:"supportedOutputModalities,omitempty"`
+	// UpstreamInputModalities lists the input modalities of each upstream model behind this
+	// entry, keyed by lower-cased upstream name, for aliases whose pool members differ.
+	UpstreamInputModalities map[string][]string `json:"-"`
 
 	// Thinking holds provider-specific reasoning/thinking budget capabilities.
 	// This is optional and currently used for Gemini thinking budget normalization.
@@ -158,6 +164,18 @@ func (r *ModelRegistry) invalidateAvailableModelsCacheLocked() {
 	clear(r.availableModelsCache)
 }
 
+// IsEmbeddingModel reports whether info describes a model that only serves embeddings: its
+// supported generation methods include embedContent but not generateContent. Such models
+// are registered alongside chat models so embeddings requests can route to them, and are
+// kept out of chat model listings and chat requests.
+func IsEmbeddingModel(info *ModelInfo) bool {
+	if info == nil {
+		return false
+	}
+	return slices.Contains(info.SupportedGenerationMethods, "embedContent") &&
+		!slices.Contains(info.SupportedGenerationMethods, "generateContent")
+}
+
 // LookupModelInfo searches dynamic registry (provider-specific > global) then static definitions.
 func LookupModelInfo(modelID string, provider ...string) *ModelInfo {
 	modelID = strings.TrimSpace(modelID)
@@ -537,6 +555,12 @@ func cloneModelInfo(model *ModelInfo) *ModelInfo {
 	if len(model.SupportedOutputModalities) > 0 {
 		copyModel.SupportedOutputModalities = append([]string(nil), model.SupportedOutputModalities...)
 	}
+	if model.UpstreamInputModalities != nil {
+		copyModel.UpstreamInputModalities = make(map[string][]string, len(model.UpstreamInputModalities))
+		for name, modalities := range model.UpstreamInputModalities {
+			copyModel.UpstreamInputModalities[name] = append([]string(nil), modalities...)
+		}
+	}
 	if model.Thinking != nil {
 		copyThinking := *model.Thinking
 		if len(model.Thinking.Levels) > 0 {
@@ -724,6 +748,30 @@ func (r *ModelRegistry) ResumeClientModel(clientID, modelID string) {
 	log.Debugf("Resumed client %s for model %s", clientID, modelID)
 }
 
+// GetClientModelInfo returns the model info registered by a specific client for modelID,
+// matched case-insensitively, or nil when the client does not serve the model.
+func (r *ModelRegistry) GetClientModelInfo(clientID, modelID string) *ModelInfo {
+	clientID = strings.TrimSpace(clientID)
+	modelID = strings.TrimSpace(modelID)
+	if clientID == "" || modelID == "" {
+		return nil
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	infos := r.clientModelInfos[clientID]
+	if info, ok := infos[modelID]; ok && info != nil {
+		return cloneModelInfo(info)
+	}
+	for id, info := range infos {
+		if info != nil && strings.EqualFold(id, modelID) {
+			return cloneModelInfo(info)
+		}
+	}
+	return nil
+}
+
 // ClientSupportsModel reports whether the client registered support for modelID.
 func (r *ModelRegistry) ClientSupportsModel(clientID, modelID string) bool {
 	clientID = strings.TrimSpace(clientID)
@@ -750,6 +798,8 @@ func (r *ModelRegistry) ClientSupportsModel(clientID, modelID string) bool {
 }
 
 // GetAvailableModels returns all models that have at least one available client
+// Embedding-only models (see IsEmbeddingModel) are listed for the "gemini" handler only,
+// whose model format reports supportedGenerationMethods so clients can tell them apart.
 // Parameters:
 //   - handlerType: The handler type to filter models for (e.g., "openai", "claude", "gemini")
 //
@@ -821,6 +871,10 @@ func (r *ModelRegistry) buildAvailableModelsLocked(handlerType string, now time.
 			effectiveClients = 0
 		}
 
+		if handlerType != "gemini" && IsEmbeddingModel(registration.Info) {
+			continue
+		}
+
 		if effectiveClients > 0 || (availableClients > 0 && (expiredClients > 0 || cooldownSuspended > 0) && otherSuspended == 0) {
 			model := r.convertModelToMap(registration.Info, handlerType)
 			if model != nil {
@@ -1193,6 +1247,34 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 		}
 		return result
 
+	case "ollama":
+		// Ollama /api/tags entries; proxied models carry no weights, so size is zero and the
+		// digest is derived from the model ID to stay stable across restarts.
+		digest := sha256.Sum256([]byte(model.ID))
+		modifiedAt := time.Unix(model.Created, 0).UTC()
+		if model.Created <= 0 {
+			modifiedAt = time.Unix(0, 0).UTC()
+		}
+		family := model.OwnedBy
+		if family == "" {
+			family = model.Type
+		}
+		return map[string]any{
+			"name":        model.ID,
+			"model":       model.ID,
+			"modified_at": modifiedAt.Format(time.RFC3339),
+			"size":        0,
+			"digest":      hex.EncodeToString(digest[:]),
+			"details": map[string]any{
+				"parent_model":       "",
+				"format":             "proxy",
+				"family":             family,
+				"families":           []string{family},
+				"parameter_size":     "",
+				"quantization_level": "",
+			},
+		}
+
 	default:
 		// Generic format
 		result := map[string]any{
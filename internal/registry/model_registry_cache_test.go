@@ -52,3 +52,22 @@ func TestGetAvailableModelsInvalidatesCacheOnRegistryChanges(t *testing.T) {
 		t.Fatalf("expected model to reappear after resume, got %d", len(models))
 	}
 }
+
+func TestGetAvailableModelsHidesEmbeddingModelsFromChatListings(t *testing.T) {
+	r := newTestModelRegistry()
+	models := append([]*ModelInfo{{ID: "gemini-2.5-pro", SupportedGenerationMethods: []string{"generateContent"}}}, GetGeminiEmbeddingModels()...)
+	r.RegisterClient("client-1", "gemini", models)
+
+	for _, handlerType := range []string{"openai", "claude", ""} {
+		listed := r.GetAvailableModels(handlerType)
+		if len(listed) != 1 || listed[0]["id"] != "gemini-2.5-pro" {
+			t.Fatalf("%q listing = %v, want only gemini-2.5-pro", handlerType, listed)
+		}
+	}
+	if listed := r.GetAvailableModels("gemini"); len(listed) != 2 {
+		t.Fatalf("gemini listing has %d models, want 2", len(listed))
+	}
+	if first, err := r.GetFirstAvailableModel(""); err != nil || first != "gemini-2.5-pro" {
+		t.Fatalf("GetFirstAvailableModel() = %q, %v, want gemini-2.5-pro", first, err)
+	}
+}
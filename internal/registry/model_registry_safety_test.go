@@ -29,6 +29,29 @@ func TestGetModelInfoReturnsClone(t *testing.T) {
 	}
 }
 
+func TestGetClientModelInfoReturnsClone(t *testing.T) {
+	r := newTestModelRegistry()
+	r.RegisterClient("client-1", "pool", []*ModelInfo{{
+		ID:                      "Vision-Pool",
+		UpstreamInputModalities: map[string][]string{"vision": {"TEXT", "IMAGE"}},
+	}})
+
+	first := r.GetClientModelInfo("client-1", "vision-pool")
+	if first == nil {
+		t.Fatal("expected client model info")
+	}
+	first.UpstreamInputModalities["vision"][1] = "mutated"
+	first.UpstreamInputModalities["other"] = nil
+
+	second := r.GetClientModelInfo("client-1", "Vision-Pool")
+	if got := second.UpstreamInputModalities; len(got) != 1 || got["vision"][1] != "IMAGE" {
+		t.Fatalf("expected cloned upstream modalities, got %+v", got)
+	}
+	if r.GetClientModelInfo("client-2", "vision-pool") != nil {
+		t.Fatal("expected nil for unknown client")
+	}
+}
+
 func TestGetModelsForClientReturnsClones(t *testing.T) {
 	r := newTestModelRegistry()
 	r.RegisterClient("client-1", "gemini", []*ModelInfo{{
@@ -0,0 +1,435 @@
+// Package responsecache stores completed upstream responses keyed by a normalized request
+// hash so identical requests can be answered without consuming upstream quota. Non-streaming
+// responses are kept as a single body; streaming responses are kept as the ordered list of
+// translated chunks and replayed through the regular SSE framing.
+package responsecache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/jsonstore"
+)
+
+// DefaultTTL is how long cached responses remain valid when no TTL is configured.
+const DefaultTTL = time.Hour
+
+// DefaultMaxEntries bounds the in-memory LRU when no size is configured.
+const DefaultMaxEntries = 1000
+
+// MaxEntryBytes is the largest response payload that is cached. Larger responses are
+// passed through without being stored.
+const MaxEntryBytes = 8 << 20
+
+// ErrNotFound is returned when a key is unknown or has expired.
+var ErrNotFound = errors.New("cache entry not found")
+
+// Entry is one cached response.
+type Entry struct {
+	// Key is the normalized request hash.
+	Key string `json:"key"`
+	// Handler is the client-facing API format (openai, claude, gemini, ...).
+	Handler string `json:"handler"`
+	// Model is the client-facing model name.
+	Model string `json:"model"`
+	// Stream reports whether Chunks (true) or Body (false) holds the response.
+	Stream bool `json:"stream"`
+	// Body is the translated non-streaming response payload.
+	Body []byte `json:"body,omitempty"`
+	// Chunks are the translated streaming chunks in the order they were sent.
+	Chunks [][]byte `json:"chunks,omitempty"`
+	// Headers are the filtered upstream headers captured with the response.
+	Headers http.Header `json:"headers,omitempty"`
+	// CreatedAt records when the entry was stored.
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt marks when the entry becomes invalid.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the entry is past its expiry at the given time.
+func (e *Entry) Expired(now time.Time) bool {
+	if e == nil {
+		return true
+	}
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// Size returns the number of payload bytes held by the entry.
+func (e *Entry) Size() int {
+	if e == nil {
+		return 0
+	}
+	size := len(e.Body)
+	for _, chunk := range e.Chunks {
+		size += len(chunk)
+	}
+	return size
+}
+
+// Store is an optional persistent tier behind the in-memory LRU.
+type Store interface {
+	// Get returns the entry for key or ErrNotFound.
+	Get(ctx context.Context, key string) (*Entry, error)
+	// Put stores or replaces an entry.
+	Put(ctx context.Context, entry *Entry) error
+	// Delete removes an entry. It returns ErrNotFound when the key is unknown.
+	Delete(ctx context.Context, key string) error
+	// Purge removes every entry and returns how many were removed.
+	Purge(ctx context.Context) (int, error)
+}
+
+// Summary describes a cached entry without its payload.
+type Summary struct {
+	Key       string    `json:"key"`
+	Handler   string    `json:"handler"`
+	Model     string    `json:"model"`
+	Stream    bool      `json:"stream"`
+	Size      int       `json:"size"`
+	Hits      int64     `json:"hits"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Stats reports cache effectiveness since startup or the last reset.
+type Stats struct {
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	Stores     int64   `json:"stores"`
+	Evictions  int64   `json:"evictions"`
+	Entries    int     `json:"entries"`
+	MaxEntries int     `json:"max_entries"`
+	HitRate    float64 `json:"hit_rate"`
+	Backend    string  `json:"backend"`
+}
+
+type lruItem struct {
+	entry *Entry
+	hits  int64
+}
+
+// Cache is an in-memory LRU with an optional persistent backend. Lookups that miss the
+// LRU fall through to the backend and promote the result into memory.
+type Cache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+	backend    Store
+	name       string
+
+	hits      int64
+	misses    int64
+	stores    int64
+	evictions int64
+}
+
+// New creates a cache. A ttl <= 0 uses DefaultTTL and maxEntries <= 0 uses DefaultMaxEntries.
+// backend may be nil; name labels the backend in Stats.
+func New(ttl time.Duration, maxEntries int, backend Store, name string) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	if strings.TrimSpace(name) == "" {
+		name = "memory"
+	}
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+		backend:    backend,
+		name:       name,
+	}
+}
+
+// TTL returns the lifetime applied to new entries.
+func (c *Cache) TTL() time.Duration {
+	if c == nil {
+		return DefaultTTL
+	}
+	return c.ttl
+}
+
+// Get returns a copy of the cached entry for key and records a hit or miss.
+func (c *Cache) Get(ctx context.Context, key string) (*Entry, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+	now := time.Now()
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*lruItem)
+		if !item.entry.Expired(now) {
+			item.hits++
+			c.hits++
+			c.order.MoveToFront(elem)
+			entry := cloneEntry(item.entry)
+			c.mu.Unlock()
+			return entry, true
+		}
+		c.removeElement(elem)
+	}
+	backend := c.backend
+	c.mu.Unlock()
+
+	if backend != nil {
+		entry, err := backend.Get(ctx, key)
+		if err == nil && entry != nil && !entry.Expired(now) {
+			c.mu.Lock()
+			c.hits++
+			c.insert(entry)
+			if elem, ok := c.items[key]; ok {
+				elem.Value.(*lruItem).hits++
+			}
+			c.mu.Unlock()
+			return cloneEntry(entry), true
+		}
+	}
+	c.mu.Lock()
+	c.misses++
+	c.mu.Unlock()
+	return nil, false
+}
+
+// Put stores entry in memory and, when configured, in the backend.
+func (c *Cache) Put(ctx context.Context, entry *Entry) error {
+	if c == nil || entry == nil || entry.Key == "" || entry.Size() > MaxEntryBytes {
+		return nil
+	}
+	stored := cloneEntry(entry)
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = time.Now()
+	}
+	if stored.ExpiresAt.IsZero() {
+		stored.ExpiresAt = stored.CreatedAt.Add(c.ttl)
+	}
+	c.mu.Lock()
+	c.stores++
+	c.insert(stored)
+	backend := c.backend
+	c.mu.Unlock()
+	if backend != nil {
+		return backend.Put(ctx, stored)
+	}
+	return nil
+}
+
+// Lookup returns a copy of the entry for key without affecting hit statistics or LRU order.
+func (c *Cache) Lookup(ctx context.Context, key string) (*Entry, error) {
+	if c == nil || key == "" {
+		return nil, ErrNotFound
+	}
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruItem).entry
+		if !entry.Expired(time.Now()) {
+			c.mu.Unlock()
+			return cloneEntry(entry), nil
+		}
+	}
+	backend := c.backend
+	c.mu.Unlock()
+	if backend == nil {
+		return nil, ErrNotFound
+	}
+	return backend.Get(ctx, key)
+}
+
+// Delete removes key from memory and the backend.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if c == nil || key == "" {
+		return ErrNotFound
+	}
+	c.mu.Lock()
+	elem, found := c.items[key]
+	if found {
+		c.removeElement(elem)
+	}
+	backend := c.backend
+	c.mu.Unlock()
+	if backend != nil {
+		if err := backend.Delete(ctx, key); err != nil {
+			if errors.Is(err, ErrNotFound) && found {
+				return nil
+			}
+			return err
+		}
+		return nil
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Purge removes every entry and returns how many were removed. When a backend is
+// configured the backend count is reported because it is the superset.
+func (c *Cache) Purge(ctx context.Context) (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+	c.mu.Lock()
+	removed := len(c.items)
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	backend := c.backend
+	c.mu.Unlock()
+	if backend != nil {
+		return backend.Purge(ctx)
+	}
+	return removed, nil
+}
+
+// Entries lists the live in-memory entries, most recently used first.
+func (c *Cache) Entries() []Summary {
+	if c == nil {
+		return nil
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Summary, 0, len(c.items))
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		item := elem.Value.(*lruItem)
+		if item.entry.Expired(now) {
+			continue
+		}
+		out = append(out, Summary{
+			Key:       item.entry.Key,
+			Handler:   item.entry.Handler,
+			Model:     item.entry.Model,
+			Stream:    item.entry.Stream,
+			Size:      item.entry.Size(),
+			Hits:      item.hits,
+			CreatedAt: item.entry.CreatedAt,
+			ExpiresAt: item.entry.ExpiresAt,
+		})
+	}
+	return out
+}
+
+// Stats returns the current counters.
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := Stats{
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Stores:     c.stores,
+		Evictions:  c.evictions,
+		Entries:    len(c.items),
+		MaxEntries: c.maxEntries,
+		Backend:    c.name,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+	return stats
+}
+
+// ResetStats zeroes the hit, miss, store and eviction counters.
+func (c *Cache) ResetStats() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.hits, c.misses, c.stores, c.evictions = 0, 0, 0, 0
+	c.mu.Unlock()
+}
+
+// insert adds or replaces entry and evicts the least recently used items. Callers hold c.mu.
+func (c *Cache) insert(entry *Entry) {
+	if elem, ok := c.items[entry.Key]; ok {
+		elem.Value.(*lruItem).entry = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[entry.Key] = c.order.PushFront(&lruItem{entry: entry})
+	for len(c.items) > c.maxEntries {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		c.removeElement(oldest)
+		c.evictions++
+	}
+}
+
+// removeElement drops elem from the LRU. Callers hold c.mu.
+func (c *Cache) removeElement(elem *list.Element) {
+	item := elem.Value.(*lruItem)
+	c.order.Remove(elem)
+	delete(c.items, item.entry.Key)
+}
+
+var (
+	registryMu sync.RWMutex
+	registered *Cache
+)
+
+// Register sets the process-wide response cache.
+func Register(cache *Cache) {
+	registryMu.Lock()
+	registered = cache
+	registryMu.Unlock()
+}
+
+// Default returns the process-wide response cache, lazily creating an in-memory cache.
+func Default() *Cache {
+	registryMu.RLock()
+	cache := registered
+	registryMu.RUnlock()
+	if cache != nil {
+		return cache
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registered == nil {
+		registered = New(DefaultTTL, DefaultMaxEntries, nil, "memory")
+	}
+	return registered
+}
+
+// ParseTTL converts a duration string into a TTL, falling back to DefaultTTL.
+func ParseTTL(raw string) time.Duration {
+	return jsonstore.ParseTTL(raw, DefaultTTL)
+}
+
+func cloneEntry(entry *Entry) *Entry {
+	if entry == nil {
+		return nil
+	}
+	out := *entry
+	out.Body = cloneBytes(entry.Body)
+	if entry.Chunks != nil {
+		out.Chunks = make([][]byte, len(entry.Chunks))
+		for i, chunk := range entry.Chunks {
+			out.Chunks[i] = cloneBytes(chunk)
+		}
+	}
+	if entry.Headers != nil {
+		out.Headers = entry.Headers.Clone()
+	}
+	return &out
+}
+
+func cloneBytes(src []byte) []byte {
+	if src == nil {
+		return nil
+	}
+	dst := make([]byte, len(src))
+	copy(dst, src)
+	return dst
+}
@@ -0,0 +1,97 @@
+package responsecache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeyIgnoresFieldOrderAndVolatileFields(t *testing.T) {
+	a := Key("sk-1", "openai", "gpt-5", "", false, []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}],"temperature":0.2,"user":"ci-1"}`))
+	b := Key("sk-1", "openai", "gpt-5", "", false, []byte(`{ "temperature": 0.2, "messages": [{"content":"hi","role":"user"}], "model": "gpt-5", "user": "ci-2" }`))
+	if a != b {
+		t.Fatalf("expected equal keys for equivalent requests")
+	}
+	if c := Key("sk-1", "openai", "gpt-5", "", false, []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}],"temperature":0.3}`)); c == a {
+		t.Fatalf("expected sampling params to change the key")
+	}
+	if d := Key("sk-1", "openai", "gpt-5", "", true, []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}],"temperature":0.2}`)); d == a {
+		t.Fatalf("expected streaming mode to change the key")
+	}
+	if e := Key("sk-2", "openai", "gpt-5", "", false, []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}],"temperature":0.2}`)); e == a {
+		t.Fatalf("expected scope to change the key")
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := New(time.Hour, 2, nil, "")
+	ctx := context.Background()
+	for _, key := range []string{"aa", "bb"} {
+		if err := cache.Put(ctx, &Entry{Key: key, Body: []byte(key)}); err != nil {
+			t.Fatalf("Put(%s) error = %v", key, err)
+		}
+	}
+	if _, ok := cache.Get(ctx, "aa"); !ok {
+		t.Fatalf("expected hit for aa")
+	}
+	if err := cache.Put(ctx, &Entry{Key: "cc", Body: []byte("cc")}); err != nil {
+		t.Fatalf("Put(cc) error = %v", err)
+	}
+	if _, ok := cache.Get(ctx, "bb"); ok {
+		t.Fatalf("expected bb to be evicted")
+	}
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 1 || stats.Entries != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if stats.HitRate != 0.5 {
+		t.Fatalf("HitRate = %v, want 0.5", stats.HitRate)
+	}
+}
+
+func TestCacheExpiresEntries(t *testing.T) {
+	cache := New(time.Hour, 10, nil, "")
+	ctx := context.Background()
+	past := time.Now().Add(-2 * time.Hour)
+	if err := cache.Put(ctx, &Entry{Key: "aa", CreatedAt: past, ExpiresAt: past.Add(time.Hour)}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, ok := cache.Get(ctx, "aa"); ok {
+		t.Fatalf("expected expired entry to miss")
+	}
+	if len(cache.Entries()) != 0 {
+		t.Fatalf("expected expired entry to be dropped")
+	}
+}
+
+func TestCacheFallsThroughToFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+	key := Key("", "claude", "claude-sonnet-4", "", true, []byte(`{"messages":[]}`))
+	writer := New(time.Hour, 10, store, "file")
+	if err = writer.Put(ctx, &Entry{Key: key, Stream: true, Chunks: [][]byte{[]byte("a"), []byte("b")}}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	reader := New(time.Hour, 10, store, "file")
+	got, ok := reader.Get(ctx, key)
+	if !ok {
+		t.Fatalf("expected hit from file store")
+	}
+	if len(got.Chunks) != 2 || string(got.Chunks[1]) != "b" {
+		t.Fatalf("unexpected chunks: %q", got.Chunks)
+	}
+	if removed, errPurge := reader.Purge(ctx); errPurge != nil || removed != 1 {
+		t.Fatalf("Purge() = %d, %v; want 1, nil", removed, errPurge)
+	}
+	if _, errGet := store.Get(ctx, key); !errors.Is(errGet, ErrNotFound) {
+		t.Fatalf("Get() after purge error = %v, want ErrNotFound", errGet)
+	}
+	if _, errGet := store.Get(ctx, "../escape"); !errors.Is(errGet, ErrNotFound) {
+		t.Fatalf("expected non-hex keys to be rejected, got %v", errGet)
+	}
+}
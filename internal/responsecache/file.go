@@ -0,0 +1,87 @@
+package responsecache
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/jsonstore"
+)
+
+// FileStore persists cache entries as JSON files inside a directory.
+// Expired entries are removed lazily when they are read.
+type FileStore struct {
+	dir *jsonstore.Dir
+}
+
+// NewFileStore creates a file-backed store rooted at dir.
+func NewFileStore(dir string) (*FileStore, error) {
+	d, err := jsonstore.OpenDir(dir, "response cache", "entry")
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: d}, nil
+}
+
+// Get implements Store.
+func (s *FileStore) Get(_ context.Context, key string) (*Entry, error) {
+	name, ok := s.nameFor(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var entry Entry
+	found, err := s.dir.Load(name, &entry)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return &entry, nil
+}
+
+// Put implements Store.
+func (s *FileStore) Put(_ context.Context, entry *Entry) error {
+	if entry == nil {
+		return nil
+	}
+	name, ok := s.nameFor(entry.Key)
+	if !ok {
+		return nil
+	}
+	return s.dir.Save(name, entry)
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(_ context.Context, key string) error {
+	name, ok := s.nameFor(key)
+	if !ok {
+		return ErrNotFound
+	}
+	removed, err := s.dir.Remove(name)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Purge implements Store.
+func (s *FileStore) Purge(_ context.Context) (int, error) {
+	return s.dir.Purge()
+}
+
+// nameFor maps a cache key to a file name. Only hex digests produced by Key are accepted
+// so that keys can never escape the store directory.
+func (s *FileStore) nameFor(key string) (string, bool) {
+	key = strings.TrimSpace(key)
+	if s == nil || key == "" {
+		return "", false
+	}
+	if _, err := hex.DecodeString(key); err != nil {
+		return "", false
+	}
+	return key, true
+}
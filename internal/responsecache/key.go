@@ -0,0 +1,70 @@
+package responsecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// volatileFields are request fields that do not influence the generated content and are
+// therefore excluded from the cache key.
+var volatileFields = []string{
+	"stream",
+	"stream_options",
+	"user",
+	"metadata",
+	"safety_identifier",
+	"prompt_cache_key",
+	"store",
+}
+
+// Key returns the normalized request hash for a client request. The hash covers the
+// caller scope (usually the client API key), handler format, model, alt, streaming mode and
+// the request body with object keys sorted and volatile fields removed, so that messages,
+// tools and sampling parameters all participate while field order and whitespace do not.
+func Key(scope, handlerType, model, alt string, stream bool, payload []byte) string {
+	h := sha256.New()
+	write := func(part string) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	write(scope)
+	write(strings.ToLower(strings.TrimSpace(handlerType)))
+	write(strings.TrimSpace(model))
+	write(strings.TrimSpace(alt))
+	if stream {
+		write("stream")
+	} else {
+		write("once")
+	}
+	h.Write(normalizePayload(payload))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizePayload returns a canonical JSON encoding of payload. Non-JSON payloads are
+// hashed verbatim.
+func normalizePayload(payload []byte) []byte {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return trimmed
+	}
+	if obj, ok := value.(map[string]any); ok {
+		for _, field := range volatileFields {
+			delete(obj, field)
+		}
+	}
+	// encoding/json writes map keys in sorted order, which gives a stable encoding.
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return trimmed
+	}
+	return canonical
+}
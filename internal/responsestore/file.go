@@ -0,0 +1,93 @@
+package responsestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/jsonstore"
+)
+
+// FileStore persists response records as JSON files inside a directory.
+// Expired records are removed lazily when they are read.
+type FileStore struct {
+	dir *jsonstore.Dir
+	ttl time.Duration
+}
+
+// NewFileStore creates a file-backed store rooted at dir. A ttl <= 0 uses DefaultTTL.
+func NewFileStore(dir string, ttl time.Duration) (*FileStore, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	d, err := jsonstore.OpenDir(dir, "response store", "record")
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: d, ttl: ttl}, nil
+}
+
+// Get implements Store.
+func (s *FileStore) Get(_ context.Context, id string) (*Record, error) {
+	name, ok := s.nameFor(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var record Record
+	found, err := s.dir.Load(name, &record)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return &record, nil
+}
+
+// Put implements Store.
+func (s *FileStore) Put(_ context.Context, record *Record) error {
+	if record == nil {
+		return nil
+	}
+	name, ok := s.nameFor(record.ID)
+	if !ok {
+		return nil
+	}
+	stored := cloneRecord(record)
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = time.Now()
+	}
+	if stored.ExpiresAt.IsZero() {
+		stored.ExpiresAt = stored.CreatedAt.Add(s.ttl)
+	}
+	return s.dir.Save(name, stored)
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(_ context.Context, id string) error {
+	name, ok := s.nameFor(id)
+	if !ok {
+		return ErrNotFound
+	}
+	removed, err := s.dir.Remove(name)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// nameFor maps a response ID to a file name. IDs are hashed so that arbitrary
+// upstream identifiers can never escape the store directory.
+func (s *FileStore) nameFor(id string) (string, bool) {
+	id = strings.TrimSpace(id)
+	if s == nil || id == "" {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:]), true
+}
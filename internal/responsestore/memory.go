@@ -0,0 +1,104 @@
+package responsestore
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryCleanupInterval controls how often expired in-memory records are purged.
+const memoryCleanupInterval = 10 * time.Minute
+
+// MemoryStore keeps response records in process memory with a fixed TTL.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	ttl         time.Duration
+	entries     map[string]*Record
+	cleanupOnce sync.Once
+}
+
+// NewMemoryStore creates an in-memory store. A ttl <= 0 uses DefaultTTL.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &MemoryStore{
+		ttl:     ttl,
+		entries: make(map[string]*Record),
+	}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) (*Record, error) {
+	id = strings.TrimSpace(id)
+	if s == nil || id == "" {
+		return nil, ErrNotFound
+	}
+	s.mu.RLock()
+	record, ok := s.entries[id]
+	s.mu.RUnlock()
+	if !ok || record.Expired(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return cloneRecord(record), nil
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(_ context.Context, record *Record) error {
+	if s == nil || record == nil || strings.TrimSpace(record.ID) == "" {
+		return nil
+	}
+	s.cleanupOnce.Do(s.startCleanup)
+	stored := cloneRecord(record)
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = time.Now()
+	}
+	if stored.ExpiresAt.IsZero() {
+		stored.ExpiresAt = stored.CreatedAt.Add(s.ttl)
+	}
+	s.mu.Lock()
+	s.entries[stored.ID] = stored
+	s.mu.Unlock()
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	id = strings.TrimSpace(id)
+	if s == nil || id == "" {
+		return ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.entries, id)
+	if record.Expired(time.Now()) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// startCleanup launches a background goroutine that periodically purges expired records.
+func (s *MemoryStore) startCleanup() {
+	go func() {
+		ticker := time.NewTicker(memoryCleanupInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.purgeExpired(time.Now())
+		}
+	}()
+}
+
+func (s *MemoryStore) purgeExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, record := range s.entries {
+		if record.Expired(now) {
+			delete(s.entries, id)
+		}
+	}
+}
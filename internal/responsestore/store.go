@@ -0,0 +1,111 @@
+// Package responsestore keeps server-side conversation state for the HTTP Responses API.
+// It records the expanded input and final output of stored responses so that follow-up
+// requests referencing previous_response_id can be replayed against any backend.
+package responsestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/jsonstore"
+)
+
+// DefaultTTL is how long stored responses are retained when no TTL is configured.
+const DefaultTTL = 24 * time.Hour
+
+// ErrNotFound is returned when a response ID is unknown or has expired.
+var ErrNotFound = errors.New("response not found")
+
+// Record captures a completed response together with the input that produced it.
+type Record struct {
+	// ID is the response identifier returned to the client.
+	ID string `json:"id"`
+	// Owner is the hashed client principal (see util.HashAPIKey) that created the response, if known.
+	Owner string `json:"owner,omitempty"`
+	// Model is the client-facing model name used for the request.
+	Model string `json:"model"`
+	// Input is the fully expanded input item array sent upstream.
+	Input json.RawMessage `json:"input"`
+	// Output is the output item array of the completed response.
+	Output json.RawMessage `json:"output"`
+	// Response is the complete response object as returned to the client.
+	Response json.RawMessage `json:"response"`
+	// CreatedAt records when the response was stored.
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt marks when the record becomes invalid. Zero means no expiry.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired reports whether the record is past its expiry at the given time.
+func (r *Record) Expired(now time.Time) bool {
+	if r == nil {
+		return true
+	}
+	return !r.ExpiresAt.IsZero() && !now.Before(r.ExpiresAt)
+}
+
+// Store persists response records.
+type Store interface {
+	// Get returns the record for id or ErrNotFound.
+	Get(ctx context.Context, id string) (*Record, error)
+	// Put stores or replaces a record.
+	Put(ctx context.Context, record *Record) error
+	// Delete removes a record. It returns ErrNotFound when the id is unknown.
+	Delete(ctx context.Context, id string) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registered Store
+)
+
+// RegisterStore sets the process-wide response store.
+func RegisterStore(store Store) {
+	registryMu.Lock()
+	registered = store
+	registryMu.Unlock()
+}
+
+// GetStore returns the process-wide response store, lazily creating an in-memory store.
+func GetStore() Store {
+	registryMu.RLock()
+	store := registered
+	registryMu.RUnlock()
+	if store != nil {
+		return store
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registered == nil {
+		registered = NewMemoryStore(DefaultTTL)
+	}
+	return registered
+}
+
+// ParseTTL converts a duration string into a TTL, falling back to DefaultTTL.
+func ParseTTL(raw string) time.Duration {
+	return jsonstore.ParseTTL(raw, DefaultTTL)
+}
+
+func cloneRecord(record *Record) *Record {
+	if record == nil {
+		return nil
+	}
+	out := *record
+	out.Input = cloneBytes(record.Input)
+	out.Output = cloneBytes(record.Output)
+	out.Response = cloneBytes(record.Response)
+	return &out
+}
+
+func cloneBytes(src []byte) []byte {
+	if src == nil {
+		return nil
+	}
+	dst := make([]byte, len(src))
+	copy(dst, src)
+	return dst
+}
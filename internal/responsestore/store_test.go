@@ -0,0 +1,95 @@
+package responsestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreRoundTripAndDelete(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+
+	record := &Record{ID: "resp_1", Model: "gpt-5", Input: []byte(`[{"role":"user","content":"hi"}]`), Output: []byte(`[]`)}
+	if err := store.Put(ctx, record); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	record.Input[0] = 'x'
+
+	got, err := store.Get(ctx, "resp_1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(got.Input) != `[{"role":"user","content":"hi"}]` {
+		t.Fatalf("stored input mutated through caller slice: %s", got.Input)
+	}
+	if got.ExpiresAt.IsZero() {
+		t.Fatalf("expected ExpiresAt to be set")
+	}
+
+	if err = store.Delete(ctx, "resp_1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err = store.Get(ctx, "resp_1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() after delete error = %v, want ErrNotFound", err)
+	}
+	if err = store.Delete(ctx, "resp_1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryStoreExpiresRecords(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+	past := time.Now().Add(-2 * time.Hour)
+	if err := store.Put(ctx, &Record{ID: "resp_old", CreatedAt: past, ExpiresAt: past.Add(time.Hour)}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, err := store.Get(ctx, "resp_old"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() error = %v, want ErrNotFound", err)
+	}
+	store.purgeExpired(time.Now())
+	if len(store.entries) != 0 {
+		t.Fatalf("expected expired entry to be purged, got %d entries", len(store.entries))
+	}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if err = store.Put(ctx, &Record{ID: "../escape", Model: "claude-sonnet-4", Output: []byte(`[{"type":"message"}]`)}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	got, err := store.Get(ctx, "../escape")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Model != "claude-sonnet-4" || string(got.Output) != `[{"type":"message"}]` {
+		t.Fatalf("unexpected record: %+v", got)
+	}
+	if err = store.Delete(ctx, "../escape"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err = store.Get(ctx, "../escape"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      DefaultTTL,
+		"bogus": DefaultTTL,
+		"-1h":   DefaultTTL,
+		"30m":   30 * time.Minute,
+	}
+	for raw, want := range cases {
+		if got := ParseTTL(raw); got != want {
+			t.Fatalf("ParseTTL(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// AzureOpenAIExecutor is a stateless executor for Azure OpenAI resources. The upstream model
+// is the deployment name: Chat Completions go to /openai/deployments/{deployment}, while
+// OpenAI Responses clients are served natively by /openai/responses with the deployment as
+// the model. Requests authenticate with the api-key header.
+type AzureOpenAIExecutor struct {
+	cfg *config.Config
+}
+
+// NewAzureOpenAIExecutor creates a new Azure OpenAI executor.
+func NewAzureOpenAIExecutor(cfg *config.Config) *AzureOpenAIExecutor {
+	return &AzureOpenAIExecutor{cfg: cfg}
+}
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *AzureOpenAIExecutor) Identifier() string { return "azure-openai" }
+
+// PrepareRequest injects the Azure api-key header into the outgoing HTTP request.
+func (e *AzureOpenAIExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	_, apiKey, _ := azureOpenAICreds(auth)
+	if apiKey != "" {
+		req.Header.Set("api-key", apiKey)
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	return nil
+}
+
+// HttpRequest injects Azure credentials into the request and executes it.
+func (e *AzureOpenAIExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("azure openai executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+func (e *AzureOpenAIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == embeddingsAlt {
+		return e.executeEmbeddings(ctx, auth, req, opts)
+	}
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := azureOpenAITargetFormat(from)
+	translated, err := e.translateRequest(req, opts, to, opts.Stream)
+	if err != nil {
+		return resp, err
+	}
+
+	httpResp, err := e.send(ctx, auth, baseModel, to, translated, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, body)
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(body))
+	reporter.EnsurePublished(ctx)
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, body, &param)
+	resp = cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}
+	return resp, nil
+}
+
+func (e *AzureOpenAIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := azureOpenAITargetFormat(from)
+	translated, err := e.translateRequest(req, opts, to, true)
+	if err != nil {
+		return nil, err
+	}
+	responsesAPI := to == sdktranslator.FormatOpenAIResponse
+	if !responsesAPI {
+		translated, _ = sjson.SetBytes(translated, "stream_options.include_usage", true)
+	}
+
+	httpResp, err := e.send(ctx, auth, baseModel, to, translated, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("azure openai executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, 52_428_800) // 50MB
+		var param any
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
+			if responsesAPI {
+				// Responses streams are forwarded line by line, including event and blank lines.
+				if bytes.HasPrefix(line, dataTag) {
+					data := bytes.TrimSpace(line[len(dataTag):])
+					if gjson.GetBytes(data, "type").String() == "response.completed" {
+						if detail, ok := helps.ParseCodexUsage(data); ok {
+							reporter.Publish(ctx, detail)
+						}
+					}
+				}
+			} else {
+				if detail, ok := helps.ParseOpenAIStreamUsage(line); ok {
+					reporter.Publish(ctx, detail)
+				}
+				if !bytes.HasPrefix(line, dataTag) {
+					continue
+				}
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, bytes.Clone(line), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.PublishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+		reporter.EnsurePublished(ctx)
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+func (e *AzureOpenAIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+
+	enc, err := helps.TokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("azure openai executor: tokenizer init failed: %w", err)
+	}
+	count, err := helps.CountOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("azure openai executor: token counting failed: %w", err)
+	}
+	usageJSON := helps.BuildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: translatedUsage}, nil
+}
+
+// Refresh is a no-op for API-key based Azure resources.
+func (e *AzureOpenAIExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("azure openai executor: refresh called")
+	_ = ctx
+	return auth, nil
+}
+
+// executeEmbeddings forwards OpenAI embeddings requests to the deployment's embeddings endpoint.
+func (e *AzureOpenAIExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	if from := opts.SourceFormat.String(); from != "openai" {
+		err = statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("embeddings not supported for %s requests", from)}
+		return resp, err
+	}
+	baseURL, apiKey, apiVersion := azureOpenAICreds(auth)
+	if baseURL == "" {
+		err = statusErr{code: http.StatusUnauthorized, msg: "missing azure openai base-url"}
+		return resp, err
+	}
+	body, _ := sjson.SetBytes(req.Payload, "model", baseModel)
+	endpoint := azureOpenAIURL(baseURL, apiVersion, "deployments", url.PathEscape(baseModel), "embeddings")
+	data, headers, err := doEmbeddingsRequest(ctx, e.cfg, e.Identifier(), auth, endpoint, body, func(httpReq *http.Request) {
+		httpReq.Header.Set("api-key", apiKey)
+		var attrs map[string]string
+		if auth != nil {
+			attrs = auth.Attributes
+		}
+		util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	})
+	if err != nil {
+		if se, ok := errors.AsType[statusErr](err); ok {
+			err = newAzureOpenAIStatusErr(se.code, nil, []byte(se.msg))
+		}
+		return resp, err
+	}
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(data))
+	reporter.EnsurePublished(ctx)
+	resp = cliproxyexecutor.Response{Payload: data, Headers: headers}
+	return resp, nil
+}
+
+// translateRequest converts the client payload to the target format with the deployment as
+// the model and thinking and payload overrides applied.
+func (e *AzureOpenAIExecutor) translateRequest(req cliproxyexecutor.Request, opts cliproxyexecutor.Options, to sdktranslator.Format, stream bool) ([]byte, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	translated, _ = sjson.SetBytes(translated, "model", baseModel)
+	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
+	translated = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel)
+	return thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+}
+
+// send posts body to the Chat Completions or Responses endpoint of the deployment.
+func (e *AzureOpenAIExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, deployment string, to sdktranslator.Format, body []byte, stream bool) (*http.Response, error) {
+	baseURL, apiKey, apiVersion := azureOpenAICreds(auth)
+	if baseURL == "" {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: "missing azure openai base-url"}
+	}
+	var endpoint string
+	if to == sdktranslator.FormatOpenAIResponse {
+		endpoint = azureOpenAIURL(baseURL, apiVersion, "responses")
+	} else {
+		endpoint = azureOpenAIURL(baseURL, apiVersion, "deployments", url.PathEscape(deployment), "chat", "completions")
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("api-key", apiKey)
+	httpReq.Header.Set("User-Agent", "cli-proxy-azure-openai")
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("Cache-Control", "no-cache")
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       endpoint,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+		return nil, newAzureOpenAIStatusErr(httpResp.StatusCode, httpResp.Header, b)
+	}
+	return httpResp, nil
+}
+
+// azureOpenAITargetFormat serves OpenAI Responses clients through the Azure Responses API
+// and everything else through Chat Completions.
+func azureOpenAITargetFormat(from sdktranslator.Format) sdktranslator.Format {
+	if from == sdktranslator.FormatOpenAIResponse {
+		return sdktranslator.FormatOpenAIResponse
+	}
+	return sdktranslator.FormatOpenAI
+}
+
+func azureOpenAIURL(baseURL, apiVersion string, segments ...string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/openai/" + strings.Join(segments, "/") + "?api-version=" + url.QueryEscape(apiVersion)
+}
+
+func azureOpenAICreds(auth *cliproxyauth.Auth) (baseURL, apiKey, apiVersion string) {
+	if auth != nil && auth.Attributes != nil {
+		baseURL = strings.TrimSpace(auth.Attributes["base_url"])
+		apiKey = strings.TrimSpace(auth.Attributes["api_key"])
+		apiVersion = strings.TrimSpace(auth.Attributes["api_version"])
+	}
+	if apiVersion == "" {
+		apiVersion = config.DefaultAzureOpenAIAPIVersion
+	}
+	return baseURL, apiKey, apiVersion
+}
+
+// isAzureContentFilterError reports whether body is an Azure content-management rejection.
+func isAzureContentFilterError(body []byte) bool {
+	root := gjson.ParseBytes(body)
+	return root.Get("error.code").String() == "content_filter" ||
+		root.Get("error.innererror.code").String() == "ResponsibleAIPolicyViolation"
+}
+
+// newAzureOpenAIStatusErr maps an Azure error response to a status error. Content-filter
+// rejections are reported as 400 invalid_request_error so the conductor returns them to
+// the client instead of retrying or cooling down the deployment. Rate limits carry the
+// retry-after-ms or retry-after hint.
+func newAzureOpenAIStatusErr(status int, headers http.Header, body []byte) statusErr {
+	if isAzureContentFilterError(body) {
+		out, errSet := sjson.SetBytes(body, "error.type", "invalid_request_error")
+		if errSet != nil {
+			out = body
+		}
+		return statusErr{code: http.StatusBadRequest, msg: string(out)}
+	}
+	err := statusErr{code: status, msg: string(body)}
+	if status == http.StatusTooManyRequests && headers != nil {
+		if ms, errParse := strconv.ParseInt(strings.TrimSpace(headers.Get("retry-after-ms")), 10, 64); errParse == nil && ms > 0 {
+			d := time.Duration(ms) * time.Millisecond
+			err.retryAfter = &d
+		} else if secs, errParse := strconv.ParseInt(strings.TrimSpace(headers.Get("Retry-After")), 10, 64); errParse == nil && secs > 0 {
+			d := time.Duration(secs) * time.Second
+			err.retryAfter = &d
+		}
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestAzureOpenAIExecutorRoutesByDeployment(t *testing.T) {
+	var gotURL, gotKey, gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		gotKey = r.Header.Get("api-key")
+		body, _ := io.ReadAll(r.Body)
+		gotModel = gjson.GetBytes(body, "model").String()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-ratelimit-remaining-requests", "99")
+		_, _ = w.Write([]byte(`{"id":"x","object":"response","output":[],"usage":{"input_tokens":1,"output_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	executor := NewAzureOpenAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"base_url":    server.URL,
+		"api_key":     "azure-key",
+		"api_version": "2025-04-01-preview",
+	}}
+	payload := []byte(`{"model":"gpt-4o","input":"hi"}`)
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response")}
+	resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "prod-gpt4o", Payload: payload}, opts)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotURL != "/openai/responses?api-version=2025-04-01-preview" || gotKey != "azure-key" || gotModel != "prod-gpt4o" {
+		t.Fatalf("unexpected upstream request: url=%s key=%s model=%s", gotURL, gotKey, gotModel)
+	}
+	if resp.Headers.Get("x-ratelimit-remaining-requests") != "99" {
+		t.Fatalf("rate-limit headers not propagated: %v", resp.Headers)
+	}
+
+	opts.SourceFormat = sdktranslator.FromString("openai")
+	if _, err = executor.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "prod-gpt4o", Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)}, opts); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotURL != "/openai/deployments/prod-gpt4o/chat/completions?api-version=2025-04-01-preview" {
+		t.Fatalf("chat url = %s", gotURL)
+	}
+}
+
+func TestAzureOpenAIStatusErrMapping(t *testing.T) {
+	filtered := newAzureOpenAIStatusErr(http.StatusBadRequest, nil, []byte(`{"error":{"message":"The response was filtered","type":null,"code":"content_filter","status":400}}`))
+	if filtered.StatusCode() != http.StatusBadRequest || !strings.Contains(filtered.Error(), "invalid_request_error") {
+		t.Fatalf("content filter not mapped to a request error: %d %s", filtered.StatusCode(), filtered.Error())
+	}
+	if gjson.Get(filtered.Error(), "error.code").String() != "content_filter" {
+		t.Fatalf("content filter details lost: %s", filtered.Error())
+	}
+
+	headers := http.Header{}
+	headers.Set("retry-after-ms", "1500")
+	limited := newAzureOpenAIStatusErr(http.StatusTooManyRequests, headers, []byte(`{"error":{"code":"429","message":"Rate limit"}}`))
+	if limited.RetryAfter() == nil || *limited.RetryAfter() != 1500*time.Millisecond {
+		t.Fatalf("retry-after-ms not honoured: %v", limited.RetryAfter())
+	}
+}
@@ -0,0 +1,405 @@
+package executor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// bedrockAnthropicVersion is the Messages API version Bedrock requires in invoke payloads.
+const bedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// isBedrockAnthropicModel reports whether modelID names an Anthropic model or inference
+// profile, which accept native Claude Messages payloads on the invoke APIs.
+func isBedrockAnthropicModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "anthropic.")
+}
+
+// buildBedrockInvokeBody adapts a Claude Messages payload to the invoke API: the model and
+// stream flag move to the URL, anthropic_version is required and betas are sent inline.
+func buildBedrockInvokeBody(body []byte) []byte {
+	betas, body := extractAndRemoveBetas(body)
+	body, _ = sjson.DeleteBytes(body, "model")
+	body, _ = sjson.DeleteBytes(body, "stream")
+	body, _ = sjson.SetBytes(body, "anthropic_version", bedrockAnthropicVersion)
+	if len(betas) > 0 {
+		body, _ = sjson.SetBytes(body, "anthropic_beta", betas)
+	}
+	return body
+}
+
+// convertClaudeRequestToConverse maps a Claude Messages payload onto the Bedrock Converse
+// request shape shared by /converse and /converse-stream.
+func convertClaudeRequestToConverse(body []byte) []byte {
+	root := gjson.ParseBytes(body)
+	out := []byte(`{"messages":[]}`)
+
+	system := root.Get("system")
+	if system.Type == gjson.String && system.String() != "" {
+		out, _ = sjson.SetRawBytes(out, "system", []byte(`[]`))
+		out, _ = sjson.SetBytes(out, "system.-1", map[string]string{"text": system.String()})
+	} else if system.IsArray() {
+		out, _ = sjson.SetRawBytes(out, "system", []byte(`[]`))
+		system.ForEach(func(_, block gjson.Result) bool {
+			if text := block.Get("text").String(); text != "" {
+				out, _ = sjson.SetBytes(out, "system.-1", map[string]string{"text": text})
+			}
+			return true
+		})
+	}
+
+	root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+		msg := []byte(`{"role":"","content":[]}`)
+		msg, _ = sjson.SetBytes(msg, "role", message.Get("role").String())
+		content := message.Get("content")
+		if content.Type == gjson.String {
+			msg, _ = sjson.SetBytes(msg, "content.-1", map[string]string{"text": content.String()})
+		} else {
+			content.ForEach(func(_, block gjson.Result) bool {
+				if converted := convertClaudeBlockToConverse(block); converted != nil {
+					msg, _ = sjson.SetRawBytes(msg, "content.-1", converted)
+				}
+				return true
+			})
+		}
+		out, _ = sjson.SetRawBytes(out, "messages.-1", msg)
+		return true
+	})
+
+	if v := root.Get("max_tokens"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "inferenceConfig.maxTokens", v.Int())
+	}
+	if v := root.Get("temperature"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "inferenceConfig.temperature", v.Float())
+	}
+	if v := root.Get("top_p"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "inferenceConfig.topP", v.Float())
+	}
+	if v := root.Get("stop_sequences"); v.IsArray() {
+		out, _ = sjson.SetRawBytes(out, "inferenceConfig.stopSequences", []byte(v.Raw))
+	}
+	if v := root.Get("top_k"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "additionalModelRequestFields.top_k", v.Int())
+	}
+	if v := root.Get("thinking"); v.IsObject() && v.Get("type").String() == "enabled" {
+		out, _ = sjson.SetRawBytes(out, "additionalModelRequestFields.thinking", []byte(v.Raw))
+	}
+
+	toolChoice := root.Get("tool_choice.type").String()
+	if tools := root.Get("tools"); tools.IsArray() && len(tools.Array()) > 0 && toolChoice != "none" {
+		out, _ = sjson.SetRawBytes(out, "toolConfig.tools", []byte(`[]`))
+		tools.ForEach(func(_, tool gjson.Result) bool {
+			spec := []byte(`{"toolSpec":{"name":"","inputSchema":{"json":{"type":"object"}}}}`)
+			spec, _ = sjson.SetBytes(spec, "toolSpec.name", tool.Get("name").String())
+			if desc := tool.Get("description").String(); desc != "" {
+				spec, _ = sjson.SetBytes(spec, "toolSpec.description", desc)
+			}
+			if schema := tool.Get("input_schema"); schema.IsObject() {
+				spec, _ = sjson.SetRawBytes(spec, "toolSpec.inputSchema.json", []byte(schema.Raw))
+			}
+			out, _ = sjson.SetRawBytes(out, "toolConfig.tools.-1", spec)
+			return true
+		})
+		switch toolChoice {
+		case "auto":
+			out, _ = sjson.SetRawBytes(out, "toolConfig.toolChoice", []byte(`{"auto":{}}`))
+		case "any":
+			out, _ = sjson.SetRawBytes(out, "toolConfig.toolChoice", []byte(`{"any":{}}`))
+		case "tool":
+			out, _ = sjson.SetBytes(out, "toolConfig.toolChoice.tool.name", root.Get("tool_choice.name").String())
+		}
+	}
+	return out
+}
+
+// convertClaudeBlockToConverse converts one Claude content block. Unsupported blocks yield nil.
+func convertClaudeBlockToConverse(block gjson.Result) []byte {
+	switch block.Get("type").String() {
+	case "text":
+		out, _ := sjson.SetBytes([]byte(`{}`), "text", block.Get("text").String())
+		return out
+	case "image":
+		if block.Get("source.type").String() != "base64" {
+			return nil
+		}
+		format := strings.TrimPrefix(block.Get("source.media_type").String(), "image/")
+		if format == "jpg" {
+			format = "jpeg"
+		}
+		out := []byte(`{"image":{"format":"","source":{"bytes":""}}}`)
+		out, _ = sjson.SetBytes(out, "image.format", format)
+		out, _ = sjson.SetBytes(out, "image.source.bytes", block.Get("source.data").String())
+		return out
+	case "tool_use":
+		out := []byte(`{"toolUse":{"toolUseId":"","name":"","input":{}}}`)
+		out, _ = sjson.SetBytes(out, "toolUse.toolUseId", block.Get("id").String())
+		out, _ = sjson.SetBytes(out, "toolUse.name", block.Get("name").String())
+		if input := block.Get("input"); input.IsObject() {
+			out, _ = sjson.SetRawBytes(out, "toolUse.input", []byte(input.Raw))
+		}
+		return out
+	case "tool_result":
+		out := []byte(`{"toolResult":{"toolUseId":"","content":[]}}`)
+		out, _ = sjson.SetBytes(out, "toolResult.toolUseId", block.Get("tool_use_id").String())
+		content := block.Get("content")
+		if content.Type == gjson.String {
+			out, _ = sjson.SetBytes(out, "toolResult.content.-1", map[string]string{"text": content.String()})
+		} else {
+			content.ForEach(func(_, item gjson.Result) bool {
+				if converted := convertClaudeBlockToConverse(item); converted != nil {
+					out, _ = sjson.SetRawBytes(out, "toolResult.content.-1", converted)
+				}
+				return true
+			})
+		}
+		if block.Get("is_error").Bool() {
+			out, _ = sjson.SetBytes(out, "toolResult.status", "error")
+		}
+		return out
+	case "thinking":
+		out := []byte(`{"reasoningContent":{"reasoningText":{"text":""}}}`)
+		out, _ = sjson.SetBytes(out, "reasoningContent.reasoningText.text", block.Get("thinking").String())
+		if sig := block.Get("signature").String(); sig != "" {
+			out, _ = sjson.SetBytes(out, "reasoningContent.reasoningText.signature", sig)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// convertConverseResponseToClaude maps a /converse response onto a Claude message.
+func convertConverseResponseToClaude(model string, body []byte) []byte {
+	root := gjson.ParseBytes(body)
+	out := []byte(`{"id":"","type":"message","role":"assistant","model":"","content":[],"stop_reason":"","stop_sequence":null,"usage":{"input_tokens":0,"output_tokens":0}}`)
+	out, _ = sjson.SetBytes(out, "id", bedrockMessageID())
+	out, _ = sjson.SetBytes(out, "model", model)
+	root.Get("output.message.content").ForEach(func(_, block gjson.Result) bool {
+		switch {
+		case block.Get("text").Exists():
+			out, _ = sjson.SetBytes(out, "content.-1", map[string]string{"type": "text", "text": block.Get("text").String()})
+		case block.Get("toolUse").Exists():
+			item := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
+			item, _ = sjson.SetBytes(item, "id", block.Get("toolUse.toolUseId").String())
+			item, _ = sjson.SetBytes(item, "name", block.Get("toolUse.name").String())
+			if input := block.Get("toolUse.input"); input.IsObject() {
+				item, _ = sjson.SetRawBytes(item, "input", []byte(input.Raw))
+			}
+			out, _ = sjson.SetRawBytes(out, "content.-1", item)
+		case block.Get("reasoningContent.reasoningText").Exists():
+			item := []byte(`{"type":"thinking","thinking":"","signature":""}`)
+			item, _ = sjson.SetBytes(item, "thinking", block.Get("reasoningContent.reasoningText.text").String())
+			item, _ = sjson.SetBytes(item, "signature", block.Get("reasoningContent.reasoningText.signature").String())
+			out, _ = sjson.SetRawBytes(out, "content.-1", item)
+		}
+		return true
+	})
+	out, _ = sjson.SetBytes(out, "stop_reason", converseStopReason(root.Get("stopReason").String()))
+	out, _ = sjson.SetRawBytes(out, "usage", converseUsageToClaude(root.Get("usage")))
+	return out
+}
+
+// converseStopReason maps a Converse stopReason onto the Claude stop_reason vocabulary.
+func converseStopReason(reason string) string {
+	switch reason {
+	case "tool_use", "max_tokens", "stop_sequence":
+		return reason
+	default:
+		return "end_turn"
+	}
+}
+
+func converseUsageToClaude(node gjson.Result) []byte {
+	out := []byte(`{"input_tokens":0,"output_tokens":0}`)
+	out, _ = sjson.SetBytes(out, "input_tokens", node.Get("inputTokens").Int())
+	out, _ = sjson.SetBytes(out, "output_tokens", node.Get("outputTokens").Int())
+	if v := node.Get("cacheReadInputTokens"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "cache_read_input_tokens", v.Int())
+	}
+	if v := node.Get("cacheWriteInputTokens"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "cache_creation_input_tokens", v.Int())
+	}
+	return out
+}
+
+func bedrockMessageID() string {
+	return fmt.Sprintf("msg_bdrk_%d", time.Now().UnixNano())
+}
+
+// bedrockClaudeStream converts decoded Bedrock stream events into Claude SSE lines. Invoke
+// streams carry native Claude events; Converse streams are rebuilt into the same sequence.
+type bedrockClaudeStream struct {
+	model      string
+	invoke     bool
+	started    bool
+	finished   bool
+	blockTypes map[int]string
+	stopReason string
+}
+
+func newBedrockClaudeStream(model string, invoke bool) *bedrockClaudeStream {
+	return &bedrockClaudeStream{model: model, invoke: invoke, blockTypes: make(map[int]string)}
+}
+
+// claudeSSE renders a Claude stream event as SSE lines: event, data and the blank separator.
+func claudeSSE(eventType string, data []byte) [][]byte {
+	return [][]byte{
+		[]byte("event: " + eventType),
+		append([]byte("data: "), data...),
+		{},
+	}
+}
+
+// Decode converts one event-stream frame. Exception and error frames become status errors.
+func (s *bedrockClaudeStream) Decode(msg eventStreamMessage) ([][]byte, error) {
+	switch msg.MessageType() {
+	case "exception":
+		return nil, newBedrockStatusErr(http.StatusInternalServerError, msg.Headers[":exception-type"], msg.Payload)
+	case "error":
+		body, _ := sjson.SetBytes([]byte(`{}`), "message", msg.Headers[":error-message"])
+		return nil, newBedrockStatusErr(http.StatusInternalServerError, msg.Headers[":error-code"], body)
+	}
+	if !s.invoke {
+		return s.ConverseEvent(msg.EventType(), msg.Payload), nil
+	}
+	if msg.EventType() != "chunk" {
+		return nil, nil
+	}
+	return s.InvokeChunk(msg.Payload)
+}
+
+// InvokeChunk decodes an invoke-with-response-stream "chunk" payload.
+func (s *bedrockClaudeStream) InvokeChunk(payload []byte) ([][]byte, error) {
+	encoded := gjson.GetBytes(payload, "bytes").String()
+	event, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock executor: decode stream chunk: %w", err)
+	}
+	eventType := gjson.GetBytes(event, "type").String()
+	if eventType == "" {
+		return nil, nil
+	}
+	if eventType == "message_stop" {
+		s.finished = true
+	}
+	return claudeSSE(eventType, event), nil
+}
+
+// ConverseEvent converts one ConverseStream event.
+func (s *bedrockClaudeStream) ConverseEvent(eventType string, payload []byte) [][]byte {
+	root := gjson.ParseBytes(payload)
+	var lines [][]byte
+	switch eventType {
+	case "messageStart":
+		lines = append(lines, s.start()...)
+	case "contentBlockStart":
+		lines = append(lines, s.start()...)
+		index := int(root.Get("contentBlockIndex").Int())
+		if toolUse := root.Get("start.toolUse"); toolUse.Exists() {
+			block := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
+			block, _ = sjson.SetBytes(block, "id", toolUse.Get("toolUseId").String())
+			block, _ = sjson.SetBytes(block, "name", toolUse.Get("name").String())
+			lines = append(lines, s.openBlock(index, "tool_use", block)...)
+		}
+	case "contentBlockDelta":
+		lines = append(lines, s.start()...)
+		index := int(root.Get("contentBlockIndex").Int())
+		delta := root.Get("delta")
+		switch {
+		case delta.Get("text").Exists():
+			lines = append(lines, s.openBlock(index, "text", []byte(`{"type":"text","text":""}`))...)
+			lines = append(lines, s.blockDelta(index, "text_delta", "text", delta.Get("text").String())...)
+		case delta.Get("toolUse.input").Exists():
+			lines = append(lines, s.blockDelta(index, "input_json_delta", "partial_json", delta.Get("toolUse.input").String())...)
+		case delta.Get("reasoningContent.text").Exists():
+			lines = append(lines, s.openBlock(index, "thinking", []byte(`{"type":"thinking","thinking":""}`))...)
+			lines = append(lines, s.blockDelta(index, "thinking_delta", "thinking", delta.Get("reasoningContent.text").String())...)
+		case delta.Get("reasoningContent.signature").Exists():
+			lines = append(lines, s.openBlock(index, "thinking", []byte(`{"type":"thinking","thinking":""}`))...)
+			lines = append(lines, s.blockDelta(index, "signature_delta", "signature", delta.Get("reasoningContent.signature").String())...)
+		}
+	case "contentBlockStop":
+		index := int(root.Get("contentBlockIndex").Int())
+		if _, ok := s.blockTypes[index]; ok {
+			delete(s.blockTypes, index)
+			data, _ := sjson.SetBytes([]byte(`{"type":"content_block_stop","index":0}`), "index", index)
+			lines = append(lines, claudeSSE("content_block_stop", data)...)
+		}
+	case "messageStop":
+		s.stopReason = converseStopReason(root.Get("stopReason").String())
+	case "metadata":
+		lines = append(lines, s.finish(root.Get("usage"))...)
+	}
+	return lines
+}
+
+// Finish closes a Converse stream that ended without a metadata event.
+func (s *bedrockClaudeStream) Finish() [][]byte {
+	if !s.started || s.finished {
+		return nil
+	}
+	return s.finish(gjson.Result{})
+}
+
+func (s *bedrockClaudeStream) start() [][]byte {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	data := []byte(`{"type":"message_start","message":{"id":"","type":"message","role":"assistant","model":"","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":0,"output_tokens":0}}}`)
+	data, _ = sjson.SetBytes(data, "message.id", bedrockMessageID())
+	data, _ = sjson.SetBytes(data, "message.model", s.model)
+	return claudeSSE("message_start", data)
+}
+
+func (s *bedrockClaudeStream) openBlock(index int, blockType string, block []byte) [][]byte {
+	if _, ok := s.blockTypes[index]; ok {
+		return nil
+	}
+	s.blockTypes[index] = blockType
+	data := []byte(`{"type":"content_block_start","index":0,"content_block":{}}`)
+	data, _ = sjson.SetBytes(data, "index", index)
+	data, _ = sjson.SetRawBytes(data, "content_block", block)
+	return claudeSSE("content_block_start", data)
+}
+
+func (s *bedrockClaudeStream) blockDelta(index int, deltaType, field, value string) [][]byte {
+	data := []byte(`{"type":"content_block_delta","index":0,"delta":{}}`)
+	data, _ = sjson.SetBytes(data, "index", index)
+	data, _ = sjson.SetBytes(data, "delta.type", deltaType)
+	data, _ = sjson.SetBytes(data, "delta."+field, value)
+	return claudeSSE("content_block_delta", data)
+}
+
+func (s *bedrockClaudeStream) finish(usage gjson.Result) [][]byte {
+	if s.finished {
+		return nil
+	}
+	s.finished = true
+	indexes := make([]int, 0, len(s.blockTypes))
+	for index := range s.blockTypes {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	var lines [][]byte
+	for _, index := range indexes {
+		data, _ := sjson.SetBytes([]byte(`{"type":"content_block_stop","index":0}`), "index", index)
+		lines = append(lines, claudeSSE("content_block_stop", data)...)
+	}
+	s.blockTypes = make(map[int]string)
+	if s.stopReason == "" {
+		s.stopReason = "end_turn"
+	}
+	data := []byte(`{"type":"message_delta","delta":{"stop_reason":"","stop_sequence":null},"usage":{}}`)
+	data, _ = sjson.SetBytes(data, "delta.stop_reason", s.stopReason)
+	data, _ = sjson.SetRawBytes(data, "usage", converseUsageToClaude(usage))
+	lines = append(lines, claudeSSE("message_delta", data)...)
+	lines = append(lines, claudeSSE("message_stop", []byte(`{"type":"message_stop"}`))...)
+	return lines
+}
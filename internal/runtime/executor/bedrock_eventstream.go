@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// eventStreamPreludeLen covers the total length, headers length and prelude CRC fields.
+	eventStreamPreludeLen = 12
+	// eventStreamMaxMessageLen bounds a single frame to guard against corrupt length fields.
+	eventStreamMaxMessageLen = 16 << 20
+)
+
+// eventStreamMessage is a decoded frame of the AWS event-stream binary protocol
+// (application/vnd.amazon.eventstream). Only string headers are retained.
+type eventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// MessageType returns the ":message-type" header ("event", "exception" or "error").
+func (m eventStreamMessage) MessageType() string { return m.Headers[":message-type"] }
+
+// EventType returns the ":event-type" header of an event frame.
+func (m eventStreamMessage) EventType() string { return m.Headers[":event-type"] }
+
+// readEventStreamMessage reads one frame from r. It returns io.EOF when r is exhausted at a
+// frame boundary and io.ErrUnexpectedEOF when a frame is truncated.
+func readEventStreamMessage(r io.Reader) (eventStreamMessage, error) {
+	prelude := make([]byte, eventStreamPreludeLen)
+	if _, err := io.ReadFull(r, prelude); err != nil {
+		return eventStreamMessage{}, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return eventStreamMessage{}, errors.New("event stream: prelude checksum mismatch")
+	}
+	if totalLen < eventStreamPreludeLen+4 || totalLen > eventStreamMaxMessageLen || headersLen > totalLen-eventStreamPreludeLen-4 {
+		return eventStreamMessage{}, fmt.Errorf("event stream: invalid frame length %d (headers %d)", totalLen, headersLen)
+	}
+
+	frame := make([]byte, totalLen)
+	copy(frame, prelude)
+	if _, err := io.ReadFull(r, frame[eventStreamPreludeLen:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return eventStreamMessage{}, err
+	}
+	if crc32.ChecksumIEEE(frame[:totalLen-4]) != binary.BigEndian.Uint32(frame[totalLen-4:]) {
+		return eventStreamMessage{}, errors.New("event stream: message checksum mismatch")
+	}
+
+	headers, err := decodeEventStreamHeaders(frame[eventStreamPreludeLen : eventStreamPreludeLen+headersLen])
+	if err != nil {
+		return eventStreamMessage{}, err
+	}
+	return eventStreamMessage{
+		Headers: headers,
+		Payload: frame[eventStreamPreludeLen+headersLen : totalLen-4],
+	}, nil
+}
+
+// eventStreamValueSizes lists the encoded size of fixed-width header value types.
+var eventStreamValueSizes = map[byte]int{
+	0: 0,  // bool true
+	1: 0,  // bool false
+	2: 1,  // byte
+	3: 2,  // short
+	4: 4,  // int
+	5: 8,  // long
+	8: 8,  // timestamp
+	9: 16, // uuid
+}
+
+func decodeEventStreamHeaders(raw []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(raw) > 0 {
+		nameLen := int(raw[0])
+		if len(raw) < 1+nameLen+1 {
+			return nil, errors.New("event stream: truncated header")
+		}
+		name := string(raw[1 : 1+nameLen])
+		valueType := raw[1+nameLen]
+		raw = raw[2+nameLen:]
+		switch valueType {
+		case 6, 7: // byte array, string
+			if len(raw) < 2 {
+				return nil, errors.New("event stream: truncated header value")
+			}
+			valueLen := int(binary.BigEndian.Uint16(raw[:2]))
+			if len(raw) < 2+valueLen {
+				return nil, errors.New("event stream: truncated header value")
+			}
+			if valueType == 7 {
+				headers[name] = string(raw[2 : 2+valueLen])
+			}
+			raw = raw[2+valueLen:]
+		default:
+			size, ok := eventStreamValueSizes[valueType]
+			if !ok {
+				return nil, fmt.Errorf("event stream: unknown header value type %d", valueType)
+			}
+			if len(raw) < size {
+				return nil, errors.New("event stream: truncated header value")
+			}
+			raw = raw[size:]
+		}
+	}
+	return headers, nil
+}
@@ -0,0 +1,479 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// BedrockExecutor is a stateless executor for AWS Bedrock Runtime. Requests are translated to
+// Claude Messages and signed with SigV4. Anthropic models use the invoke APIs with the native
+// payload; every other model goes through Converse. Streams are decoded from the AWS
+// event-stream framing back into Claude SSE so the Claude translators handle the rest.
+type BedrockExecutor struct {
+	cfg *config.Config
+}
+
+// NewBedrockExecutor creates a new Bedrock executor.
+func NewBedrockExecutor(cfg *config.Config) *BedrockExecutor { return &BedrockExecutor{cfg: cfg} }
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *BedrockExecutor) Identifier() string { return "bedrock" }
+
+// PrepareRequest signs the outgoing HTTP request with the auth's AWS credentials.
+func (e *BedrockExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	creds, err := resolveBedrockCredentials(auth)
+	if err != nil {
+		return err
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return fmt.Errorf("bedrock executor: read request body: %w", err)
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	signAWSRequest(req, body, creds, bedrockRegion(auth), bedrockService, time.Now())
+	return nil
+}
+
+// HttpRequest signs the request with the auth's AWS credentials and executes it.
+func (e *BedrockExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("bedrock executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+func (e *BedrockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	if opts.Alt == embeddingsAlt {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/embeddings not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	// Use streaming translation to preserve function calling, except for claude.
+	stream := from != to
+	body, err := e.translateRequest(req, opts, stream)
+	if err != nil {
+		return resp, err
+	}
+
+	invoke := isBedrockAnthropicModel(baseModel)
+	httpResp, err := e.send(ctx, auth, baseModel, body, invoke, stream)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+	}()
+
+	var data []byte
+	if stream {
+		var sse bytes.Buffer
+		decoder := newBedrockClaudeStream(baseModel, invoke)
+		for {
+			lines, errRead := e.readStreamEvent(ctx, httpResp.Body, decoder)
+			if errors.Is(errRead, io.EOF) {
+				break
+			}
+			if errRead != nil {
+				helps.RecordAPIResponseError(ctx, e.cfg, errRead)
+				return resp, errRead
+			}
+			for _, line := range lines {
+				if detail, ok := helps.ParseClaudeStreamUsage(line); ok {
+					reporter.Publish(ctx, detail)
+				}
+				sse.Write(line)
+				sse.WriteByte('\n')
+			}
+		}
+		for _, line := range decoder.Finish() {
+			sse.Write(line)
+			sse.WriteByte('\n')
+		}
+		data = sse.Bytes()
+	} else {
+		data, err = io.ReadAll(httpResp.Body)
+		if err != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, err)
+			return resp, err
+		}
+		helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+		if !invoke {
+			data = convertConverseResponseToClaude(baseModel, data)
+		}
+		reporter.Publish(ctx, helps.ParseClaudeUsage(data))
+	}
+	reporter.EnsurePublished(ctx)
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	resp = cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}
+	return resp, nil
+}
+
+func (e *BedrockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body, err := e.translateRequest(req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	invoke := isBedrockAnthropicModel(baseModel)
+	httpResp, err := e.send(ctx, auth, baseModel, body, invoke, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bedrock reports throttling that happens after the response headers as an exception
+	// frame. Read the first frame before returning so such errors still reach the conductor
+	// as status errors and trigger the usual cooldown and retry handling.
+	decoder := newBedrockClaudeStream(baseModel, invoke)
+	first, errFirst := e.readStreamEvent(ctx, httpResp.Body, decoder)
+	if errFirst != nil && !errors.Is(errFirst, io.EOF) {
+		helps.RecordAPIResponseError(ctx, e.cfg, errFirst)
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+		return nil, errFirst
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("bedrock executor: close response body error: %v", errClose)
+			}
+		}()
+
+		var param any
+		emit := func(lines [][]byte) {
+			for _, line := range lines {
+				if detail, ok := helps.ParseClaudeStreamUsage(line); ok {
+					reporter.Publish(ctx, detail)
+				}
+				// If from == to (Claude → Claude), forward the SSE lines without translation.
+				if from == to {
+					cloned := make([]byte, len(line)+1)
+					copy(cloned, line)
+					cloned[len(line)] = '\n'
+					out <- cliproxyexecutor.StreamChunk{Payload: cloned}
+					continue
+				}
+				chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, bytes.Clone(line), &param)
+				for i := range chunks {
+					out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
+				}
+			}
+		}
+
+		emit(first)
+		errRead := errFirst
+		for errRead == nil {
+			var lines [][]byte
+			lines, errRead = e.readStreamEvent(ctx, httpResp.Body, decoder)
+			emit(lines)
+		}
+		if !errors.Is(errRead, io.EOF) {
+			helps.RecordAPIResponseError(ctx, e.cfg, errRead)
+			reporter.PublishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errRead}
+			return
+		}
+		emit(decoder.Finish())
+		reporter.EnsurePublished(ctx)
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// CountTokens estimates prompt tokens locally; Bedrock has no token counting endpoint that
+// covers every model family.
+func (e *BedrockExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+
+	enc, err := helps.TokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("bedrock executor: tokenizer init failed: %w", err)
+	}
+	count, err := helps.CountClaudeTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("bedrock executor: token counting failed: %w", err)
+	}
+	usageJSON, _ := sjson.SetBytes([]byte(`{"input_tokens":0}`), "input_tokens", count)
+	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: out}, nil
+}
+
+// Refresh is a no-op; profile credentials are re-read from disk when they change.
+func (e *BedrockExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("bedrock executor: refresh called")
+	_ = ctx
+	return auth, nil
+}
+
+// translateRequest converts the client payload into a Claude Messages body with thinking and
+// payload overrides applied.
+func (e *BedrockExecutor) translateRequest(req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) ([]byte, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	body, err := thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
+	body = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = disableThinkingIfToolChoiceForced(body)
+	return body, nil
+}
+
+// send builds, signs and executes the upstream request. Non-2xx responses are returned as
+// status errors mapped from the Bedrock error type.
+func (e *BedrockExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, model string, body []byte, invoke, stream bool) (*http.Response, error) {
+	creds, err := resolveBedrockCredentials(auth)
+	if err != nil {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: err.Error()}
+	}
+
+	var action string
+	var upstreamBody []byte
+	switch {
+	case invoke && stream:
+		action, upstreamBody = "invoke-with-response-stream", buildBedrockInvokeBody(body)
+	case invoke:
+		action, upstreamBody = "invoke", buildBedrockInvokeBody(body)
+	case stream:
+		action, upstreamBody = "converse-stream", convertClaudeRequestToConverse(body)
+	default:
+		action, upstreamBody = "converse", convertClaudeRequestToConverse(body)
+	}
+	url := fmt.Sprintf("%s/model/%s/%s", bedrockBaseURL(auth), awsURIEscape(model), action)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(upstreamBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	signAWSRequest(httpReq, upstreamBody, creds, bedrockRegion(auth), bedrockService, time.Now())
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      upstreamBody,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+		return nil, newBedrockStatusErr(httpResp.StatusCode, httpResp.Header.Get("X-Amzn-ErrorType"), b)
+	}
+	return httpResp, nil
+}
+
+// readStreamEvent reads the next event-stream frame and converts it into Claude SSE lines.
+func (e *BedrockExecutor) readStreamEvent(ctx context.Context, r io.Reader, decoder *bedrockClaudeStream) ([][]byte, error) {
+	msg, err := readEventStreamMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, msg.Payload)
+	return decoder.Decode(msg)
+}
+
+func bedrockBaseURL(auth *cliproxyauth.Auth) string {
+	if auth != nil && auth.Attributes != nil {
+		if base := strings.TrimSpace(auth.Attributes["base_url"]); base != "" {
+			return strings.TrimSuffix(base, "/")
+		}
+	}
+	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", bedrockRegion(auth))
+}
+
+func bedrockRegion(auth *cliproxyauth.Auth) string {
+	if auth != nil && auth.Attributes != nil {
+		if region := strings.TrimSpace(auth.Attributes["region"]); region != "" {
+			return region
+		}
+	}
+	return config.DefaultBedrockRegion
+}
+
+// bedrockErrorStatus maps a Bedrock error type onto the HTTP status the conductor's cooldown
+// logic keys on. Unknown types keep the fallback status.
+func bedrockErrorStatus(errorType string, fallback int) int {
+	switch strings.TrimSuffix(strings.ToLower(errorType), "exception") {
+	case "throttling", "servicequotaexceeded", "toomanyrequests":
+		return http.StatusTooManyRequests
+	case "modelnotready", "serviceunavailable":
+		return http.StatusServiceUnavailable
+	case "internalserver", "internalfailure":
+		return http.StatusInternalServerError
+	case "modeltimeout":
+		return http.StatusRequestTimeout
+	case "modelerror", "modelstreamerror":
+		return http.StatusBadGateway
+	case "accessdenied":
+		return http.StatusForbidden
+	case "unrecognizedclient", "invalidsignature", "incompletesignature", "expiredtoken", "missingauthenticationtoken":
+		return http.StatusUnauthorized
+	case "resourcenotfound":
+		return http.StatusNotFound
+	case "validation":
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
+// newBedrockStatusErr builds a status error with a Claude-style error body from a Bedrock
+// error response or stream exception.
+func newBedrockStatusErr(status int, errorType string, body []byte) statusErr {
+	// X-Amzn-ErrorType carries "Type:namespace"; JSON bodies may carry "namespace#Type".
+	errorType, _, _ = strings.Cut(strings.TrimSpace(errorType), ":")
+	if errorType == "" {
+		errorType = gjson.GetBytes(body, "__type").String()
+		if idx := strings.LastIndex(errorType, "#"); idx >= 0 {
+			errorType = errorType[idx+1:]
+		}
+	}
+	code := bedrockErrorStatus(errorType, status)
+	if code < 400 {
+		code = http.StatusInternalServerError
+	}
+
+	message := gjson.GetBytes(body, "message").String()
+	if message == "" {
+		message = gjson.GetBytes(body, "Message").String()
+	}
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	if errorType != "" {
+		message = errorType + ": " + message
+	}
+
+	var claudeType string
+	switch code {
+	case http.StatusBadRequest:
+		claudeType = "invalid_request_error"
+	case http.StatusUnauthorized:
+		claudeType = "authentication_error"
+	case http.StatusForbidden:
+		claudeType = "permission_error"
+	case http.StatusNotFound:
+		claudeType = "not_found_error"
+	case http.StatusTooManyRequests:
+		claudeType = "rate_limit_error"
+	case http.StatusServiceUnavailable:
+		claudeType = "overloaded_error"
+	default:
+		claudeType = "api_error"
+	}
+	out := []byte(`{"type":"error","error":{"type":"","message":""}}`)
+	out, _ = sjson.SetBytes(out, "error.type", claudeType)
+	out, _ = sjson.SetBytes(out, "error.message", message)
+	return statusErr{code: code, msg: string(out)}
+}
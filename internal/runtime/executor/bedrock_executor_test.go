@@ -0,0 +1,218 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// encodeEventStreamMessage encodes a frame with string headers, mirroring readEventStreamMessage.
+func encodeEventStreamMessage(headers map[string]string, payload []byte) []byte {
+	var headerBytes []byte
+	for name, value := range headers {
+		headerBytes = append(headerBytes, byte(len(name)))
+		headerBytes = append(headerBytes, name...)
+		headerBytes = append(headerBytes, 7)
+		headerBytes = binary.BigEndian.AppendUint16(headerBytes, uint16(len(value)))
+		headerBytes = append(headerBytes, value...)
+	}
+	totalLen := eventStreamPreludeLen + len(headerBytes) + len(payload) + 4
+	frame := make([]byte, 0, totalLen)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(totalLen))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(headerBytes)))
+	frame = binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame[:8]))
+	frame = append(frame, headerBytes...)
+	frame = append(frame, payload...)
+	return binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+}
+
+func converseEvent(eventType, payload string) []byte {
+	return encodeEventStreamMessage(map[string]string{":message-type": "event", ":event-type": eventType, ":content-type": "application/json"}, []byte(payload))
+}
+
+func TestSignAWSRequestMatchesReferenceVector(t *testing.T) {
+	// get-vanilla from the AWS SigV4 test suite.
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signAWSRequest(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q\nwant %q", got, want)
+	}
+	if got := awsCanonicalURI("/model/us.anthropic.claude-v1%3A0/invoke"); got != "/model/us.anthropic.claude-v1%253A0/invoke" {
+		t.Fatalf("canonical URI = %q", got)
+	}
+}
+
+func TestBedrockConverseStreamDecodesToClaudeSSE(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(converseEvent("messageStart", `{"role":"assistant"}`))
+	stream.Write(converseEvent("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hi"}}`))
+	stream.Write(converseEvent("contentBlockStop", `{"contentBlockIndex":0}`))
+	stream.Write(converseEvent("contentBlockStart", `{"contentBlockIndex":1,"start":{"toolUse":{"toolUseId":"tu_1","name":"lookup"}}}`))
+	stream.Write(converseEvent("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"{\"q\":1}"}}}`))
+	stream.Write(converseEvent("contentBlockStop", `{"contentBlockIndex":1}`))
+	stream.Write(converseEvent("messageStop", `{"stopReason":"tool_use"}`))
+	stream.Write(converseEvent("metadata", `{"usage":{"inputTokens":5,"outputTokens":7}}`))
+
+	decoder := newBedrockClaudeStream("meta.llama3", false)
+	var events []string
+	var data []string
+	for {
+		msg, err := readEventStreamMessage(&stream)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("readEventStreamMessage: %v", err)
+		}
+		lines, err := decoder.Decode(msg)
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		for _, line := range lines {
+			if after, ok := bytes.CutPrefix(line, []byte("event: ")); ok {
+				events = append(events, string(after))
+			} else if after, ok := bytes.CutPrefix(line, []byte("data: ")); ok {
+				data = append(data, string(after))
+			}
+		}
+	}
+	if len(decoder.Finish()) != 0 {
+		t.Fatalf("finished stream should not emit more events")
+	}
+
+	want := "message_start,content_block_start,content_block_delta,content_block_stop,content_block_start,content_block_delta,content_block_stop,message_delta,message_stop"
+	if got := strings.Join(events, ","); got != want {
+		t.Fatalf("events = %s", got)
+	}
+	if gjson.Get(data[5], "delta.partial_json").String() != `{"q":1}` || gjson.Get(data[4], "content_block.id").String() != "tu_1" {
+		t.Fatalf("tool use not mapped: %s %s", data[4], data[5])
+	}
+	if gjson.Get(data[7], "delta.stop_reason").String() != "tool_use" || gjson.Get(data[7], "usage.output_tokens").Int() != 7 {
+		t.Fatalf("unexpected message_delta: %s", data[7])
+	}
+}
+
+func TestConvertClaudeRequestToConverse(t *testing.T) {
+	body := []byte(`{
+		"model":"m","max_tokens":128,"temperature":0.5,"stop_sequences":["END"],
+		"system":[{"type":"text","text":"be brief"}],
+		"messages":[
+			{"role":"user","content":[{"type":"text","text":"look"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAAA"}}]},
+			{"role":"assistant","content":[{"type":"tool_use","id":"tu_1","name":"lookup","input":{"q":"cat"}}]},
+			{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu_1","content":"a cat","is_error":true}]}
+		],
+		"tools":[{"name":"lookup","description":"find","input_schema":{"type":"object","properties":{"q":{"type":"string"}}}}],
+		"tool_choice":{"type":"tool","name":"lookup"}
+	}`)
+	root := gjson.ParseBytes(convertClaudeRequestToConverse(body))
+
+	if root.Get("system.0.text").String() != "be brief" || root.Get("inferenceConfig.maxTokens").Int() != 128 || root.Get("inferenceConfig.stopSequences.0").String() != "END" {
+		t.Fatalf("system/inference not mapped: %s", root.Raw)
+	}
+	if root.Get("messages.0.content.1.image.format").String() != "png" || root.Get("messages.0.content.1.image.source.bytes").String() != "AAAA" {
+		t.Fatalf("image not mapped: %s", root.Get("messages.0").Raw)
+	}
+	if root.Get("messages.1.content.0.toolUse.input.q").String() != "cat" {
+		t.Fatalf("tool use not mapped: %s", root.Get("messages.1").Raw)
+	}
+	result := root.Get("messages.2.content.0.toolResult")
+	if result.Get("toolUseId").String() != "tu_1" || result.Get("content.0.text").String() != "a cat" || result.Get("status").String() != "error" {
+		t.Fatalf("tool result not mapped: %s", result.Raw)
+	}
+	if root.Get("toolConfig.tools.0.toolSpec.inputSchema.json.properties.q.type").String() != "string" || root.Get("toolConfig.toolChoice.tool.name").String() != "lookup" {
+		t.Fatalf("tools not mapped: %s", root.Get("toolConfig").Raw)
+	}
+}
+
+func TestBedrockErrorsMapToConductorStatusCodes(t *testing.T) {
+	cases := []struct {
+		status    int
+		errorType string
+		body      string
+		want      int
+	}{
+		{http.StatusBadRequest, "ThrottlingException:http://internal.amazon.com/coral/com.amazon.bedrock/", `{"message":"Too many requests"}`, http.StatusTooManyRequests},
+		{http.StatusBadRequest, "", `{"__type":"com.amazon.coral.service#ServiceQuotaExceededException","Message":"quota"}`, http.StatusTooManyRequests},
+		{http.StatusBadRequest, "ModelNotReadyException", `{}`, http.StatusServiceUnavailable},
+		{http.StatusForbidden, "UnrecognizedClientException", `{"message":"bad token"}`, http.StatusUnauthorized},
+		{http.StatusBadRequest, "ValidationException", `{"message":"bad input"}`, http.StatusBadRequest},
+		{http.StatusTeapot, "", `oops`, http.StatusTeapot},
+	}
+	for _, tc := range cases {
+		err := newBedrockStatusErr(tc.status, tc.errorType, []byte(tc.body))
+		if err.StatusCode() != tc.want {
+			t.Fatalf("%s %s: status = %d, want %d", tc.errorType, tc.body, err.StatusCode(), tc.want)
+		}
+		if !gjson.Get(err.Error(), "error.message").Exists() {
+			t.Fatalf("error body is not Claude-shaped: %s", err.Error())
+		}
+	}
+}
+
+func TestBedrockExecutorStreamSurfacesThrottlingException(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		_, _ = w.Write(encodeEventStreamMessage(map[string]string{
+			":message-type":   "exception",
+			":exception-type": "throttlingException",
+		}, []byte(`{"message":"Too many tokens, please wait before trying again."}`)))
+	}))
+	defer server.Close()
+
+	executor := NewBedrockExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Provider: "bedrock", Attributes: map[string]string{
+		"api_key":           "AKIDEXAMPLE",
+		"secret_access_key": "secret",
+		"base_url":          server.URL,
+		"region":            "us-west-2",
+	}}
+	_, err := executor.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "us.anthropic.claude-sonnet-4-5-v1:0",
+		Payload: []byte(`{"model":"x","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude"), Stream: true})
+
+	status, ok := errors.AsType[statusErr](err)
+	if !ok || status.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 status error, got %v", err)
+	}
+	if gotPath != "/model/us.anthropic.claude-sonnet-4-5-v1%3A0/invoke-with-response-stream" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if !strings.Contains(gotAuth, "Credential=AKIDEXAMPLE/") || !strings.Contains(gotAuth, "/us-west-2/bedrock/aws4_request") {
+		t.Fatalf("request not signed for bedrock: %q", gotAuth)
+	}
+}
+
+func TestBedrockInvokeChunkDecodesClaudeEvent(t *testing.T) {
+	decoder := newBedrockClaudeStream("anthropic.claude", true)
+	event := `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}`
+	payload := `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `"}`
+	lines, err := decoder.Decode(eventStreamMessage{Headers: map[string]string{":message-type": "event", ":event-type": "chunk"}, Payload: []byte(payload)})
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(lines) != 3 || string(lines[0]) != "event: content_block_delta" || string(lines[1]) != "data: "+event {
+		t.Fatalf("unexpected lines: %q", lines)
+	}
+}
@@ -0,0 +1,267 @@
+package executor
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const (
+	sigV4Algorithm   = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat  = "20060102T150405Z"
+	sigV4DateFormat  = "20060102"
+	bedrockService   = "bedrock"
+	bedrockProfileID = "profile:"
+)
+
+// awsCredentials holds the AWS credentials used to sign a request.
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// resolveBedrockCredentials returns the signing credentials of a Bedrock auth. Static keys
+// are read from the auth attributes; profile-based auths are resolved from the shared
+// credentials file on every call so rotated keys are picked up without a reload.
+func resolveBedrockCredentials(auth *cliproxyauth.Auth) (awsCredentials, error) {
+	if auth == nil || auth.Attributes == nil {
+		return awsCredentials{}, fmt.Errorf("bedrock executor: missing credentials")
+	}
+	attrs := auth.Attributes
+	key := strings.TrimSpace(attrs["api_key"])
+	profile := strings.TrimSpace(attrs["profile"])
+	if profile == "" && strings.HasPrefix(key, bedrockProfileID) {
+		profile = strings.TrimPrefix(key, bedrockProfileID)
+	}
+	if profile == "" {
+		secret := strings.TrimSpace(attrs["secret_access_key"])
+		if key == "" || secret == "" {
+			return awsCredentials{}, fmt.Errorf("bedrock executor: missing access key or secret")
+		}
+		return awsCredentials{AccessKeyID: key, SecretAccessKey: secret, SessionToken: strings.TrimSpace(attrs["session_token"])}, nil
+	}
+	return loadAWSProfileCredentials(strings.TrimSpace(attrs["credentials_file"]), profile)
+}
+
+// awsCredentialsFile caches a parsed shared credentials file keyed by its modification time.
+type awsCredentialsFile struct {
+	modTime  time.Time
+	profiles map[string]map[string]string
+}
+
+var (
+	awsCredentialsFilesMu sync.Mutex
+	awsCredentialsFiles   = make(map[string]awsCredentialsFile)
+)
+
+// loadAWSProfileCredentials reads profile from the shared credentials file at path,
+// defaulting to $AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials.
+func loadAWSProfileCredentials(path, profile string) (awsCredentials, error) {
+	if path == "" {
+		path = strings.TrimSpace(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"))
+	}
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return awsCredentials{}, fmt.Errorf("bedrock executor: resolve home directory: %w", err)
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("bedrock executor: stat credentials file: %w", err)
+	}
+
+	awsCredentialsFilesMu.Lock()
+	cached, ok := awsCredentialsFiles[path]
+	awsCredentialsFilesMu.Unlock()
+	if !ok || !cached.modTime.Equal(info.ModTime()) {
+		profiles, errParse := parseAWSCredentialsFile(path)
+		if errParse != nil {
+			return awsCredentials{}, errParse
+		}
+		cached = awsCredentialsFile{modTime: info.ModTime(), profiles: profiles}
+		awsCredentialsFilesMu.Lock()
+		awsCredentialsFiles[path] = cached
+		awsCredentialsFilesMu.Unlock()
+	}
+
+	values, ok := cached.profiles[profile]
+	if !ok {
+		return awsCredentials{}, fmt.Errorf("bedrock executor: profile %q not found in %s", profile, path)
+	}
+	creds := awsCredentials{
+		AccessKeyID:     values["aws_access_key_id"],
+		SecretAccessKey: values["aws_secret_access_key"],
+		SessionToken:    values["aws_session_token"],
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("bedrock executor: profile %q has no static credentials", profile)
+	}
+	return creds, nil
+}
+
+// parseAWSCredentialsFile parses the INI layout of an AWS shared credentials file. Both
+// "[name]" and the config-file style "[profile name]" section headers are accepted.
+func parseAWSCredentialsFile(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock executor: open credentials file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	profiles := make(map[string]map[string]string)
+	var current map[string]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			name = strings.TrimSpace(strings.TrimPrefix(name, "profile "))
+			current = make(map[string]string)
+			profiles[name] = current
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		current[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("bedrock executor: read credentials file: %w", err)
+	}
+	return profiles, nil
+}
+
+// signAWSRequest signs req in place with AWS Signature Version 4. The host header, the
+// content type and every x-amz-* header present on the request are signed.
+func signAWSRequest(req *http.Request, body []byte, creds awsCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(sigV4TimeFormat)
+	date := now.Format(sigV4DateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	payloadHash := sha256Hex(body)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, value := range values {
+				trimmed[i] = strings.Join(strings.Fields(value), " ")
+			}
+			headers[lower] = strings.Join(trimmed, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name)
+		canonicalHeaders.WriteByte(':')
+		canonicalHeaders.WriteString(headers[name])
+		canonicalHeaders.WriteByte('\n')
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsCanonicalURI(req.URL.EscapedPath()),
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// awsCanonicalURI encodes every segment of an already escaped path once more, as SigV4
+// requires for all services except S3.
+func awsCanonicalURI(escapedPath string) string {
+	if escapedPath == "" {
+		return "/"
+	}
+	segments := strings.Split(escapedPath, "/")
+	for i, segment := range segments {
+		segments[i] = awsURIEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func awsCanonicalQuery(values map[string][]string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(values))
+	for key, vals := range values {
+		for _, value := range vals {
+			pairs = append(pairs, awsURIEscape(key)+"="+awsURIEscape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEscape percent-encodes everything except the RFC 3986 unreserved characters.
+func awsURIEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -1991,3 +1991,55 @@ func TestRemapOAuthToolNames_Lowercase_ReverseApplied(t *testing.T) {
 		t.Fatalf("content.0.name = %q, want %q", got, "bash")
 	}
 }
+
+func TestClaudeExecutor_RunsUpstreamInterceptor(t *testing.T) {
+	var gotHeader, gotMarker string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotHeader = r.Header.Get("X-Hook")
+		gotMarker = gjson.GetBytes(body, "metadata.hook").String()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","model":"claude-3-5-sonnet","role":"assistant","content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	executor := NewClaudeExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"api_key":  "key-123",
+		"base_url": server.URL,
+	}}
+
+	calls := 0
+	ctx := cliproxyexecutor.WithUpstreamInterceptor(context.Background(), func(req *http.Request) error {
+		calls++
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		body, _ = sjson.SetBytes(body, "metadata.hook", "ran")
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.Header.Set("X-Hook", "ran")
+		return nil
+	})
+
+	payload := []byte(`{"model":"claude-3-5-sonnet","messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`)
+	if _, err := executor.Execute(ctx, auth, cliproxyexecutor.Request{
+		Model:   "claude-3-5-sonnet",
+		Payload: payload,
+	}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("claude"),
+	}); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("interceptor calls = %d, want 1", calls)
+	}
+	if gotHeader != "ran" {
+		t.Fatalf("upstream X-Hook header = %q, want %q", gotHeader, "ran")
+	}
+	if gotMarker != "ran" {
+		t.Fatalf("upstream metadata.hook = %q, want %q", gotMarker, "ran")
+	}
+}
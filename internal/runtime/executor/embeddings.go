@@ -0,0 +1,340 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	geminiembeddings "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/embeddings"
+	openaiembeddings "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/gemini/embeddings"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// embeddingsAlt is the execution Alt value used by the embeddings handlers.
+const embeddingsAlt = "embeddings"
+
+// executeEmbeddings sends an embeddings request to the Gemini API using
+// embedContent or batchEmbedContents depending on the client request shape.
+func (e *GeminiExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat.String()
+	body, batch, err := geminiEmbeddingsRequest(from, baseModel, req.Payload)
+	if err != nil {
+		return resp, err
+	}
+	action := "embedContent"
+	if batch {
+		action = "batchEmbedContents"
+	}
+	url := fmt.Sprintf("%s/%s/models/%s:%s", resolveGeminiBaseURL(auth), glAPIVersion, baseModel, action)
+
+	apiKey, bearer := geminiCreds(auth)
+	data, headers, err := doEmbeddingsRequest(ctx, e.cfg, e.Identifier(), auth, url, body, func(httpReq *http.Request) {
+		if apiKey != "" {
+			httpReq.Header.Set("x-goog-api-key", apiKey)
+		} else if bearer != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		applyGeminiHeaders(httpReq, auth)
+	})
+	if err != nil {
+		return resp, err
+	}
+	reporter.Publish(ctx, helps.ParseGeminiUsage(data))
+	reporter.EnsurePublished(ctx)
+	resp = cliproxyexecutor.Response{Payload: geminiEmbeddingsResponse(from, req.Model, opts.OriginalRequest, data), Headers: headers}
+	return resp, nil
+}
+
+// vertexMaxEmbeddingInstances bounds the instances sent in one Vertex predict call.
+const vertexMaxEmbeddingInstances = 250
+
+// executeEmbeddings sends embeddings requests to the Vertex AI predict method of the
+// text embedding models. Gemini-shaped items are converted to predict instances, sent in
+// as few calls as the model allows, and reassembled into a batchEmbedContents-shaped
+// response.
+func (e *GeminiVertexExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat.String()
+	body, batch, err := geminiEmbeddingsRequest(from, baseModel, req.Payload)
+	if err != nil {
+		return resp, err
+	}
+
+	var url string
+	var prepare func(*http.Request)
+	apiKey, baseURL := vertexAPICreds(auth)
+	if apiKey != "" {
+		if baseURL == "" {
+			baseURL = "https://aiplatform.googleapis.com"
+		}
+		url = fmt.Sprintf("%s/%s/publishers/google/models/%s:predict", baseURL, vertexAPIVersion, baseModel)
+		prepare = func(httpReq *http.Request) {
+			httpReq.Header.Set("x-goog-api-key", apiKey)
+		}
+	} else {
+		projectID, location, saJSON, errCreds := vertexCreds(auth)
+		if errCreds != nil {
+			return resp, errCreds
+		}
+		token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON)
+		if errTok != nil {
+			log.Errorf("vertex executor: access token error: %v", errTok)
+			return resp, statusErr{code: http.StatusInternalServerError, msg: "internal server error"}
+		}
+		url = fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:predict", vertexBaseURL(location), vertexAPIVersion, projectID, location, baseModel)
+		prepare = func(httpReq *http.Request) {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	prepareWithHeaders := func(httpReq *http.Request) {
+		prepare(httpReq)
+		applyGeminiHeaders(httpReq, auth)
+		var attrs map[string]string
+		if auth != nil {
+			attrs = auth.Attributes
+		}
+		util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	}
+
+	items := []gjson.Result{gjson.ParseBytes(body)}
+	if batch {
+		items = gjson.GetBytes(body, "requests").Array()
+	}
+	// gemini-embedding models accept a single instance per predict call.
+	chunkSize := vertexMaxEmbeddingInstances
+	if strings.HasPrefix(baseModel, "gemini-embedding") {
+		chunkSize = 1
+	}
+	combined := []byte(`{"embeddings":[]}`)
+	var promptTokens int64
+	var headers http.Header
+	for start := 0; start < len(items); start += chunkSize {
+		chunk := items[start:min(start+chunkSize, len(items))]
+		data, respHeaders, errDo := doEmbeddingsRequest(ctx, e.cfg, e.Identifier(), auth, url, vertexPredictRequest(chunk), prepareWithHeaders)
+		if errDo != nil {
+			err = errDo
+			return resp, err
+		}
+		headers = respHeaders
+		predictions := gjson.GetBytes(data, "predictions").Array()
+		for i := range chunk {
+			values := []byte(`[]`)
+			if i < len(predictions) {
+				if v := predictions[i].Get("embeddings.values"); v.IsArray() {
+					values = []byte(v.Raw)
+				}
+				promptTokens += predictions[i].Get("embeddings.statistics.token_count").Int()
+			}
+			embedding, _ := sjson.SetRawBytes([]byte(`{}`), "values", values)
+			combined, _ = sjson.SetRawBytes(combined, "embeddings.-1", embedding)
+		}
+	}
+	if promptTokens > 0 {
+		combined, _ = sjson.SetBytes(combined, "usageMetadata.promptTokenCount", promptTokens)
+		combined, _ = sjson.SetBytes(combined, "usageMetadata.totalTokenCount", promptTokens)
+	}
+	reporter.Publish(ctx, helps.ParseGeminiUsage(combined))
+	reporter.EnsurePublished(ctx)
+
+	out := combined
+	if !batch {
+		out = []byte(`{"embedding":{"values":[]}}`)
+		if first := gjson.GetBytes(combined, "embeddings.0"); first.Exists() {
+			out, _ = sjson.SetRawBytes(out, "embedding", []byte(first.Raw))
+		}
+		if usageMetadata := gjson.GetBytes(combined, "usageMetadata"); usageMetadata.Exists() {
+			out, _ = sjson.SetRawBytes(out, "usageMetadata", []byte(usageMetadata.Raw))
+		}
+	}
+	resp = cliproxyexecutor.Response{Payload: geminiEmbeddingsResponse(from, req.Model, opts.OriginalRequest, out), Headers: headers}
+	return resp, nil
+}
+
+// executeEmbeddings forwards embeddings requests to the provider's /embeddings endpoint.
+// OpenAI-shaped requests pass through unchanged apart from the upstream model name.
+func (e *OpenAICompatExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	baseURL, apiKey := e.resolveCredentials(auth)
+	if baseURL == "" {
+		err = statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+		return resp, err
+	}
+
+	from := opts.SourceFormat.String()
+	var body []byte
+	switch from {
+	case "openai":
+		body, _ = sjson.SetBytes(req.Payload, "model", baseModel)
+	case "gemini":
+		body = openaiembeddings.ConvertGeminiEmbeddingsRequestToOpenAI(baseModel, req.Payload)
+	default:
+		err = statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("embeddings not supported for %s requests", from)}
+		return resp, err
+	}
+
+	url := strings.TrimSuffix(baseURL, "/") + "/embeddings"
+	data, headers, err := doEmbeddingsRequest(ctx, e.cfg, e.Identifier(), auth, url, body, func(httpReq *http.Request) {
+		if apiKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
+		var attrs map[string]string
+		if auth != nil {
+			attrs = auth.Attributes
+		}
+		util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	})
+	if err != nil {
+		return resp, err
+	}
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(data))
+	reporter.EnsurePublished(ctx)
+
+	out := data
+	if from == "gemini" {
+		out = openaiembeddings.ConvertOpenAIEmbeddingsResponseToGemini(data, openaiembeddings.IsGeminiBatchEmbeddingsRequest(req.Payload))
+	}
+	resp = cliproxyexecutor.Response{Payload: out, Headers: headers}
+	return resp, nil
+}
+
+// geminiEmbeddingsRequest builds a Gemini embed request from the client payload.
+// It reports whether the body uses the batchEmbedContents shape.
+func geminiEmbeddingsRequest(from, model string, payload []byte) ([]byte, bool, error) {
+	modelPath := "models/" + model
+	switch from {
+	case "gemini":
+		body := bytes.Clone(payload)
+		if requests := gjson.GetBytes(body, "requests"); requests.IsArray() {
+			for i := range requests.Array() {
+				body, _ = sjson.SetBytes(body, fmt.Sprintf("requests.%d.model", i), modelPath)
+			}
+			return body, true, nil
+		}
+		body, _ = sjson.SetBytes(body, "model", modelPath)
+		return body, false, nil
+	case "openai":
+		body := geminiembeddings.ConvertOpenAIEmbeddingsRequestToGemini(model, payload)
+		if gjson.GetBytes(body, "requests.#").Int() == 0 {
+			return nil, false, statusErr{code: http.StatusBadRequest, msg: "embeddings input must be a string or an array of strings"}
+		}
+		return body, true, nil
+	default:
+		return nil, false, statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("embeddings not supported for %s requests", from)}
+	}
+}
+
+// vertexPredictRequest converts Gemini embedContent items into a Vertex predict request.
+// The texts of each item's parts become one instance; outputDimensionality, which predict
+// takes per request, is read from the first item that sets it.
+func vertexPredictRequest(items []gjson.Result) []byte {
+	body := []byte(`{"instances":[]}`)
+	for _, item := range items {
+		var texts []string
+		for _, part := range item.Get("content.parts").Array() {
+			if text := part.Get("text"); text.Exists() {
+				texts = append(texts, text.String())
+			}
+		}
+		instance, _ := sjson.SetBytes([]byte(`{}`), "content", strings.Join(texts, "\n"))
+		if taskType := item.Get("taskType"); taskType.Exists() {
+			instance, _ = sjson.SetBytes(instance, "task_type", taskType.String())
+		}
+		if title := item.Get("title"); title.Exists() {
+			instance, _ = sjson.SetBytes(instance, "title", title.String())
+		}
+		body, _ = sjson.SetRawBytes(body, "instances.-1", instance)
+		if dims := item.Get("outputDimensionality"); dims.Exists() && !gjson.GetBytes(body, "parameters.outputDimensionality").Exists() {
+			body, _ = sjson.SetBytes(body, "parameters.outputDimensionality", dims.Int())
+		}
+	}
+	return body
+}
+
+// geminiEmbeddingsResponse translates a Gemini embeddings response back to the client format.
+func geminiEmbeddingsResponse(from, model string, originalRequest, data []byte) []byte {
+	if from == "openai" {
+		return geminiembeddings.ConvertGeminiEmbeddingsResponseToOpenAI(model, originalRequest, data)
+	}
+	return data
+}
+
+// doEmbeddingsRequest performs a single JSON POST with request/response logging and
+// maps non-2xx statuses to statusErr so the conductor can apply its retry policy.
+func doEmbeddingsRequest(ctx context.Context, cfg *config.Config, provider string, auth *cliproxyauth.Auth, url string, body []byte, prepare func(*http.Request)) ([]byte, http.Header, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if prepare != nil {
+		prepare(httpReq)
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  provider,
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, cfg, err)
+		return nil, nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("%s executor: close embeddings response body error: %v", provider, errClose)
+		}
+	}()
+	helps.RecordAPIResponseMetadata(ctx, cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		helps.AppendAPIResponseChunk(ctx, cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		return nil, nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, cfg, err)
+		return nil, nil, err
+	}
+	helps.AppendAPIResponseChunk(ctx, cfg, data)
+	return data, httpResp.Header.Clone(), nil
+}
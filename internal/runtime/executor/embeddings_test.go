@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestGeminiVertexEmbeddingsUsePredict(t *testing.T) {
+	var gotPath string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"predictions":[` +
+			`{"embeddings":{"values":[0.1,0.2],"statistics":{"token_count":3}}},` +
+			`{"embeddings":{"values":[0.3,0.4],"statistics":{"token_count":4}}}]}`))
+	}))
+	defer server.Close()
+
+	executor := NewGeminiVertexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL, "api_key": "vertex-key"}}
+	payload := []byte(`{"model":"text-embedding-005","input":["first","second"],"dimensions":2}`)
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Alt: embeddingsAlt, OriginalRequest: payload}
+	resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "text-embedding-005", Payload: payload}, opts)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/v1/publishers/google/models/text-embedding-005:predict" {
+		t.Fatalf("upstream path = %s", gotPath)
+	}
+	if gjson.GetBytes(gotBody, "instances.#").Int() != 2 || gjson.GetBytes(gotBody, "instances.1.content").String() != "second" {
+		t.Fatalf("unexpected predict instances: %s", gotBody)
+	}
+	if gjson.GetBytes(gotBody, "parameters.outputDimensionality").Int() != 2 {
+		t.Fatalf("output dimensionality not forwarded: %s", gotBody)
+	}
+	if gjson.GetBytes(resp.Payload, "data.#").Int() != 2 || gjson.GetBytes(resp.Payload, "data.1.embedding.0").Float() != 0.3 {
+		t.Fatalf("unexpected response: %s", resp.Payload)
+	}
+	if gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int() != 7 {
+		t.Fatalf("token usage not summed: %s", resp.Payload)
+	}
+}
@@ -1,8 +1,11 @@
 package helps
 
 import (
+	"encoding/json"
 	"sync"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sharedstate"
 )
 
 type CodexCache struct {
@@ -59,10 +62,31 @@ func GetCodexCache(key string) (CodexCache, bool) {
 	return cache, true
 }
 
-// SetCodexCache stores a cache entry.
+// SetCodexCache stores a cache entry and replicates it to other proxy replicas.
 func SetCodexCache(key string, cache CodexCache) {
+	storeCodexCache(key, cache)
+	sharedstate.Publish(sharedstate.KindCodexPromptCache, key, cache.ID, cache.Expire)
+}
+
+func storeCodexCache(key string, cache CodexCache) {
 	codexCacheCleanupOnce.Do(startCodexCacheCleanup)
 	codexCacheMu.Lock()
 	codexCacheMap[key] = cache
 	codexCacheMu.Unlock()
 }
+
+func init() {
+	sharedstate.Subscribe(sharedstate.KindCodexPromptCache, func(entry sharedstate.Entry) {
+		if entry.Deleted {
+			codexCacheMu.Lock()
+			delete(codexCacheMap, entry.Key)
+			codexCacheMu.Unlock()
+			return
+		}
+		var id string
+		if err := json.Unmarshal(entry.Value, &id); err != nil || id == "" {
+			return
+		}
+		storeCodexCache(entry.Key, CodexCache{ID: id, Expire: entry.ExpiresAt})
+	})
+}
@@ -1,13 +1,16 @@
 package helps
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/proxyutil"
 	log "github.com/sirupsen/logrus"
 )
@@ -47,7 +50,7 @@ func NewProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 		transport := buildProxyTransport(proxyURL)
 		if transport != nil {
 			httpClient.Transport = transport
-			return httpClient
+			return withUpstreamInterceptor(ctx, httpClient)
 		}
 		// If proxy setup failed, log and fall through to context RoundTripper
 		log.Debugf("failed to setup proxy from URL: %s, falling back to context transport", proxyURL)
@@ -58,9 +61,72 @@ func NewProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 		httpClient.Transport = rt
 	}
 
+	return withUpstreamInterceptor(ctx, httpClient)
+}
+
+// withUpstreamInterceptor routes the client's requests through the upstream interceptor
+// attached to ctx, if any, whichever transport was selected.
+func withUpstreamInterceptor(ctx context.Context, httpClient *http.Client) *http.Client {
+	intercept := cliproxyexecutor.UpstreamInterceptorFrom(ctx)
+	if intercept == nil {
+		return httpClient
+	}
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	httpClient.Transport = interceptingTransport{base: base, intercept: intercept}
 	return httpClient
 }
 
+// InterceptUpstreamPayload runs the UpstreamInterceptor attached to ctx, if any, on a request
+// that is not sent through an http.Client, such as a websocket message. The request is
+// presented to the interceptor as an HTTP request to url; the possibly rewritten headers and
+// body are returned.
+func InterceptUpstreamPayload(ctx context.Context, method, url string, headers http.Header, body []byte) (http.Header, []byte, error) {
+	intercept := cliproxyexecutor.UpstreamInterceptorFrom(ctx)
+	if intercept == nil {
+		return headers, body, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return headers, body, err
+	}
+	if headers != nil {
+		req.Header = headers.Clone()
+	}
+	if err = intercept(req); err != nil {
+		return headers, body, err
+	}
+	out := body
+	if req.Body != nil {
+		out, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return headers, body, err
+		}
+	}
+	return req.Header, out, nil
+}
+
+// interceptingTransport runs an UpstreamInterceptor on a copy of each request before sending it.
+type interceptingTransport struct {
+	base      http.RoundTripper
+	intercept cliproxyexecutor.UpstreamInterceptor
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t interceptingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+	if err := t.intercept(clone); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(clone)
+}
+
 // buildProxyTransport creates an HTTP transport configured for the given proxy URL.
 // It supports SOCKS5, HTTP, and HTTPS proxy protocols.
 //
@@ -57,6 +57,134 @@ func CountOpenAIChatTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
 	addIfNotEmpty(&segments, root.Get("input").String())
 	addIfNotEmpty(&segments, root.Get("prompt").String())
 
+	return countSegments(enc, segments)
+}
+
+// CountOpenAIResponsesTokens approximates prompt tokens for OpenAI Responses API payloads.
+func CountOpenAIResponsesTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
+	if len(payload) == 0 {
+		return 0, nil
+	}
+
+	root := gjson.ParseBytes(payload)
+	segments := make([]string, 0, 32)
+
+	addIfNotEmpty(&segments, root.Get("instructions").String())
+	input := root.Get("input")
+	if input.Type == gjson.String {
+		addIfNotEmpty(&segments, input.String())
+	} else if input.IsArray() {
+		input.ForEach(func(_, item gjson.Result) bool {
+			switch item.Get("type").String() {
+			case "function_call", "custom_tool_call":
+				addIfNotEmpty(&segments, item.Get("name").String())
+				addIfNotEmpty(&segments, item.Get("arguments").String())
+				addIfNotEmpty(&segments, item.Get("input").String())
+			case "function_call_output", "custom_tool_call_output":
+				collectOpenAIContent(item.Get("output"), &segments)
+			case "reasoning":
+				item.Get("summary").ForEach(func(_, part gjson.Result) bool {
+					addIfNotEmpty(&segments, part.Get("text").String())
+					return true
+				})
+			default:
+				addIfNotEmpty(&segments, item.Get("role").String())
+				collectOpenAIContent(item.Get("content"), &segments)
+			}
+			return true
+		})
+	}
+	collectOpenAITools(root.Get("tools"), &segments)
+	collectOpenAIToolChoice(root.Get("tool_choice"), &segments)
+	if format := root.Get("text.format"); format.Exists() {
+		collectOpenAIResponseFormat(format, &segments)
+	}
+
+	return countSegments(enc, segments)
+}
+
+// CountClaudeTokens approximates prompt tokens for Claude Messages API payloads.
+func CountClaudeTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
+	if len(payload) == 0 {
+		return 0, nil
+	}
+
+	root := gjson.ParseBytes(payload)
+	segments := make([]string, 0, 32)
+
+	collectClaudeContent(root.Get("system"), &segments)
+	root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+		addIfNotEmpty(&segments, message.Get("role").String())
+		collectClaudeContent(message.Get("content"), &segments)
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		addIfNotEmpty(&segments, tool.Get("name").String())
+		addIfNotEmpty(&segments, tool.Get("description").String())
+		if schema := tool.Get("input_schema"); schema.Exists() {
+			addIfNotEmpty(&segments, schema.Raw)
+		}
+		return true
+	})
+
+	return countSegments(enc, segments)
+}
+
+// CountGeminiTokens approximates prompt tokens for Gemini generateContent payloads. Gemini CLI
+// envelopes that wrap the request in a "request" object are unwrapped first.
+func CountGeminiTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
+	if len(payload) == 0 {
+		return 0, nil
+	}
+
+	root := gjson.ParseBytes(payload)
+	if request := root.Get("request"); request.IsObject() {
+		root = request
+	}
+	segments := make([]string, 0, 32)
+
+	system := root.Get("systemInstruction")
+	if !system.Exists() {
+		system = root.Get("system_instruction")
+	}
+	collectGeminiParts(system.Get("parts"), &segments)
+	root.Get("contents").ForEach(func(_, content gjson.Result) bool {
+		addIfNotEmpty(&segments, content.Get("role").String())
+		collectGeminiParts(content.Get("parts"), &segments)
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		declarations := tool.Get("functionDeclarations")
+		if !declarations.Exists() {
+			declarations = tool.Get("function_declarations")
+		}
+		declarations.ForEach(func(_, decl gjson.Result) bool {
+			addIfNotEmpty(&segments, decl.Get("name").String())
+			addIfNotEmpty(&segments, decl.Get("description").String())
+			if params := decl.Get("parameters"); params.Exists() {
+				addIfNotEmpty(&segments, params.Raw)
+			}
+			if params := decl.Get("parametersJsonSchema"); params.Exists() {
+				addIfNotEmpty(&segments, params.Raw)
+			}
+			return true
+		})
+		return true
+	})
+
+	return countSegments(enc, segments)
+}
+
+func countSegments(enc tokenizer.Codec, segments []string) (int64, error) {
 	joined := strings.TrimSpace(strings.Join(segments, "\n"))
 	if joined == "" {
 		return 0, nil
@@ -129,6 +257,60 @@ func collectOpenAIContent(content gjson.Result, segments *[]string) {
 	}
 }
 
+func collectClaudeContent(content gjson.Result, segments *[]string) {
+	if !content.Exists() {
+		return
+	}
+	if content.Type == gjson.String {
+		addIfNotEmpty(segments, content.String())
+		return
+	}
+	if !content.IsArray() {
+		return
+	}
+	content.ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "text":
+			addIfNotEmpty(segments, block.Get("text").String())
+		case "thinking":
+			addIfNotEmpty(segments, block.Get("thinking").String())
+		case "tool_use", "server_tool_use":
+			addIfNotEmpty(segments, block.Get("name").String())
+			if input := block.Get("input"); input.Exists() {
+				addIfNotEmpty(segments, input.Raw)
+			}
+		case "tool_result":
+			collectClaudeContent(block.Get("content"), segments)
+		case "image", "document", "redacted_thinking":
+			// Binary sources are billed by the provider separately from text tokens.
+		default:
+			if text := block.Get("text"); text.Exists() {
+				addIfNotEmpty(segments, text.String())
+			}
+		}
+		return true
+	})
+}
+
+func collectGeminiParts(parts gjson.Result, segments *[]string) {
+	parts.ForEach(func(_, part gjson.Result) bool {
+		addIfNotEmpty(segments, part.Get("text").String())
+		if call := part.Get("functionCall"); call.Exists() {
+			addIfNotEmpty(segments, call.Get("name").String())
+			if args := call.Get("args"); args.Exists() {
+				addIfNotEmpty(segments, args.Raw)
+			}
+		}
+		if response := part.Get("functionResponse"); response.Exists() {
+			addIfNotEmpty(segments, response.Get("name").String())
+			if body := response.Get("response"); body.Exists() {
+				addIfNotEmpty(segments, body.Raw)
+			}
+		}
+		return true
+	})
+}
+
 func collectOpenAIToolCalls(calls gjson.Result, segments *[]string) {
 	if !calls.Exists() || !calls.IsArray() {
 		return
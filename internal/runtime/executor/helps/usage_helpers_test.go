@@ -47,6 +47,25 @@ func TestParseOpenAIUsageResponses(t *testing.T) {
 	}
 }
 
+func TestParseClaudeUsageKeepsCacheCreationSeparate(t *testing.T) {
+	data := []byte(`{"usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":3000}}`)
+	detail := ParseClaudeUsage(data)
+	if detail.CachedTokens != 0 {
+		t.Fatalf("cached tokens = %d, want %d", detail.CachedTokens, 0)
+	}
+	if detail.CacheCreationTokens != 3000 {
+		t.Fatalf("cache creation tokens = %d, want %d", detail.CacheCreationTokens, 3000)
+	}
+
+	streamed, ok := ParseClaudeStreamUsage([]byte(`data: {"type":"message_delta","usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":3000}}`))
+	if !ok {
+		t.Fatal("expected stream usage to parse")
+	}
+	if streamed.CachedTokens != 0 || streamed.CacheCreationTokens != 3000 {
+		t.Fatalf("stream cached = %d, creation = %d, want 0 and 3000", streamed.CachedTokens, streamed.CacheCreationTokens)
+	}
+}
+
 func TestUsageReporterBuildRecordIncludesLatency(t *testing.T) {
 	reporter := &UsageReporter{
 		provider:    "openai",
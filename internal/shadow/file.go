@@ -0,0 +1,59 @@
+package shadow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	filePrefix = "shadow-"
+	fileSuffix = ".jsonl"
+	dayLayout  = "2006-01-02"
+)
+
+// FileRecorder appends records to one JSON-lines file per UTC day inside a directory.
+type FileRecorder struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileRecorder creates a recorder writing below dir.
+func NewFileRecorder(dir string) (*FileRecorder, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("shadow: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("shadow: create directory: %w", err)
+	}
+	return &FileRecorder{dir: dir}, nil
+}
+
+// Record implements Recorder.
+func (r *FileRecorder) Record(_ context.Context, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("shadow: encode record: %w", err)
+	}
+	line = append(line, '\n')
+	path := filepath.Join(r.dir, filePrefix+record.Timestamp.UTC().Format(dayLayout)+fileSuffix)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("shadow: open segment: %w", err)
+	}
+	if _, err = file.Write(line); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("shadow: write record: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("shadow: close segment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Package shadow mirrors a sample of live traffic to a secondary model so a backend can be
+// evaluated before clients are moved to it. Mirrored calls run detached from the client
+// request on credentials reserved for shadow traffic; their responses are discarded and a
+// Record of the primary and the shadow call is written for offline comparison.
+package shadow
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// Record roles. Both records of a mirrored request share the same ID.
+const (
+	RolePrimary = "primary"
+	RoleShadow  = "shadow"
+)
+
+// DefaultMaxConcurrent caps in-flight shadow calls when no limit is configured.
+const DefaultMaxConcurrent = 16
+
+// DefaultTimeout bounds a single shadow call.
+const DefaultTimeout = 5 * time.Minute
+
+// Record describes how one side of a mirrored request performed. APIKey holds the
+// util.HashAPIKey form of the client key, never the key itself.
+type Record struct {
+	ID          string    `json:"id"`
+	Role        string    `json:"role"`
+	Rule        string    `json:"rule,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	APIKey      string    `json:"api_key,omitempty"`
+	Handler     string    `json:"handler"`
+	Model       string    `json:"model"`
+	AuthID      string    `json:"auth_id,omitempty"`
+	Stream      bool      `json:"stream"`
+	Status      int       `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	LatencyMs   int64     `json:"latency_ms"`
+	FirstByteMs int64     `json:"first_byte_ms,omitempty"`
+
+	InputTokens     int64 `json:"input_tokens"`
+	OutputTokens    int64 `json:"output_tokens"`
+	ReasoningTokens int64 `json:"reasoning_tokens"`
+	CachedTokens    int64 `json:"cached_tokens"`
+	TotalTokens     int64 `json:"total_tokens"`
+
+	// Request and Output hold the client payload and the response body when the rule
+	// records text. The request is only stored on the primary record.
+	Request string `json:"request,omitempty"`
+	Output  string `json:"output,omitempty"`
+}
+
+// Recorder persists shadow records.
+type Recorder interface {
+	Record(ctx context.Context, record Record) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registered Recorder
+
+	inflight atomic.Int64
+)
+
+// Register installs the process-wide recorder. Passing nil discards records.
+func Register(recorder Recorder) {
+	registryMu.Lock()
+	registered = recorder
+	registryMu.Unlock()
+}
+
+// Default returns the process-wide recorder, or nil when none is registered.
+func Default() Recorder {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registered
+}
+
+// Match returns the first enabled rule matching the client key and model, after sampling
+// the rule's percentage. It returns nil when the request should not be mirrored.
+func Match(cfg *config.SDKConfig, apiKey, model string) *config.ShadowRule {
+	if cfg == nil || !cfg.Shadow.Enable {
+		return nil
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for i := range cfg.Shadow.Rules {
+		rule := &cfg.Shadow.Rules[i]
+		if strings.TrimSpace(rule.TargetModel) == "" || len(rule.AuthIDs) == 0 {
+			continue
+		}
+		if !matchesAny(rule.APIKeys, apiKey, false) || !matchesAny(rule.Models, model, true) {
+			continue
+		}
+		if rule.Percent <= 0 || rand.Float64()*100 >= rule.Percent {
+			return nil
+		}
+		return rule
+	}
+	return nil
+}
+
+// Acquire reserves a slot for a shadow call. Calls beyond the configured limit are dropped
+// rather than queued, so mirroring never builds up a backlog. Release must be called when
+// the slot was granted.
+func Acquire(cfg *config.SDKConfig) bool {
+	limit := int64(DefaultMaxConcurrent)
+	if cfg != nil && cfg.Shadow.MaxConcurrent > 0 {
+		limit = int64(cfg.Shadow.MaxConcurrent)
+	}
+	if inflight.Add(1) > limit {
+		inflight.Add(-1)
+		return false
+	}
+	return true
+}
+
+// Release frees a slot granted by Acquire.
+func Release() {
+	inflight.Add(-1)
+}
+
+// Timeout returns the configured per-call timeout.
+func Timeout(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil || strings.TrimSpace(cfg.Shadow.Timeout) == "" {
+		return DefaultTimeout
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(cfg.Shadow.Timeout))
+	if err != nil || d <= 0 {
+		return DefaultTimeout
+	}
+	return d
+}
+
+// matchesAny reports whether value matches one of patterns. An empty list or "*" matches
+// everything; model patterns may contain '*' wildcards and compare case-insensitively.
+func matchesAny(patterns []string, value string, wildcard bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "*" {
+			return true
+		}
+		if !wildcard {
+			if pattern != "" && pattern == value {
+				return true
+			}
+			continue
+		}
+		if util.MatchWildcard(strings.ToLower(pattern), value) {
+			return true
+		}
+	}
+	return false
+}
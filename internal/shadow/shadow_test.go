@@ -0,0 +1,69 @@
+package shadow
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestMatch_SelectsFirstMatchingRule(t *testing.T) {
+	cfg := &config.SDKConfig{Shadow: config.ShadowConfig{
+		Enable: true,
+		Rules: []config.ShadowRule{
+			{Name: "no-auth", Percent: 100, TargetModel: "x"},
+			{Name: "team-a", APIKeys: []string{"key-a"}, Models: []string{"claude-*"}, Percent: 100, TargetModel: "gemini", AuthIDs: []string{"s"}},
+			{Name: "all", Percent: 100, TargetModel: "gpt", AuthIDs: []string{"s"}},
+		},
+	}}
+
+	if rule := Match(cfg, "key-a", "Claude-Sonnet"); rule == nil || rule.Name != "team-a" {
+		t.Fatalf("Match(key-a, claude) = %+v, want team-a", rule)
+	}
+	if rule := Match(cfg, "key-b", "claude-sonnet"); rule == nil || rule.Name != "all" {
+		t.Fatalf("Match(key-b, claude) = %+v, want all", rule)
+	}
+
+	cfg.Shadow.Rules[2].Percent = 0
+	if rule := Match(cfg, "key-b", "claude-sonnet"); rule != nil {
+		t.Fatalf("Match with 0%% = %+v, want nil", rule)
+	}
+	cfg.Shadow.Enable = false
+	if rule := Match(cfg, "key-a", "claude-sonnet"); rule != nil {
+		t.Fatalf("Match while disabled = %+v, want nil", rule)
+	}
+}
+
+func TestAcquire_EnforcesLimit(t *testing.T) {
+	cfg := &config.SDKConfig{Shadow: config.ShadowConfig{MaxConcurrent: 1}}
+	if !Acquire(cfg) {
+		t.Fatal("first Acquire = false, want true")
+	}
+	if Acquire(cfg) {
+		t.Fatal("second Acquire = true, want false")
+	}
+	Release()
+	if !Acquire(cfg) {
+		t.Fatal("Acquire after Release = false, want true")
+	}
+	Release()
+}
+
+func TestStreamUsage_MergesClaudeEvents(t *testing.T) {
+	var detail coreusage.Detail
+	StreamUsage("claude", []byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":12,\"output_tokens\":1}}}\n"), &detail)
+	StreamUsage("claude", []byte("event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":40}}\n"), &detail)
+
+	if detail.InputTokens != 12 || detail.OutputTokens != 40 || detail.TotalTokens != 52 {
+		t.Fatalf("detail = %+v, want 12 in / 40 out / 52 total", detail)
+	}
+}
+
+func TestStreamUsage_OpenAIFinalChunk(t *testing.T) {
+	var detail coreusage.Detail
+	StreamUsage("openai", []byte("data: {\"choices\":[]}\n\ndata: {\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":2,\"total_tokens\":7}}\n\ndata: [DONE]\n"), &detail)
+
+	if detail.InputTokens != 5 || detail.OutputTokens != 2 || detail.TotalTokens != 7 {
+		t.Fatalf("detail = %+v", detail)
+	}
+}
@@ -0,0 +1,124 @@
+package shadow
+
+import (
+	"bytes"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+// Usage extracts token usage from a complete response in the client's protocol.
+func Usage(handlerType string, payload []byte) coreusage.Detail {
+	switch handlerType {
+	case constant.Claude:
+		return helps.ParseClaudeUsage(payload)
+	case constant.Gemini:
+		return helps.ParseGeminiUsage(payload)
+	case constant.GeminiCLI:
+		return helps.ParseGeminiCLIUsage(payload)
+	case constant.Ollama:
+		return ollamaUsage(gjson.ParseBytes(payload))
+	default:
+		return helps.ParseOpenAIUsage(payload)
+	}
+}
+
+// StreamUsage accumulates token usage from one streamed chunk in the client's protocol into
+// detail. Providers report input and output counts in different events, so non-zero values
+// replace earlier ones field by field.
+func StreamUsage(handlerType string, chunk []byte, detail *coreusage.Detail) {
+	if detail == nil {
+		return
+	}
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		var (
+			found coreusage.Detail
+			ok    bool
+		)
+		switch handlerType {
+		case constant.Claude:
+			found, ok = helps.ParseClaudeStreamUsage(line)
+			if !ok {
+				found, ok = claudeMessageStartUsage(line)
+			}
+		case constant.Gemini:
+			found, ok = helps.ParseGeminiStreamUsage(line)
+		case constant.GeminiCLI:
+			found, ok = helps.ParseGeminiCLIStreamUsage(line)
+		case constant.OpenaiResponse:
+			found, ok = helps.ParseCodexUsage(ssePayload(line))
+		case constant.Ollama:
+			if node := gjson.ParseBytes(bytes.TrimSpace(line)); node.Get("done").Bool() {
+				found, ok = ollamaUsage(node), true
+			}
+		default:
+			found, ok = helps.ParseOpenAIStreamUsage(line)
+		}
+		if ok {
+			mergeUsage(detail, found)
+		}
+	}
+}
+
+func claudeMessageStartUsage(line []byte) (coreusage.Detail, bool) {
+	node := gjson.GetBytes(ssePayload(line), "message.usage")
+	if !node.Exists() {
+		return coreusage.Detail{}, false
+	}
+	detail := coreusage.Detail{
+		InputTokens:         node.Get("input_tokens").Int(),
+		OutputTokens:        node.Get("output_tokens").Int(),
+		CachedTokens:        node.Get("cache_read_input_tokens").Int(),
+		CacheCreationTokens: node.Get("cache_creation_input_tokens").Int(),
+		CachedSeparate:      true,
+	}
+	detail.TotalTokens = detail.InputTokens + detail.OutputTokens
+	return detail, true
+}
+
+func ollamaUsage(node gjson.Result) coreusage.Detail {
+	detail := coreusage.Detail{
+		InputTokens:  node.Get("prompt_eval_count").Int(),
+		OutputTokens: node.Get("eval_count").Int(),
+	}
+	detail.TotalTokens = detail.InputTokens + detail.OutputTokens
+	return detail
+}
+
+func mergeUsage(dst *coreusage.Detail, src coreusage.Detail) {
+	if src.InputTokens > 0 {
+		dst.InputTokens = src.InputTokens
+	}
+	if src.OutputTokens > 0 {
+		dst.OutputTokens = src.OutputTokens
+	}
+	if src.ReasoningTokens > 0 {
+		dst.ReasoningTokens = src.ReasoningTokens
+	}
+	if src.CachedTokens > 0 {
+		dst.CachedTokens = src.CachedTokens
+	}
+	if src.CacheCreationTokens > 0 {
+		dst.CacheCreationTokens = src.CacheCreationTokens
+	}
+	dst.CachedSeparate = dst.CachedSeparate || src.CachedSeparate
+	dst.ReasoningSeparate = dst.ReasoningSeparate || src.ReasoningSeparate
+	if total := dst.InputTokens + dst.OutputTokens; src.TotalTokens > total {
+		dst.TotalTokens = src.TotalTokens
+	} else {
+		dst.TotalTokens = total
+	}
+}
+
+func ssePayload(line []byte) []byte {
+	line = bytes.TrimSpace(line)
+	if bytes.HasPrefix(line, []byte("data:")) {
+		line = bytes.TrimSpace(line[len("data:"):])
+	}
+	if len(line) == 0 || line[0] != '{' {
+		return nil
+	}
+	return line
+}
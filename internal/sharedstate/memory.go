@@ -0,0 +1,63 @@
+package sharedstate
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBackend is an in-process Backend. It shares state between replicators running in
+// the same process, which is what a single replica needs and what tests use to model
+// several replicas.
+type MemoryBackend struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+	last    time.Time
+}
+
+// NewMemoryBackend creates an empty in-process backend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{entries: make(map[string]Entry)}
+}
+
+// Put implements Backend.
+func (b *MemoryBackend) Put(_ context.Context, entries []Entry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	for _, entry := range entries {
+		// Versions must strictly increase so Changes never skips a write.
+		stamp := time.Now()
+		if !stamp.After(b.last) {
+			stamp = b.last.Add(time.Nanosecond)
+		}
+		b.last = stamp
+		entry.UpdatedAt = stamp
+		b.entries[entry.Kind+"\x00"+entry.Key] = entry
+	}
+	for id, entry := range b.entries {
+		if entry.Expired(now) {
+			delete(b.entries, id)
+		}
+	}
+	return nil
+}
+
+// Changes implements Backend.
+func (b *MemoryBackend) Changes(_ context.Context, since time.Time) ([]Entry, time.Time, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	cursor := since
+	out := make([]Entry, 0)
+	for _, entry := range b.entries {
+		if !entry.UpdatedAt.After(since) || entry.Expired(now) {
+			continue
+		}
+		out = append(out, entry)
+		if entry.UpdatedAt.After(cursor) {
+			cursor = entry.UpdatedAt
+		}
+	}
+	return out, cursor, nil
+}
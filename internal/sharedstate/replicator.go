@@ -0,0 +1,188 @@
+package sharedstate
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// purgeEvery is how many sync rounds pass between expired-entry purges.
+const purgeEvery = 60
+
+// Replicator periodically flushes locally published entries to a Backend and applies the
+// entries other replicas wrote to local subscribers.
+type Replicator struct {
+	backend  Backend
+	origin   string
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]Entry
+
+	// syncMu serializes Sync so the cursor and applied versions are only touched by one round.
+	syncMu  sync.Mutex
+	cursor  time.Time
+	applied map[string]appliedVersion
+	rounds  int
+
+	started  atomic.Bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// appliedVersion remembers the newest version of a key already applied, so entries returned
+// again by a backend are not re-applied.
+type appliedVersion struct {
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+// NewReplicator creates a replicator writing as origin and syncing every interval.
+func NewReplicator(backend Backend, origin string, interval time.Duration) *Replicator {
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+	if origin == "" {
+		origin = NewOrigin()
+	}
+	return &Replicator{
+		backend:  backend,
+		origin:   origin,
+		interval: interval,
+		pending:  make(map[string]Entry),
+		applied:  make(map[string]appliedVersion),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Origin returns the replica identifier written into published entries.
+func (r *Replicator) Origin() string {
+	return r.origin
+}
+
+// Start loads the current shared state, applying it to subscribers, then syncs in the
+// background until Stop is called.
+func (r *Replicator) Start(ctx context.Context) error {
+	if err := r.Sync(ctx); err != nil {
+		return err
+	}
+	if r.started.CompareAndSwap(false, true) {
+		go r.loop()
+	}
+	return nil
+}
+
+// Stop ends background syncing after flushing entries that are still pending.
+func (r *Replicator) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		if r.started.Load() {
+			<-r.done
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.flush(ctx); err != nil {
+			log.Warnf("shared state: final flush failed: %v", err)
+		}
+	})
+}
+
+func (r *Replicator) loop() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*r.interval+5*time.Second)
+			if err := r.Sync(ctx); err != nil {
+				log.Warnf("shared state: sync failed: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Sync runs one replication round: pending local entries are written to the backend, then
+// entries written by other replicas since the previous round are applied.
+func (r *Replicator) Sync(ctx context.Context) error {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+	if err := r.flush(ctx); err != nil {
+		return err
+	}
+	entries, cursor, err := r.backend.Changes(ctx, r.cursor)
+	if err != nil {
+		return err
+	}
+	r.cursor = cursor
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.Origin == r.origin || entry.Expired(now) {
+			continue
+		}
+		id := entry.Kind + "\x00" + entry.Key
+		if last, ok := r.applied[id]; ok && !entry.UpdatedAt.After(last.updatedAt) {
+			continue
+		}
+		r.applied[id] = appliedVersion{updatedAt: entry.UpdatedAt, expiresAt: entry.ExpiresAt}
+		dispatch(entry)
+	}
+	r.rounds++
+	if r.rounds%purgeEvery == 0 {
+		for id, version := range r.applied {
+			if !version.expiresAt.IsZero() && now.After(version.expiresAt) {
+				delete(r.applied, id)
+			}
+		}
+		if purger, ok := r.backend.(ExpiredPurger); ok {
+			if errPurge := purger.PurgeExpired(ctx); errPurge != nil {
+				log.Debugf("shared state: purge expired entries: %v", errPurge)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Replicator) enqueue(entry Entry) {
+	entry.Origin = r.origin
+	r.mu.Lock()
+	r.pending[entry.Kind+"\x00"+entry.Key] = entry
+	r.mu.Unlock()
+}
+
+// flush writes pending entries, coalesced to the latest write per key. On failure they are
+// put back unless a newer write for the same key arrived meanwhile.
+func (r *Replicator) flush(ctx context.Context) error {
+	r.mu.Lock()
+	if len(r.pending) == 0 {
+		r.mu.Unlock()
+		return nil
+	}
+	batch := r.pending
+	r.pending = make(map[string]Entry)
+	r.mu.Unlock()
+
+	entries := make([]Entry, 0, len(batch))
+	for _, entry := range batch {
+		entries = append(entries, entry)
+	}
+	if err := r.backend.Put(ctx, entries); err != nil {
+		r.mu.Lock()
+		for id, entry := range batch {
+			if _, newer := r.pending[id]; !newer {
+				r.pending[id] = entry
+			}
+		}
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package sharedstate
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReplicator_AppliesEntriesFromOtherReplicas(t *testing.T) {
+	backend := NewMemoryBackend()
+	writer := NewReplicator(backend, "replica-a", time.Second)
+	reader := NewReplicator(backend, "replica-b", time.Second)
+	Register(writer)
+	t.Cleanup(func() { Register(nil) })
+
+	var received []Entry
+	unsubscribe := Subscribe(KindSession, func(entry Entry) { received = append(received, entry) })
+	defer unsubscribe()
+
+	ctx := context.Background()
+	Publish(KindSession, "k1", "first", time.Now().Add(time.Minute))
+	Publish(KindSession, "k1", "second", time.Now().Add(time.Minute))
+	if err := writer.Sync(ctx); err != nil {
+		t.Fatalf("writer Sync error = %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("writer applied its own entries: %+v", received)
+	}
+
+	if err := reader.Sync(ctx); err != nil {
+		t.Fatalf("reader Sync error = %v", err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("received %d entries, want 1 coalesced entry", len(received))
+	}
+	if got := string(received[0].Value); got != `"second"` {
+		t.Fatalf("value = %s, want latest write", got)
+	}
+
+	reader.cursor = time.Time{}
+	if err := reader.Sync(ctx); err != nil {
+		t.Fatalf("reader Sync error = %v", err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("received %d entries after re-read, want 1", len(received))
+	}
+
+	Delete(KindSession, "k1")
+	if err := writer.Sync(ctx); err != nil {
+		t.Fatalf("writer Sync error = %v", err)
+	}
+	if err := reader.Sync(ctx); err != nil {
+		t.Fatalf("reader Sync error = %v", err)
+	}
+	if len(received) != 2 || !received[1].Deleted {
+		t.Fatalf("entries = %+v, want deletion applied", received)
+	}
+}
+
+func TestReplicator_SkipsExpiredEntries(t *testing.T) {
+	backend := NewMemoryBackend()
+	if err := backend.Put(context.Background(), []Entry{{Kind: KindSignature, Key: "old", Origin: "other", ExpiresAt: time.Now().Add(-time.Second)}}); err != nil {
+		t.Fatalf("Put error = %v", err)
+	}
+	reader := NewReplicator(backend, "replica-b", time.Second)
+
+	called := false
+	unsubscribe := Subscribe(KindSignature, func(Entry) { called = true })
+	defer unsubscribe()
+
+	if err := reader.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync error = %v", err)
+	}
+	if called {
+		t.Fatal("expired entry was applied")
+	}
+}
+
+func TestPublish_NoopWithoutReplicator(t *testing.T) {
+	Register(nil)
+	Publish(KindSession, "k", "v", time.Time{})
+	if Enabled() {
+		t.Fatal("Enabled() = true without a replicator")
+	}
+}
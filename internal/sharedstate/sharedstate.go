@@ -0,0 +1,190 @@
+// Package sharedstate replicates process-local state between proxy replicas: session
+// affinity bindings, thinking signatures, Codex prompt cache keys and credential cooldowns.
+// Writers publish entries by kind and key; an active Replicator pushes them to a shared
+// Backend and applies entries written by other replicas to the local subscribers of that
+// kind, so every replica converges within a couple of sync intervals.
+//
+// Without an active replicator Publish is a no-op and state stays process-local.
+package sharedstate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Entry kinds replicated by the proxy.
+const (
+	KindSession          = "session"
+	KindSignature        = "signature"
+	KindCodexPromptCache = "codex-prompt-cache"
+	KindCooldown         = "cooldown"
+)
+
+// DefaultSyncInterval is the replication period used when none is configured. Writes reach
+// other replicas within roughly two intervals: one to flush and one to be pulled.
+const DefaultSyncInterval = time.Second
+
+// Entry is one replicated value. A later entry with the same kind and key replaces it.
+type Entry struct {
+	Kind  string          `json:"kind"`
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value,omitempty"`
+	// Deleted marks a removal of the key.
+	Deleted bool `json:"deleted,omitempty"`
+	// ExpiresAt is when the entry stops being relevant; zero means never.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	// Origin identifies the replica that wrote the entry.
+	Origin string `json:"origin"`
+	// UpdatedAt is assigned by the backend and orders writes to the same key.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Expired reports whether the entry is past its expiry at the given time.
+func (e Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// Backend stores the latest entry per kind and key where every replica can read it.
+type Backend interface {
+	// Put writes entries, replacing earlier entries with the same kind and key.
+	Put(ctx context.Context, entries []Entry) error
+	// Changes returns the unexpired entries updated after since, and the cursor to pass to
+	// the next call. A zero since returns every unexpired entry. Backends may return an
+	// entry more than once.
+	Changes(ctx context.Context, since time.Time) ([]Entry, time.Time, error)
+}
+
+// ExpiredPurger is implemented by backends that need expired entries removed periodically.
+type ExpiredPurger interface {
+	PurgeExpired(ctx context.Context) error
+}
+
+var (
+	subscribersMu  sync.RWMutex
+	subscribers    = make(map[string]map[uint64]func(Entry))
+	nextSubscriber uint64
+
+	active atomic.Pointer[Replicator]
+)
+
+// Subscribe registers fn to receive entries of kind written by other replicas. The returned
+// function removes the subscription.
+func Subscribe(kind string, fn func(Entry)) func() {
+	if fn == nil {
+		return func() {}
+	}
+	subscribersMu.Lock()
+	nextSubscriber++
+	id := nextSubscriber
+	if subscribers[kind] == nil {
+		subscribers[kind] = make(map[uint64]func(Entry))
+	}
+	subscribers[kind][id] = fn
+	subscribersMu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			subscribersMu.Lock()
+			delete(subscribers[kind], id)
+			subscribersMu.Unlock()
+		})
+	}
+}
+
+func dispatch(entry Entry) {
+	subscribersMu.RLock()
+	fns := make([]func(Entry), 0, len(subscribers[entry.Kind]))
+	for _, fn := range subscribers[entry.Kind] {
+		fns = append(fns, fn)
+	}
+	subscribersMu.RUnlock()
+	for _, fn := range fns {
+		fn(entry)
+	}
+}
+
+// Register makes r the active replicator used by Publish and Delete. Passing nil disables
+// replication.
+func Register(r *Replicator) {
+	active.Store(r)
+}
+
+// Start starts the active replicator, if any. Callers subscribe first so the initial load
+// of the shared state reaches them.
+func Start(ctx context.Context) error {
+	if r := active.Load(); r != nil {
+		return r.Start(ctx)
+	}
+	return nil
+}
+
+// Stop stops the active replicator, if any, flushing entries that are still pending.
+func Stop() {
+	if r := active.Load(); r != nil {
+		r.Stop()
+	}
+}
+
+// Enabled reports whether a replicator is active.
+func Enabled() bool {
+	return active.Load() != nil
+}
+
+// Publish replicates value under kind and key until expiresAt. It is a no-op when no
+// replicator is active.
+func Publish(kind, key string, value any, expiresAt time.Time) {
+	r := active.Load()
+	if r == nil || key == "" {
+		return
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		log.Debugf("shared state: encode %s entry: %v", kind, err)
+		return
+	}
+	r.enqueue(Entry{Kind: kind, Key: key, Value: raw, ExpiresAt: expiresAt})
+}
+
+// Delete replicates the removal of kind and key. It is a no-op when no replicator is active.
+func Delete(kind, key string) {
+	r := active.Load()
+	if r == nil || key == "" {
+		return
+	}
+	r.enqueue(Entry{Kind: kind, Key: key, Deleted: true, ExpiresAt: time.Now().Add(time.Hour)})
+}
+
+// ParseInterval parses a sync interval, falling back to DefaultSyncInterval.
+func ParseInterval(raw string) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return DefaultSyncInterval
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		log.Warnf("shared state: invalid sync interval %q, using %s", raw, DefaultSyncInterval)
+		return DefaultSyncInterval
+	}
+	return d
+}
+
+// NewOrigin returns an identifier for this replica: the host name plus a random suffix, so
+// restarted processes on the same host are told apart.
+func NewOrigin() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "replica"
+	}
+	buf := make([]byte, 4)
+	_, _ = rand.Read(buf)
+	return host + "-" + hex.EncodeToString(buf)
+}
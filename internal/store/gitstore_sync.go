@@ -0,0 +1,221 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// StartRemoteSync pulls remote changes every interval until ctx is cancelled. Pulled files
+// land in the working tree, where the watcher picks them up. A zero interval only records the
+// policy used by manual pulls.
+func (s *GitTokenStore) StartRemoteSync(ctx context.Context, interval time.Duration, policy string) {
+	s.remoteSync.configure("git", interval, policy)
+	if interval <= 0 {
+		return
+	}
+	go runRemotePull(ctx, "git token store", interval, s.PullRemote)
+}
+
+// RemoteSyncStatus reports the state of the remote pull.
+func (s *GitTokenStore) RemoteSyncStatus() RemoteSyncStatus {
+	return s.remoteSync.snapshot("git")
+}
+
+// PullRemote fetches the remote branch and merges it into the working tree file by file. The
+// last commit this store pushed or pulled is the common base: files changed only remotely are
+// written, files changed only locally are committed and pushed, and files changed on both
+// sides follow the conflict policy. Because the store squashes history on every push, remote
+// commits rarely fast-forward, so the merge compares trees instead of commits.
+func (s *GitTokenStore) PullRemote(ctx context.Context) (RemotePullResult, error) {
+	s.mu.Lock()
+	result, err := s.pullRemoteLocked(ctx)
+	s.mu.Unlock()
+	s.remoteSync.record(result, err)
+	return result, err
+}
+
+func (s *GitTokenStore) pullRemoteLocked(ctx context.Context) (RemotePullResult, error) {
+	result := RemotePullResult{Updated: []string{}, Removed: []string{}, KeptLocal: []string{}}
+	repoDir := s.repoDirSnapshot()
+	if repoDir == "" {
+		return result, fmt.Errorf("git token store: repository path not configured")
+	}
+	repo, err := git.PlainOpen(repoDir)
+	if err != nil {
+		return result, fmt.Errorf("git token store: open repo: %w", err)
+	}
+	if err = repo.FetchContext(ctx, &git.FetchOptions{RemoteName: "origin", Auth: s.gitAuth()}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return result, fmt.Errorf("git token store: fetch: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			// Nothing has been committed yet; the first push publishes the workspace.
+			return result, nil
+		}
+		return result, fmt.Errorf("git token store: get head: %w", err)
+	}
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", head.Name().Short()), true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return result, nil
+		}
+		return result, fmt.Errorf("git token store: resolve remote branch: %w", err)
+	}
+	if remoteRef.Hash() == head.Hash() {
+		return result, nil
+	}
+	remoteCommit, err := repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return result, fmt.Errorf("git token store: load remote commit: %w", err)
+	}
+	remoteFiles, err := commitFiles(remoteCommit)
+	if err != nil {
+		return result, err
+	}
+	baseFiles := map[string][]byte{}
+	if headCommit, errHead := repo.CommitObject(head.Hash()); errHead == nil {
+		if baseFiles, err = commitFiles(headCommit); err != nil {
+			return result, err
+		}
+	}
+
+	paths := make(map[string]struct{}, len(remoteFiles)+len(baseFiles))
+	for path := range remoteFiles {
+		paths[path] = struct{}{}
+	}
+	for path := range baseFiles {
+		paths[path] = struct{}{}
+	}
+	for _, path := range s.localSyncCandidates(repoDir) {
+		paths[path] = struct{}{}
+	}
+
+	policy := s.remoteSync.policy()
+	remoteTime := remoteCommit.Committer.When
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+	for _, rel := range sorted {
+		full := filepath.Join(repoDir, filepath.FromSlash(rel))
+		remote, inRemote := remoteFiles[rel]
+		base, inBase := baseFiles[rel]
+		local, inLocal, localTime, errLocal := readLocalFile(full)
+		if errLocal != nil {
+			return result, fmt.Errorf("git token store: read %s: %w", rel, errLocal)
+		}
+		localChanged := inLocal != inBase || !bytes.Equal(local, base)
+		remoteChanged := inRemote != inBase || !bytes.Equal(remote, base)
+		sameContent := inLocal == inRemote && bytes.Equal(local, remote)
+		action, conflict := resolvePull(policy, localChanged, remoteChanged, sameContent, localTime, remoteTime)
+		if conflict {
+			result.Conflicts++
+		}
+		switch action {
+		case pullKeepLocal:
+			result.KeptLocal = append(result.KeptLocal, rel)
+		case pullApplyRemote:
+			if !inRemote {
+				if errRemove := os.Remove(full); errRemove != nil && !errors.Is(errRemove, fs.ErrNotExist) {
+					return result, fmt.Errorf("git token store: remove %s: %w", rel, errRemove)
+				}
+				result.Removed = append(result.Removed, rel)
+				continue
+			}
+			if errWrite := writeFileAtomic(full, remote); errWrite != nil {
+				return result, fmt.Errorf("git token store: write %s: %w", rel, errWrite)
+			}
+			result.Updated = append(result.Updated, rel)
+		}
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return result, fmt.Errorf("git token store: worktree: %w", err)
+	}
+	if err = worktree.Reset(&git.ResetOptions{Commit: remoteRef.Hash(), Mode: git.MixedReset}); err != nil {
+		return result, fmt.Errorf("git token store: move to remote head: %w", err)
+	}
+	if len(result.KeptLocal) > 0 {
+		if err = s.commitAndPushLocked("Sync local changes after remote pull", result.KeptLocal...); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// localSyncCandidates lists the untracked files a pull may need to push: auth records at the top
+// of the auth directory, as the watcher sees them, and the config file. Other files below the
+// auth directory (ledgers, caches) are never picked up unless they are tracked.
+func (s *GitTokenStore) localSyncCandidates(repoDir string) []string {
+	var out []string
+	if baseDir := s.baseDirSnapshot(); baseDir != "" {
+		entries, _ := os.ReadDir(baseDir)
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+				continue
+			}
+			if rel, err := filepath.Rel(repoDir, filepath.Join(baseDir, entry.Name())); err == nil && !strings.HasPrefix(rel, "..") {
+				out = append(out, filepath.ToSlash(rel))
+			}
+		}
+	}
+	if configPath := s.ConfigPath(); configPath != "" {
+		if rel, err := filepath.Rel(repoDir, configPath); err == nil && !strings.HasPrefix(rel, "..") {
+			out = append(out, filepath.ToSlash(rel))
+		}
+	}
+	return out
+}
+
+func commitFiles(commit *object.Commit) (map[string][]byte, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("git token store: load tree: %w", err)
+	}
+	files := make(map[string][]byte)
+	err = tree.Files().ForEach(func(f *object.File) error {
+		contents, errContents := f.Contents()
+		if errContents != nil {
+			return errContents
+		}
+		files[f.Name] = []byte(contents)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("git token store: read tree: %w", err)
+	}
+	return files, nil
+}
+
+func readLocalFile(path string) ([]byte, bool, time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, time.Time{}, nil
+		}
+		return nil, false, time.Time{}, err
+	}
+	if info.IsDir() {
+		return nil, false, time.Time{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, time.Time{}, err
+	}
+	return data, true, info.ModTime(), nil
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestResolvePull(t *testing.T) {
+	older := time.Unix(1711929600, 0)
+	newer := older.Add(time.Hour)
+	cases := []struct {
+		name                        string
+		policy                      string
+		localChanged, remoteChanged bool
+		sameContent                 bool
+		localTime, remoteTime       time.Time
+		want                        pullAction
+		wantConflict                bool
+	}{
+		{name: "unchanged", policy: SyncPolicyRemoteWins, want: pullSkip},
+		{name: "local only", policy: SyncPolicyRemoteWins, localChanged: true, want: pullKeepLocal},
+		{name: "remote only", policy: SyncPolicyNewestWins, remoteChanged: true, localTime: newer, remoteTime: older, want: pullApplyRemote},
+		{name: "same edit", policy: SyncPolicyRemoteWins, localChanged: true, remoteChanged: true, sameContent: true, want: pullSkip},
+		{name: "remote wins", policy: SyncPolicyRemoteWins, localChanged: true, remoteChanged: true, localTime: newer, remoteTime: older, want: pullApplyRemote, wantConflict: true},
+		{name: "newest local", policy: SyncPolicyNewestWins, localChanged: true, remoteChanged: true, localTime: newer, remoteTime: older, want: pullKeepLocal, wantConflict: true},
+		{name: "newest remote", policy: SyncPolicyNewestWins, localChanged: true, remoteChanged: true, localTime: older, remoteTime: newer, want: pullApplyRemote, wantConflict: true},
+	}
+	for _, tc := range cases {
+		got, conflict := resolvePull(tc.policy, tc.localChanged, tc.remoteChanged, tc.sameContent, tc.localTime, tc.remoteTime)
+		if got != tc.want || conflict != tc.wantConflict {
+			t.Errorf("%s: resolvePull = (%v, %v), want (%v, %v)", tc.name, got, conflict, tc.want, tc.wantConflict)
+		}
+	}
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	if policy, err := ParseSyncPolicy(""); err != nil || policy != SyncPolicyRemoteWins {
+		t.Fatalf("ParseSyncPolicy(\"\") = %q, %v", policy, err)
+	}
+	if policy, err := ParseSyncPolicy(" Newest-Wins "); err != nil || policy != SyncPolicyNewestWins {
+		t.Fatalf("ParseSyncPolicy(newest) = %q, %v", policy, err)
+	}
+	if _, err := ParseSyncPolicy("local-wins"); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+}
+
+func TestGitTokenStorePullRemoteAppliesRemoteChanges(t *testing.T) {
+	root := t.TempDir()
+	remoteDir := setupGitRemoteRepository(t, root, "master", testBranchSpec{name: "master", contents: "initial\n"})
+	store := NewGitTokenStore(remoteDir, "", "", "")
+	store.SetBaseDir(filepath.Join(root, "workspace", "auths"))
+	if err := store.EnsureRepository(); err != nil {
+		t.Fatalf("EnsureRepository: %v", err)
+	}
+	store.StartRemoteSync(t.Context(), 0, SyncPolicyRemoteWins)
+
+	advanceRemoteBranch(t, filepath.Join(root, "seed"), remoteDir, "master", "from another instance\n", "remote edit")
+	result, err := store.PullRemote(t.Context())
+	if err != nil {
+		t.Fatalf("PullRemote: %v", err)
+	}
+	if !slices.Contains(result.Updated, "branch.txt") || result.Conflicts != 0 {
+		t.Fatalf("unexpected pull result: %+v", result)
+	}
+	assertRepositoryBranchAndContents(t, filepath.Join(root, "workspace"), "master", "from another instance\n")
+
+	status := store.RemoteSyncStatus()
+	if status.LastError != "" || status.LastSuccess.IsZero() || status.Enabled {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+func TestGitTokenStorePullRemoteNewestWinsKeepsLocalEdit(t *testing.T) {
+	root := t.TempDir()
+	remoteDir := setupGitRemoteRepository(t, root, "master", testBranchSpec{name: "master", contents: "initial\n"})
+	store := NewGitTokenStore(remoteDir, "", "", "")
+	store.SetBaseDir(filepath.Join(root, "workspace", "auths"))
+	if err := store.EnsureRepository(); err != nil {
+		t.Fatalf("EnsureRepository: %v", err)
+	}
+	store.StartRemoteSync(t.Context(), 0, SyncPolicyNewestWins)
+
+	advanceRemoteBranch(t, filepath.Join(root, "seed"), remoteDir, "master", "remote edit\n", "remote edit")
+	local := filepath.Join(root, "workspace", "branch.txt")
+	if err := os.WriteFile(local, []byte("local edit\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(local, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := store.PullRemote(t.Context())
+	if err != nil {
+		t.Fatalf("PullRemote: %v", err)
+	}
+	if !slices.Contains(result.KeptLocal, "branch.txt") || result.Conflicts != 1 {
+		t.Fatalf("unexpected pull result: %+v", result)
+	}
+	assertRemoteBranchContents(t, remoteDir, "master", "local edit\n")
+}
@@ -17,6 +17,7 @@ import (
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
@@ -49,6 +50,12 @@ type ObjectTokenStore struct {
 	configPath string
 	authDir    string
 	mu         sync.Mutex
+
+	// synced holds the version of each object last exchanged with the bucket, the common
+	// base for remote pulls.
+	syncMu     sync.Mutex
+	synced     map[string]objectSyncEntry
+	remoteSync remoteSyncState
 }
 
 // NewObjectTokenStore initializes an object storage backed token store.
@@ -193,14 +200,14 @@ func (s *ObjectTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (s
 			return "", fmt.Errorf("object store: marshal metadata: %w", errMarshal)
 		}
 		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
+			if plain, errOpen := authcrypt.Open(existing); errOpen == nil && jsonEqual(plain, raw) && authcrypt.IsSealed(existing) == authcrypt.Enabled() {
 				return path, nil
 			}
 		} else if errRead != nil && !errors.Is(errRead, fs.ErrNotExist) {
 			return "", fmt.Errorf("object store: read existing metadata: %w", errRead)
 		}
 		tmp := path + ".tmp"
-		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
+		if errWrite := authcrypt.WriteFile(tmp, raw, 0o600); errWrite != nil {
 			return "", fmt.Errorf("object store: write temp auth file: %w", errWrite)
 		}
 		if errRename := os.Rename(tmp, path); errRename != nil {
@@ -340,7 +347,7 @@ func (s *ObjectTokenStore) ensureBucket(ctx context.Context) error {
 
 func (s *ObjectTokenStore) syncConfigFromBucket(ctx context.Context, example string) error {
 	key := s.prefixedKey(objectStoreConfigKey)
-	_, err := s.client.StatObject(ctx, s.cfg.Bucket, key, minio.StatObjectOptions{})
+	stat, err := s.client.StatObject(ctx, s.cfg.Bucket, key, minio.StatObjectOptions{})
 	switch {
 	case err == nil:
 		object, errGet := s.client.GetObject(ctx, s.cfg.Bucket, key, minio.GetObjectOptions{})
@@ -352,9 +359,11 @@ func (s *ObjectTokenStore) syncConfigFromBucket(ctx context.Context, example str
 		if errRead != nil {
 			return fmt.Errorf("object store: read config: %w", errRead)
 		}
-		if errWrite := os.WriteFile(s.configPath, normalizeLineEndingsBytes(data), 0o600); errWrite != nil {
+		normalized := normalizeLineEndingsBytes(data)
+		if errWrite := os.WriteFile(s.configPath, normalized, 0o600); errWrite != nil {
 			return fmt.Errorf("object store: write config: %w", errWrite)
 		}
+		s.recordSynced(objectStoreConfigKey, stat.ETag, normalized)
 	case isObjectNotFound(err):
 		if _, statErr := os.Stat(s.configPath); errors.Is(statErr, fs.ErrNotExist) {
 			if example != "" {
@@ -433,6 +442,7 @@ func (s *ObjectTokenStore) syncAuthFromBucket(ctx context.Context) error {
 		if errWrite := os.WriteFile(local, data, 0o600); errWrite != nil {
 			return fmt.Errorf("object store: write auth %s: %w", local, errWrite)
 		}
+		s.recordSynced(objectStoreAuthPrefix+"/"+filepath.ToSlash(cleanRel), object.ETag, data)
 	}
 	return nil
 }
@@ -477,24 +487,23 @@ func (s *ObjectTokenStore) putObject(ctx context.Context, key string, data []byt
 	}
 	fullKey := s.prefixedKey(key)
 	reader := bytes.NewReader(data)
-	_, err := s.client.PutObject(ctx, s.cfg.Bucket, fullKey, reader, int64(len(data)), minio.PutObjectOptions{
+	info, err := s.client.PutObject(ctx, s.cfg.Bucket, fullKey, reader, int64(len(data)), minio.PutObjectOptions{
 		ContentType: contentType,
 	})
 	if err != nil {
 		return fmt.Errorf("object store: put object %s: %w", fullKey, err)
 	}
+	s.recordSynced(key, info.ETag, data)
 	return nil
 }
 
 func (s *ObjectTokenStore) deleteObject(ctx context.Context, key string) error {
 	fullKey := s.prefixedKey(key)
 	err := s.client.RemoveObject(ctx, s.cfg.Bucket, fullKey, minio.RemoveObjectOptions{})
-	if err != nil {
-		if isObjectNotFound(err) {
-			return nil
-		}
+	if err != nil && !isObjectNotFound(err) {
 		return fmt.Errorf("object store: delete object %s: %w", fullKey, err)
 	}
+	s.forgetSynced(key)
 	return nil
 }
 
@@ -554,7 +563,7 @@ func (s *ObjectTokenStore) resolveDeletePath(id string) (string, error) {
 }
 
 func (s *ObjectTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth, error) {
-	data, err := os.ReadFile(path)
+	data, err := authcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
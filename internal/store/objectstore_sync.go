@@ -0,0 +1,216 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// objectSyncEntry is the last version of an object both sides agreed on: its ETag in the
+// bucket and a hash of the content in the workspace.
+type objectSyncEntry struct {
+	etag string
+	sum  [sha256.Size]byte
+}
+
+// StartRemoteSync pulls bucket changes every interval until ctx is cancelled. Pulled files
+// land in the workspace, where the watcher picks them up. A zero interval only records the
+// policy used by manual pulls.
+func (s *ObjectTokenStore) StartRemoteSync(ctx context.Context, interval time.Duration, policy string) {
+	s.remoteSync.configure("object", interval, policy)
+	if interval <= 0 {
+		return
+	}
+	go runRemotePull(ctx, "object store", interval, s.PullRemote)
+}
+
+// RemoteSyncStatus reports the state of the remote pull.
+func (s *ObjectTokenStore) RemoteSyncStatus() RemoteSyncStatus {
+	return s.remoteSync.snapshot("object")
+}
+
+// PullRemote lists the bucket and merges it into the workspace object by object. The version
+// last downloaded or uploaded by this store is the common base: objects changed only in the
+// bucket are downloaded, files changed only locally are uploaded, and files changed on both
+// sides follow the conflict policy, comparing the local modification time with the object's
+// LastModified.
+func (s *ObjectTokenStore) PullRemote(ctx context.Context) (RemotePullResult, error) {
+	s.mu.Lock()
+	result, err := s.pullRemoteLocked(ctx)
+	s.mu.Unlock()
+	s.remoteSync.record(result, err)
+	return result, err
+}
+
+func (s *ObjectTokenStore) pullRemoteLocked(ctx context.Context) (RemotePullResult, error) {
+	result := RemotePullResult{Updated: []string{}, Removed: []string{}, KeptLocal: []string{}}
+	remote := make(map[string]minio.ObjectInfo)
+	listPrefix := s.prefixedKey("")
+	for object := range s.client.ListObjects(ctx, s.cfg.Bucket, minio.ListObjectsOptions{Prefix: listPrefix, Recursive: true}) {
+		if object.Err != nil {
+			return result, fmt.Errorf("object store: list objects: %w", object.Err)
+		}
+		key := strings.TrimLeft(strings.TrimPrefix(object.Key, listPrefix), "/")
+		if _, ok := s.localPathForKey(key); ok {
+			remote[key] = object
+		}
+	}
+
+	keys := make(map[string]struct{}, len(remote))
+	for key := range remote {
+		keys[key] = struct{}{}
+	}
+	s.syncMu.Lock()
+	for key := range s.synced {
+		keys[key] = struct{}{}
+	}
+	s.syncMu.Unlock()
+	keys[objectStoreConfigKey] = struct{}{}
+	if entries, err := os.ReadDir(s.authDir); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+				keys[objectStoreAuthPrefix+"/"+entry.Name()] = struct{}{}
+			}
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	policy := s.remoteSync.policy()
+	for _, key := range sorted {
+		local, ok := s.localPathForKey(key)
+		if !ok {
+			continue
+		}
+		info, inRemote := remote[key]
+		base, inBase := s.syncedEntry(key)
+		localData, inLocal, localTime, err := readLocalFile(local)
+		if err != nil {
+			return result, fmt.Errorf("object store: read %s: %w", local, err)
+		}
+		localChanged := inLocal != inBase || (inLocal && sha256.Sum256(localData) != base.sum)
+		remoteChanged := inRemote != inBase || (inRemote && info.ETag != base.etag)
+
+		var remoteData []byte
+		if inRemote && remoteChanged {
+			if remoteData, err = s.getObject(ctx, info.Key); err != nil {
+				return result, err
+			}
+		}
+		sameContent := inLocal == inRemote && (!inRemote || bytes.Equal(localData, remoteData))
+		action, conflict := resolvePull(policy, localChanged, remoteChanged, sameContent, localTime, info.LastModified)
+		if conflict {
+			result.Conflicts++
+		}
+		switch action {
+		case pullSkip:
+			if remoteChanged && inRemote {
+				s.recordSynced(key, info.ETag, remoteData)
+			} else if remoteChanged {
+				s.forgetSynced(key)
+			}
+		case pullKeepLocal:
+			if inLocal {
+				err = s.putObject(ctx, key, localData, contentTypeForKey(key))
+			} else {
+				err = s.deleteObject(ctx, key)
+			}
+			if err != nil {
+				return result, err
+			}
+			result.KeptLocal = append(result.KeptLocal, key)
+		case pullApplyRemote:
+			if !inRemote {
+				if errRemove := os.Remove(local); errRemove != nil && !errors.Is(errRemove, fs.ErrNotExist) {
+					return result, fmt.Errorf("object store: remove %s: %w", local, errRemove)
+				}
+				s.forgetSynced(key)
+				result.Removed = append(result.Removed, key)
+				continue
+			}
+			data := remoteData
+			if key == objectStoreConfigKey {
+				data = normalizeLineEndingsBytes(data)
+			}
+			if errWrite := writeFileAtomic(local, data); errWrite != nil {
+				return result, fmt.Errorf("object store: write %s: %w", local, errWrite)
+			}
+			s.recordSynced(key, info.ETag, data)
+			result.Updated = append(result.Updated, key)
+		}
+	}
+	return result, nil
+}
+
+// localPathForKey maps an object key (without the configured prefix) to its workspace path.
+func (s *ObjectTokenStore) localPathForKey(key string) (string, bool) {
+	if key == objectStoreConfigKey {
+		return s.configPath, true
+	}
+	rel, ok := strings.CutPrefix(key, objectStoreAuthPrefix+"/")
+	if !ok || rel == "" || strings.HasSuffix(rel, "/") {
+		return "", false
+	}
+	clean := filepath.Clean(filepath.FromSlash(rel))
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(os.PathSeparator)) {
+		return "", false
+	}
+	return filepath.Join(s.authDir, clean), true
+}
+
+func (s *ObjectTokenStore) getObject(ctx context.Context, fullKey string) ([]byte, error) {
+	reader, err := s.client.GetObject(ctx, s.cfg.Bucket, fullKey, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("object store: download %s: %w", fullKey, err)
+	}
+	defer func() { _ = reader.Close() }()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("object store: read %s: %w", fullKey, err)
+	}
+	return data, nil
+}
+
+func (s *ObjectTokenStore) syncedEntry(key string) (objectSyncEntry, bool) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	entry, ok := s.synced[key]
+	return entry, ok
+}
+
+// recordSynced remembers the version both sides now share. Keys exclude the configured prefix.
+func (s *ObjectTokenStore) recordSynced(key, etag string, data []byte) {
+	s.syncMu.Lock()
+	if s.synced == nil {
+		s.synced = make(map[string]objectSyncEntry)
+	}
+	s.synced[key] = objectSyncEntry{etag: etag, sum: sha256.Sum256(data)}
+	s.syncMu.Unlock()
+}
+
+func (s *ObjectTokenStore) forgetSynced(key string) {
+	s.syncMu.Lock()
+	delete(s.synced, key)
+	s.syncMu.Unlock()
+}
+
+func contentTypeForKey(key string) string {
+	if key == objectStoreConfigKey {
+		return "application/x-yaml"
+	}
+	return "application/json"
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// documentSweepInterval controls how often expired rows are deleted from a document table.
+const documentSweepInterval = 10 * time.Minute
+
+// documentTable accesses a table of expiring JSON documents (id, content, created_at,
+// expires_at) managed by PostgresStore, the layout shared by the response store and the
+// response cache. noun names one document in error messages. Expired rows are hidden from
+// reads and deleted periodically once the first document has been written.
+type documentTable struct {
+	store     *PostgresStore
+	table     string
+	noun      string
+	sweepOnce sync.Once
+}
+
+func newDocumentTable(store *PostgresStore, table, noun string) *documentTable {
+	return &documentTable{store: store, table: table, noun: noun}
+}
+
+func (t *documentTable) ready() error {
+	if t.store == nil || t.store.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	return nil
+}
+
+func (t *documentTable) name() string {
+	return t.store.fullTableName(t.table)
+}
+
+// get decodes the unexpired document id into v. It reports false when there is none.
+func (t *documentTable) get(ctx context.Context, id string, v any) (bool, error) {
+	if err := t.ready(); err != nil {
+		return false, err
+	}
+	query := fmt.Sprintf(`
+		SELECT content FROM %s
+		WHERE id = $1 AND (expires_at IS NULL OR expires_at > NOW())
+	`, t.name())
+	var content []byte
+	if err := t.store.db.QueryRowContext(ctx, query, id).Scan(&content); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("postgres store: load %s: %w", t.noun, err)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return false, fmt.Errorf("postgres store: decode %s: %w", t.noun, err)
+	}
+	return true, nil
+}
+
+// put inserts or replaces document id. A zero expiresAt never expires.
+func (t *documentTable) put(ctx context.Context, id string, v any, createdAt, expiresAt time.Time) error {
+	if err := t.ready(); err != nil {
+		return err
+	}
+	t.sweepOnce.Do(t.startSweep)
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("postgres store: encode %s: %w", t.noun, err)
+	}
+	var expires any
+	if !expiresAt.IsZero() {
+		expires = expiresAt
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, content, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id)
+		DO UPDATE SET content = EXCLUDED.content, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+	`, t.name())
+	if _, err = t.store.db.ExecContext(ctx, query, id, json.RawMessage(payload), createdAt, expires); err != nil {
+		return fmt.Errorf("postgres store: upsert %s: %w", t.noun, err)
+	}
+	return nil
+}
+
+// sweep deletes expired rows and returns how many were removed.
+func (t *documentTable) sweep(ctx context.Context) (int64, error) {
+	if err := t.ready(); err != nil {
+		return 0, err
+	}
+	result, err := t.store.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE expires_at IS NOT NULL AND expires_at <= NOW()", t.name()))
+	if err != nil {
+		return 0, fmt.Errorf("postgres store: purge expired %ss: %w", t.noun, err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected, nil
+}
+
+// startSweep launches a background goroutine that deletes expired rows right away and then
+// every documentSweepInterval, so the table does not grow without bound.
+func (t *documentTable) startSweep() {
+	go func() {
+		ticker := time.NewTicker(documentSweepInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := t.sweep(context.Background()); err != nil {
+				log.WithError(err).Warnf("postgres store: sweep expired %ss", t.noun)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// delete removes document id. When liveOnly is set, expired rows count as missing. It
+// reports false when no row was removed.
+func (t *documentTable) delete(ctx context.Context, id string, liveOnly bool) (bool, error) {
+	if err := t.ready(); err != nil {
+		return false, err
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", t.name())
+	if liveOnly {
+		query += " AND (expires_at IS NULL OR expires_at > NOW())"
+	}
+	result, err := t.store.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("postgres store: delete %s: %w", t.noun, err)
+	}
+	if affected, errRows := result.RowsAffected(); errRows == nil && affected == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// purge removes every document and returns how many were removed.
+func (t *documentTable) purge(ctx context.Context) (int, error) {
+	if err := t.ready(); err != nil {
+		return 0, err
+	}
+	result, err := t.store.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", t.name()))
+	if err != nil {
+		return 0, fmt.Errorf("postgres store: purge %ss: %w", t.noun, err)
+	}
+	affected, errRows := result.RowsAffected()
+	if errRows != nil {
+		return 0, nil
+	}
+	return int(affected), nil
+}
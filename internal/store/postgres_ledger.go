@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ledger"
+)
+
+// PostgresLedgerStore persists usage ledger entries in the ledger table managed by
+// PostgresStore and aggregates them with SQL.
+type PostgresLedgerStore struct {
+	store *PostgresStore
+}
+
+// NewPostgresLedgerStore wraps an initialized PostgresStore.
+func NewPostgresLedgerStore(store *PostgresStore) *PostgresLedgerStore {
+	return &PostgresLedgerStore{store: store}
+}
+
+// ledgerGroupColumns maps grouping dimensions to SQL expressions. Only these fixed
+// expressions are ever interpolated into queries.
+var ledgerGroupColumns = map[string]string{
+	ledger.GroupAPIKey:    "api_key",
+	ledger.GroupAuthIndex: "auth_index",
+	ledger.GroupProvider:  "provider",
+	ledger.GroupModel:     "model",
+	ledger.GroupDay:       "to_char(requested_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')",
+}
+
+// Append implements ledger.Store.
+func (r *PostgresLedgerStore) Append(ctx context.Context, entry ledger.Entry) error {
+	if err := r.ready(); err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			requested_at, api_key, auth_id, auth_index, provider, model, source, latency_ms, failed,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, r.store.fullTableName(r.store.cfg.LedgerTable))
+	if _, err := r.store.db.ExecContext(ctx, query,
+		entry.Timestamp, entry.APIKey, entry.AuthID, entry.AuthIndex, entry.Provider, entry.Model, entry.Source,
+		entry.LatencyMs, entry.Failed,
+		entry.InputTokens, entry.OutputTokens, entry.ReasoningTokens, entry.CachedTokens, entry.TotalTokens,
+	); err != nil {
+		return fmt.Errorf("postgres store: insert ledger entry: %w", err)
+	}
+	return nil
+}
+
+// Query implements ledger.Store.
+func (r *PostgresLedgerStore) Query(ctx context.Context, q ledger.Query) ([]ledger.Row, error) {
+	if err := r.ready(); err != nil {
+		return nil, err
+	}
+	selects := make([]string, 0, len(q.GroupBy))
+	for _, group := range q.GroupBy {
+		column, ok := ledgerGroupColumns[group]
+		if !ok {
+			return nil, fmt.Errorf("postgres store: unsupported ledger group %q", group)
+		}
+		selects = append(selects, column)
+	}
+	conditions := []string{"requested_at >= $1", "requested_at < $2"}
+	args := []any{q.From, q.To}
+	addFilter := func(expr, value string) {
+		if value == "" {
+			return
+		}
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(expr, len(args)))
+	}
+	addFilter("api_key = $%d", q.APIKey)
+	addFilter("auth_index = $%d", q.AuthIndex)
+	addFilter("LOWER(provider) = LOWER($%d)", q.Provider)
+	addFilter("LOWER(model) = LOWER($%d)", q.Model)
+
+	columns := append(append([]string(nil), selects...),
+		"COUNT(*)",
+		"COUNT(*) FILTER (WHERE failed)",
+		"COALESCE(SUM(input_tokens), 0)",
+		"COALESCE(SUM(output_tokens), 0)",
+		"COALESCE(SUM(reasoning_tokens), 0)",
+		"COALESCE(SUM(cached_tokens), 0)",
+		"COALESCE(SUM(total_tokens), 0)",
+		"COALESCE(SUM(latency_ms), 0)",
+	)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s",
+		strings.Join(columns, ", "), r.store.fullTableName(r.store.cfg.LedgerTable), strings.Join(conditions, " AND "))
+	if len(selects) > 0 {
+		query += " GROUP BY " + strings.Join(selects, ", ")
+	}
+
+	rows, err := r.store.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: query ledger: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	out := make([]ledger.Row, 0)
+	for rows.Next() {
+		var row ledger.Row
+		dims := make([]string, len(q.GroupBy))
+		dest := make([]any, 0, len(columns))
+		for i := range dims {
+			dest = append(dest, &dims[i])
+		}
+		dest = append(dest, &row.Requests, &row.Failures, &row.InputTokens, &row.OutputTokens,
+			&row.ReasoningTokens, &row.CachedTokens, &row.TotalTokens, &row.LatencyMsTotal)
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("postgres store: scan ledger row: %w", err)
+		}
+		if row.Requests == 0 {
+			continue
+		}
+		for i, group := range q.GroupBy {
+			switch group {
+			case ledger.GroupAPIKey:
+				row.APIKey = dims[i]
+			case ledger.GroupAuthIndex:
+				row.AuthIndex = dims[i]
+			case ledger.GroupProvider:
+				row.Provider = dims[i]
+			case ledger.GroupModel:
+				row.Model = dims[i]
+			case ledger.GroupDay:
+				row.Day = dims[i]
+			}
+		}
+		out = append(out, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres store: iterate ledger rows: %w", err)
+	}
+	ledger.SortRows(out)
+	return out, nil
+}
+
+func (r *PostgresLedgerStore) ready() error {
+	if r == nil || r.store == nil || r.store.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	return nil
+}
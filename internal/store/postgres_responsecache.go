@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsecache"
+)
+
+// PostgresResponseCacheStore persists response cache entries in the response cache table
+// managed by PostgresStore so cached responses are shared across replicas.
+type PostgresResponseCacheStore struct {
+	table *documentTable
+}
+
+// NewPostgresResponseCacheStore wraps an initialized PostgresStore.
+func NewPostgresResponseCacheStore(store *PostgresStore) *PostgresResponseCacheStore {
+	r := &PostgresResponseCacheStore{table: newDocumentTable(store, "", "cache entry")}
+	if store != nil {
+		r.table.table = store.cfg.ResponseCacheTable
+	}
+	return r
+}
+
+// Get implements responsecache.Store.
+func (r *PostgresResponseCacheStore) Get(ctx context.Context, key string) (*responsecache.Entry, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, responsecache.ErrNotFound
+	}
+	var entry responsecache.Entry
+	found, err := r.table.get(ctx, key, &entry)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, responsecache.ErrNotFound
+	}
+	return &entry, nil
+}
+
+// Put implements responsecache.Store.
+func (r *PostgresResponseCacheStore) Put(ctx context.Context, entry *responsecache.Entry) error {
+	if entry == nil || strings.TrimSpace(entry.Key) == "" {
+		return nil
+	}
+	createdAt := entry.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	return r.table.put(ctx, entry.Key, entry, createdAt, entry.ExpiresAt)
+}
+
+// Delete implements responsecache.Store.
+func (r *PostgresResponseCacheStore) Delete(ctx context.Context, key string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return responsecache.ErrNotFound
+	}
+	removed, err := r.table.delete(ctx, key, false)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return responsecache.ErrNotFound
+	}
+	return nil
+}
+
+// Purge implements responsecache.Store.
+func (r *PostgresResponseCacheStore) Purge(ctx context.Context) (int, error) {
+	return r.table.purge(ctx)
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+)
+
+// PostgresResponseStore persists Responses API conversation state in the response table
+// managed by PostgresStore so that previous_response_id works across replicas.
+type PostgresResponseStore struct {
+	table *documentTable
+	ttl   time.Duration
+}
+
+// NewPostgresResponseStore wraps an initialized PostgresStore. A ttl <= 0 uses responsestore.DefaultTTL.
+func NewPostgresResponseStore(store *PostgresStore, ttl time.Duration) *PostgresResponseStore {
+	if ttl <= 0 {
+		ttl = responsestore.DefaultTTL
+	}
+	r := &PostgresResponseStore{ttl: ttl, table: newDocumentTable(store, "", "response")}
+	if store != nil {
+		r.table.table = store.cfg.ResponseTable
+	}
+	return r
+}
+
+// Get implements responsestore.Store.
+func (r *PostgresResponseStore) Get(ctx context.Context, id string) (*responsestore.Record, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, responsestore.ErrNotFound
+	}
+	var record responsestore.Record
+	found, err := r.table.get(ctx, id, &record)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, responsestore.ErrNotFound
+	}
+	return &record, nil
+}
+
+// Put implements responsestore.Store.
+func (r *PostgresResponseStore) Put(ctx context.Context, record *responsestore.Record) error {
+	if record == nil || strings.TrimSpace(record.ID) == "" {
+		return nil
+	}
+	stored := *record
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = time.Now()
+	}
+	if stored.ExpiresAt.IsZero() {
+		stored.ExpiresAt = stored.CreatedAt.Add(r.ttl)
+	}
+	return r.table.put(ctx, stored.ID, &stored, stored.CreatedAt, stored.ExpiresAt)
+}
+
+// Delete implements responsestore.Store.
+func (r *PostgresResponseStore) Delete(ctx context.Context, id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return responsestore.ErrNotFound
+	}
+	removed, err := r.table.delete(ctx, id, true)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return responsestore.ErrNotFound
+	}
+	return nil
+}
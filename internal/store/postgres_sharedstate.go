@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sharedstate"
+)
+
+// sharedStateLookback is subtracted from the cursor when polling for changes. updated_at is
+// taken from the database clock when a row is written, so a transaction that commits after a
+// poll may carry an earlier timestamp than rows the poll already returned; re-reading a short
+// window catches those rows. The replicator drops versions it has already applied.
+const sharedStateLookback = 5 * time.Second
+
+// PostgresSharedStateBackend replicates shared state entries through the shared state table
+// managed by PostgresStore.
+type PostgresSharedStateBackend struct {
+	store *PostgresStore
+}
+
+// NewPostgresSharedStateBackend wraps an initialized PostgresStore.
+func NewPostgresSharedStateBackend(store *PostgresStore) *PostgresSharedStateBackend {
+	return &PostgresSharedStateBackend{store: store}
+}
+
+// Put implements sharedstate.Backend.
+func (b *PostgresSharedStateBackend) Put(ctx context.Context, entries []sharedstate.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := b.ready(); err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (kind, key, value, deleted, origin, expires_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, clock_timestamp())
+		ON CONFLICT (kind, key)
+		DO UPDATE SET value = EXCLUDED.value, deleted = EXCLUDED.deleted, origin = EXCLUDED.origin,
+			expires_at = EXCLUDED.expires_at, updated_at = EXCLUDED.updated_at
+	`, b.store.fullTableName(b.store.cfg.SharedStateTable))
+	tx, err := b.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("postgres store: begin shared state write: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, entry := range entries {
+		var value any
+		if len(entry.Value) > 0 {
+			value = []byte(entry.Value)
+		}
+		var expiresAt any
+		if !entry.ExpiresAt.IsZero() {
+			expiresAt = entry.ExpiresAt
+		}
+		if _, err = tx.ExecContext(ctx, query, entry.Kind, entry.Key, value, entry.Deleted, entry.Origin, expiresAt); err != nil {
+			return fmt.Errorf("postgres store: upsert shared state entry: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("postgres store: commit shared state write: %w", err)
+	}
+	return nil
+}
+
+// Changes implements sharedstate.Backend.
+func (b *PostgresSharedStateBackend) Changes(ctx context.Context, since time.Time) ([]sharedstate.Entry, time.Time, error) {
+	if err := b.ready(); err != nil {
+		return nil, since, err
+	}
+	from := since
+	if !from.IsZero() {
+		from = from.Add(-sharedStateLookback)
+	}
+	query := fmt.Sprintf(`
+		SELECT kind, key, value, deleted, origin, expires_at, updated_at FROM %s
+		WHERE updated_at > $1 AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY updated_at
+	`, b.store.fullTableName(b.store.cfg.SharedStateTable))
+	rows, err := b.store.db.QueryContext(ctx, query, from)
+	if err != nil {
+		return nil, since, fmt.Errorf("postgres store: query shared state: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	cursor := since
+	var entries []sharedstate.Entry
+	for rows.Next() {
+		var (
+			entry     sharedstate.Entry
+			value     []byte
+			expiresAt sql.NullTime
+		)
+		if err = rows.Scan(&entry.Kind, &entry.Key, &value, &entry.Deleted, &entry.Origin, &expiresAt, &entry.UpdatedAt); err != nil {
+			return nil, since, fmt.Errorf("postgres store: scan shared state: %w", err)
+		}
+		entry.Value = value
+		if expiresAt.Valid {
+			entry.ExpiresAt = expiresAt.Time
+		}
+		if entry.UpdatedAt.After(cursor) {
+			cursor = entry.UpdatedAt
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, since, fmt.Errorf("postgres store: iterate shared state: %w", err)
+	}
+	return entries, cursor, nil
+}
+
+// PurgeExpired implements sharedstate.ExpiredPurger.
+func (b *PostgresSharedStateBackend) PurgeExpired(ctx context.Context) error {
+	if err := b.ready(); err != nil {
+		return err
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE expires_at IS NOT NULL AND expires_at <= NOW()", b.store.fullTableName(b.store.cfg.SharedStateTable))
+	if _, err := b.store.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("postgres store: purge shared state: %w", err)
+	}
+	return nil
+}
+
+func (b *PostgresSharedStateBackend) ready() error {
+	if b == nil || b.store == nil || b.store.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	return nil
+}
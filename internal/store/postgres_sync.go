@@ -0,0 +1,344 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultPostgresSyncInterval is how often a full reconciliation runs when none is configured.
+// Notifications normally deliver changes within milliseconds; the reconciliation only covers
+// notifications lost while the listening connection was down.
+const DefaultPostgresSyncInterval = 5 * time.Minute
+
+// defaultNotifyChannel is the LISTEN/NOTIFY channel shared by replicas of one deployment.
+const defaultNotifyChannel = "cliproxy_store_changes"
+
+// reconcileDeleteGrace protects local auth files that were just written and are still being
+// persisted from being removed by a reconciliation that ran before their database insert.
+const reconcileDeleteGrace = time.Minute
+
+const (
+	listenRetryMin = time.Second
+	listenRetryMax = 30 * time.Second
+)
+
+// Notification kinds.
+const (
+	notifyKindAuth   = "auth"
+	notifyKindConfig = "config"
+)
+
+// storeNotification is the NOTIFY payload. Payloads only identify the changed record; the
+// receiver loads the content itself, which keeps payloads under the 8000 byte limit.
+type storeNotification struct {
+	Origin  string `json:"origin"`
+	Kind    string `json:"kind"`
+	ID      string `json:"id,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// StartSync keeps the local spool in step with changes made by other replicas. It listens for
+// notifications on a dedicated connection and applies each change to the spool, where the
+// watcher picks it up like any other file change, and runs a full reconciliation every
+// interval to cover notifications missed while disconnected. It returns immediately; the
+// background work stops when ctx is cancelled.
+func (s *PostgresStore) StartSync(ctx context.Context, interval time.Duration) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultPostgresSyncInterval
+	}
+	go s.listenLoop(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Reconcile(ctx); err != nil && ctx.Err() == nil {
+					log.WithError(err).Warn("postgres store: reconciliation failed")
+				}
+			}
+		}
+	}()
+}
+
+// Reconcile brings the local spool in line with the database: auth records and the config
+// that differ are rewritten, and spool files of auth records deleted from the database are
+// removed.
+func (s *PostgresStore) Reconcile(ctx context.Context) error {
+	if err := s.applyConfig(ctx); err != nil {
+		return err
+	}
+	query := fmt.Sprintf("SELECT id, content FROM %s", s.fullTableName(s.cfg.AuthTable))
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("postgres store: load auth for reconciliation: %w", err)
+	}
+	remote := make(map[string]string)
+	for rows.Next() {
+		var id, payload string
+		if err = rows.Scan(&id, &payload); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("postgres store: scan auth row: %w", err)
+		}
+		remote[id] = payload
+	}
+	_ = rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("postgres store: iterate auth rows: %w", err)
+	}
+	for id, payload := range remote {
+		if err = s.writeRemoteAuth(id, []byte(payload)); err != nil {
+			log.WithError(err).Warnf("postgres store: failed to apply auth %s", id)
+		}
+	}
+
+	s.pruneSpool(remote)
+	return nil
+}
+
+// pruneSpool removes spool files of auth records that no longer exist in the database. Only
+// records this store mirrored or persisted are considered, so other files that happen to live
+// under the auth directory are never touched.
+func (s *PostgresStore) pruneSpool(remote map[string]string) {
+	s.mu.Lock()
+	candidates := make([]string, 0, len(s.knownAuth))
+	for id := range s.knownAuth {
+		if _, ok := remote[id]; !ok {
+			candidates = append(candidates, id)
+		}
+	}
+	s.mu.Unlock()
+
+	cutoff := time.Now().Add(-reconcileDeleteGrace)
+	for _, id := range candidates {
+		path, err := s.absoluteAuthPath(id)
+		if err != nil {
+			continue
+		}
+		if info, errStat := os.Stat(path); errStat != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if errRemove := s.removeRemoteAuth(id); errRemove != nil {
+			log.WithError(errRemove).Warnf("postgres store: failed to remove auth %s", id)
+		}
+	}
+}
+
+// notify announces a change to the other replicas. Failures are logged rather than returned:
+// the write itself succeeded and the periodic reconciliation delivers it regardless.
+func (s *PostgresStore) notify(ctx context.Context, kind, id string, deleted bool) {
+	payload, err := json.Marshal(storeNotification{Origin: s.origin, Kind: kind, ID: id, Deleted: deleted})
+	if err != nil {
+		return
+	}
+	if _, err = s.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", s.cfg.NotifyChannel, string(payload)); err != nil {
+		log.WithError(err).Warnf("postgres store: notify %s change", kind)
+	}
+}
+
+func (s *PostgresStore) listenLoop(ctx context.Context) {
+	backoff := listenRetryMin
+	for ctx.Err() == nil {
+		started := time.Now()
+		err := s.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > listenRetryMax {
+			backoff = listenRetryMin
+		}
+		log.WithError(err).Warnf("postgres store: change listener disconnected, retrying in %s", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, listenRetryMax)
+	}
+}
+
+// listen holds one connection in LISTEN mode until it fails. Changes made while no listener
+// was active are picked up by reconciling once the channel is subscribed.
+func (s *PostgresStore) listen(ctx context.Context) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres store: acquire listen connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	return conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("postgres store: unexpected driver connection %T", driverConn)
+		}
+		pgConn := stdConn.Conn()
+		if _, errListen := pgConn.Exec(ctx, "LISTEN "+quoteIdentifier(s.cfg.NotifyChannel)); errListen != nil {
+			return fmt.Errorf("postgres store: listen: %w", errListen)
+		}
+		if errReconcile := s.Reconcile(ctx); errReconcile != nil {
+			log.WithError(errReconcile).Warn("postgres store: reconciliation failed")
+		}
+		for {
+			notification, errWait := pgConn.WaitForNotification(ctx)
+			if errWait != nil {
+				return errWait
+			}
+			s.applyNotification(ctx, notification.Payload)
+		}
+	})
+}
+
+func (s *PostgresStore) applyNotification(ctx context.Context, payload string) {
+	var n storeNotification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		log.WithError(err).Debug("postgres store: ignoring malformed notification")
+		return
+	}
+	if n.Origin == s.origin {
+		return
+	}
+	var err error
+	switch n.Kind {
+	case notifyKindConfig:
+		err = s.applyConfig(ctx)
+	case notifyKindAuth:
+		if n.ID == "" {
+			return
+		}
+		err = s.applyAuth(ctx, n.ID)
+	}
+	if err != nil {
+		log.WithError(err).Warnf("postgres store: failed to apply %s change %s", n.Kind, n.ID)
+	}
+}
+
+// applyAuth loads one auth record and mirrors it into the spool; a missing record removes
+// the local file.
+func (s *PostgresStore) applyAuth(ctx context.Context, id string) error {
+	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.AuthTable))
+	var payload string
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&payload)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return s.removeRemoteAuth(id)
+	case err != nil:
+		return fmt.Errorf("postgres store: load auth %s: %w", id, err)
+	}
+	return s.writeRemoteAuth(id, []byte(payload))
+}
+
+// writeRemoteAuth writes a database record to the spool unless the local file already holds
+// the same JSON. The record is remembered so the watcher's write-back is not echoed.
+func (s *PostgresStore) writeRemoteAuth(id string, data []byte) error {
+	path, err := s.absoluteAuthPath(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, errRead := os.ReadFile(path); errRead == nil && jsonEqual(existing, data) {
+		return nil
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("postgres store: create auth subdir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("postgres store: write auth file: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("postgres store: rename auth file: %w", err)
+	}
+	s.appliedAuth[id] = data
+	s.knownAuth[id] = struct{}{}
+	log.Debugf("postgres store: applied remote auth change %s", id)
+	return nil
+}
+
+func (s *PostgresStore) removeRemoteAuth(id string) error {
+	path, err := s.absoluteAuthPath(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = os.Remove(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("postgres store: delete auth file: %w", err)
+	}
+	s.appliedAuth[id] = nil
+	delete(s.knownAuth, id)
+	log.Debugf("postgres store: applied remote auth removal %s", id)
+	return nil
+}
+
+// applyConfig mirrors the database config into the spool when it differs from the local file.
+func (s *PostgresStore) applyConfig(ctx context.Context) error {
+	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.ConfigTable))
+	var content string
+	err := s.db.QueryRowContext(ctx, query, defaultConfigKey).Scan(&content)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return fmt.Errorf("postgres store: load config: %w", err)
+	}
+	normalized := normalizeLineEndings(content)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, errRead := os.ReadFile(s.configPath); errRead == nil && normalizeLineEndings(string(existing)) == normalized {
+		return nil
+	}
+	tmp := s.configPath + ".tmp"
+	if err = os.WriteFile(tmp, []byte(normalized), 0o600); err != nil {
+		return fmt.Errorf("postgres store: write config to spool: %w", err)
+	}
+	if err = os.Rename(tmp, s.configPath); err != nil {
+		return fmt.Errorf("postgres store: rename config: %w", err)
+	}
+	s.appliedConfig = &normalized
+	log.Info("postgres store: applied config change from another replica")
+	return nil
+}
+
+// consumeAppliedAuth reports whether data (nil for a removed file) is the record last applied
+// from another replica, so persisting it would only echo the change back. The marker is
+// cleared either way: any later local change must be persisted.
+func (s *PostgresStore) consumeAppliedAuth(id string, data []byte) bool {
+	applied, ok := s.appliedAuth[id]
+	if !ok {
+		return false
+	}
+	delete(s.appliedAuth, id)
+	if applied == nil || data == nil {
+		return applied == nil && data == nil
+	}
+	return jsonEqual(applied, data)
+}
+
+// consumeAppliedConfig is the config counterpart of consumeAppliedAuth.
+func (s *PostgresStore) consumeAppliedConfig(data []byte) bool {
+	applied := s.appliedConfig
+	if applied == nil {
+		return false
+	}
+	s.appliedConfig = nil
+	return *applied == normalizeLineEndings(string(data))
+}
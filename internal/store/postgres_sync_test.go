@@ -0,0 +1,115 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newSpoolOnlyPostgresStore(t *testing.T) *PostgresStore {
+	t.Helper()
+	root := t.TempDir()
+	authDir := filepath.Join(root, "auths")
+	if err := os.MkdirAll(authDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return &PostgresStore{
+		spoolRoot:   root,
+		configPath:  filepath.Join(root, "config.yaml"),
+		authDir:     authDir,
+		origin:      "test",
+		appliedAuth: make(map[string][]byte),
+		knownAuth:   make(map[string]struct{}),
+	}
+}
+
+func TestPostgresStoreRemoteAuth_SuppressesEcho(t *testing.T) {
+	s := newSpoolOnlyPostgresStore(t)
+	remote := []byte(`{"type": "claude", "email": "a@example.com"}`)
+	if err := s.writeRemoteAuth("team/claude.json", remote); err != nil {
+		t.Fatalf("writeRemoteAuth: %v", err)
+	}
+	local, err := os.ReadFile(filepath.Join(s.authDir, "team", "claude.json"))
+	if err != nil {
+		t.Fatalf("spool file missing: %v", err)
+	}
+
+	if !s.consumeAppliedAuth("team/claude.json", []byte(`{"email":"a@example.com","type":"claude"}`)) {
+		t.Fatal("watcher write-back of the applied record was not recognized")
+	}
+	if s.consumeAppliedAuth("team/claude.json", local) {
+		t.Fatal("applied marker was not cleared; later local changes would be dropped")
+	}
+
+	delete(s.appliedAuth, "team/claude.json")
+	if err = s.writeRemoteAuth("team/claude.json", []byte(`{"email":"a@example.com","type":"claude"}`)); err != nil {
+		t.Fatalf("writeRemoteAuth(same json): %v", err)
+	}
+	if _, marked := s.appliedAuth["team/claude.json"]; marked {
+		t.Fatal("unchanged record was rewritten")
+	}
+}
+
+func TestPostgresStoreRemoteAuth_Removal(t *testing.T) {
+	s := newSpoolOnlyPostgresStore(t)
+	if err := s.writeRemoteAuth("codex.json", []byte(`{"type":"codex"}`)); err != nil {
+		t.Fatal(err)
+	}
+	delete(s.appliedAuth, "codex.json")
+
+	if err := s.removeRemoteAuth("codex.json"); err != nil {
+		t.Fatalf("removeRemoteAuth: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.authDir, "codex.json")); !os.IsNotExist(err) {
+		t.Fatalf("spool file still present: %v", err)
+	}
+	if !s.consumeAppliedAuth("codex.json", nil) {
+		t.Fatal("watcher removal of the applied deletion was not recognized")
+	}
+	if s.consumeAppliedAuth("codex.json", []byte(`{"type":"codex"}`)) {
+		t.Fatal("a recreated file must be persisted")
+	}
+}
+
+func TestPostgresStoreApplyNotification_IgnoresOwnOrigin(t *testing.T) {
+	s := newSpoolOnlyPostgresStore(t)
+	// A nil database would panic if the notification were applied.
+	s.applyNotification(t.Context(), `{"origin":"test","kind":"auth","id":"x.json"}`)
+	s.applyNotification(t.Context(), `not json`)
+}
+
+func TestPostgresStorePruneSpool_OnlyRemovesKnownAuth(t *testing.T) {
+	s := newSpoolOnlyPostgresStore(t)
+	if err := s.writeRemoteAuth("gone.json", []byte(`{"type":"codex"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.writeRemoteAuth("kept.json", []byte(`{"type":"claude"}`)); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(s.authDir, "responses", "resp_1.json")
+	if err := os.MkdirAll(filepath.Dir(other), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(other, []byte(`{"id":"resp_1"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * reconcileDeleteGrace)
+	for _, path := range []string{other, filepath.Join(s.authDir, "gone.json"), filepath.Join(s.authDir, "kept.json")} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.pruneSpool(map[string]string{"kept.json": `{"type":"claude"}`})
+
+	if _, err := os.Stat(filepath.Join(s.authDir, "gone.json")); !os.IsNotExist(err) {
+		t.Fatalf("deleted auth still in spool: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.authDir, "kept.json")); err != nil {
+		t.Fatalf("live auth removed: %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("non-auth file removed: %v", err)
+	}
+}
@@ -14,24 +14,36 @@ import (
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sharedstate"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
-	defaultConfigTable = "config_store"
-	defaultAuthTable   = "auth_store"
-	defaultConfigKey   = "config"
+	defaultConfigTable        = "config_store"
+	defaultAuthTable          = "auth_store"
+	defaultResponseTable      = "response_store"
+	defaultResponseCacheTable = "response_cache"
+	defaultLedgerTable        = "usage_ledger"
+	defaultSharedStateTable   = "shared_state"
+	defaultConfigKey          = "config"
 )
 
 // PostgresStoreConfig captures configuration required to initialize a Postgres-backed store.
 type PostgresStoreConfig struct {
-	DSN         string
-	Schema      string
-	ConfigTable string
-	AuthTable   string
-	SpoolDir    string
+	DSN                string
+	Schema             string
+	ConfigTable        string
+	AuthTable          string
+	ResponseTable      string
+	ResponseCacheTable string
+	LedgerTable        string
+	SharedStateTable   string
+	SpoolDir           string
+	// NotifyChannel is the LISTEN/NOTIFY channel used to announce changes to other replicas.
+	NotifyChannel string
 }
 
 // PostgresStore persists configuration and authentication metadata using PostgreSQL as backend
@@ -42,7 +54,18 @@ type PostgresStore struct {
 	spoolRoot  string
 	configPath string
 	authDir    string
+	origin     string
 	mu         sync.Mutex
+
+	// appliedAuth and appliedConfig remember records written to the spool on behalf of other
+	// replicas, so the watcher persisting them again does not echo the change. A nil auth
+	// entry marks a removal.
+	appliedAuth   map[string][]byte
+	appliedConfig *string
+
+	// knownAuth holds the ids of auth records this store has mirrored to or persisted from the
+	// spool. Reconciliation only prunes these, never unrelated files under the auth directory.
+	knownAuth map[string]struct{}
 }
 
 // NewPostgresStore establishes a connection to PostgreSQL and prepares the local workspace.
@@ -58,6 +81,21 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	if cfg.AuthTable == "" {
 		cfg.AuthTable = defaultAuthTable
 	}
+	if cfg.ResponseTable == "" {
+		cfg.ResponseTable = defaultResponseTable
+	}
+	if cfg.ResponseCacheTable == "" {
+		cfg.ResponseCacheTable = defaultResponseCacheTable
+	}
+	if cfg.LedgerTable == "" {
+		cfg.LedgerTable = defaultLedgerTable
+	}
+	if cfg.SharedStateTable == "" {
+		cfg.SharedStateTable = defaultSharedStateTable
+	}
+	if cfg.NotifyChannel == "" {
+		cfg.NotifyChannel = defaultNotifyChannel
+	}
 
 	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
 	if spoolRoot == "" {
@@ -90,11 +128,14 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	}
 
 	store := &PostgresStore{
-		db:         db,
-		cfg:        cfg,
-		spoolRoot:  absSpool,
-		configPath: filepath.Join(configDir, "config.yaml"),
-		authDir:    authDir,
+		db:          db,
+		cfg:         cfg,
+		spoolRoot:   absSpool,
+		configPath:  filepath.Join(configDir, "config.yaml"),
+		authDir:     authDir,
+		origin:      sharedstate.NewOrigin(),
+		appliedAuth: make(map[string][]byte),
+		knownAuth:   make(map[string]struct{}),
 	}
 	return store, nil
 }
@@ -140,6 +181,77 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, authTable)); err != nil {
 		return fmt.Errorf("postgres store: create auth table: %w", err)
 	}
+	responseTable := s.fullTableName(s.cfg.ResponseTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			content JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			expires_at TIMESTAMPTZ
+		)
+	`, responseTable)); err != nil {
+		return fmt.Errorf("postgres store: create response table: %w", err)
+	}
+	responseCacheTable := s.fullTableName(s.cfg.ResponseCacheTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			content JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			expires_at TIMESTAMPTZ
+		)
+	`, responseCacheTable)); err != nil {
+		return fmt.Errorf("postgres store: create response cache table: %w", err)
+	}
+	ledgerTable := s.fullTableName(s.cfg.LedgerTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			requested_at TIMESTAMPTZ NOT NULL,
+			api_key TEXT NOT NULL DEFAULT '',
+			auth_id TEXT NOT NULL DEFAULT '',
+			auth_index TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL DEFAULT '',
+			latency_ms BIGINT NOT NULL DEFAULT 0,
+			failed BOOLEAN NOT NULL DEFAULT FALSE,
+			input_tokens BIGINT NOT NULL DEFAULT 0,
+			output_tokens BIGINT NOT NULL DEFAULT 0,
+			reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+			cached_tokens BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0
+		)
+	`, ledgerTable)); err != nil {
+		return fmt.Errorf("postgres store: create usage ledger table: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE INDEX IF NOT EXISTS %s ON %s (requested_at)",
+		quoteIdentifier(s.cfg.LedgerTable+"_requested_at_idx"), ledgerTable,
+	)); err != nil {
+		return fmt.Errorf("postgres store: create usage ledger index: %w", err)
+	}
+	sharedStateTable := s.fullTableName(s.cfg.SharedStateTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			kind TEXT NOT NULL,
+			key TEXT NOT NULL,
+			value JSONB,
+			deleted BOOLEAN NOT NULL DEFAULT FALSE,
+			origin TEXT NOT NULL DEFAULT '',
+			expires_at TIMESTAMPTZ,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+			PRIMARY KEY (kind, key)
+		)
+	`, sharedStateTable)); err != nil {
+		return fmt.Errorf("postgres store: create shared state table: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE INDEX IF NOT EXISTS %s ON %s (updated_at)",
+		quoteIdentifier(s.cfg.SharedStateTable+"_updated_at_idx"), sharedStateTable,
+	)); err != nil {
+		return fmt.Errorf("postgres store: create shared state index: %w", err)
+	}
 	return nil
 }
 
@@ -223,14 +335,14 @@ func (s *PostgresStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (stri
 			return "", fmt.Errorf("postgres store: marshal metadata: %w", errMarshal)
 		}
 		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
+			if plain, errOpen := authcrypt.Open(existing); errOpen == nil && jsonEqual(plain, raw) && authcrypt.IsSealed(existing) == authcrypt.Enabled() {
 				return path, nil
 			}
 		} else if errRead != nil && !errors.Is(errRead, fs.ErrNotExist) {
 			return "", fmt.Errorf("postgres store: read existing metadata: %w", errRead)
 		}
 		tmp := path + ".tmp"
-		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
+		if errWrite := authcrypt.WriteFile(tmp, raw, 0o600); errWrite != nil {
 			return "", fmt.Errorf("postgres store: write temp auth file: %w", errWrite)
 		}
 		if errRename := os.Rename(tmp, path); errRename != nil {
@@ -284,8 +396,13 @@ func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 			log.WithError(errPath).Warnf("postgres store: skipping auth %s outside spool", id)
 			continue
 		}
+		plain, errOpen := authcrypt.Open([]byte(payload))
+		if errOpen != nil {
+			log.WithError(errOpen).Warnf("postgres store: skipping auth %s that cannot be decrypted", id)
+			continue
+		}
 		metadata := make(map[string]any)
-		if err = json.Unmarshal([]byte(payload), &metadata); err != nil {
+		if err = json.Unmarshal(plain, &metadata); err != nil {
 			log.WithError(err).Warnf("postgres store: skipping auth %s with invalid json", id)
 			continue
 		}
@@ -389,6 +506,9 @@ func (s *PostgresStore) PersistConfig(ctx context.Context) error {
 		}
 		return fmt.Errorf("postgres store: read config file: %w", err)
 	}
+	if s.consumeAppliedConfig(data) {
+		return nil
+	}
 	return s.persistConfig(ctx, data)
 }
 
@@ -469,6 +589,9 @@ func (s *PostgresStore) syncAuthFromDatabase(ctx context.Context) error {
 		if err = os.WriteFile(path, []byte(payload), 0o600); err != nil {
 			return fmt.Errorf("postgres store: write auth file: %w", err)
 		}
+		s.mu.Lock()
+		s.knownAuth[id] = struct{}{}
+		s.mu.Unlock()
 	}
 	if err = rows.Err(); err != nil {
 		return fmt.Errorf("postgres store: iterate auth rows: %w", err)
@@ -480,6 +603,9 @@ func (s *PostgresStore) syncAuthFile(ctx context.Context, relID, path string) er
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
+			if s.consumeAppliedAuth(relID, nil) {
+				return nil
+			}
 			return s.deleteAuthRecord(ctx, relID)
 		}
 		return fmt.Errorf("postgres store: read auth file: %w", err)
@@ -487,6 +613,9 @@ func (s *PostgresStore) syncAuthFile(ctx context.Context, relID, path string) er
 	if len(data) == 0 {
 		return s.deleteAuthRecord(ctx, relID)
 	}
+	if s.consumeAppliedAuth(relID, data) {
+		return nil
+	}
 	return s.persistAuth(ctx, relID, data)
 }
 
@@ -512,6 +641,8 @@ func (s *PostgresStore) persistAuth(ctx context.Context, relID string, data []by
 	if _, err := s.db.ExecContext(ctx, query, relID, jsonPayload); err != nil {
 		return fmt.Errorf("postgres store: upsert auth record: %w", err)
 	}
+	s.knownAuth[relID] = struct{}{}
+	s.notify(ctx, notifyKindAuth, relID, false)
 	return nil
 }
 
@@ -520,6 +651,8 @@ func (s *PostgresStore) deleteAuthRecord(ctx context.Context, relID string) erro
 	if _, err := s.db.ExecContext(ctx, query, relID); err != nil {
 		return fmt.Errorf("postgres store: delete auth record: %w", err)
 	}
+	delete(s.knownAuth, relID)
+	s.notify(ctx, notifyKindAuth, relID, true)
 	return nil
 }
 
@@ -534,6 +667,7 @@ func (s *PostgresStore) persistConfig(ctx context.Context, data []byte) error {
 	if _, err := s.db.ExecContext(ctx, query, defaultConfigKey, normalized); err != nil {
 		return fmt.Errorf("postgres store: upsert config: %w", err)
 	}
+	s.notify(ctx, notifyKindConfig, "", false)
 	return nil
 }
 
@@ -542,6 +676,7 @@ func (s *PostgresStore) deleteConfigRecord(ctx context.Context) error {
 	if _, err := s.db.ExecContext(ctx, query, defaultConfigKey); err != nil {
 		return fmt.Errorf("postgres store: delete config: %w", err)
 	}
+	s.notify(ctx, notifyKindConfig, "", true)
 	return nil
 }
 
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Conflict policies applied when a file changed both locally and remotely since the last sync.
+const (
+	// SyncPolicyRemoteWins overwrites local edits with the remote copy.
+	SyncPolicyRemoteWins = "remote-wins"
+	// SyncPolicyNewestWins keeps whichever copy was modified last: the local file's
+	// modification time against the remote commit or object timestamp.
+	SyncPolicyNewestWins = "newest-wins"
+)
+
+// ParseSyncPolicy normalizes a conflict policy name. An empty value selects remote-wins.
+func ParseSyncPolicy(raw string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(raw)); policy {
+	case "":
+		return SyncPolicyRemoteWins, nil
+	case SyncPolicyRemoteWins, SyncPolicyNewestWins:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown sync policy %q (want %s or %s)", raw, SyncPolicyRemoteWins, SyncPolicyNewestWins)
+	}
+}
+
+// RemotePullResult lists what one pull changed in the local workspace. Paths are relative to
+// the workspace root.
+type RemotePullResult struct {
+	Updated   []string `json:"updated"`
+	Removed   []string `json:"removed"`
+	KeptLocal []string `json:"kept-local"`
+	Conflicts int      `json:"conflicts"`
+}
+
+// RemoteSyncStatus reports the state of the periodic remote pull for the management API.
+type RemoteSyncStatus struct {
+	Backend     string            `json:"backend"`
+	Enabled     bool              `json:"enabled"`
+	Interval    string            `json:"interval,omitempty"`
+	Policy      string            `json:"policy"`
+	LastAttempt time.Time         `json:"last-attempt,omitzero"`
+	LastSuccess time.Time         `json:"last-success,omitzero"`
+	LastError   string            `json:"last-error,omitempty"`
+	LastResult  *RemotePullResult `json:"last-result,omitempty"`
+}
+
+// remoteSyncState tracks the pull status of one store.
+type remoteSyncState struct {
+	mu     sync.Mutex
+	status RemoteSyncStatus
+}
+
+func (st *remoteSyncState) policy() string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.status.Policy == "" {
+		return SyncPolicyRemoteWins
+	}
+	return st.status.Policy
+}
+
+func (st *remoteSyncState) configure(backend string, interval time.Duration, policy string) {
+	st.mu.Lock()
+	st.status.Backend = backend
+	st.status.Enabled = interval > 0
+	st.status.Interval = ""
+	if interval > 0 {
+		st.status.Interval = interval.String()
+	}
+	st.status.Policy = policy
+	st.mu.Unlock()
+}
+
+func (st *remoteSyncState) snapshot(backend string) RemoteSyncStatus {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	status := st.status
+	if status.Backend == "" {
+		status.Backend = backend
+	}
+	if status.Policy == "" {
+		status.Policy = SyncPolicyRemoteWins
+	}
+	if status.LastResult != nil {
+		result := *status.LastResult
+		status.LastResult = &result
+	}
+	return status
+}
+
+func (st *remoteSyncState) record(result RemotePullResult, err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	now := time.Now()
+	st.status.LastAttempt = now
+	if err != nil {
+		st.status.LastError = err.Error()
+		return
+	}
+	st.status.LastError = ""
+	st.status.LastSuccess = now
+	st.status.LastResult = &result
+}
+
+// runRemotePull calls pull every interval until ctx is cancelled.
+func runRemotePull(ctx context.Context, name string, interval time.Duration, pull func(context.Context) (RemotePullResult, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := pull(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.WithError(err).Warnf("%s: remote pull failed", name)
+				}
+				continue
+			}
+			if n := len(result.Updated) + len(result.Removed); n > 0 || result.Conflicts > 0 {
+				log.Infof("%s: pulled %d remote change(s), kept %d local, %d conflict(s)", name, n, len(result.KeptLocal), result.Conflicts)
+			}
+		}
+	}
+}
+
+// pullAction is the outcome of comparing one path across the last synced, local and remote
+// versions.
+type pullAction int
+
+const (
+	pullSkip pullAction = iota
+	pullApplyRemote
+	pullKeepLocal
+)
+
+// resolvePull decides a three-way comparison. Local-only edits are kept so they get pushed,
+// remote-only edits are applied, and edits on both sides follow the policy; identical edits
+// need nothing. conflict reports whether both sides changed to different contents.
+func resolvePull(policy string, localChanged, remoteChanged, sameContent bool, localModTime, remoteModTime time.Time) (action pullAction, conflict bool) {
+	switch {
+	case !remoteChanged && !localChanged:
+		return pullSkip, false
+	case !remoteChanged:
+		return pullKeepLocal, false
+	case !localChanged:
+		return pullApplyRemote, false
+	case sameContent:
+		return pullSkip, false
+	}
+	if policy == SyncPolicyNewestWins && localModTime.After(remoteModTime) {
+		return pullKeepLocal, true
+	}
+	return pullApplyRemote, true
+}
+
+// writeFileAtomic replaces path with data through a temporary file so the watcher never sees a
+// partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
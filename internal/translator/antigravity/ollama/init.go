@@ -0,0 +1,23 @@
+// Package ollama translates Ollama API requests to Antigravity by chaining through the
+// OpenAI Chat Completions translators.
+package ollama
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	chat_completions "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/antigravity/openai/chat-completions"
+	openaiollama "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/ollama"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		Ollama,
+		Antigravity,
+		openaiollama.RequestVia(chat_completions.ConvertOpenAIRequestToAntigravity),
+		openaiollama.ResponseVia(interfaces.TranslateResponse{
+			Stream:    chat_completions.ConvertAntigravityResponseToOpenAI,
+			NonStream: chat_completions.ConvertAntigravityResponseToOpenAINonStream,
+		}),
+	)
+}
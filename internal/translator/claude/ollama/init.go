@@ -0,0 +1,23 @@
+// Package ollama translates Ollama API requests to Claude Messages by chaining through the
+// OpenAI Chat Completions translators.
+package ollama
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	chat_completions "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/openai/chat-completions"
+	openaiollama "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/ollama"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		Ollama,
+		Claude,
+		openaiollama.RequestVia(chat_completions.ConvertOpenAIRequestToClaude),
+		openaiollama.ResponseVia(interfaces.TranslateResponse{
+			Stream:    chat_completions.ConvertClaudeResponseToOpenAI,
+			NonStream: chat_completions.ConvertClaudeResponseToOpenAINonStream,
+		}),
+	)
+}
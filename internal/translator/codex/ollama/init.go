@@ -0,0 +1,23 @@
+// Package ollama translates Ollama API requests to Codex Responses by chaining through the
+// OpenAI Chat Completions translators.
+package ollama
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	chat_completions "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/codex/openai/chat-completions"
+	openaiollama "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/ollama"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		Ollama,
+		Codex,
+		openaiollama.RequestVia(chat_completions.ConvertOpenAIRequestToCodex),
+		openaiollama.ResponseVia(interfaces.TranslateResponse{
+			Stream:    chat_completions.ConvertCodexResponseToOpenAI,
+			NonStream: chat_completions.ConvertCodexResponseToOpenAINonStream,
+		}),
+	)
+}
@@ -0,0 +1,23 @@
+// Package ollama translates Ollama API requests to Gemini by chaining through the
+// OpenAI Chat Completions translators.
+package ollama
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	chat_completions "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/chat-completions"
+	openaiollama "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/ollama"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		Ollama,
+		Gemini,
+		openaiollama.RequestVia(chat_completions.ConvertOpenAIRequestToGemini),
+		openaiollama.ResponseVia(interfaces.TranslateResponse{
+			Stream:    chat_completions.ConvertGeminiResponseToOpenAI,
+			NonStream: chat_completions.ConvertGeminiResponseToOpenAINonStream,
+		}),
+	)
+}
@@ -0,0 +1,67 @@
+// Package embeddings provides translation between the OpenAI Embeddings API and the
+// Gemini batchEmbedContents API. It converts OpenAI embedding inputs into Gemini
+// embed requests and maps Gemini embedding vectors back into OpenAI list objects.
+package embeddings
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingsRequestToGemini converts an OpenAI embeddings request into a
+// Gemini batchEmbedContents request. Only string inputs are supported because Gemini
+// does not accept pre-tokenized input; token arrays are skipped.
+//
+// Parameters:
+//   - modelName: The upstream Gemini model name
+//   - rawJSON: The raw JSON request data from the OpenAI API
+//
+// Returns:
+//   - []byte: The batchEmbedContents request body; its "requests" array is empty when no input is usable
+func ConvertOpenAIEmbeddingsRequestToGemini(modelName string, rawJSON []byte) []byte {
+	out := []byte(`{"requests":[]}`)
+	modelPath := modelName
+	if !strings.HasPrefix(modelPath, "models/") {
+		modelPath = "models/" + modelPath
+	}
+
+	dimensions := gjson.GetBytes(rawJSON, "dimensions")
+	taskType := gjson.GetBytes(rawJSON, "task_type")
+
+	for _, text := range OpenAIEmbeddingInputs(rawJSON) {
+		item := []byte(`{}`)
+		item, _ = sjson.SetBytes(item, "model", modelPath)
+		item, _ = sjson.SetBytes(item, "content.parts.0.text", text)
+		if dimensions.Exists() && dimensions.Int() > 0 {
+			item, _ = sjson.SetBytes(item, "outputDimensionality", dimensions.Int())
+		}
+		if taskType.Type == gjson.String && taskType.String() != "" {
+			item, _ = sjson.SetBytes(item, "taskType", taskType.String())
+		}
+		out, _ = sjson.SetRawBytes(out, "requests.-1", item)
+	}
+	return out
+}
+
+// OpenAIEmbeddingInputs extracts the text inputs from an OpenAI embeddings request.
+// The input field may be a single string or an array of strings; other shapes are ignored.
+func OpenAIEmbeddingInputs(rawJSON []byte) []string {
+	input := gjson.GetBytes(rawJSON, "input")
+	switch {
+	case input.Type == gjson.String:
+		return []string{input.String()}
+	case input.IsArray():
+		items := input.Array()
+		texts := make([]string, 0, len(items))
+		for _, item := range items {
+			if item.Type == gjson.String {
+				texts = append(texts, item.String())
+			}
+		}
+		return texts
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package embeddings
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiEmbeddingsResponseToOpenAI converts a Gemini embedContent or
+// batchEmbedContents response into an OpenAI embeddings list object.
+//
+// Parameters:
+//   - modelName: The client-facing model name reported in the response
+//   - originalRequestRawJSON: The original OpenAI request, used for encoding_format
+//   - rawJSON: The raw JSON response from the Gemini API
+//
+// Returns:
+//   - []byte: The OpenAI-compatible embeddings response
+func ConvertGeminiEmbeddingsResponseToOpenAI(modelName string, originalRequestRawJSON, rawJSON []byte) []byte {
+	useBase64 := gjson.GetBytes(originalRequestRawJSON, "encoding_format").String() == "base64"
+
+	var vectors []gjson.Result
+	if embeddings := gjson.GetBytes(rawJSON, "embeddings"); embeddings.IsArray() {
+		vectors = embeddings.Array()
+	} else if embedding := gjson.GetBytes(rawJSON, "embedding"); embedding.Exists() {
+		vectors = []gjson.Result{embedding}
+	}
+
+	out := []byte(`{"object":"list","data":[],"model":"","usage":{"prompt_tokens":0,"total_tokens":0}}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	for i, vector := range vectors {
+		item := []byte(`{"object":"embedding","index":0,"embedding":[]}`)
+		item, _ = sjson.SetBytes(item, "index", i)
+		values := vector.Get("values")
+		if useBase64 {
+			item, _ = sjson.SetBytes(item, "embedding", encodeEmbeddingBase64(values))
+		} else if values.IsArray() {
+			item, _ = sjson.SetRawBytes(item, "embedding", []byte(values.Raw))
+		}
+		out, _ = sjson.SetRawBytes(out, "data.-1", item)
+	}
+
+	if promptTokens := gjson.GetBytes(rawJSON, "usageMetadata.promptTokenCount"); promptTokens.Exists() {
+		out, _ = sjson.SetBytes(out, "usage.prompt_tokens", promptTokens.Int())
+		out, _ = sjson.SetBytes(out, "usage.total_tokens", promptTokens.Int())
+	}
+	return out
+}
+
+// encodeEmbeddingBase64 packs float values as little-endian float32, matching OpenAI's base64 encoding.
+func encodeEmbeddingBase64(values gjson.Result) string {
+	items := values.Array()
+	buf := make([]byte, 4*len(items))
+	for i, v := range items {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(v.Float())))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,72 @@
+package embeddings
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIEmbeddingsRequestToGemini(t *testing.T) {
+	raw := []byte(`{"model":"gemini-embedding-001","input":["alpha","beta",[1,2]],"dimensions":256}`)
+	out := ConvertOpenAIEmbeddingsRequestToGemini("gemini-embedding-001", raw)
+
+	requests := gjson.GetBytes(out, "requests").Array()
+	if len(requests) != 2 {
+		t.Fatalf("requests = %d, want 2: %s", len(requests), out)
+	}
+	if got := requests[0].Get("model").String(); got != "models/gemini-embedding-001" {
+		t.Fatalf("model = %q", got)
+	}
+	if got := requests[1].Get("content.parts.0.text").String(); got != "beta" {
+		t.Fatalf("text = %q", got)
+	}
+	if got := requests[0].Get("outputDimensionality").Int(); got != 256 {
+		t.Fatalf("outputDimensionality = %d, want 256", got)
+	}
+}
+
+func TestConvertOpenAIEmbeddingsRequestToGeminiSingleString(t *testing.T) {
+	out := ConvertOpenAIEmbeddingsRequestToGemini("models/text-embedding-004", []byte(`{"input":"hello"}`))
+	if got := gjson.GetBytes(out, "requests.#").Int(); got != 1 {
+		t.Fatalf("requests = %d, want 1", got)
+	}
+	if got := gjson.GetBytes(out, "requests.0.model").String(); got != "models/text-embedding-004" {
+		t.Fatalf("model = %q", got)
+	}
+}
+
+func TestConvertGeminiEmbeddingsResponseToOpenAI(t *testing.T) {
+	raw := []byte(`{"embeddings":[{"values":[0.5,-1]},{"values":[0.25]}]}`)
+	out := ConvertGeminiEmbeddingsResponseToOpenAI("gemini-embedding-001", []byte(`{"input":["a","b"]}`), raw)
+
+	if got := gjson.GetBytes(out, "object").String(); got != "list" {
+		t.Fatalf("object = %q", got)
+	}
+	data := gjson.GetBytes(out, "data").Array()
+	if len(data) != 2 {
+		t.Fatalf("data = %d, want 2: %s", len(data), out)
+	}
+	if data[1].Get("index").Int() != 1 || data[1].Get("embedding.0").Float() != 0.25 {
+		t.Fatalf("unexpected second embedding: %s", data[1].Raw)
+	}
+	if got := gjson.GetBytes(out, "model").String(); got != "gemini-embedding-001" {
+		t.Fatalf("model = %q", got)
+	}
+}
+
+func TestConvertGeminiEmbeddingsResponseToOpenAIBase64(t *testing.T) {
+	raw := []byte(`{"embedding":{"values":[1.5]}}`)
+	out := ConvertGeminiEmbeddingsResponseToOpenAI("m", []byte(`{"input":"a","encoding_format":"base64"}`), raw)
+
+	encoded := gjson.GetBytes(out, "data.0.embedding").String()
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) != 4 {
+		t.Fatalf("decode base64 embedding: %v (len %d)", err, len(decoded))
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(decoded)); got != 1.5 {
+		t.Fatalf("decoded value = %v, want 1.5", got)
+	}
+}
@@ -0,0 +1,55 @@
+// Package embeddings provides translation between the Gemini embedContent /
+// batchEmbedContents APIs and the OpenAI Embeddings API for OpenAI-compatible upstreams.
+package embeddings
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiEmbeddingsRequestToOpenAI converts a Gemini embedContent or
+// batchEmbedContents request into an OpenAI embeddings request.
+//
+// Parameters:
+//   - modelName: The upstream model name
+//   - rawJSON: The raw JSON request data from the Gemini API
+//
+// Returns:
+//   - []byte: The OpenAI embeddings request body
+func ConvertGeminiEmbeddingsRequestToOpenAI(modelName string, rawJSON []byte) []byte {
+	out := []byte(`{"model":"","input":[],"encoding_format":"float"}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+
+	requests := []gjson.Result{gjson.ParseBytes(rawJSON)}
+	if batch := gjson.GetBytes(rawJSON, "requests"); batch.IsArray() {
+		requests = batch.Array()
+	}
+	var dimensions int64
+	for _, request := range requests {
+		var text strings.Builder
+		request.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			if partText := part.Get("text"); partText.Exists() {
+				if text.Len() > 0 {
+					text.WriteString("\n")
+				}
+				text.WriteString(partText.String())
+			}
+			return true
+		})
+		out, _ = sjson.SetBytes(out, "input.-1", text.String())
+		if dimensions == 0 {
+			dimensions = request.Get("outputDimensionality").Int()
+		}
+	}
+	if dimensions > 0 {
+		out, _ = sjson.SetBytes(out, "dimensions", dimensions)
+	}
+	return out
+}
+
+// IsGeminiBatchEmbeddingsRequest reports whether the request uses the batchEmbedContents shape.
+func IsGeminiBatchEmbeddingsRequest(rawJSON []byte) bool {
+	return gjson.GetBytes(rawJSON, "requests").IsArray()
+}
@@ -0,0 +1,52 @@
+package embeddings
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingsResponseToGemini converts an OpenAI embeddings list into a Gemini
+// embedContent response, or a batchEmbedContents response when batch is true.
+//
+// Parameters:
+//   - rawJSON: The raw JSON response from the OpenAI-compatible API
+//   - batch: Whether the client used batchEmbedContents
+//
+// Returns:
+//   - []byte: The Gemini-compatible embeddings response
+func ConvertOpenAIEmbeddingsResponseToGemini(rawJSON []byte, batch bool) []byte {
+	data := gjson.GetBytes(rawJSON, "data").Array()
+	ordered := make([]gjson.Result, len(data))
+	for i, item := range data {
+		idx := int(item.Get("index").Int())
+		if !item.Get("index").Exists() || idx < 0 || idx >= len(ordered) || ordered[idx].Exists() {
+			idx = i
+		}
+		ordered[idx] = item
+	}
+
+	out := []byte(`{"embeddings":[]}`)
+	for _, item := range ordered {
+		values := item.Get("embedding")
+		entry := []byte(`{"values":[]}`)
+		if values.IsArray() {
+			entry, _ = sjson.SetRawBytes(entry, "values", []byte(values.Raw))
+		}
+		out, _ = sjson.SetRawBytes(out, "embeddings.-1", entry)
+	}
+	if promptTokens := gjson.GetBytes(rawJSON, "usage.prompt_tokens"); promptTokens.Exists() {
+		out, _ = sjson.SetBytes(out, "usageMetadata.promptTokenCount", promptTokens.Int())
+	}
+	if batch {
+		return out
+	}
+
+	single := []byte(`{"embedding":{"values":[]}}`)
+	if first := gjson.GetBytes(out, "embeddings.0"); first.Exists() {
+		single, _ = sjson.SetRawBytes(single, "embedding", []byte(first.Raw))
+	}
+	if usageMetadata := gjson.GetBytes(out, "usageMetadata"); usageMetadata.Exists() {
+		single, _ = sjson.SetRawBytes(single, "usageMetadata", []byte(usageMetadata.Raw))
+	}
+	return single
+}
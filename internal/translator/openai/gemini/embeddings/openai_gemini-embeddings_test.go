@@ -0,0 +1,43 @@
+package embeddings
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertGeminiEmbeddingsRequestToOpenAI(t *testing.T) {
+	raw := []byte(`{"requests":[{"model":"models/x","content":{"parts":[{"text":"a"},{"text":"b"}]},"outputDimensionality":64},{"content":{"parts":[{"text":"c"}]}}]}`)
+	out := ConvertGeminiEmbeddingsRequestToOpenAI("text-embedding-3-small", raw)
+
+	if got := gjson.GetBytes(out, "model").String(); got != "text-embedding-3-small" {
+		t.Fatalf("model = %q", got)
+	}
+	inputs := gjson.GetBytes(out, "input").Array()
+	if len(inputs) != 2 || inputs[0].String() != "a\nb" || inputs[1].String() != "c" {
+		t.Fatalf("unexpected input: %s", gjson.GetBytes(out, "input").Raw)
+	}
+	if got := gjson.GetBytes(out, "dimensions").Int(); got != 64 {
+		t.Fatalf("dimensions = %d, want 64", got)
+	}
+	if !IsGeminiBatchEmbeddingsRequest(raw) {
+		t.Fatalf("expected batch request to be detected")
+	}
+}
+
+func TestConvertOpenAIEmbeddingsResponseToGemini(t *testing.T) {
+	raw := []byte(`{"object":"list","data":[{"index":1,"embedding":[2]},{"index":0,"embedding":[1]}],"usage":{"prompt_tokens":7,"total_tokens":7}}`)
+
+	batch := ConvertOpenAIEmbeddingsResponseToGemini(raw, true)
+	if got := gjson.GetBytes(batch, "embeddings.0.values.0").Int(); got != 1 {
+		t.Fatalf("embeddings not ordered by index: %s", batch)
+	}
+	if got := gjson.GetBytes(batch, "usageMetadata.promptTokenCount").Int(); got != 7 {
+		t.Fatalf("promptTokenCount = %d, want 7", got)
+	}
+
+	single := ConvertOpenAIEmbeddingsResponseToGemini(raw, false)
+	if got := gjson.GetBytes(single, "embedding.values.0").Int(); got != 1 {
+		t.Fatalf("single embedding = %s", single)
+	}
+}
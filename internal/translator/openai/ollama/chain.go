@@ -0,0 +1,46 @@
+package ollama
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+// chainParams holds the per-stream state of both translation stages.
+type chainParams struct {
+	openAIRequest []byte
+	inner         any
+	outer         any
+}
+
+// RequestVia returns a request translator that converts an Ollama request to OpenAI Chat
+// Completions and then hands it to toTarget, the OpenAI translator of the target format.
+func RequestVia(toTarget interfaces.TranslateRequestFunc) interfaces.TranslateRequestFunc {
+	return func(modelName string, rawJSON []byte, stream bool) []byte {
+		return toTarget(modelName, ConvertOllamaRequestToOpenAI(modelName, rawJSON, stream), stream)
+	}
+}
+
+// ResponseVia returns response translators that convert target responses to OpenAI Chat
+// Completions with fromTarget and then to Ollama records. The inner translator sees the
+// OpenAI form of the original request, which is what it was written against.
+func ResponseVia(fromTarget interfaces.TranslateResponse) interfaces.TranslateResponse {
+	return interfaces.TranslateResponse{
+		Stream: func(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+			if *param == nil {
+				*param = &chainParams{openAIRequest: ConvertOllamaRequestToOpenAI(modelName, originalRequestRawJSON, true)}
+			}
+			state := (*param).(*chainParams)
+			var out [][]byte
+			for _, chunk := range fromTarget.Stream(ctx, modelName, state.openAIRequest, requestRawJSON, rawJSON, &state.inner) {
+				out = append(out, ConvertOpenAIResponseToOllama(ctx, modelName, originalRequestRawJSON, requestRawJSON, chunk, &state.outer)...)
+			}
+			return out
+		},
+		NonStream: func(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
+			openAIRequest := ConvertOllamaRequestToOpenAI(modelName, originalRequestRawJSON, false)
+			openAIResponse := fromTarget.NonStream(ctx, modelName, openAIRequest, requestRawJSON, rawJSON, param)
+			return ConvertOpenAIResponseToOllamaNonStream(ctx, modelName, originalRequestRawJSON, requestRawJSON, openAIResponse, nil)
+		},
+	}
+}
@@ -0,0 +1,209 @@
+// Package ollama provides translation between the Ollama API (/api/chat and /api/generate)
+// and OpenAI Chat Completions. Other targets reach Ollama clients by chaining through the
+// OpenAI translators with RequestVia and ResponseVia.
+package ollama
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOllamaRequestToOpenAI converts an Ollama chat or generate request into an OpenAI
+// Chat Completions request. Generate requests (prompt, system, images) become a single user
+// turn preceded by an optional system message.
+//
+// Parameters:
+//   - modelName: The name of the model to use for the request
+//   - inputRawJSON: The raw JSON request data from the Ollama API
+//   - stream: A boolean indicating if the request is for a streaming response
+//
+// Returns:
+//   - []byte: The transformed request data in OpenAI Chat Completions format
+func ConvertOllamaRequestToOpenAI(modelName string, inputRawJSON []byte, stream bool) []byte {
+	root := gjson.ParseBytes(inputRawJSON)
+	out := []byte(`{"model":"","messages":[]}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	out, _ = sjson.SetBytes(out, "stream", stream)
+
+	if messages := root.Get("messages"); messages.IsArray() {
+		out = appendChatMessages(out, messages)
+	} else {
+		if system := root.Get("system"); system.Exists() && system.String() != "" {
+			out, _ = sjson.SetBytes(out, "messages.-1", map[string]string{"role": "system", "content": system.String()})
+		}
+		out = appendMessage(out, "user", root.Get("prompt").String(), root.Get("images"))
+	}
+
+	options := root.Get("options")
+	if v := options.Get("temperature"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "temperature", v.Float())
+	}
+	if v := options.Get("top_p"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "top_p", v.Float())
+	}
+	if v := options.Get("num_predict"); v.Exists() && v.Int() > 0 {
+		out, _ = sjson.SetBytes(out, "max_tokens", v.Int())
+	}
+	if v := options.Get("seed"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "seed", v.Int())
+	}
+	if v := options.Get("frequency_penalty"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "frequency_penalty", v.Float())
+	}
+	if v := options.Get("presence_penalty"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "presence_penalty", v.Float())
+	}
+	if v := options.Get("stop"); v.Exists() {
+		out, _ = sjson.SetRawBytes(out, "stop", []byte(v.Raw))
+	}
+
+	// Structured outputs: "json" selects JSON mode, an object is a JSON schema.
+	if format := root.Get("format"); format.Exists() {
+		switch {
+		case format.IsObject():
+			out, _ = sjson.SetBytes(out, "response_format.type", "json_schema")
+			out, _ = sjson.SetBytes(out, "response_format.json_schema.name", "response")
+			out, _ = sjson.SetRawBytes(out, "response_format.json_schema.schema", []byte(format.Raw))
+		case format.String() == "json":
+			out, _ = sjson.SetBytes(out, "response_format.type", "json_object")
+		}
+	}
+
+	// Thinking: true/false toggle reasoning, level strings ("low", "medium", "high") pass through.
+	if think := root.Get("think"); think.Exists() {
+		switch think.Type {
+		case gjson.True:
+			if effort, ok := thinking.ConvertBudgetToLevel(-1); ok && effort != "" {
+				out, _ = sjson.SetBytes(out, "reasoning_effort", effort)
+			}
+		case gjson.False:
+			if effort, ok := thinking.ConvertBudgetToLevel(0); ok && effort != "" {
+				out, _ = sjson.SetBytes(out, "reasoning_effort", effort)
+			}
+		case gjson.String:
+			if effort := strings.ToLower(strings.TrimSpace(think.String())); effort != "" {
+				out, _ = sjson.SetBytes(out, "reasoning_effort", effort)
+			}
+		}
+	}
+
+	if tools := root.Get("tools"); tools.IsArray() && len(tools.Array()) > 0 {
+		out, _ = sjson.SetRawBytes(out, "tools", []byte(tools.Raw))
+	}
+	return out
+}
+
+// appendChatMessages converts Ollama chat messages. Ollama tool calls carry no ids, so ids are
+// generated and tool results are paired with the oldest pending call of the same name.
+func appendChatMessages(out []byte, messages gjson.Result) []byte {
+	callCount := 0
+	type pendingCall struct{ id, name string }
+	var pending []pendingCall
+
+	messages.ForEach(func(_, message gjson.Result) bool {
+		role := message.Get("role").String()
+		switch role {
+		case "assistant":
+			msg := []byte(`{"role":"assistant","content":""}`)
+			msg, _ = sjson.SetBytes(msg, "content", message.Get("content").String())
+			if text := message.Get("thinking").String(); text != "" {
+				msg, _ = sjson.SetBytes(msg, "reasoning_content", text)
+			}
+			message.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+				callCount++
+				id := fmt.Sprintf("call_%d", callCount)
+				name := call.Get("function.name").String()
+				arguments := call.Get("function.arguments")
+				argumentsText := arguments.Raw
+				if arguments.Type == gjson.String {
+					argumentsText = arguments.String()
+				} else if argumentsText == "" {
+					argumentsText = "{}"
+				}
+				toolCall := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
+				toolCall, _ = sjson.SetBytes(toolCall, "id", id)
+				toolCall, _ = sjson.SetBytes(toolCall, "function.name", name)
+				toolCall, _ = sjson.SetBytes(toolCall, "function.arguments", argumentsText)
+				msg, _ = sjson.SetRawBytes(msg, "tool_calls.-1", toolCall)
+				pending = append(pending, pendingCall{id: id, name: name})
+				return true
+			})
+			out, _ = sjson.SetRawBytes(out, "messages.-1", msg)
+		case "tool":
+			name := message.Get("tool_name").String()
+			if name == "" {
+				name = message.Get("name").String()
+			}
+			id := ""
+			for i, call := range pending {
+				if name == "" || call.name == name {
+					id = call.id
+					pending = append(pending[:i], pending[i+1:]...)
+					break
+				}
+			}
+			if id == "" {
+				callCount++
+				id = fmt.Sprintf("call_%d", callCount)
+			}
+			msg := []byte(`{"role":"tool","tool_call_id":"","content":""}`)
+			msg, _ = sjson.SetBytes(msg, "tool_call_id", id)
+			msg, _ = sjson.SetBytes(msg, "content", message.Get("content").String())
+			out, _ = sjson.SetRawBytes(out, "messages.-1", msg)
+		default:
+			if role == "" {
+				role = "user"
+			}
+			out = appendMessage(out, role, message.Get("content").String(), message.Get("images"))
+		}
+		return true
+	})
+	return out
+}
+
+// appendMessage appends a text message, switching to content parts when images are attached.
+func appendMessage(out []byte, role, text string, images gjson.Result) []byte {
+	if !images.IsArray() || len(images.Array()) == 0 {
+		msg, _ := sjson.SetBytes([]byte(`{"role":"","content":""}`), "role", role)
+		msg, _ = sjson.SetBytes(msg, "content", text)
+		out, _ = sjson.SetRawBytes(out, "messages.-1", msg)
+		return out
+	}
+	msg, _ := sjson.SetBytes([]byte(`{"role":"","content":[]}`), "role", role)
+	if text != "" {
+		msg, _ = sjson.SetBytes(msg, "content.-1", map[string]string{"type": "text", "text": text})
+	}
+	images.ForEach(func(_, image gjson.Result) bool {
+		data := strings.TrimSpace(image.String())
+		if data == "" {
+			return true
+		}
+		if !strings.HasPrefix(data, "data:") {
+			data = "data:" + imageMimeType(data) + ";base64," + data
+		}
+		part := []byte(`{"type":"image_url","image_url":{"url":""}}`)
+		part, _ = sjson.SetBytes(part, "image_url.url", data)
+		msg, _ = sjson.SetRawBytes(msg, "content.-1", part)
+		return true
+	})
+	out, _ = sjson.SetRawBytes(out, "messages.-1", msg)
+	return out
+}
+
+// imageMimeType sniffs the MIME type of a base64-encoded image from its leading bytes.
+func imageMimeType(data string) string {
+	switch {
+	case strings.HasPrefix(data, "/9j/"):
+		return "image/jpeg"
+	case strings.HasPrefix(data, "R0lG"):
+		return "image/gif"
+	case strings.HasPrefix(data, "UklG"):
+		return "image/webp"
+	default:
+		return "image/png"
+	}
+}
@@ -0,0 +1,228 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// convertOpenAIResponseToOllamaParams tracks state across streaming chunks.
+type convertOpenAIResponseToOllamaParams struct {
+	Started      time.Time
+	ToolCalls    map[int]*ollamaToolCall
+	FinishReason string
+	PromptTokens int64
+	EvalTokens   int64
+	HasUsage     bool
+	Done         bool
+}
+
+// ollamaToolCall accumulates a streamed OpenAI tool call.
+type ollamaToolCall struct {
+	Name      string
+	Arguments string
+}
+
+// ConvertOpenAIResponseToOllama converts one OpenAI Chat Completions stream chunk into Ollama
+// NDJSON records. Chat requests produce message records and generate requests produce response
+// records. Tool calls are buffered and emitted once complete, and the final "done" record is
+// emitted when both the finish reason and the usage are known, or at "[DONE]".
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The name of the model being used for the response
+//   - originalRequestRawJSON: The original Ollama request
+//   - requestRawJSON: The translated request
+//   - rawJSON: The raw OpenAI chunk, optionally prefixed with "data:"
+//   - param: A pointer to a parameter object for maintaining state between calls
+//
+// Returns:
+//   - [][]byte: A slice of Ollama JSON records without trailing newlines
+func ConvertOpenAIResponseToOllama(_ context.Context, modelName string, originalRequestRawJSON, _, rawJSON []byte, param *any) [][]byte {
+	if *param == nil {
+		*param = &convertOpenAIResponseToOllamaParams{Started: time.Now(), ToolCalls: make(map[int]*ollamaToolCall)}
+	}
+	state := (*param).(*convertOpenAIResponseToOllamaParams)
+	if state.Done {
+		return [][]byte{}
+	}
+
+	if bytes.HasPrefix(rawJSON, []byte("data:")) {
+		rawJSON = bytes.TrimSpace(rawJSON[5:])
+	}
+	rawJSON = bytes.TrimSpace(rawJSON)
+	chat := isChatRequest(originalRequestRawJSON)
+	model := responseModel(modelName, originalRequestRawJSON)
+	if bytes.Equal(rawJSON, []byte("[DONE]")) {
+		return state.finish(model, chat)
+	}
+	if len(rawJSON) == 0 || !gjson.ValidBytes(rawJSON) {
+		return [][]byte{}
+	}
+
+	root := gjson.ParseBytes(rawJSON)
+	var out [][]byte
+	choice := root.Get("choices.0")
+	delta := choice.Get("delta")
+	content := delta.Get("content").String()
+	reasoning := delta.Get("reasoning_content").String()
+	if content != "" || reasoning != "" {
+		out = append(out, newRecord(model, chat, content, reasoning))
+	}
+	delta.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+		index := int(call.Get("index").Int())
+		acc, ok := state.ToolCalls[index]
+		if !ok {
+			acc = &ollamaToolCall{}
+			state.ToolCalls[index] = acc
+		}
+		if name := call.Get("function.name").String(); name != "" {
+			acc.Name = name
+		}
+		acc.Arguments += call.Get("function.arguments").String()
+		return true
+	})
+
+	if reason := choice.Get("finish_reason").String(); reason != "" {
+		state.FinishReason = reason
+		if record := state.flushToolCalls(model, chat); record != nil {
+			out = append(out, record)
+		}
+	}
+	if usage := root.Get("usage"); usage.Exists() && usage.Type != gjson.Null {
+		state.PromptTokens = usage.Get("prompt_tokens").Int()
+		state.EvalTokens = usage.Get("completion_tokens").Int()
+		state.HasUsage = true
+	}
+	if state.FinishReason != "" && state.HasUsage {
+		out = append(out, state.finish(model, chat)...)
+	}
+	return out
+}
+
+// flushToolCalls emits the buffered tool calls as a single message record.
+func (s *convertOpenAIResponseToOllamaParams) flushToolCalls(model string, chat bool) []byte {
+	if len(s.ToolCalls) == 0 || !chat {
+		return nil
+	}
+	indexes := make([]int, 0, len(s.ToolCalls))
+	for index := range s.ToolCalls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	record := newRecord(model, true, "", "")
+	for _, index := range indexes {
+		call := s.ToolCalls[index]
+		record = appendToolCall(record, "message.tool_calls", call.Name, call.Arguments)
+	}
+	s.ToolCalls = make(map[int]*ollamaToolCall)
+	return record
+}
+
+func (s *convertOpenAIResponseToOllamaParams) finish(model string, chat bool) [][]byte {
+	if s.Done {
+		return [][]byte{}
+	}
+	s.Done = true
+	var out [][]byte
+	if record := s.flushToolCalls(model, chat); record != nil {
+		out = append(out, record)
+	}
+	record := newRecord(model, chat, "", "")
+	record, _ = sjson.SetBytes(record, "done", true)
+	record, _ = sjson.SetBytes(record, "done_reason", doneReason(s.FinishReason))
+	record, _ = sjson.SetBytes(record, "total_duration", time.Since(s.Started).Nanoseconds())
+	record, _ = sjson.SetBytes(record, "prompt_eval_count", s.PromptTokens)
+	record, _ = sjson.SetBytes(record, "eval_count", s.EvalTokens)
+	return append(out, record)
+}
+
+// ConvertOpenAIResponseToOllamaNonStream converts a complete OpenAI Chat Completions response
+// into a single Ollama chat or generate response.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The name of the model being used for the response
+//   - originalRequestRawJSON: The original Ollama request
+//   - requestRawJSON: The translated request
+//   - rawJSON: The raw OpenAI response
+//   - param: A pointer to a parameter object for the conversion
+//
+// Returns:
+//   - []byte: The Ollama JSON response
+func ConvertOpenAIResponseToOllamaNonStream(_ context.Context, modelName string, originalRequestRawJSON, _, rawJSON []byte, _ *any) []byte {
+	root := gjson.ParseBytes(rawJSON)
+	chat := isChatRequest(originalRequestRawJSON)
+	model := responseModel(modelName, originalRequestRawJSON)
+	message := root.Get("choices.0.message")
+
+	out := newRecord(model, chat, message.Get("content").String(), message.Get("reasoning_content").String())
+	if chat {
+		message.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+			out = appendToolCall(out, "message.tool_calls", call.Get("function.name").String(), call.Get("function.arguments").String())
+			return true
+		})
+	}
+	out, _ = sjson.SetBytes(out, "done", true)
+	out, _ = sjson.SetBytes(out, "done_reason", doneReason(root.Get("choices.0.finish_reason").String()))
+	out, _ = sjson.SetBytes(out, "total_duration", 0)
+	out, _ = sjson.SetBytes(out, "prompt_eval_count", root.Get("usage.prompt_tokens").Int())
+	out, _ = sjson.SetBytes(out, "eval_count", root.Get("usage.completion_tokens").Int())
+	return out
+}
+
+// newRecord builds an Ollama record that is not yet done.
+func newRecord(model string, chat bool, content, reasoning string) []byte {
+	var record []byte
+	if chat {
+		record = []byte(`{"model":"","created_at":"","message":{"role":"assistant","content":""},"done":false}`)
+		record, _ = sjson.SetBytes(record, "message.content", content)
+		if reasoning != "" {
+			record, _ = sjson.SetBytes(record, "message.thinking", reasoning)
+		}
+	} else {
+		record = []byte(`{"model":"","created_at":"","response":"","done":false}`)
+		record, _ = sjson.SetBytes(record, "response", content)
+		if reasoning != "" {
+			record, _ = sjson.SetBytes(record, "thinking", reasoning)
+		}
+	}
+	record, _ = sjson.SetBytes(record, "model", model)
+	record, _ = sjson.SetBytes(record, "created_at", time.Now().UTC().Format(time.RFC3339Nano))
+	return record
+}
+
+// appendToolCall appends an Ollama tool call, whose arguments are an object rather than a
+// JSON-encoded string.
+func appendToolCall(record []byte, path, name, arguments string) []byte {
+	call := []byte(`{"function":{"name":"","arguments":{}}}`)
+	call, _ = sjson.SetBytes(call, "function.name", name)
+	if args := gjson.Parse(arguments); args.IsObject() {
+		call, _ = sjson.SetRawBytes(call, "function.arguments", []byte(args.Raw))
+	}
+	record, _ = sjson.SetRawBytes(record, path+".-1", call)
+	return record
+}
+
+func isChatRequest(originalRequestRawJSON []byte) bool {
+	return gjson.GetBytes(originalRequestRawJSON, "messages").Exists()
+}
+
+// responseModel echoes the model the client asked for, falling back to the upstream model.
+func responseModel(modelName string, originalRequestRawJSON []byte) string {
+	if model := gjson.GetBytes(originalRequestRawJSON, "model").String(); model != "" {
+		return model
+	}
+	return modelName
+}
+
+func doneReason(finishReason string) string {
+	if finishReason == "length" {
+		return "length"
+	}
+	return "stop"
+}
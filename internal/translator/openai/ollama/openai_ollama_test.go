@@ -0,0 +1,123 @@
+package ollama
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOllamaRequestToOpenAIChat(t *testing.T) {
+	raw := []byte(`{
+		"model":"gpt-5:latest",
+		"messages":[
+			{"role":"system","content":"be brief"},
+			{"role":"user","content":"what is this?","images":["/9j/4AAQ"]},
+			{"role":"assistant","content":"","tool_calls":[{"function":{"name":"lookup","arguments":{"q":"cat"}}}]},
+			{"role":"tool","tool_name":"lookup","content":"a cat"}
+		],
+		"options":{"temperature":0.2,"num_predict":64,"stop":["\n\n"]},
+		"format":"json",
+		"think":true
+	}`)
+
+	out := ConvertOllamaRequestToOpenAI("gpt-5", raw, true)
+	root := gjson.ParseBytes(out)
+	if root.Get("model").String() != "gpt-5" || !root.Get("stream").Bool() {
+		t.Fatalf("unexpected model/stream: %s", out)
+	}
+	if got := root.Get("messages.1.content.1.image_url.url").String(); got != "data:image/jpeg;base64,/9j/4AAQ" {
+		t.Fatalf("image url = %q", got)
+	}
+	call := root.Get("messages.2.tool_calls.0")
+	if call.Get("id").String() != "call_1" || call.Get("function.arguments").String() != `{"q":"cat"}` {
+		t.Fatalf("unexpected tool call: %s", call.Raw)
+	}
+	if root.Get("messages.3.tool_call_id").String() != "call_1" {
+		t.Fatalf("tool result not paired: %s", root.Get("messages.3").Raw)
+	}
+	if root.Get("max_tokens").Int() != 64 || root.Get("temperature").Float() != 0.2 || root.Get("stop.0").String() != "\n\n" {
+		t.Fatalf("options not mapped: %s", out)
+	}
+	if root.Get("response_format.type").String() != "json_object" || root.Get("reasoning_effort").String() != "auto" {
+		t.Fatalf("format/think not mapped: %s", out)
+	}
+}
+
+func TestConvertOllamaRequestToOpenAIGenerate(t *testing.T) {
+	raw := []byte(`{"model":"m","system":"sys","prompt":"hello","stream":false}`)
+	out := ConvertOllamaRequestToOpenAI("m", raw, false)
+	if got := gjson.GetBytes(out, "messages.#.role").String(); got != `["system","user"]` {
+		t.Fatalf("roles = %s", got)
+	}
+	if got := gjson.GetBytes(out, "messages.1.content").String(); got != "hello" {
+		t.Fatalf("prompt = %q", got)
+	}
+}
+
+func TestConvertOpenAIResponseToOllamaStream(t *testing.T) {
+	original := []byte(`{"model":"gpt-5:latest","messages":[{"role":"user","content":"hi"}]}`)
+	var param any
+	var records []string
+	for _, line := range []string{
+		`data: {"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"reasoning_content":"hmm","content":"lo"}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"c1","function":{"name":"lookup","arguments":"{\"q\":"}}]}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"cat\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		`data: {"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":3}}`,
+		`data: [DONE]`,
+	} {
+		for _, record := range ConvertOpenAIResponseToOllama(context.Background(), "gpt-5", original, nil, []byte(line), &param) {
+			records = append(records, string(record))
+		}
+	}
+
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d: %v", len(records), records)
+	}
+	if gjson.Get(records[0], "model").String() != "gpt-5:latest" || gjson.Get(records[0], "message.content").String() != "Hel" {
+		t.Fatalf("unexpected first record: %s", records[0])
+	}
+	if gjson.Get(records[1], "message.thinking").String() != "hmm" {
+		t.Fatalf("thinking not mapped: %s", records[1])
+	}
+	if gjson.Get(records[2], "message.tool_calls.0.function.arguments.q").String() != "cat" {
+		t.Fatalf("tool call not assembled: %s", records[2])
+	}
+	last := gjson.Parse(records[3])
+	if !last.Get("done").Bool() || last.Get("done_reason").String() != "stop" || last.Get("prompt_eval_count").Int() != 7 || last.Get("eval_count").Int() != 3 {
+		t.Fatalf("unexpected done record: %s", records[3])
+	}
+}
+
+func TestResponseViaChainsThroughOpenAI(t *testing.T) {
+	var innerOriginal string
+	inner := interfaces.TranslateResponse{
+		Stream: func(_ context.Context, _ string, originalRequestRawJSON, _, rawJSON []byte, _ *any) [][]byte {
+			innerOriginal = string(originalRequestRawJSON)
+			text := strings.TrimPrefix(string(rawJSON), "upstream:")
+			return [][]byte{[]byte(`{"choices":[{"index":0,"delta":{"content":"` + text + `"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1}}`)}
+		},
+		NonStream: func(_ context.Context, _ string, _, _, _ []byte, _ *any) []byte {
+			return []byte(`{"choices":[{"message":{"role":"assistant","content":"done"},"finish_reason":"length"}],"usage":{"prompt_tokens":2,"completion_tokens":5}}`)
+		},
+	}
+	chained := ResponseVia(inner)
+	original := []byte(`{"model":"m","prompt":"hi"}`)
+
+	var param any
+	records := chained.Stream(context.Background(), "m", original, nil, []byte("upstream:ok"), &param)
+	if len(records) != 2 || gjson.GetBytes(records[0], "response").String() != "ok" || !gjson.GetBytes(records[1], "done").Bool() {
+		t.Fatalf("unexpected chained stream: %q", records)
+	}
+	if !gjson.Get(innerOriginal, "messages").Exists() {
+		t.Fatalf("inner translator should see the OpenAI request, got %s", innerOriginal)
+	}
+
+	out := chained.NonStream(context.Background(), "m", original, nil, nil, nil)
+	if gjson.GetBytes(out, "response").String() != "done" || gjson.GetBytes(out, "done_reason").String() != "length" || gjson.GetBytes(out, "eval_count").Int() != 5 {
+		t.Fatalf("unexpected chained non-stream: %s", out)
+	}
+}
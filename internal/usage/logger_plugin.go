@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
 	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 )
 
@@ -64,6 +65,7 @@ type RequestStatistics struct {
 	successCount  int64
 	failureCount  int64
 	totalTokens   int64
+	totalCost     float64
 
 	apis map[string]*apiStats
 
@@ -71,12 +73,15 @@ type RequestStatistics struct {
 	requestsByHour map[int]int64
 	tokensByDay    map[string]int64
 	tokensByHour   map[int]int64
+
+	redactions RedactionSnapshot
 }
 
 // apiStats holds aggregated metrics for a single API key.
 type apiStats struct {
 	TotalRequests int64
 	TotalTokens   int64
+	TotalCost     float64
 	Models        map[string]*modelStats
 }
 
@@ -84,16 +89,18 @@ type apiStats struct {
 type modelStats struct {
 	TotalRequests int64
 	TotalTokens   int64
+	TotalCost     float64
 	Details       []RequestDetail
 }
 
-// RequestDetail stores the timestamp, latency, and token usage for a single request.
+// RequestDetail stores the timestamp, latency, token usage and cost for a single request.
 type RequestDetail struct {
 	Timestamp time.Time  `json:"timestamp"`
 	LatencyMs int64      `json:"latency_ms"`
 	Source    string     `json:"source"`
 	AuthIndex string     `json:"auth_index"`
 	Tokens    TokenStats `json:"tokens"`
+	Cost      float64    `json:"cost"`
 	Failed    bool       `json:"failed"`
 }
 
@@ -112,19 +119,38 @@ type StatisticsSnapshot struct {
 	SuccessCount  int64 `json:"success_count"`
 	FailureCount  int64 `json:"failure_count"`
 	TotalTokens   int64 `json:"total_tokens"`
+	// TotalCost is the spend in USD priced from the model price table.
+	TotalCost float64 `json:"total_cost"`
 
 	APIs map[string]APISnapshot `json:"apis"`
 
+	// Credentials aggregates requests, tokens and cost per credential (auth index).
+	Credentials map[string]CredentialSnapshot `json:"credentials"`
+
 	RequestsByDay  map[string]int64 `json:"requests_by_day"`
 	RequestsByHour map[string]int64 `json:"requests_by_hour"`
 	TokensByDay    map[string]int64 `json:"tokens_by_day"`
 	TokensByHour   map[string]int64 `json:"tokens_by_hour"`
+
+	// Redactions counts prompts in which the redaction engine found secrets or PII.
+	Redactions RedactionSnapshot `json:"redactions"`
+}
+
+// RedactionSnapshot summarises redaction events. Each event is one request whose prompt
+// contained at least one finding; ByDetector counts individual matches.
+type RedactionSnapshot struct {
+	TotalEvents int64            `json:"total_events"`
+	Masked      int64            `json:"masked"`
+	Blocked     int64            `json:"blocked"`
+	Allowed     int64            `json:"allowed"`
+	ByDetector  map[string]int64 `json:"by_detector"`
 }
 
 // APISnapshot summarises metrics for a single API key.
 type APISnapshot struct {
 	TotalRequests int64                    `json:"total_requests"`
 	TotalTokens   int64                    `json:"total_tokens"`
+	TotalCost     float64                  `json:"total_cost"`
 	Models        map[string]ModelSnapshot `json:"models"`
 }
 
@@ -132,9 +158,17 @@ type APISnapshot struct {
 type ModelSnapshot struct {
 	TotalRequests int64           `json:"total_requests"`
 	TotalTokens   int64           `json:"total_tokens"`
+	TotalCost     float64         `json:"total_cost"`
 	Details       []RequestDetail `json:"details"`
 }
 
+// CredentialSnapshot summarises metrics for a single credential.
+type CredentialSnapshot struct {
+	TotalRequests int64   `json:"total_requests"`
+	TotalTokens   int64   `json:"total_tokens"`
+	TotalCost     float64 `json:"total_cost"`
+}
+
 var defaultRequestStatistics = NewRequestStatistics()
 
 // GetRequestStatistics returns the shared statistics store.
@@ -165,6 +199,7 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	}
 	detail := normaliseDetail(record.Detail)
 	totalTokens := detail.TotalTokens
+	cost := pricing.Cost(record)
 	statsKey := record.APIKey
 	if statsKey == "" {
 		statsKey = resolveAPIIdentifier(ctx, record)
@@ -191,6 +226,7 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		s.failureCount++
 	}
 	s.totalTokens += totalTokens
+	s.totalCost += cost
 
 	stats, ok := s.apis[statsKey]
 	if !ok {
@@ -203,6 +239,7 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		Source:    record.Source,
 		AuthIndex: record.AuthIndex,
 		Tokens:    detail,
+		Cost:      cost,
 		Failed:    failed,
 	})
 
@@ -212,9 +249,38 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	s.tokensByHour[hourKey] += totalTokens
 }
 
+// RecordRedaction counts a redaction event. action is the applied mode (mask, block or allow)
+// and findings maps detector names to match counts.
+func (s *RequestStatistics) RecordRedaction(action string, findings map[string]int) {
+	if s == nil || len(findings) == 0 {
+		return
+	}
+	if !statisticsEnabled.Load() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.redactions.TotalEvents++
+	switch action {
+	case "block":
+		s.redactions.Blocked++
+	case "allow":
+		s.redactions.Allowed++
+	default:
+		s.redactions.Masked++
+	}
+	if s.redactions.ByDetector == nil {
+		s.redactions.ByDetector = make(map[string]int64)
+	}
+	for detector, count := range findings {
+		s.redactions.ByDetector[detector] += int64(count)
+	}
+}
+
 func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail RequestDetail) {
 	stats.TotalRequests++
 	stats.TotalTokens += detail.Tokens.TotalTokens
+	stats.TotalCost += detail.Cost
 	modelStatsValue, ok := stats.Models[model]
 	if !ok {
 		modelStatsValue = &modelStats{}
@@ -222,6 +288,7 @@ func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail
 	}
 	modelStatsValue.TotalRequests++
 	modelStatsValue.TotalTokens += detail.Tokens.TotalTokens
+	modelStatsValue.TotalCost += detail.Cost
 	modelStatsValue.Details = append(modelStatsValue.Details, detail)
 }
 
@@ -239,12 +306,15 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result.SuccessCount = s.successCount
 	result.FailureCount = s.failureCount
 	result.TotalTokens = s.totalTokens
+	result.TotalCost = s.totalCost
 
 	result.APIs = make(map[string]APISnapshot, len(s.apis))
+	result.Credentials = make(map[string]CredentialSnapshot)
 	for apiName, stats := range s.apis {
 		apiSnapshot := APISnapshot{
 			TotalRequests: stats.TotalRequests,
 			TotalTokens:   stats.TotalTokens,
+			TotalCost:     stats.TotalCost,
 			Models:        make(map[string]ModelSnapshot, len(stats.Models)),
 		}
 		for modelName, modelStatsValue := range stats.Models {
@@ -253,8 +323,19 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 			apiSnapshot.Models[modelName] = ModelSnapshot{
 				TotalRequests: modelStatsValue.TotalRequests,
 				TotalTokens:   modelStatsValue.TotalTokens,
+				TotalCost:     modelStatsValue.TotalCost,
 				Details:       requestDetails,
 			}
+			for _, detail := range requestDetails {
+				if detail.AuthIndex == "" {
+					continue
+				}
+				credential := result.Credentials[detail.AuthIndex]
+				credential.TotalRequests++
+				credential.TotalTokens += detail.Tokens.TotalTokens
+				credential.TotalCost += detail.Cost
+				result.Credentials[detail.AuthIndex] = credential
+			}
 		}
 		result.APIs[apiName] = apiSnapshot
 	}
@@ -281,6 +362,12 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 		result.TokensByHour[key] = v
 	}
 
+	result.Redactions = s.redactions
+	result.Redactions.ByDetector = make(map[string]int64, len(s.redactions.ByDetector))
+	for detector, v := range s.redactions.ByDetector {
+		result.Redactions.ByDetector[detector] = v
+	}
+
 	return result
 }
 
@@ -368,6 +455,7 @@ func (s *RequestStatistics) recordImported(apiName, modelName string, stats *api
 		s.successCount++
 	}
 	s.totalTokens += totalTokens
+	s.totalCost += detail.Cost
 
 	s.updateAPIStats(stats, modelName, detail)
 
@@ -0,0 +1,24 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDataDirectory(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("WRITABLE_PATH", base)
+	if got, want := ResolveDataDirectory("batches"), filepath.Join(base, "data", "batches"); got != want {
+		t.Fatalf("with WRITABLE_PATH: got %q, want %q", got, want)
+	}
+
+	t.Setenv("WRITABLE_PATH", "")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ResolveDataDirectory("responses"), filepath.Join(wd, "data", "responses"); got != want {
+		t.Fatalf("without WRITABLE_PATH: got %q, want %q", got, want)
+	}
+}
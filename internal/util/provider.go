@@ -4,6 +4,8 @@
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"net/url"
 	"strings"
 
@@ -169,6 +171,19 @@ func HideAPIKey(apiKey string) string {
 	return apiKey
 }
 
+// apiKeyHashPrefix marks hashed client API keys.
+const apiKeyHashPrefix = "sha256:"
+
+// HashAPIKey returns a stable identifier for a client API key that can be persisted in place
+// of the key itself. Already hashed values are returned unchanged.
+func HashAPIKey(apiKey string) string {
+	if apiKey == "" || strings.HasPrefix(apiKey, apiKeyHashPrefix) {
+		return apiKey
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return apiKeyHashPrefix + hex.EncodeToString(sum[:])
+}
+
 // maskAuthorizationHeader masks the Authorization header value while preserving the auth type prefix.
 // Common formats: "Bearer <token>", "Basic <credentials>", "ApiKey <key>", etc.
 // It preserves the prefix (e.g., "Bearer ") and only masks the token/credential part.
@@ -125,3 +125,17 @@ func WritablePath() string {
 	}
 	return ""
 }
+
+// ResolveDataDirectory returns the default directory for persistent feature data such as stored
+// responses, batches or ledgers: data/<name> under WRITABLE_PATH, or under the working directory
+// when it is unset. It is never placed under the auth directory, which token stores scan for
+// auth records and the Postgres store clears on startup.
+func ResolveDataDirectory(name string) string {
+	base := WritablePath()
+	if base == "" {
+		if wd, err := os.Getwd(); err == nil {
+			base = wd
+		}
+	}
+	return filepath.Join(base, "data", name)
+}
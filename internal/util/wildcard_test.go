@@ -0,0 +1,27 @@
+package util
+
+import "testing"
+
+func TestMatchWildcard(t *testing.T) {
+	cases := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"", "", false},
+		{"gpt-5", "gpt-5", true},
+		{"gpt-5", "gpt-5-mini", false},
+		{"*", "anything", true},
+		{"gpt-*", "gpt-5-mini", true},
+		{"*-mini", "gpt-5-mini", true},
+		{"claude-*-4-*", "claude-sonnet-4-5", true},
+		{"claude-*-4-*", "claude-sonnet-3-5", false},
+		{"a*a", "a", false},
+		{"GPT-*", "gpt-5", false},
+	}
+	for _, tc := range cases {
+		if got := MatchWildcard(tc.pattern, tc.value); got != tc.want {
+			t.Errorf("MatchWildcard(%q, %q) = %v, want %v", tc.pattern, tc.value, got, tc.want)
+		}
+	}
+}
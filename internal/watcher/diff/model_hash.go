@@ -20,7 +20,11 @@ func ComputeOpenAICompatModelsHash(models []config.OpenAICompatibilityModel) str
 			if name == "" && alias == "" {
 				continue
 			}
-			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+			key := strings.ToLower(name) + "|" + strings.ToLower(alias)
+			if len(model.InputModalities) > 0 {
+				key += "|" + strings.ToUpper(strings.Join(model.InputModalities, ","))
+			}
+			out(key)
 		}
 	})
 	return hashJoined(keys)
@@ -41,6 +45,36 @@ func ComputeVertexCompatModelsHash(models []config.VertexCompatModel) string {
 	return hashJoined(keys)
 }
 
+// ComputeBedrockModelsHash returns a stable hash for Bedrock model aliases.
+func ComputeBedrockModelsHash(models []config.BedrockModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeAzureOpenAIModelsHash returns a stable hash for Azure OpenAI deployment aliases.
+func ComputeAzureOpenAIModelsHash(models []config.AzureOpenAIModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
 // ComputeClaudeModelsHash returns a stable hash for Claude model aliases.
 func ComputeClaudeModelsHash(models []config.ClaudeModel) string {
 	keys := normalizeModelPairs(func(out func(key string)) {
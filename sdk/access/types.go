@@ -28,6 +28,14 @@ const (
 	// AccessProviderTypeConfigAPIKey is the built-in provider validating inline API keys.
 	AccessProviderTypeConfigAPIKey = "config-api-key"
 
+	// AccessProviderTypeJWT is the built-in provider validating signed JWT bearer tokens
+	// against a JWKS URL or a local key file.
+	AccessProviderTypeJWT = "jwt"
+
+	// AccessProviderTypeOIDC is the JWT provider with the JWKS URL discovered from the
+	// issuer's OpenID configuration.
+	AccessProviderTypeOIDC = "oidc"
+
 	// DefaultAccessProviderName is applied when no provider name is supplied.
 	DefaultAccessProviderName = "config-inline"
 )
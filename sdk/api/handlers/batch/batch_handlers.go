@@ -0,0 +1,198 @@
+// Package batch provides HTTP handlers for the OpenAI Batch API (/v1/files, /v1/batches) and
+// the Anthropic Message Batches API (/v1/messages/batches). No proxied backend offers batch
+// processing, so batches are emulated locally: requests are queued on disk and executed
+// through the auth manager like interactive requests, then served in each API's own shape.
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/quota"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// defaultRetryAfter is used when a cooldown response carries no Retry-After header.
+	defaultRetryAfter = 30 * time.Second
+	// defaultListLimit and maxListLimit bound the page size of list endpoints.
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// BatchAPIHandler contains the handlers for the batch endpoints and owns the runner that
+// executes queued batches.
+type BatchAPIHandler struct {
+	*handlers.BaseAPIHandler
+	runner *batch.Runner
+}
+
+// NewBatchAPIHandler creates a batch handler backed by store. Call Resume to restart batches
+// left unfinished by a previous run and Stop on shutdown.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//   - store: The on-disk batch store
+//   - concurrency: The number of requests executed in parallel across all batches
+//
+// Returns:
+//   - *BatchAPIHandler: A new batch API handlers instance
+func NewBatchAPIHandler(apiHandlers *handlers.BaseAPIHandler, store *batch.Store, concurrency int) *BatchAPIHandler {
+	h := &BatchAPIHandler{BaseAPIHandler: apiHandlers}
+	h.runner = batch.NewRunner(store, h.executeItem, concurrency)
+	return h
+}
+
+// Resume restarts every unfinished batch found in the store.
+func (h *BatchAPIHandler) Resume() error {
+	return h.runner.Resume()
+}
+
+// Stop aborts in-flight batch requests; they are executed again after the next Resume.
+func (h *BatchAPIHandler) Stop() {
+	h.runner.Stop()
+}
+
+// batchRoute maps a batch item URL to the handler type and execution alt used for it.
+func batchRoute(url string) (handlerType, alt string, ok bool) {
+	switch strings.TrimSpace(url) {
+	case "/v1/chat/completions":
+		return OpenAI, "", true
+	case "/v1/responses":
+		return OpenaiResponse, "", true
+	case "/v1/embeddings":
+		return OpenAI, "embeddings", true
+	case "/v1/messages":
+		return Claude, "", true
+	}
+	return "", "", false
+}
+
+// executeItem runs one batch request through the auth manager. When every credential for the
+// model is cooling down (or the upstream rate-limits), the item is re-queued after Retry-After
+// instead of failing, so large batches drain at the pace the credentials allow. Client quota
+// rejections are final: the owner's limits do not lift on a credential's schedule.
+func (h *BatchAPIHandler) executeItem(ctx context.Context, b *batch.Batch, item batch.Item) batch.Result {
+	handlerType, alt, ok := batchRoute(item.URL)
+	if !ok {
+		return errorResult(http.StatusBadRequest, "Unsupported url "+item.URL+".")
+	}
+	body := item.Body
+	if gjson.GetBytes(body, "stream").Exists() {
+		body, _ = sjson.DeleteBytes(body, "stream")
+	}
+	modelName := strings.TrimSpace(gjson.GetBytes(body, "model").String())
+	if modelName == "" {
+		return errorResult(http.StatusBadRequest, "Missing required parameter: 'model'.")
+	}
+
+	resp, _, errMsg := h.ExecuteWithAuthManager(batchRequestContext(ctx, b.Owner, item.URL, body), handlerType, modelName, body, alt)
+	if errMsg != nil {
+		status := errMsg.StatusCode
+		if status <= 0 {
+			status = http.StatusInternalServerError
+		}
+		var limitErr *quota.LimitError
+		if status == http.StatusTooManyRequests && !errors.As(errMsg.Error, &limitErr) {
+			retryAfter := defaultRetryAfter
+			if errMsg.Addon != nil {
+				if seconds, err := strconv.Atoi(strings.TrimSpace(errMsg.Addon.Get("Retry-After"))); err == nil && seconds > 0 {
+					retryAfter = time.Duration(seconds) * time.Second
+				}
+			}
+			return batch.Result{RetryAfter: retryAfter}
+		}
+		text := http.StatusText(status)
+		if errMsg.Error != nil {
+			text = errMsg.Error.Error()
+		}
+		return batch.Result{StatusCode: status, Body: handlers.BuildErrorResponseBody(status, text)}
+	}
+	return batch.Result{StatusCode: http.StatusOK, Body: bytes.Clone(resp)}
+}
+
+// batchRequestContext attaches a detached request context carrying the batch owner's API key,
+// so quotas, usage records and request logs are attributed to the client that submitted it.
+func batchRequestContext(ctx context.Context, owner, url string, body []byte) context.Context {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return ctx
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The execute path sets response headers on the gin context; they go to a discarded recorder.
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	if owner != "" {
+		c.Set("apiKey", owner)
+	}
+	return context.WithValue(ctx, "gin", c)
+}
+
+func errorResult(status int, message string) batch.Result {
+	return batch.Result{StatusCode: status, Body: handlers.BuildErrorResponseBody(status, message)}
+}
+
+// requestOwner returns the client API key of the request, used to scope files and batches.
+func requestOwner(c *gin.Context) string {
+	if v, exists := c.Get("apiKey"); exists {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// ownedBy prevents one client key from reading or cancelling another key's batches.
+func ownedBy(recordOwner, owner string) bool {
+	return recordOwner == "" || recordOwner == owner
+}
+
+// listLimit parses the "limit" query parameter.
+func listLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return defaultListLimit
+	}
+	return min(limit, maxListLimit)
+}
+
+// paginate returns the page of batches following afterID, and whether more remain.
+func paginate(batches []*batch.Batch, afterID string, limit int) ([]*batch.Batch, bool) {
+	if afterID != "" {
+		for i, b := range batches {
+			if b.ID == afterID {
+				batches = batches[i+1:]
+				break
+			}
+		}
+	}
+	if len(batches) > limit {
+		return batches[:limit], true
+	}
+	return batches, false
+}
+
+func unixOrNil(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Unix()
+}
+
+func rfc3339OrNil(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,223 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/quota"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type batchCaptureExecutor struct {
+	mu      sync.Mutex
+	formats []string
+}
+
+func (e *batchCaptureExecutor) Identifier() string { return "test-batch-provider" }
+
+func (e *batchCaptureExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.formats = append(e.formats, opts.SourceFormat.String())
+	e.mu.Unlock()
+	if gjson.GetBytes(req.Payload, "stream").Exists() {
+		return coreexecutor.Response{}, errors.New("stream flag was forwarded")
+	}
+	return coreexecutor.Response{Payload: []byte(`{"id":"resp","model":"test-batch-model"}`)}, nil
+}
+
+func (e *batchCaptureExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *batchCaptureExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *batchCaptureExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *batchCaptureExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newBatchTestRouter(t *testing.T) (*gin.Engine, *batchCaptureExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	executor := &batchCaptureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "auth-batch", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-batch-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	store, err := batch.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	h := NewBatchAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager), store, 2)
+	t.Cleanup(h.Stop)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("apiKey", "client-a") })
+	router.POST("/v1/files", h.UploadFile)
+	router.GET("/v1/files/:id/content", h.FileContent)
+	router.POST("/v1/batches", h.CreateBatch)
+	router.GET("/v1/batches/:id", h.GetBatch)
+	router.POST("/v1/messages/batches", h.CreateMessageBatch)
+	router.GET("/v1/messages/batches/:id", h.GetMessageBatch)
+	router.GET("/v1/messages/batches/:id/results", h.MessageBatchResults)
+	return router, executor
+}
+
+func serve(router *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func pollUntil(t *testing.T, router *gin.Engine, path, field, want string) []byte {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		resp := serve(router, httptest.NewRequest(http.MethodGet, path, nil))
+		if gjson.GetBytes(resp.Body.Bytes(), field).String() == want {
+			return resp.Body.Bytes()
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("%s never reported %s=%s", path, field, want)
+	return nil
+}
+
+func TestOpenAIBatchLifecycle(t *testing.T) {
+	router, executor := newBatchTestRouter(t)
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	_ = writer.WriteField("purpose", "batch")
+	part, _ := writer.CreateFormFile("file", "input.jsonl")
+	_, _ = part.Write([]byte(`{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"test-batch-model","messages":[{"role":"user","content":"hi"}],"stream":true}}
+{"custom_id":"b","method":"POST","url":"/v1/chat/completions","body":{"model":"test-batch-model","messages":[{"role":"user","content":"yo"}]}}
+`))
+	_ = writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/v1/files", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	upload := serve(router, req)
+	if upload.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", upload.Code, upload.Body.String())
+	}
+	fileID := gjson.GetBytes(upload.Body.Bytes(), "id").String()
+
+	create := serve(router, httptest.NewRequest(http.MethodPost, "/v1/batches", strings.NewReader(`{"input_file_id":"`+fileID+`","endpoint":"/v1/chat/completions","completion_window":"24h"}`)))
+	if create.Code != http.StatusOK {
+		t.Fatalf("create status = %d: %s", create.Code, create.Body.String())
+	}
+	batchID := gjson.GetBytes(create.Body.Bytes(), "id").String()
+
+	done := pollUntil(t, router, "/v1/batches/"+batchID, "status", "completed")
+	if got := gjson.GetBytes(done, "request_counts.completed").Int(); got != 2 {
+		t.Fatalf("completed = %d: %s", got, done)
+	}
+	output := serve(router, httptest.NewRequest(http.MethodGet, "/v1/files/"+gjson.GetBytes(done, "output_file_id").String()+"/content", nil))
+	lines := strings.Split(strings.TrimSpace(output.Body.String()), "\n")
+	if len(lines) != 2 || gjson.Get(lines[0], "response.status_code").Int() != 200 || gjson.Get(lines[0], "response.body.id").String() != "resp" {
+		t.Fatalf("unexpected output file: %s", output.Body.String())
+	}
+	for _, format := range executor.formats {
+		if format != "openai" {
+			t.Fatalf("source format = %q, want openai", format)
+		}
+	}
+}
+
+func TestClaudeMessageBatchLifecycle(t *testing.T) {
+	router, executor := newBatchTestRouter(t)
+
+	create := serve(router, httptest.NewRequest(http.MethodPost, "/v1/messages/batches", strings.NewReader(`{"requests":[{"custom_id":"one","params":{"model":"test-batch-model","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}}]}`)))
+	if create.Code != http.StatusOK {
+		t.Fatalf("create status = %d: %s", create.Code, create.Body.String())
+	}
+	batchID := gjson.GetBytes(create.Body.Bytes(), "id").String()
+	if !strings.HasPrefix(batchID, "msgbatch_") || gjson.GetBytes(create.Body.Bytes(), "type").String() != "message_batch" {
+		t.Fatalf("unexpected batch object: %s", create.Body.String())
+	}
+
+	done := pollUntil(t, router, "/v1/messages/batches/"+batchID, "processing_status", "ended")
+	if got := gjson.GetBytes(done, "request_counts.succeeded").Int(); got != 1 {
+		t.Fatalf("succeeded = %d: %s", got, done)
+	}
+	results := serve(router, httptest.NewRequest(http.MethodGet, "/v1/messages/batches/"+batchID+"/results", nil))
+	line := strings.TrimSpace(results.Body.String())
+	if gjson.Get(line, "custom_id").String() != "one" || gjson.Get(line, "result.type").String() != "succeeded" {
+		t.Fatalf("unexpected results: %s", line)
+	}
+	if len(executor.formats) != 1 || executor.formats[0] != "claude" {
+		t.Fatalf("source formats = %v, want [claude]", executor.formats)
+	}
+}
+
+func TestClaudeMessageBatchRejectsDuplicateCustomIDs(t *testing.T) {
+	router, _ := newBatchTestRouter(t)
+	body := `{"requests":[{"custom_id":"x","params":{"model":"test-batch-model"}},{"custom_id":"x","params":{"model":"test-batch-model"}}]}`
+	resp := serve(router, httptest.NewRequest(http.MethodPost, "/v1/messages/batches", strings.NewReader(body)))
+	if resp.Code != http.StatusBadRequest || gjson.GetBytes(resp.Body.Bytes(), "error.type").String() != "invalid_request_error" {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestExecuteItemWithClientQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &batchCaptureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "auth-batch-quota", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-batch-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	quota.Configure(&sdkconfig.SDKConfig{ClientQuotas: sdkconfig.ClientQuotaConfig{
+		Keys: []sdkconfig.ClientKeyQuota{{APIKey: "client-q", RequestsPerMinute: 1}},
+	}})
+	t.Cleanup(func() { quota.Configure(&sdkconfig.SDKConfig{}) })
+
+	store, err := batch.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	h := NewBatchAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager), store, 1)
+	t.Cleanup(h.Stop)
+
+	b := &batch.Batch{ID: "batch_quota", Owner: "client-q"}
+	item := batch.Item{CustomID: "a", URL: "/v1/chat/completions", Body: []byte(`{"model":"test-batch-model","messages":[{"role":"user","content":"hi"}]}`)}
+	if result := h.executeItem(context.Background(), b, item); result.StatusCode != http.StatusOK {
+		t.Fatalf("first item: %+v", result)
+	}
+	// The quota rejection sets Retry-After on the batch context and must end the item.
+	result := h.executeItem(context.Background(), b, item)
+	if result.RetryAfter != 0 || result.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("quota rejection: %+v", result)
+	}
+}
@@ -0,0 +1,216 @@
+package batch
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/tidwall/gjson"
+)
+
+// maxClaudeCustomIDLen is the Message Batches limit on custom_id length.
+const maxClaudeCustomIDLen = 64
+
+// CreateMessageBatch handles POST /v1/messages/batches. The requests are stored as a
+// synthesised JSONL input file and queued like an OpenAI batch against /v1/messages.
+func (h *BatchAPIHandler) CreateMessageBatch(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", "Invalid request: body must be a JSON object.")
+		return
+	}
+	requests := gjson.GetBytes(rawJSON, "requests")
+	if !requests.IsArray() || len(requests.Array()) == 0 {
+		writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", "requests: must contain at least one request")
+		return
+	}
+
+	var items []batch.Item
+	seen := make(map[string]struct{})
+	var errValidate error
+	requests.ForEach(func(index, request gjson.Result) bool {
+		customID := strings.TrimSpace(request.Get("custom_id").String())
+		params := request.Get("params")
+		switch {
+		case customID == "" || len(customID) > maxClaudeCustomIDLen:
+			errValidate = fmt.Errorf("requests.%d.custom_id: must be 1 to %d characters", index.Int(), maxClaudeCustomIDLen)
+		case !params.IsObject():
+			errValidate = fmt.Errorf("requests.%d.params: must be an object", index.Int())
+		case strings.TrimSpace(params.Get("model").String()) == "":
+			errValidate = fmt.Errorf("requests.%d.params.model: field required", index.Int())
+		case len(items) >= batch.MaxItems:
+			errValidate = fmt.Errorf("requests: must contain at most %d requests", batch.MaxItems)
+		}
+		if errValidate == nil {
+			if _, dup := seen[customID]; dup {
+				errValidate = fmt.Errorf("requests.%d.custom_id: duplicate custom_id %q", index.Int(), customID)
+			}
+		}
+		if errValidate != nil {
+			return false
+		}
+		seen[customID] = struct{}{}
+		items = append(items, batch.Item{CustomID: customID, Method: http.MethodPost, URL: "/v1/messages", Body: []byte(params.Raw)})
+		return true
+	})
+	if errValidate != nil {
+		writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", errValidate.Error())
+		return
+	}
+
+	content, err := batch.EncodeItems(items)
+	if err != nil {
+		writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	owner := requestOwner(c)
+	id := batch.NewID("msgbatch_")
+	file := &batch.File{
+		ID:        batch.NewID("file-"),
+		Owner:     owner,
+		Purpose:   batch.PurposeMessageBatch,
+		Filename:  id + "_input.jsonl",
+		CreatedAt: time.Now(),
+	}
+	if err = h.runner.Store().PutFile(file, content); err != nil {
+		writeClaudeError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	b := &batch.Batch{
+		ID:          id,
+		Format:      batch.FormatClaude,
+		Owner:       owner,
+		Endpoint:    "/v1/messages",
+		InputFileID: file.ID,
+		Total:       len(items),
+	}
+	if err = h.runner.Submit(b); err != nil {
+		writeClaudeError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, claudeBatchObject(c, b))
+}
+
+// GetMessageBatch handles GET /v1/messages/batches/:id.
+func (h *BatchAPIHandler) GetMessageBatch(c *gin.Context) {
+	b, ok := h.lookupBatch(c, batch.FormatClaude)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, claudeBatchObject(c, b))
+}
+
+// ListMessageBatches handles GET /v1/messages/batches.
+func (h *BatchAPIHandler) ListMessageBatches(c *gin.Context) {
+	batches, err := h.ownedBatches(c, batch.FormatClaude)
+	if err != nil {
+		writeClaudeError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	page, hasMore := paginate(batches, c.Query("after_id"), listLimit(c))
+	data := make([]gin.H, 0, len(page))
+	for _, b := range page {
+		data = append(data, claudeBatchObject(c, b))
+	}
+	out := gin.H{"data": data, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(page) > 0 {
+		out["first_id"], out["last_id"] = page[0].ID, page[len(page)-1].ID
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// CancelMessageBatch handles POST /v1/messages/batches/:id/cancel. Cancelling an ended batch
+// returns it unchanged, as the Anthropic API does.
+func (h *BatchAPIHandler) CancelMessageBatch(c *gin.Context) {
+	b, ok := h.lookupBatch(c, batch.FormatClaude)
+	if !ok {
+		return
+	}
+	if !b.Terminal() {
+		var err error
+		if b, err = h.runner.Cancel(b.ID); err != nil {
+			writeClaudeError(c, http.StatusInternalServerError, "api_error", err.Error())
+			return
+		}
+	}
+	c.JSON(http.StatusOK, claudeBatchObject(c, b))
+}
+
+// MessageBatchResults handles GET /v1/messages/batches/:id/results, streaming one JSONL result
+// per request once the batch has ended.
+func (h *BatchAPIHandler) MessageBatchResults(c *gin.Context) {
+	b, ok := h.lookupBatch(c, batch.FormatClaude)
+	if !ok {
+		return
+	}
+	if !b.Terminal() {
+		writeClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Batch %s is still in progress; results are available once processing has ended.", b.ID))
+		return
+	}
+	items, err := h.runner.Store().Items(b.InputFileID)
+	if err != nil {
+		writeClaudeError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	results, err := h.runner.Store().Results(b.ID)
+	if err != nil {
+		writeClaudeError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	c.Data(http.StatusOK, "application/binary", batch.RenderClaudeResults(items, results, b.Status))
+}
+
+func claudeBatchObject(c *gin.Context, b *batch.Batch) gin.H {
+	processingStatus := "in_progress"
+	switch {
+	case b.Terminal():
+		processingStatus = "ended"
+	case b.Status == batch.StatusCancelling:
+		processingStatus = "canceling"
+	}
+	counts := gin.H{"processing": 0, "succeeded": b.Completed, "errored": b.Failed, "canceled": 0, "expired": 0}
+	switch b.Status {
+	case batch.StatusCancelled:
+		counts["canceled"] = b.Pending()
+	case batch.StatusExpired:
+		counts["expired"] = b.Pending()
+	case batch.StatusCompleted, batch.StatusFailed:
+	default:
+		counts["processing"] = b.Pending()
+	}
+	var resultsURL any
+	if b.Terminal() {
+		resultsURL = requestBaseURL(c) + "/v1/messages/batches/" + b.ID + "/results"
+	}
+	return gin.H{
+		"id":                  b.ID,
+		"type":                "message_batch",
+		"processing_status":   processingStatus,
+		"request_counts":      counts,
+		"created_at":          b.CreatedAt.UTC().Format(time.RFC3339),
+		"expires_at":          rfc3339OrNil(b.ExpiresAt),
+		"ended_at":            rfc3339OrNil(b.EndedAt),
+		"cancel_initiated_at": rfc3339OrNil(b.CancellingAt),
+		"archived_at":         nil,
+		"results_url":         resultsURL,
+	}
+}
+
+// requestBaseURL returns the scheme and host clients used to reach the proxy.
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := strings.TrimSpace(c.GetHeader("X-Forwarded-Proto")); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+func writeClaudeError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, gin.H{"type": "error", "error": gin.H{"type": errType, "message": message}})
+}
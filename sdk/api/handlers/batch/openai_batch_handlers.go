@@ -0,0 +1,342 @@
+package batch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// maxUploadBytes caps /v1/files uploads, matching the OpenAI batch input limit.
+const maxUploadBytes = 200 << 20
+
+// UploadFile handles POST /v1/files. Only purpose=batch is accepted; the JSONL content is
+// validated on upload so malformed inputs are rejected before a batch is created.
+func (h *BatchAPIHandler) UploadFile(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadBytes)
+	purpose := strings.TrimSpace(c.PostForm("purpose"))
+	if purpose != batch.PurposeBatch {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid 'purpose': only %q is supported.", batch.PurposeBatch))
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, "Missing required parameter: 'file'.")
+		return
+	}
+	f, err := header.Open()
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid file: %v", err))
+		return
+	}
+	content, err := io.ReadAll(f)
+	_ = f.Close()
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid file: %v", err))
+		return
+	}
+	if _, err = batch.ParseItems(bytes.NewReader(content)); err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid batch input file: %v", err))
+		return
+	}
+
+	file := &batch.File{
+		ID:        batch.NewID("file-"),
+		Owner:     requestOwner(c),
+		Purpose:   purpose,
+		Filename:  header.Filename,
+		CreatedAt: time.Now(),
+	}
+	if err = h.runner.Store().PutFile(file, content); err != nil {
+		writeOpenAIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, openAIFileObject(file))
+}
+
+// ListFiles handles GET /v1/files.
+func (h *BatchAPIHandler) ListFiles(c *gin.Context) {
+	files, err := h.runner.Store().ListFiles(requestOwner(c))
+	if err != nil {
+		writeOpenAIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	purpose := c.Query("purpose")
+	data := make([]gin.H, 0, len(files))
+	for _, file := range files {
+		if file.Purpose == batch.PurposeMessageBatch || (purpose != "" && file.Purpose != purpose) {
+			continue
+		}
+		data = append(data, openAIFileObject(file))
+	}
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": data, "has_more": false})
+}
+
+// GetFile handles GET /v1/files/:id.
+func (h *BatchAPIHandler) GetFile(c *gin.Context) {
+	file, ok := h.lookupFile(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, openAIFileObject(file))
+}
+
+// FileContent handles GET /v1/files/:id/content.
+func (h *BatchAPIHandler) FileContent(c *gin.Context) {
+	file, ok := h.lookupFile(c)
+	if !ok {
+		return
+	}
+	content, err := h.runner.Store().OpenFileContent(file.ID)
+	if err != nil {
+		writeOpenAIError(c, http.StatusNotFound, fmt.Sprintf("No such File object: %s", file.ID))
+		return
+	}
+	defer func() { _ = content.Close() }()
+	c.DataFromReader(http.StatusOK, file.Bytes, "application/jsonl", content, nil)
+}
+
+// DeleteFile handles DELETE /v1/files/:id.
+func (h *BatchAPIHandler) DeleteFile(c *gin.Context) {
+	file, ok := h.lookupFile(c)
+	if !ok {
+		return
+	}
+	if err := h.runner.Store().DeleteFile(file.ID); err != nil && !errors.Is(err, batch.ErrNotFound) {
+		writeOpenAIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": file.ID, "object": "file", "deleted": true})
+}
+
+func (h *BatchAPIHandler) lookupFile(c *gin.Context) (*batch.File, bool) {
+	id := c.Param("id")
+	file, err := h.runner.Store().GetFile(id)
+	if err == nil && (!ownedBy(file.Owner, requestOwner(c)) || file.Purpose == batch.PurposeMessageBatch) {
+		err = batch.ErrNotFound
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, batch.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeOpenAIError(c, status, fmt.Sprintf("No such File object: %s", id))
+		return nil, false
+	}
+	return file, true
+}
+
+// CreateBatch handles POST /v1/batches.
+func (h *BatchAPIHandler) CreateBatch(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeOpenAIError(c, http.StatusBadRequest, "Invalid request: body must be a JSON object.")
+		return
+	}
+	root := gjson.ParseBytes(rawJSON)
+	inputFileID := strings.TrimSpace(root.Get("input_file_id").String())
+	endpoint := strings.TrimSpace(root.Get("endpoint").String())
+	window := strings.TrimSpace(root.Get("completion_window").String())
+	switch {
+	case inputFileID == "":
+		writeOpenAIError(c, http.StatusBadRequest, "Missing required parameter: 'input_file_id'.")
+		return
+	case window != "24h":
+		writeOpenAIError(c, http.StatusBadRequest, "Invalid 'completion_window': only '24h' is supported.")
+		return
+	}
+	if handlerType, _, ok := batchRoute(endpoint); !ok || handlerType == Claude {
+		writeOpenAIError(c, http.StatusBadRequest, "Invalid 'endpoint': must be one of /v1/chat/completions, /v1/responses or /v1/embeddings.")
+		return
+	}
+
+	owner := requestOwner(c)
+	file, err := h.runner.Store().GetFile(inputFileID)
+	if err != nil || !ownedBy(file.Owner, owner) || file.Purpose != batch.PurposeBatch {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid 'input_file_id': no batch file %s.", inputFileID))
+		return
+	}
+	items, err := h.runner.Store().Items(file.ID)
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid batch input file: %v", err))
+		return
+	}
+	for _, item := range items {
+		if item.URL != endpoint {
+			writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Request %q targets %s but the batch endpoint is %s.", item.CustomID, item.URL, endpoint))
+			return
+		}
+	}
+	var metadata map[string]string
+	root.Get("metadata").ForEach(func(key, value gjson.Result) bool {
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[key.String()] = value.String()
+		return true
+	})
+
+	b := &batch.Batch{
+		ID:               batch.NewID("batch_"),
+		Format:           batch.FormatOpenAI,
+		Owner:            owner,
+		Endpoint:         endpoint,
+		InputFileID:      file.ID,
+		CompletionWindow: window,
+		Metadata:         metadata,
+		Total:            len(items),
+	}
+	if err = h.runner.Submit(b); err != nil {
+		writeOpenAIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, openAIBatchObject(b))
+}
+
+// GetBatch handles GET /v1/batches/:id.
+func (h *BatchAPIHandler) GetBatch(c *gin.Context) {
+	b, ok := h.lookupBatch(c, batch.FormatOpenAI)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, openAIBatchObject(b))
+}
+
+// CancelBatch handles POST /v1/batches/:id/cancel.
+func (h *BatchAPIHandler) CancelBatch(c *gin.Context) {
+	b, ok := h.lookupBatch(c, batch.FormatOpenAI)
+	if !ok {
+		return
+	}
+	if b.Terminal() {
+		writeOpenAIError(c, http.StatusConflict, fmt.Sprintf("Cannot cancel a batch with status '%s'.", b.Status))
+		return
+	}
+	b, err := h.runner.Cancel(b.ID)
+	if err != nil {
+		writeOpenAIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, openAIBatchObject(b))
+}
+
+// ListBatches handles GET /v1/batches.
+func (h *BatchAPIHandler) ListBatches(c *gin.Context) {
+	batches, err := h.ownedBatches(c, batch.FormatOpenAI)
+	if err != nil {
+		writeOpenAIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	page, hasMore := paginate(batches, c.Query("after"), listLimit(c))
+	data := make([]gin.H, 0, len(page))
+	for _, b := range page {
+		data = append(data, openAIBatchObject(b))
+	}
+	out := gin.H{"object": "list", "data": data, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(page) > 0 {
+		out["first_id"], out["last_id"] = page[0].ID, page[len(page)-1].ID
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *BatchAPIHandler) lookupBatch(c *gin.Context, format string) (*batch.Batch, bool) {
+	id := c.Param("id")
+	b, err := h.runner.Get(id)
+	if err == nil && (b.Format != format || !ownedBy(b.Owner, requestOwner(c))) {
+		err = batch.ErrNotFound
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, batch.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		if format == batch.FormatClaude {
+			writeClaudeError(c, status, "not_found_error", fmt.Sprintf("No batch with id %s.", id))
+		} else {
+			writeOpenAIError(c, status, fmt.Sprintf("No such Batch object: %s", id))
+		}
+		return nil, false
+	}
+	return b, true
+}
+
+func (h *BatchAPIHandler) ownedBatches(c *gin.Context, format string) ([]*batch.Batch, error) {
+	all, err := h.runner.List()
+	if err != nil {
+		return nil, err
+	}
+	owner := requestOwner(c)
+	out := make([]*batch.Batch, 0, len(all))
+	for _, b := range all {
+		if b.Format == format && ownedBy(b.Owner, owner) {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func openAIFileObject(file *batch.File) gin.H {
+	return gin.H{
+		"id":         file.ID,
+		"object":     "file",
+		"bytes":      file.Bytes,
+		"created_at": file.CreatedAt.Unix(),
+		"filename":   file.Filename,
+		"purpose":    file.Purpose,
+		"status":     "processed",
+	}
+}
+
+func openAIBatchObject(b *batch.Batch) gin.H {
+	var errorsObj any
+	if b.FailureReason != "" {
+		errorsObj = gin.H{"object": "list", "data": []gin.H{{"code": "batch_failed", "message": b.FailureReason}}}
+	}
+	var outputFileID, errorFileID any
+	if b.OutputFileID != "" {
+		outputFileID = b.OutputFileID
+	}
+	if b.ErrorFileID != "" {
+		errorFileID = b.ErrorFileID
+	}
+	return gin.H{
+		"id":                b.ID,
+		"object":            "batch",
+		"endpoint":          b.Endpoint,
+		"errors":            errorsObj,
+		"input_file_id":     b.InputFileID,
+		"completion_window": b.CompletionWindow,
+		"status":            b.Status,
+		"output_file_id":    outputFileID,
+		"error_file_id":     errorFileID,
+		"created_at":        b.CreatedAt.Unix(),
+		"in_progress_at":    unixOrNil(b.InProgressAt),
+		"expires_at":        unixOrNil(b.ExpiresAt),
+		"finalizing_at":     unixOrNil(b.FinalizingAt),
+		"completed_at":      unixOrNil(b.CompletedAt),
+		"failed_at":         unixOrNil(b.FailedAt),
+		"expired_at":        unixOrNil(b.ExpiredAt),
+		"cancelling_at":     unixOrNil(b.CancellingAt),
+		"cancelled_at":      unixOrNil(b.CancelledAt),
+		"request_counts":    gin.H{"total": b.Total, "completed": b.Completed, "failed": b.Failed},
+		"metadata":          b.Metadata,
+	}
+}
+
+func writeOpenAIError(c *gin.Context, status int, message string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/quota"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+// checkClientQuota enforces the per-client-key limits for the authenticated API key.
+// When charge is false only the model allowlist is evaluated (used by token counting).
+// Retry-After is set on the response directly because quota rejections must carry it
+// regardless of the passthrough-headers setting.
+func checkClientQuota(ctx context.Context, handlerType, modelName string, charge bool) *interfaces.ErrorMessage {
+	enforcer := quota.Default()
+	if !enforcer.Enabled() {
+		return nil
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	if ginCtx == nil {
+		return nil
+	}
+	apiKey := ""
+	if v, exists := ginCtx.Get("apiKey"); exists {
+		apiKey = fmt.Sprintf("%v", v)
+	}
+
+	var limitErr *quota.LimitError
+	if charge {
+		limitErr = enforcer.Admit(apiKey, modelName)
+	} else {
+		limitErr = enforcer.CheckModel(apiKey, modelName)
+	}
+	if limitErr == nil {
+		return nil
+	}
+	limitErr.Format = handlerType
+	headers := limitErr.Headers()
+	if retryAfter := headers.Get("Retry-After"); retryAfter != "" {
+		ginCtx.Header("Retry-After", retryAfter)
+	}
+	return &interfaces.ErrorMessage{StatusCode: limitErr.StatusCode(), Error: limitErr, Addon: headers}
+}
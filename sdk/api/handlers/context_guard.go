@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/contextguard"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// contextTokensEstimatedHeader reports the estimated prompt tokens before trimming.
+	contextTokensEstimatedHeader = "X-Context-Tokens-Estimated"
+	// contextTokensTrimmedHeader reports the estimated prompt tokens removed by the guard.
+	contextTokensTrimmedHeader = "X-Context-Tokens-Trimmed"
+)
+
+// ContextGuardPreflight enforces the context window of every execution candidate, including
+// those of fallback models. It implements coreauth.RequestPreflight and is installed on the
+// core manager with SetRequestPreflight.
+//
+// The prompt is estimated once translated into the request format of the candidate's provider
+// and checked against the input limit of its upstream model. Oversized prompts are rejected in
+// the client's protocol or trimmed according to the policy for the client key or model. The
+// estimate and the number of trimmed tokens of the dispatched candidate are reported in
+// response headers.
+type ContextGuardPreflight struct{}
+
+var _ coreauth.RequestPreflight = ContextGuardPreflight{}
+
+// Preflight implements coreauth.RequestPreflight.
+func (ContextGuardPreflight) Preflight(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Request, error) {
+	guard := contextguard.Default()
+	if !guard.Enabled() || len(req.Payload) == 0 {
+		return req, nil
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	apiKey := ""
+	if ginCtx != nil {
+		if v, exists := ginCtx.Get("apiKey"); exists {
+			apiKey = fmt.Sprintf("%v", v)
+		}
+	}
+	provider := ""
+	if auth != nil {
+		provider = auth.Provider
+	}
+	target := contextguard.Target{
+		Model:    strings.TrimSpace(thinking.ParseSuffix(req.Model).ModelName),
+		Provider: provider,
+		Format:   contextguard.FormatForProvider(provider),
+	}
+	result, err := guard.Check(opts.SourceFormat.String(), apiKey, target, req.Payload, opts.Stream)
+	if err != nil {
+		if exceeded, ok := errors.AsType[*contextguard.ExceededError](err); ok {
+			if ginCtx != nil {
+				ginCtx.Header(contextTokensEstimatedHeader, strconv.FormatInt(exceeded.Estimated, 10))
+				ginCtx.Header(contextTokensTrimmedHeader, "0")
+			}
+			return req, exceeded
+		}
+		log.Warnf("context guard: estimate failed for model %s: %v", target.Model, err)
+		return req, nil
+	}
+	if result.Limit <= 0 {
+		return req, nil
+	}
+	if ginCtx != nil {
+		ginCtx.Header(contextTokensEstimatedHeader, strconv.FormatInt(result.Estimated, 10))
+		ginCtx.Header(contextTokensTrimmedHeader, strconv.FormatInt(result.Trimmed, 10))
+	}
+	if result.Trimmed > 0 {
+		log.Debugf("context guard: trimmed %d of an estimated %d prompt tokens for model %s (%s)", result.Trimmed, result.Estimated, target.Model, result.Policy)
+	}
+	req.Payload = result.Payload
+	return req, nil
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/contextguard"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestContextGuardPreflightChecksEachCandidateModel(t *testing.T) {
+	contextguard.Configure(&config.SDKConfig{ContextGuard: config.ContextGuardConfig{
+		Enable: true,
+		Models: []config.ContextGuardModel{{Model: "small-model", Limit: 50}, {Model: "large-model", Limit: 5000}},
+	}})
+	t.Cleanup(func() { contextguard.Configure(nil) })
+
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(rec)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	payload := []byte(`{"messages":[{"role":"user","content":"` + strings.Repeat("lorem ", 200) + `"}]}`)
+	opts := coreexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+	auth := &coreauth.Auth{ID: "a", Provider: "claude"}
+
+	_, err := ContextGuardPreflight{}.Preflight(ctx, auth, coreexecutor.Request{Model: "small-model", Payload: payload}, opts)
+	if _, ok := errors.AsType[*contextguard.ExceededError](err); !ok {
+		t.Fatalf("expected ExceededError for the small model, got %v", err)
+	}
+	req, err := ContextGuardPreflight{}.Preflight(ctx, auth, coreexecutor.Request{Model: "large-model(high)", Payload: payload}, opts)
+	if err != nil {
+		t.Fatalf("Preflight: %v", err)
+	}
+	if string(req.Payload) != string(payload) {
+		t.Fatalf("payload changed: %s", req.Payload)
+	}
+	if rec.Header().Get(contextTokensEstimatedHeader) == "" || rec.Header().Get(contextTokensTrimmedHeader) != "0" {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+}
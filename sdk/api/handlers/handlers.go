@@ -16,6 +16,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsecache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -47,6 +49,9 @@ type ErrorDetail struct {
 
 const idempotencyKeyMetadataKey = "idempotency_key"
 
+// EmbeddingsAlt is the execution alt used by the embeddings endpoints.
+const EmbeddingsAlt = "embeddings"
+
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
@@ -211,6 +216,20 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	return meta
 }
 
+// ServedModelHeader reports which model answered when the requested model has a fallback chain.
+const ServedModelHeader = "X-Served-Model"
+
+// setServedModelHeader exposes the model that answered, as recorded by the auth manager.
+func setServedModelHeader(ctx context.Context, meta map[string]any) {
+	served, _ := meta[coreexecutor.ServedModelMetadataKey].(string)
+	if served == "" || ctx == nil {
+		return
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		ginCtx.Header(ServedModelHeader, served)
+	}
+}
+
 func pinnedAuthIDFromContext(ctx context.Context) string {
 	if ctx == nil {
 		return ""
@@ -470,9 +489,22 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg == nil {
+		errMsg = checkEmbeddingRoute(normalizedModel, alt)
+	}
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	if errMsg = checkClientQuota(ctx, handlerType, modelName, true); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if rawJSON, errMsg = applyRedaction(ctx, handlerType, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	cacheKey := h.responseCacheKey(ctx, handlerType, modelName, rawJSON, alt, false)
+	if cached := lookupResponseCache(ctx, cacheKey); cached != nil {
+		return cached.Body, h.cachedResponseHeaders(cached), nil
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -490,7 +522,9 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = reqMeta
+	mirror := h.startShadow(ctx, handlerType, modelName, rawJSON, alt, false, reqMeta)
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	setServedModelHeader(ctx, reqMeta)
 	if err != nil {
 		err = enrichAuthSelectionError(err, providers, normalizedModel)
 		status := http.StatusInternalServerError
@@ -505,8 +539,20 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 				addon = hdr.Clone()
 			}
 		}
+		mirror.fail(status, err)
+		mirror.finish()
 		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	mirror.complete(resp.Payload)
+	if cacheKey != "" {
+		storeResponseCache(ctx, &responsecache.Entry{
+			Key:     cacheKey,
+			Handler: handlerType,
+			Model:   modelName,
+			Body:    resp.Payload,
+			Headers: FilterUpstreamHeaders(resp.Headers),
+		})
+	}
 	if !PassthroughHeadersEnabled(h.Cfg) {
 		return resp.Payload, nil, nil
 	}
@@ -517,9 +563,18 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg == nil {
+		errMsg = checkEmbeddingRoute(normalizedModel, alt)
+	}
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	if errMsg = checkClientQuota(ctx, handlerType, modelName, false); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if rawJSON, errMsg = applyRedaction(ctx, handlerType, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -565,12 +620,32 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // The returned http.Header carries upstream response headers captured before streaming begins.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg == nil {
+		errMsg = checkEmbeddingRoute(normalizedModel, alt)
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
 		close(errChan)
 		return nil, nil, errChan
 	}
+	if errMsg = checkClientQuota(ctx, handlerType, modelName, true); errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
+	if rawJSON, errMsg = applyRedaction(ctx, handlerType, rawJSON); errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
+	cacheKey := h.responseCacheKey(ctx, handlerType, modelName, rawJSON, alt, true)
+	if cached := lookupResponseCache(ctx, cacheKey); cached != nil {
+		dataChan, errChan := replayCachedStream(ctx, cached)
+		return dataChan, h.cachedResponseHeaders(cached), errChan
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -588,7 +663,9 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = reqMeta
+	mirror := h.startShadow(ctx, handlerType, modelName, rawJSON, alt, true, reqMeta)
 	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	setServedModelHeader(ctx, reqMeta)
 	if err != nil {
 		err = enrichAuthSelectionError(err, providers, normalizedModel)
 		errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -606,6 +683,8 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		}
 		errChan <- &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 		close(errChan)
+		mirror.fail(status, err)
+		mirror.finish()
 		return nil, nil, errChan
 	}
 	passthroughHeadersEnabled := PassthroughHeadersEnabled(h.Cfg)
@@ -619,11 +698,22 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		}
 	}
 	chunks := streamResult.Chunks
+	// servedHeaders follows bootstrap retries so the cache stores the headers of the
+	// stream whose chunks were recorded.
+	servedHeaders := streamResult.Headers
+	var recorded [][]byte
+	recordedSize := 0
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
 		defer close(dataChan)
 		defer close(errChan)
+		defer func() {
+			if ctx != nil && ctx.Err() != nil {
+				mirror.fail(shadowClientClosedStatus, ctx.Err())
+			}
+			mirror.finish()
+		}()
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
@@ -683,6 +773,16 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					chunk, ok = <-chunks
 				}
 				if !ok {
+					if cacheKey != "" && recordedSize <= responsecache.MaxEntryBytes {
+						storeResponseCache(ctx, &responsecache.Entry{
+							Key:     cacheKey,
+							Handler: handlerType,
+							Model:   modelName,
+							Stream:  true,
+							Chunks:  recorded,
+							Headers: FilterUpstreamHeaders(servedHeaders),
+						})
+					}
 					return
 				}
 				if chunk.Err != nil {
@@ -694,10 +794,12 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 							bootstrapRetries++
 							retryResult, retryErr := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 							if retryErr == nil {
+								setServedModelHeader(ctx, reqMeta)
 								if passthroughHeadersEnabled {
 									replaceHeader(upstreamHeaders, FilterUpstreamHeaders(retryResult.Headers))
 								}
 								chunks = retryResult.Chunks
+								servedHeaders = retryResult.Headers
 								continue outer
 							}
 							streamErr = enrichAuthSelectionError(retryErr, providers, normalizedModel)
@@ -716,6 +818,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 							addon = hdr.Clone()
 						}
 					}
+					mirror.fail(status, streamErr)
 					_ = sendErr(&interfaces.ErrorMessage{StatusCode: status, Error: streamErr, Addon: addon})
 					return
 				}
@@ -727,6 +830,11 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 						}
 					}
 					sentPayload = true
+					mirror.observe(chunk.Payload)
+					if cacheKey != "" && recordedSize <= responsecache.MaxEntryBytes {
+						recorded = append(recorded, cloneBytes(chunk.Payload))
+						recordedSize += len(chunk.Payload)
+					}
 					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
 						return
 					}
@@ -778,6 +886,19 @@ func statusFromError(err error) int {
 	return 0
 }
 
+// checkEmbeddingRoute keeps embedding-only models out of chat requests. Embedding models are
+// registered next to chat models so the embeddings handlers (alt "embeddings") can route to
+// them; any other request naming one is rejected before a credential is selected.
+func checkEmbeddingRoute(model, alt string) *interfaces.ErrorMessage {
+	if alt == EmbeddingsAlt {
+		return nil
+	}
+	if !registry.IsEmbeddingModel(registry.LookupModelInfo(thinking.ParseSuffix(model).ModelName)) {
+		return nil
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("model %s only supports embeddings", model)}
+}
+
 func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, err *interfaces.ErrorMessage) {
 	resolvedModelName := modelName
 	initialSuffix := thinking.ParseSuffix(modelName)
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"net/http"
 	"reflect"
 	"testing"
 	"time"
@@ -116,3 +117,23 @@ func TestGetRequestDetails_PreservesSuffix(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckEmbeddingRoute_RejectsEmbeddingModelsOutsideEmbeddings(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-embedding-route", "gemini", append([]*registry.ModelInfo{
+		{ID: "gemini-2.5-flash", SupportedGenerationMethods: []string{"generateContent"}},
+	}, registry.GetGeminiEmbeddingModels()...))
+	t.Cleanup(func() {
+		modelRegistry.UnregisterClient("test-embedding-route")
+	})
+
+	if errMsg := checkEmbeddingRoute("gemini-embedding-001", ""); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("chat request for embedding model: got %+v, want 400", errMsg)
+	}
+	if errMsg := checkEmbeddingRoute("gemini-embedding-001", EmbeddingsAlt); errMsg != nil {
+		t.Fatalf("embeddings request for embedding model: unexpected error %v", errMsg.Error)
+	}
+	if errMsg := checkEmbeddingRoute("gemini-2.5-flash", ""); errMsg != nil {
+		t.Fatalf("chat request for chat model: unexpected error %v", errMsg.Error)
+	}
+}
@@ -0,0 +1,334 @@
+// Package ollama provides HTTP handlers for the Ollama API surface.
+// It serves /api/chat, /api/generate, /api/tags and /api/show so that tools written for a
+// local Ollama server can use every proxied model. Requests are translated from the Ollama
+// format to the backend format, and streaming responses are framed as newline-delimited JSON
+// records instead of Server-Sent Events.
+package ollama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// latestTag is the tag Ollama clients append to untagged model names.
+const latestTag = ":latest"
+
+// OllamaAPIHandler contains the handlers for Ollama API endpoints.
+type OllamaAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewOllamaAPIHandler creates a new Ollama API handlers instance.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//
+// Returns:
+//   - *OllamaAPIHandler: A new Ollama API handlers instance
+func NewOllamaAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OllamaAPIHandler {
+	return &OllamaAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OllamaAPIHandler) HandlerType() string {
+	return Ollama
+}
+
+// Models returns the Ollama-formatted model metadata supported by this handler.
+func (h *OllamaAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels(Ollama)
+}
+
+// Tags handles GET /api/tags, listing every proxied model as a local model.
+func (h *OllamaAPIHandler) Tags(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"models": h.Models()})
+}
+
+// Show handles POST /api/show, returning the details and capabilities of a single model.
+func (h *OllamaAPIHandler) Show(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	requested := gjson.GetBytes(rawJSON, "model").String()
+	if requested == "" {
+		requested = gjson.GetBytes(rawJSON, "name").String()
+	}
+	modelName := normalizeModelName(requested)
+	if modelName == "" {
+		writeError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+
+	var entry map[string]any
+	for _, model := range h.Models() {
+		if name, _ := model["name"].(string); strings.EqualFold(name, modelName) {
+			entry = model
+			break
+		}
+	}
+	if entry == nil {
+		writeError(c, http.StatusNotFound, fmt.Sprintf("model '%s' not found", requested))
+		return
+	}
+
+	details, _ := entry["details"].(map[string]any)
+	family, _ := details["family"].(string)
+	modelInfo := map[string]any{"general.architecture": family}
+	capabilities := []string{"completion", "tools"}
+	if info := registry.LookupModelInfo(modelName); info != nil {
+		if info.ContextLength > 0 {
+			modelInfo[family+".context_length"] = info.ContextLength
+		} else if info.InputTokenLimit > 0 {
+			modelInfo[family+".context_length"] = info.InputTokenLimit
+		}
+		for _, modality := range info.SupportedInputModalities {
+			if strings.EqualFold(modality, "IMAGE") {
+				capabilities = append(capabilities, "vision")
+				break
+			}
+		}
+		if info.Thinking != nil {
+			capabilities = append(capabilities, "thinking")
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"modelfile":    "",
+		"parameters":   "",
+		"template":     "",
+		"details":      details,
+		"model_info":   modelInfo,
+		"capabilities": capabilities,
+		"modified_at":  entry["modified_at"],
+	})
+}
+
+// Chat handles POST /api/chat.
+func (h *OllamaAPIHandler) Chat(c *gin.Context) {
+	h.handle(c)
+}
+
+// Generate handles POST /api/generate.
+func (h *OllamaAPIHandler) Generate(c *gin.Context) {
+	h.handle(c)
+}
+
+// handle dispatches a chat or generate request. Unlike the OpenAI API, Ollama streams unless
+// the request sets "stream": false.
+func (h *OllamaAPIHandler) handle(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	if !gjson.ValidBytes(rawJSON) {
+		writeError(c, http.StatusBadRequest, "invalid JSON request body")
+		return
+	}
+	modelName := normalizeModelName(gjson.GetBytes(rawJSON, "model").String())
+	if modelName == "" {
+		writeError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+	stream := true
+	if v := gjson.GetBytes(rawJSON, "stream"); v.Exists() {
+		stream = v.Bool()
+	}
+	// Translators read the stream flag from the payload; make the Ollama default explicit.
+	rawJSON, _ = sjson.SetBytes(rawJSON, "stream", stream)
+
+	if stream {
+		h.handleStreamingResponse(c, modelName, rawJSON)
+	} else {
+		h.handleNonStreamingResponse(c, modelName, rawJSON)
+	}
+}
+
+func (h *OllamaAPIHandler) handleNonStreamingResponse(c *gin.Context, modelName string, rawJSON []byte) {
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
+	if errMsg != nil {
+		h.writeErrorMessage(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	c.Header("Content-Type", "application/json")
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+func (h *OllamaAPIHandler) handleStreamingResponse(c *gin.Context, modelName string, rawJSON []byte) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		writeError(c, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	dataChan, upstreamHeaders, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
+
+	setNDJSONHeaders := func() {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("Access-Control-Allow-Origin", "*")
+	}
+
+	// Peek at the first chunk to determine success or failure before setting headers.
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			cliCancel(c.Request.Context().Err())
+			return
+		case errMsg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			h.writeErrorMessage(c, errMsg)
+			if errMsg != nil {
+				cliCancel(errMsg.Error)
+			} else {
+				cliCancel(nil)
+			}
+			return
+		case chunk, ok := <-dataChan:
+			setNDJSONHeaders()
+			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+			done := false
+			writeRecord := func(record []byte) {
+				if len(record) == 0 {
+					return
+				}
+				if gjson.GetBytes(record, "done").Bool() {
+					done = true
+				}
+				_, _ = c.Writer.Write(record)
+				_, _ = c.Writer.Write([]byte("\n"))
+			}
+			if !ok {
+				writeRecord(doneRecord(rawJSON))
+				flusher.Flush()
+				cliCancel(nil)
+				return
+			}
+			writeRecord(chunk)
+			flusher.Flush()
+
+			disableKeepAlive := time.Duration(0)
+			h.ForwardStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, handlers.StreamForwardOptions{
+				KeepAliveInterval: &disableKeepAlive,
+				WriteChunk:        writeRecord,
+				WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
+					if errMsg == nil {
+						return
+					}
+					_, message := errorMessageText(errMsg)
+					writeRecord(errorBody(message))
+				},
+				WriteDone: func() {
+					if !done {
+						writeRecord(doneRecord(rawJSON))
+					}
+				},
+			})
+			return
+		}
+	}
+}
+
+// doneRecord builds the terminal record for a stream that ended without one.
+func doneRecord(rawJSON []byte) []byte {
+	record := []byte(`{"model":"","created_at":"","done":true,"done_reason":"stop"}`)
+	record, _ = sjson.SetBytes(record, "model", gjson.GetBytes(rawJSON, "model").String())
+	record, _ = sjson.SetBytes(record, "created_at", time.Now().UTC().Format(time.RFC3339Nano))
+	if gjson.GetBytes(rawJSON, "messages").Exists() {
+		record, _ = sjson.SetRawBytes(record, "message", []byte(`{"role":"assistant","content":""}`))
+	} else {
+		record, _ = sjson.SetBytes(record, "response", "")
+	}
+	return record
+}
+
+// normalizeModelName strips the ":latest" tag Ollama clients add to untagged names.
+func normalizeModelName(model string) string {
+	model = strings.TrimSpace(model)
+	if len(model) > len(latestTag) && strings.EqualFold(model[len(model)-len(latestTag):], latestTag) {
+		model = model[:len(model)-len(latestTag)]
+	}
+	return model
+}
+
+// writeErrorMessage writes an upstream or pipeline error in the Ollama error shape.
+func (h *OllamaAPIHandler) writeErrorMessage(c *gin.Context, msg *interfaces.ErrorMessage) {
+	if msg != nil && msg.Addon != nil && handlers.PassthroughHeadersEnabled(h.Cfg) {
+		for key, values := range msg.Addon {
+			if len(values) == 0 {
+				continue
+			}
+			c.Writer.Header().Del(key)
+			for _, value := range values {
+				c.Writer.Header().Add(key, value)
+			}
+		}
+	}
+	status, message := errorMessageText(msg)
+	writeError(c, status, message)
+}
+
+func writeError(c *gin.Context, status int, message string) {
+	c.Header("Content-Type", "application/json")
+	c.Status(status)
+	_, _ = c.Writer.Write(errorBody(message))
+}
+
+// errorMessageText returns the status and plain message of msg. Errors rendered as JSON in
+// another protocol contribute their error.message (or error when it is a string).
+func errorMessageText(msg *interfaces.ErrorMessage) (int, string) {
+	status := http.StatusInternalServerError
+	if msg != nil && msg.StatusCode > 0 {
+		status = msg.StatusCode
+	}
+	message := http.StatusText(status)
+	if msg == nil || msg.Error == nil {
+		return status, message
+	}
+	text := strings.TrimSpace(msg.Error.Error())
+	if text == "" {
+		return status, message
+	}
+	if gjson.Valid(text) {
+		root := gjson.Parse(text)
+		if v := root.Get("error.message"); v.Exists() && v.String() != "" {
+			return status, v.String()
+		}
+		if v := root.Get("error"); v.Type == gjson.String && v.String() != "" {
+			return status, v.String()
+		}
+		if v := root.Get("message"); v.Type == gjson.String && v.String() != "" {
+			return status, v.String()
+		}
+	}
+	return status, text
+}
+
+func errorBody(message string) []byte {
+	body, _ := sjson.SetBytes([]byte(`{"error":""}`), "error", message)
+	return body
+}
@@ -0,0 +1,75 @@
+package ollama
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func newOllamaTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := NewOllamaAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	router := gin.New()
+	router.GET("/api/tags", h.Tags)
+	router.POST("/api/show", h.Show)
+	return router
+}
+
+func TestTagsAndShowListRegisteredModels(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("ollama-test-client", "openai", []*registry.ModelInfo{{
+		ID:                       "ollama-test-model",
+		OwnedBy:                  "acme",
+		Created:                  1700000000,
+		ContextLength:            32000,
+		SupportedInputModalities: []string{"TEXT", "IMAGE"},
+	}})
+	t.Cleanup(func() { reg.UnregisterClient("ollama-test-client") })
+	router := newOllamaTestRouter(t)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/tags", nil))
+	tag := gjson.Get(recorder.Body.String(), `models.#(name=="ollama-test-model")`)
+	if !tag.Exists() || tag.Get("details.family").String() != "acme" || len(tag.Get("digest").String()) != 64 {
+		t.Fatalf("model missing from tags: %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/show", strings.NewReader(`{"model":"ollama-test-model:latest"}`)))
+	body := recorder.Body.String()
+	if recorder.Code != http.StatusOK || gjson.Get(body, "model_info.acme\\.context_length").Int() != 32000 {
+		t.Fatalf("unexpected show response %d: %s", recorder.Code, body)
+	}
+	if !strings.Contains(gjson.Get(body, "capabilities").Raw, `"vision"`) {
+		t.Fatalf("vision capability missing: %s", body)
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/show", strings.NewReader(`{"model":"missing"}`)))
+	if recorder.Code != http.StatusNotFound || gjson.Get(recorder.Body.String(), "error").String() != "model 'missing' not found" {
+		t.Fatalf("unexpected not-found response %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestErrorMessageTextUnwrapsProtocolErrors(t *testing.T) {
+	status, message := errorMessageText(&interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      errors.New(`{"error":{"message":"prompt too long","type":"invalid_request_error"}}`),
+	})
+	if status != http.StatusBadRequest || message != "prompt too long" {
+		t.Fatalf("errorMessageText = %d %q", status, message)
+	}
+	if got := normalizeModelName("llama3:LATEST"); got != "llama3" {
+		t.Fatalf("normalizeModelName = %q", got)
+	}
+}
@@ -0,0 +1,461 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// imagesMaxCount caps the "n" parameter; each image is a separate upstream request.
+	imagesMaxCount = 10
+	// imageEditsMaxMemory bounds the multipart form held in memory for /v1/images/edits.
+	imageEditsMaxMemory = 32 << 20
+)
+
+// geminiImageAspectRatios lists the aspect ratios accepted by generationConfig.imageConfig.
+var geminiImageAspectRatios = []string{"1:1", "2:3", "3:2", "3:4", "4:3", "4:5", "5:4", "9:16", "16:9", "21:9"}
+
+// imageInput is an image attached to an edit request.
+type imageInput struct {
+	MimeType string
+	Data     string // base64
+}
+
+// imageRequest is the provider-neutral form of an OpenAI Images API request.
+type imageRequest struct {
+	Model          string
+	Prompt         string
+	N              int
+	Size           string
+	ResponseFormat string
+	Images         []imageInput
+	Mask           *imageInput
+}
+
+// ImageGenerations handles the /v1/images/generations endpoint.
+// The prompt is sent to a Gemini image model as a generateContent request through the auth
+// manager, so credential selection, cooldowns and usage accounting apply as for chat requests.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) ImageGenerations(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeImagesError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if !gjson.ValidBytes(rawJSON) {
+		writeImagesError(c, http.StatusBadRequest, "Invalid request: body must be a JSON object.")
+		return
+	}
+	req := imageRequest{
+		Model:          strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String()),
+		Prompt:         strings.TrimSpace(gjson.GetBytes(rawJSON, "prompt").String()),
+		N:              int(gjson.GetBytes(rawJSON, "n").Int()),
+		Size:           gjson.GetBytes(rawJSON, "size").String(),
+		ResponseFormat: gjson.GetBytes(rawJSON, "response_format").String(),
+	}
+	h.handleImages(c, req)
+}
+
+// ImageEdits handles the /v1/images/edits endpoint.
+// Both the multipart form used by the OpenAI SDKs and a JSON body with data-URL images
+// ("images": [{"image_url": "data:..."}]) are accepted. The source images, and the optional
+// mask, are attached as inline image parts of the Gemini request.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) ImageEdits(c *gin.Context) {
+	var (
+		req imageRequest
+		err error
+	)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		req, err = parseMultipartImageEdit(c)
+	} else {
+		req, err = parseJSONImageEdit(c)
+	}
+	if err != nil {
+		writeImagesError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if len(req.Images) == 0 {
+		writeImagesError(c, http.StatusBadRequest, "Missing required parameter: 'image'.")
+		return
+	}
+	h.handleImages(c, req)
+}
+
+func (h *OpenAIAPIHandler) handleImages(c *gin.Context, req imageRequest) {
+	if req.Model == "" {
+		writeImagesError(c, http.StatusBadRequest, "Missing required parameter: 'model'.")
+		return
+	}
+	if req.Prompt == "" {
+		writeImagesError(c, http.StatusBadRequest, "Missing required parameter: 'prompt'.")
+		return
+	}
+	if req.N <= 0 {
+		req.N = 1
+	}
+	if req.N > imagesMaxCount {
+		writeImagesError(c, http.StatusBadRequest, fmt.Sprintf("Invalid 'n': must be at most %d.", imagesMaxCount))
+		return
+	}
+	switch req.ResponseFormat {
+	case "":
+		req.ResponseFormat = "b64_json"
+	case "b64_json", "url":
+	default:
+		writeImagesError(c, http.StatusBadRequest, fmt.Sprintf("Invalid 'response_format': %q is not one of 'b64_json' or 'url'.", req.ResponseFormat))
+		return
+	}
+	if info := registry.LookupModelInfo(req.Model); info != nil && len(info.SupportedOutputModalities) > 0 && !containsFold(info.SupportedOutputModalities, "IMAGE") {
+		writeImagesError(c, http.StatusBadRequest, fmt.Sprintf("Model %s does not support image output.", req.Model))
+		return
+	}
+
+	payload := buildGeminiImagePayload(req)
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+
+	results := h.generateImages(cliCtx, c, req.Model, payload, req.N)
+	data := make([]any, 0, req.N)
+	var inputTokens, outputTokens, totalTokens int64
+	var upstreamHeaders http.Header
+	for _, result := range results {
+		if result.errMsg != nil {
+			h.WriteErrorResponse(c, result.errMsg)
+			cliCancel(result.errMsg.Error)
+			return
+		}
+		upstreamHeaders = result.headers
+		images, finishReason, text := extractGeminiImages(result.resp)
+		if len(images) == 0 {
+			status, errType := http.StatusBadGateway, "server_error"
+			if strings.Contains(finishReason, "SAFETY") || strings.Contains(finishReason, "PROHIBITED") || strings.Contains(finishReason, "BLOCKLIST") {
+				status, errType = http.StatusBadRequest, "invalid_request_error"
+			}
+			message := "The model did not return an image"
+			if finishReason != "" {
+				message += " (finish reason " + finishReason + ")"
+			}
+			if text != "" {
+				message += ": " + text
+			}
+			c.JSON(status, handlers.ErrorResponse{Error: handlers.ErrorDetail{Message: message, Type: errType}})
+			cliCancel(errors.New(message))
+			return
+		}
+		for _, img := range images {
+			if req.ResponseFormat == "url" {
+				data = append(data, map[string]string{"url": "data:" + img.MimeType + ";base64," + img.Data})
+			} else {
+				data = append(data, map[string]string{"b64_json": img.Data})
+			}
+		}
+		usage := gjson.GetBytes(result.resp, "usageMetadata")
+		inputTokens += usage.Get("promptTokenCount").Int()
+		outputTokens += usage.Get("candidatesTokenCount").Int()
+		totalTokens += usage.Get("totalTokenCount").Int()
+	}
+
+	out := []byte(`{"created":0,"data":[]}`)
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	out, _ = sjson.SetBytes(out, "data", data)
+	if totalTokens > 0 {
+		out, _ = sjson.SetBytes(out, "usage.input_tokens", inputTokens)
+		out, _ = sjson.SetBytes(out, "usage.output_tokens", outputTokens)
+		out, _ = sjson.SetBytes(out, "usage.total_tokens", totalTokens)
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(out)
+	cliCancel()
+}
+
+// imageCallResult is the outcome of one generateContent call made for an images request.
+type imageCallResult struct {
+	resp    []byte
+	headers http.Header
+	errMsg  *interfaces.ErrorMessage
+}
+
+// generateImages makes n generateContent calls, since Gemini image models return a single
+// candidate. With n > 1 the calls run concurrently and skip the response cache, which would
+// otherwise answer every call after the first with the same image. Each concurrent call gets
+// its own gin context so header writes on the execute path do not race; the headers a call
+// sets are copied back once all calls are done.
+func (h *OpenAIAPIHandler) generateImages(ctx context.Context, c *gin.Context, modelName string, payload []byte, n int) []imageCallResult {
+	results := make([]imageCallResult, n)
+	if n == 1 {
+		results[0].resp, results[0].headers, results[0].errMsg = h.ExecuteWithAuthManager(ctx, "gemini", modelName, payload, "")
+		return results
+	}
+	ctx = handlers.WithoutResponseCache(ctx)
+	callCtxs := make([]*gin.Context, n)
+	for i := range callCtxs {
+		callCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		callCtx.Request = c.Request
+		callCtx.Keys = maps.Clone(c.Keys)
+		callCtxs[i] = callCtx
+	}
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			callCtx := context.WithValue(ctx, "gin", callCtxs[i])
+			results[i].resp, results[i].headers, results[i].errMsg = h.ExecuteWithAuthManager(callCtx, "gemini", modelName, payload, "")
+		}(i)
+	}
+	wg.Wait()
+	for _, callCtx := range callCtxs {
+		for key, values := range callCtx.Writer.Header() {
+			c.Writer.Header()[key] = values
+		}
+	}
+	return results
+}
+
+// buildGeminiImagePayload converts req into a Gemini generateContent request asking for image output.
+func buildGeminiImagePayload(req imageRequest) []byte {
+	out := []byte(`{"contents":[{"role":"user","parts":[]}],"generationConfig":{"responseModalities":["TEXT","IMAGE"]}}`)
+	for _, img := range req.Images {
+		out, _ = sjson.SetBytes(out, "contents.0.parts.-1", map[string]any{"inlineData": map[string]string{"mimeType": img.MimeType, "data": img.Data}})
+	}
+	prompt := req.Prompt
+	if req.Mask != nil {
+		out, _ = sjson.SetBytes(out, "contents.0.parts.-1", map[string]any{"inlineData": map[string]string{"mimeType": req.Mask.MimeType, "data": req.Mask.Data}})
+		prompt = "The last image is a mask: only change the areas where the mask is transparent and keep everything else identical.\n\n" + prompt
+	}
+	out, _ = sjson.SetBytes(out, "contents.0.parts.-1", map[string]string{"text": prompt})
+	if ratio, imageSize := geminiImageConfigForSize(req.Size); ratio != "" {
+		out, _ = sjson.SetBytes(out, "generationConfig.imageConfig.aspectRatio", ratio)
+		if imageSize != "" {
+			out, _ = sjson.SetBytes(out, "generationConfig.imageConfig.imageSize", imageSize)
+		}
+	}
+	return out
+}
+
+// geminiImageConfigForSize maps an OpenAI "WIDTHxHEIGHT" size to the closest supported Gemini
+// aspect ratio and, for large sizes, an image size tier. "auto" and unparsable sizes yield "".
+func geminiImageConfigForSize(size string) (aspectRatio, imageSize string) {
+	w, h, ok := strings.Cut(strings.ToLower(strings.TrimSpace(size)), "x")
+	if !ok {
+		return "", ""
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return "", ""
+	}
+	target := math.Log(float64(width) / float64(height))
+	best, bestDelta := "", math.Inf(1)
+	for _, ratio := range geminiImageAspectRatios {
+		rw, rh, _ := strings.Cut(ratio, ":")
+		fw, _ := strconv.ParseFloat(rw, 64)
+		fh, _ := strconv.ParseFloat(rh, 64)
+		if delta := math.Abs(math.Log(fw/fh) - target); delta < bestDelta {
+			best, bestDelta = ratio, delta
+		}
+	}
+	switch longest := max(width, height); {
+	case longest >= 4096:
+		imageSize = "4K"
+	case longest >= 2048:
+		imageSize = "2K"
+	}
+	return best, imageSize
+}
+
+// extractGeminiImages returns the inline images of a Gemini generateContent response together
+// with the first candidate's finish reason and any text the model produced instead.
+func extractGeminiImages(resp []byte) (images []imageInput, finishReason, text string) {
+	var texts []string
+	gjson.GetBytes(resp, "candidates").ForEach(func(_, candidate gjson.Result) bool {
+		if finishReason == "" {
+			finishReason = candidate.Get("finishReason").String()
+		}
+		candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			if part.Get("thought").Bool() {
+				return true
+			}
+			inline := part.Get("inlineData")
+			if !inline.Exists() {
+				inline = part.Get("inline_data")
+			}
+			if data := inline.Get("data").String(); data != "" {
+				mimeType := inline.Get("mimeType").String()
+				if mimeType == "" {
+					mimeType = inline.Get("mime_type").String()
+				}
+				if mimeType == "" {
+					mimeType = "image/png"
+				}
+				images = append(images, imageInput{MimeType: mimeType, Data: data})
+			} else if t := strings.TrimSpace(part.Get("text").String()); t != "" {
+				texts = append(texts, t)
+			}
+			return true
+		})
+		return true
+	})
+	return images, finishReason, strings.Join(texts, " ")
+}
+
+func parseMultipartImageEdit(c *gin.Context) (imageRequest, error) {
+	if err := c.Request.ParseMultipartForm(imageEditsMaxMemory); err != nil {
+		return imageRequest{}, err
+	}
+	form := c.Request.MultipartForm
+	req := imageRequest{
+		Model:          strings.TrimSpace(c.PostForm("model")),
+		Prompt:         strings.TrimSpace(c.PostForm("prompt")),
+		Size:           c.PostForm("size"),
+		ResponseFormat: c.PostForm("response_format"),
+	}
+	if n := strings.TrimSpace(c.PostForm("n")); n != "" {
+		parsed, err := strconv.Atoi(n)
+		if err != nil {
+			return imageRequest{}, fmt.Errorf("'n' must be an integer")
+		}
+		req.N = parsed
+	}
+	for _, field := range []string{"image", "image[]"} {
+		for _, fh := range form.File[field] {
+			img, err := readImageFile(fh)
+			if err != nil {
+				return imageRequest{}, err
+			}
+			req.Images = append(req.Images, img)
+		}
+	}
+	if files := form.File["mask"]; len(files) > 0 {
+		mask, err := readImageFile(files[0])
+		if err != nil {
+			return imageRequest{}, err
+		}
+		req.Mask = &mask
+	}
+	return req, nil
+}
+
+func parseJSONImageEdit(c *gin.Context) (imageRequest, error) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		return imageRequest{}, err
+	}
+	if !gjson.ValidBytes(rawJSON) {
+		return imageRequest{}, fmt.Errorf("body must be a JSON object")
+	}
+	root := gjson.ParseBytes(rawJSON)
+	req := imageRequest{
+		Model:          strings.TrimSpace(root.Get("model").String()),
+		Prompt:         strings.TrimSpace(root.Get("prompt").String()),
+		N:              int(root.Get("n").Int()),
+		Size:           root.Get("size").String(),
+		ResponseFormat: root.Get("response_format").String(),
+	}
+	var errImage error
+	root.Get("images").ForEach(func(_, item gjson.Result) bool {
+		img, errParse := parseImageDataURL(item.Get("image_url").String())
+		if errParse != nil {
+			errImage = errParse
+			return false
+		}
+		req.Images = append(req.Images, img)
+		return true
+	})
+	if errImage != nil {
+		return imageRequest{}, errImage
+	}
+	if maskURL := root.Get("mask.image_url").String(); maskURL != "" {
+		mask, errParse := parseImageDataURL(maskURL)
+		if errParse != nil {
+			return imageRequest{}, errParse
+		}
+		req.Mask = &mask
+	}
+	return req, nil
+}
+
+// parseImageDataURL decodes a base64 data URL. Remote URLs are rejected because the proxy
+// does not fetch third-party content on behalf of clients.
+func parseImageDataURL(raw string) (imageInput, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(raw), "data:")
+	if !ok {
+		return imageInput{}, fmt.Errorf("image_url must be a base64 data URL")
+	}
+	meta, data, ok := strings.Cut(rest, ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return imageInput{}, fmt.Errorf("image_url must be a base64 data URL")
+	}
+	mimeType := strings.TrimSuffix(meta, ";base64")
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+	return imageInput{MimeType: mimeType, Data: data}, nil
+}
+
+func readImageFile(fh *multipart.FileHeader) (imageInput, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return imageInput{}, fmt.Errorf("open %s: %w", fh.Filename, err)
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return imageInput{}, fmt.Errorf("read %s: %w", fh.Filename, err)
+	}
+	mimeType := strings.TrimSpace(fh.Header.Get("Content-Type"))
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = misc.MimeTypes[strings.TrimPrefix(strings.ToLower(filepath.Ext(fh.Filename)), ".")]
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return imageInput{}, fmt.Errorf("%s is not an image (%s)", fh.Filename, mimeType)
+	}
+	return imageInput{MimeType: mimeType, Data: base64.StdEncoding.EncodeToString(data)}, nil
+}
+
+func writeImagesError(c *gin.Context, status int, message string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type imageCaptureExecutor struct {
+	mu           sync.Mutex
+	payloads     [][]byte
+	sourceFormat string
+	response     string
+}
+
+func (e *imageCaptureExecutor) Identifier() string { return "test-image-provider" }
+
+func (e *imageCaptureExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.payloads = append(e.payloads, req.Payload)
+	e.sourceFormat = opts.SourceFormat.String()
+	return coreexecutor.Response{Payload: []byte(e.response)}, nil
+}
+
+func (e *imageCaptureExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *imageCaptureExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *imageCaptureExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *imageCaptureExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newImagesTestRouter(t *testing.T, executor *imageCaptureExecutor) *gin.Engine {
+	t.Helper()
+	return newImagesTestRouterWithConfig(t, executor, &sdkconfig.SDKConfig{})
+}
+
+func newImagesTestRouterWithConfig(t *testing.T, executor *imageCaptureExecutor, cfg *sdkconfig.SDKConfig) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "auth-images", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-image-model", SupportedOutputModalities: []string{"TEXT", "IMAGE"}}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+	router := gin.New()
+	router.POST("/v1/images/generations", h.ImageGenerations)
+	router.POST("/v1/images/edits", h.ImageEdits)
+	return router
+}
+
+const testGeminiImageResponse = `{"candidates":[{"content":{"role":"model","parts":[{"text":"Here you go"},{"inlineData":{"mimeType":"image/png","data":"aW1n"}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":1290,"totalTokenCount":1295}}`
+
+func TestOpenAIImageGenerationsTranslatesToGemini(t *testing.T) {
+	executor := &imageCaptureExecutor{response: testGeminiImageResponse}
+	router := newImagesTestRouter(t, executor)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(`{"model":"test-image-model","prompt":"a red fox","n":2,"size":"1792x1024"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	if len(executor.payloads) != 2 || executor.sourceFormat != "gemini" {
+		t.Fatalf("calls = %d, source format = %q", len(executor.payloads), executor.sourceFormat)
+	}
+	payload := executor.payloads[0]
+	if got := gjson.GetBytes(payload, "contents.0.parts.0.text").String(); got != "a red fox" {
+		t.Fatalf("prompt = %q", got)
+	}
+	if got := gjson.GetBytes(payload, "generationConfig.imageConfig.aspectRatio").String(); got != "16:9" {
+		t.Fatalf("aspect ratio = %q", got)
+	}
+	if !strings.Contains(gjson.GetBytes(payload, "generationConfig.responseModalities").Raw, "IMAGE") {
+		t.Fatalf("image output not requested: %s", payload)
+	}
+	body := resp.Body.Bytes()
+	if n := len(gjson.GetBytes(body, "data").Array()); n != 2 {
+		t.Fatalf("data entries = %d: %s", n, body)
+	}
+	if got := gjson.GetBytes(body, "data.0.b64_json").String(); got != "aW1n" {
+		t.Fatalf("b64_json = %q", got)
+	}
+	if got := gjson.GetBytes(body, "usage.total_tokens").Int(); got != 2590 {
+		t.Fatalf("total tokens = %d", got)
+	}
+}
+
+func TestOpenAIImageEditsMultipart(t *testing.T) {
+	executor := &imageCaptureExecutor{response: testGeminiImageResponse}
+	router := newImagesTestRouter(t, executor)
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	_ = writer.WriteField("model", "test-image-model")
+	_ = writer.WriteField("prompt", "add a hat")
+	_ = writer.WriteField("response_format", "url")
+	part, _ := writer.CreateFormFile("image", "cat.png")
+	_, _ = part.Write([]byte("\x89PNG\r\n\x1a\nfake"))
+	_ = writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/edits", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	payload := executor.payloads[0]
+	if got := gjson.GetBytes(payload, "contents.0.parts.0.inlineData.mimeType").String(); got != "image/png" {
+		t.Fatalf("inline image mime type = %q: %s", got, payload)
+	}
+	if got := gjson.GetBytes(payload, "contents.0.parts.1.text").String(); got != "add a hat" {
+		t.Fatalf("prompt = %q", got)
+	}
+	if got := gjson.GetBytes(resp.Body.Bytes(), "data.0.url").String(); got != "data:image/png;base64,aW1n" {
+		t.Fatalf("url = %q", got)
+	}
+}
+
+func TestOpenAIImageGenerationsReportsMissingImage(t *testing.T) {
+	executor := &imageCaptureExecutor{response: `{"candidates":[{"finishReason":"IMAGE_SAFETY"}]}`}
+	router := newImagesTestRouter(t, executor)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(`{"model":"test-image-model","prompt":"something"}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	if !strings.Contains(resp.Body.String(), "IMAGE_SAFETY") {
+		t.Fatalf("finish reason not reported: %s", resp.Body.String())
+	}
+}
+
+func TestOpenAIImageGenerationsBypassResponseCache(t *testing.T) {
+	executor := &imageCaptureExecutor{response: testGeminiImageResponse}
+	cfg := &sdkconfig.SDKConfig{ResponseCache: sdkconfig.ResponseCacheConfig{Enable: true, APIKeys: []string{"*"}}}
+	router := newImagesTestRouterWithConfig(t, executor, cfg)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(`{"model":"test-image-model","prompt":"a red fox","n":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	if len(executor.payloads) != 3 {
+		t.Fatalf("upstream calls = %d, want 3", len(executor.payloads))
+	}
+	if got := resp.Header().Get(handlers.ResponseCacheStatusHeader); got != "" {
+		t.Fatalf("cache status = %q, want none", got)
+	}
+}
@@ -0,0 +1,311 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// storedResponseTurn carries the state needed to persist a single /v1/responses turn.
+// A nil turn means server-side storage is disabled or the client opted out via store=false.
+type storedResponseTurn struct {
+	store responsestore.Store
+	owner string
+	model string
+	input string
+}
+
+// responsesStoreEnabled reports whether server-side conversation state is configured.
+func (h *OpenAIResponsesAPIHandler) responsesStoreEnabled() bool {
+	return h != nil && h.Cfg != nil && h.Cfg.ResponsesStore.Enable
+}
+
+// prepareStoredResponseTurn expands previous_response_id into a full input transcript and
+// returns the rewritten request together with the state used to store the new response.
+// When storage is disabled the request is returned unchanged so upstream semantics apply.
+func (h *OpenAIResponsesAPIHandler) prepareStoredResponseTurn(c *gin.Context, rawJSON []byte) ([]byte, *storedResponseTurn, *interfaces.ErrorMessage) {
+	if !h.responsesStoreEnabled() {
+		return rawJSON, nil, nil
+	}
+	store := responsestore.GetStore()
+	owner := responsesStoreOwner(c)
+	ctx := context.Background()
+	if c != nil && c.Request != nil {
+		ctx = c.Request.Context()
+	}
+
+	currentInput, errInput := responsesInputItems(gjson.GetBytes(rawJSON, "input"))
+	if errInput != nil {
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errInput}
+	}
+
+	expandedInput := currentInput
+	if previousID := strings.TrimSpace(gjson.GetBytes(rawJSON, "previous_response_id").String()); previousID != "" {
+		record, errGet := store.Get(ctx, previousID)
+		if errGet == nil && !responsesStoreOwnerMatches(record, owner) {
+			errGet = responsestore.ErrNotFound
+		}
+		if errGet != nil {
+			if errors.Is(errGet, responsestore.ErrNotFound) {
+				return nil, nil, previousResponseNotFoundError(previousID)
+			}
+			return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: errGet}
+		}
+
+		merged, errMerge := mergeJSONArrayRaw(normalizeJSONArrayRaw(record.Input), normalizeJSONArrayRaw(record.Output))
+		if errMerge == nil {
+			merged, errMerge = mergeJSONArrayRaw(merged, currentInput)
+		}
+		if errMerge != nil {
+			return nil, nil, &interfaces.ErrorMessage{
+				StatusCode: http.StatusBadRequest,
+				Error:      fmt.Errorf("invalid stored response input: %w", errMerge),
+			}
+		}
+		if deduped, errDedupe := dedupeFunctionCallsByCallID(merged); errDedupe == nil {
+			merged = deduped
+		}
+		expandedInput = merged
+
+		var errSet error
+		rawJSON, errSet = sjson.SetRawBytes(rawJSON, "input", []byte(expandedInput))
+		if errSet != nil {
+			return nil, nil, &interfaces.ErrorMessage{
+				StatusCode: http.StatusBadRequest,
+				Error:      fmt.Errorf("failed to expand previous_response_id: %w", errSet),
+			}
+		}
+		rawJSON, _ = sjson.DeleteBytes(rawJSON, "previous_response_id")
+		if strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String()) == "" && record.Model != "" {
+			rawJSON, _ = sjson.SetBytes(rawJSON, "model", record.Model)
+		}
+	}
+
+	if storeFlag := gjson.GetBytes(rawJSON, "store"); storeFlag.Exists() && storeFlag.Type == gjson.False {
+		return rawJSON, nil, nil
+	}
+	return rawJSON, &storedResponseTurn{
+		store: store,
+		owner: owner,
+		model: gjson.GetBytes(rawJSON, "model").String(),
+		input: expandedInput,
+	}, nil
+}
+
+// save persists a completed response object. Failures are logged and never surfaced to the client.
+func (t *storedResponseTurn) save(ctx context.Context, response []byte) {
+	if t == nil || t.store == nil || len(response) == 0 {
+		return
+	}
+	id := strings.TrimSpace(gjson.GetBytes(response, "id").String())
+	if id == "" {
+		return
+	}
+	output := gjson.GetBytes(response, "output")
+	outputRaw := "[]"
+	if output.Exists() && output.IsArray() {
+		outputRaw = output.Raw
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// The request context may already be cancelled once the client has its answer.
+	ctx = context.WithoutCancel(ctx)
+	record := &responsestore.Record{
+		ID:       id,
+		Owner:    t.owner,
+		Model:    t.model,
+		Input:    json.RawMessage(t.input),
+		Output:   json.RawMessage(outputRaw),
+		Response: json.RawMessage(response),
+	}
+	if err := t.store.Put(ctx, record); err != nil {
+		log.Warnf("responses store: failed to save response %s: %v", id, err)
+	}
+}
+
+// observeStreamFrame returns a frame observer that stores the response carried by
+// the terminal response.completed event, or nil when storage is disabled.
+func (t *storedResponseTurn) observeStreamFrame(ctx context.Context) func([]byte) {
+	if t == nil {
+		return nil
+	}
+	return func(frame []byte) {
+		for _, payload := range websocketJSONPayloadsFromChunk(frame) {
+			if gjson.GetBytes(payload, "type").String() != wsEventTypeCompleted {
+				continue
+			}
+			response := gjson.GetBytes(payload, "response")
+			if response.Exists() && response.IsObject() {
+				t.save(ctx, []byte(response.Raw))
+			}
+		}
+	}
+}
+
+// GetResponse handles GET /v1/responses/:id and returns a stored response object.
+func (h *OpenAIResponsesAPIHandler) GetResponse(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	record, ok := h.lookupStoredResponse(c, id)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, "application/json", record.Response)
+}
+
+// GetResponseInputItems handles GET /v1/responses/:id/input_items and lists the
+// expanded input items that produced a stored response.
+func (h *OpenAIResponsesAPIHandler) GetResponseInputItems(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	record, ok := h.lookupStoredResponse(c, id)
+	if !ok {
+		return
+	}
+	items := gjson.Parse(normalizeJSONArrayRaw(record.Input)).Array()
+	data := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		data = append(data, json.RawMessage(item.Raw))
+	}
+	body := gin.H{
+		"object":   "list",
+		"data":     data,
+		"has_more": false,
+	}
+	if len(items) > 0 {
+		body["first_id"] = items[0].Get("id").String()
+		body["last_id"] = items[len(items)-1].Get("id").String()
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// DeleteResponse handles DELETE /v1/responses/:id and removes a stored response.
+func (h *OpenAIResponsesAPIHandler) DeleteResponse(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, ok := h.lookupStoredResponse(c, id); !ok {
+		return
+	}
+	if err := responsestore.GetStore().Delete(c.Request.Context(), id); err != nil {
+		if errors.Is(err, responsestore.ErrNotFound) {
+			writeResponseNotFound(c, id)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: err.Error(),
+				Type:    "server_error",
+			},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"object":  "response",
+		"deleted": true,
+	})
+}
+
+func (h *OpenAIResponsesAPIHandler) lookupStoredResponse(c *gin.Context, id string) (*responsestore.Record, bool) {
+	if !h.responsesStoreEnabled() || id == "" {
+		writeResponseNotFound(c, id)
+		return nil, false
+	}
+	record, err := responsestore.GetStore().Get(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, responsestore.ErrNotFound) {
+			writeResponseNotFound(c, id)
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: err.Error(),
+				Type:    "server_error",
+			},
+		})
+		return nil, false
+	}
+	if !responsesStoreOwnerMatches(record, responsesStoreOwner(c)) {
+		writeResponseNotFound(c, id)
+		return nil, false
+	}
+	return record, true
+}
+
+func writeResponseNotFound(c *gin.Context, id string) {
+	c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: fmt.Sprintf("Response with id '%s' not found.", id),
+			Type:    "invalid_request_error",
+			Code:    "response_not_found",
+		},
+	})
+}
+
+func previousResponseNotFoundError(id string) *interfaces.ErrorMessage {
+	body, _ := json.Marshal(handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: fmt.Sprintf("Previous response with id '%s' not found.", id),
+			Type:    "invalid_request_error",
+			Code:    "previous_response_not_found",
+		},
+	})
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New(string(body))}
+}
+
+// responsesInputItems normalizes the request input into a JSON array of input items.
+// A plain string input is treated as a single user message.
+func responsesInputItems(input gjson.Result) (string, error) {
+	switch {
+	case !input.Exists() || input.Type == gjson.Null:
+		return "[]", nil
+	case input.Type == gjson.String:
+		item, err := json.Marshal([]map[string]string{{
+			"type":    "message",
+			"role":    "user",
+			"content": input.String(),
+		}})
+		if err != nil {
+			return "", err
+		}
+		return string(item), nil
+	case input.IsArray():
+		return input.Raw, nil
+	default:
+		return "[]", nil
+	}
+}
+
+// responsesStoreOwner returns the hashed client key that owns stored responses, so raw keys
+// never reach the store.
+func responsesStoreOwner(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	if v, exists := c.Get("apiKey"); exists {
+		if s, ok := v.(string); ok {
+			return util.HashAPIKey(s)
+		}
+	}
+	return ""
+}
+
+// responsesStoreOwnerMatches prevents one client key from reading another key's conversation.
+// Records created without a principal are only visible to requests without one. Owners stored
+// as raw keys by earlier versions are hashed before comparison.
+func responsesStoreOwnerMatches(record *responsestore.Record, owner string) bool {
+	if record == nil {
+		return false
+	}
+	return util.HashAPIKey(record.Owner) == owner
+}
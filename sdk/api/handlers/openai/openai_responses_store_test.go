@@ -0,0 +1,235 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type storedResponsesCaptureExecutor struct {
+	payloads [][]byte
+}
+
+func (e *storedResponsesCaptureExecutor) Identifier() string { return "test-provider" }
+
+func (e *storedResponsesCaptureExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.payloads = append(e.payloads, append([]byte(nil), req.Payload...))
+	n := len(e.payloads)
+	body := fmt.Sprintf(`{"id":"resp_%d","object":"response","status":"completed","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"answer %d"}]}]}`, n, n)
+	return coreexecutor.Response{Payload: []byte(body)}, nil
+}
+
+func (e *storedResponsesCaptureExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *storedResponsesCaptureExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *storedResponsesCaptureExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *storedResponsesCaptureExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newStoredResponsesRouter(t *testing.T, executor *storedResponsesCaptureExecutor) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "auth-stored-responses", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-model"}})
+
+	previous := responsestore.GetStore()
+	responsestore.RegisterStore(responsestore.NewMemoryStore(time.Hour))
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+		responsestore.RegisterStore(previous)
+	})
+
+	cfg := &sdkconfig.SDKConfig{ResponsesStore: sdkconfig.ResponsesStoreConfig{Enable: true}}
+	h := NewOpenAIResponsesAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-Test-Key"); key != "" {
+			c.Set("apiKey", key)
+		}
+	})
+	router.POST("/v1/responses", h.Responses)
+	router.GET("/v1/responses/:id", h.GetResponse)
+	router.GET("/v1/responses/:id/input_items", h.GetResponseInputItems)
+	router.DELETE("/v1/responses/:id", h.DeleteResponse)
+	return router
+}
+
+func serveStoredResponses(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func serveStoredResponsesAs(router *gin.Engine, key, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-Key", key)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestResponsesExpandsPreviousResponseID(t *testing.T) {
+	executor := &storedResponsesCaptureExecutor{}
+	router := newStoredResponsesRouter(t, executor)
+
+	first := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"model":"test-model","input":"hello"}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("first status = %d, body = %s", first.Code, first.Body.String())
+	}
+
+	second := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"previous_response_id":"resp_1","input":[{"type":"message","role":"user","content":"again"}]}`)
+	if second.Code != http.StatusOK {
+		t.Fatalf("second status = %d, body = %s", second.Code, second.Body.String())
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("executor calls = %d, want 2", len(executor.payloads))
+	}
+	upstream := executor.payloads[1]
+	if gjson.GetBytes(upstream, "previous_response_id").Exists() {
+		t.Fatalf("previous_response_id should be removed before execution: %s", upstream)
+	}
+	if got := gjson.GetBytes(upstream, "model").String(); got != "test-model" {
+		t.Fatalf("model = %q, want test-model", got)
+	}
+	input := gjson.GetBytes(upstream, "input").Array()
+	if len(input) != 3 {
+		t.Fatalf("expanded input length = %d, want 3: %s", len(input), upstream)
+	}
+	if input[0].Get("content").String() != "hello" || input[1].Get("role").String() != "assistant" || input[2].Get("content").String() != "again" {
+		t.Fatalf("unexpected expanded input: %s", gjson.GetBytes(upstream, "input").Raw)
+	}
+
+	items := serveStoredResponses(router, http.MethodGet, "/v1/responses/resp_2/input_items", "")
+	if items.Code != http.StatusOK || len(gjson.Get(items.Body.String(), "data").Array()) != 3 {
+		t.Fatalf("input_items status = %d, body = %s", items.Code, items.Body.String())
+	}
+}
+
+func TestResponsesUnknownPreviousResponseID(t *testing.T) {
+	executor := &storedResponsesCaptureExecutor{}
+	router := newStoredResponsesRouter(t, executor)
+
+	resp := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"model":"test-model","previous_response_id":"resp_missing","input":"hi"}`)
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+	if code := gjson.Get(resp.Body.String(), "error.code").String(); code != "previous_response_not_found" {
+		t.Fatalf("error code = %q, body = %s", code, resp.Body.String())
+	}
+	if len(executor.payloads) != 0 {
+		t.Fatalf("executor calls = %d, want 0", len(executor.payloads))
+	}
+}
+
+func TestResponsesGetAndDeleteStoredResponse(t *testing.T) {
+	executor := &storedResponsesCaptureExecutor{}
+	router := newStoredResponsesRouter(t, executor)
+
+	if resp := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"model":"test-model","input":"hello"}`); resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d", resp.Code)
+	}
+	if resp := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"model":"test-model","input":"skip","store":false}`); resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d", resp.Code)
+	}
+
+	got := serveStoredResponses(router, http.MethodGet, "/v1/responses/resp_1", "")
+	if got.Code != http.StatusOK || gjson.Get(got.Body.String(), "id").String() != "resp_1" {
+		t.Fatalf("get status = %d, body = %s", got.Code, got.Body.String())
+	}
+	if resp := serveStoredResponses(router, http.MethodGet, "/v1/responses/resp_2", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("store=false response should not be retrievable, status = %d", resp.Code)
+	}
+
+	deleted := serveStoredResponses(router, http.MethodDelete, "/v1/responses/resp_1", "")
+	if deleted.Code != http.StatusOK || !gjson.Get(deleted.Body.String(), "deleted").Bool() {
+		t.Fatalf("delete status = %d, body = %s", deleted.Code, deleted.Body.String())
+	}
+	if resp := serveStoredResponses(router, http.MethodGet, "/v1/responses/resp_1", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("get after delete status = %d, want %d", resp.Code, http.StatusNotFound)
+	}
+}
+
+func TestResponsesStoreCapturesStreamCompletion(t *testing.T) {
+	store := responsestore.NewMemoryStore(time.Hour)
+	turn := &storedResponseTurn{store: store, model: "test-model", input: `[{"role":"user","content":"hi"}]`}
+	framer := &responsesSSEFramer{onFrame: turn.observeStreamFrame(context.Background())}
+	recorder := httptest.NewRecorder()
+
+	framer.WriteChunk(recorder, []byte("event: response.created\ndata: {\"type\":\"response.created\",\"response\":{\"id\":\"resp_s\"}}\n\n"))
+	framer.WriteChunk(recorder, []byte("event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_s\",\"output\":[{\"type\":\"message\"}]}}"))
+	framer.WriteChunk(recorder, []byte("\n\n"))
+
+	record, err := store.Get(context.Background(), "resp_s")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(record.Output) != `[{"type":"message"}]` {
+		t.Fatalf("output = %s", record.Output)
+	}
+}
+
+func TestResponsesStoreOwnerIsHashedAndScoped(t *testing.T) {
+	executor := &storedResponsesCaptureExecutor{}
+	router := newStoredResponsesRouter(t, executor)
+
+	if resp := serveStoredResponsesAs(router, "client-a", http.MethodPost, "/v1/responses", `{"model":"test-model","input":"hello"}`); resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	record, err := responsestore.GetStore().Get(context.Background(), "resp_1")
+	if err != nil {
+		t.Fatalf("Get stored record: %v", err)
+	}
+	if record.Owner != util.HashAPIKey("client-a") {
+		t.Fatalf("record owner = %q, want hashed client key", record.Owner)
+	}
+
+	if resp := serveStoredResponsesAs(router, "client-a", http.MethodGet, "/v1/responses/resp_1", ""); resp.Code != http.StatusOK {
+		t.Fatalf("owner get status = %d, want %d", resp.Code, http.StatusOK)
+	}
+	if resp := serveStoredResponsesAs(router, "client-b", http.MethodGet, "/v1/responses/resp_1", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("other key get status = %d, want %d", resp.Code, http.StatusNotFound)
+	}
+
+	if resp := serveStoredResponses(router, http.MethodPost, "/v1/responses", `{"model":"test-model","input":"anonymous"}`); resp.Code != http.StatusOK {
+		t.Fatalf("anonymous create status = %d", resp.Code)
+	}
+	if resp := serveStoredResponsesAs(router, "client-a", http.MethodGet, "/v1/responses/resp_2", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("keyed get of ownerless record status = %d, want %d", resp.Code, http.StatusNotFound)
+	}
+	if resp := serveStoredResponses(router, http.MethodGet, "/v1/responses/resp_2", ""); resp.Code != http.StatusOK {
+		t.Fatalf("anonymous get status = %d, want %d", resp.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redact"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// applyRedaction runs the redaction engine on the client payload before it is handed to
+// the executors. Depending on the mode configured for the client key the payload is
+// masked, forwarded unchanged, or the request is rejected in the client's protocol.
+// Every request with findings is counted in the usage statistics.
+func applyRedaction(ctx context.Context, handlerType string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	engine := redact.Default()
+	if !engine.Enabled() || len(rawJSON) == 0 {
+		return rawJSON, nil
+	}
+	apiKey := ""
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		if v, exists := ginCtx.Get("apiKey"); exists {
+			apiKey = fmt.Sprintf("%v", v)
+		}
+	}
+	mode := engine.ModeFor(apiKey)
+	if mode == redact.ModeMask {
+		masked, findings := engine.Mask(rawJSON)
+		if len(findings) > 0 {
+			usage.GetRequestStatistics().RecordRedaction(string(mode), findings)
+		}
+		return masked, nil
+	}
+	findings := engine.Detect(rawJSON)
+	if len(findings) == 0 {
+		return rawJSON, nil
+	}
+	usage.GetRequestStatistics().RecordRedaction(string(mode), findings)
+	if mode == redact.ModeBlock {
+		blocked := &redact.BlockedError{Detectors: findings.Detectors(), Format: handlerType}
+		return nil, &interfaces.ErrorMessage{StatusCode: blocked.StatusCode(), Error: blocked}
+	}
+	return rawJSON, nil
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsecache"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ResponseCacheHeader lets clients opt in ("use") or out ("bypass") of the response cache.
+	ResponseCacheHeader = "X-Response-Cache"
+	// ResponseCacheStatusHeader reports whether a response was served from the cache ("hit") or not ("miss").
+	ResponseCacheStatusHeader = "X-Response-Cache-Status"
+)
+
+type responseCacheBypassKey struct{}
+
+// WithoutResponseCache marks ctx so executions made with it neither read nor fill the response
+// cache, for handlers that repeat an identical request to collect independent results.
+func WithoutResponseCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, responseCacheBypassKey{}, true)
+}
+
+// responseCacheKey returns the cache key for the request, or "" when the response cache
+// is disabled or the caller has not opted in via header or API-key policy.
+func (h *BaseAPIHandler) responseCacheKey(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, stream bool) string {
+	if h == nil || h.Cfg == nil || !h.Cfg.ResponseCache.Enable || ctx == nil {
+		return ""
+	}
+	if bypass, _ := ctx.Value(responseCacheBypassKey{}).(bool); bypass {
+		return ""
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	if ginCtx == nil {
+		return ""
+	}
+	apiKey := ""
+	if v, exists := ginCtx.Get("apiKey"); exists {
+		apiKey = fmt.Sprintf("%v", v)
+	}
+	if !responseCacheOptedIn(ginCtx.GetHeader(ResponseCacheHeader), apiKey, h.Cfg.ResponseCache.APIKeys) {
+		return ""
+	}
+	return responsecache.Key(apiKey, handlerType, modelName, alt, stream, rawJSON)
+}
+
+// responseCacheOptedIn applies the header override first and falls back to the API-key policy.
+func responseCacheOptedIn(header, apiKey string, policyKeys []string) bool {
+	switch strings.ToLower(strings.TrimSpace(header)) {
+	case "1", "true", "on", "use", "yes":
+		return true
+	case "0", "false", "off", "bypass", "no", "no-store":
+		return false
+	}
+	for _, key := range policyKeys {
+		key = strings.TrimSpace(key)
+		if key == "*" || (key != "" && key == apiKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupResponseCache returns the cached entry for key and reports the cache status on
+// the response. It returns nil on a miss or when key is empty.
+func lookupResponseCache(ctx context.Context, key string) *responsecache.Entry {
+	if key == "" {
+		return nil
+	}
+	entry, ok := responsecache.Default().Get(ctx, key)
+	if ok {
+		setResponseCacheStatus(ctx, "hit")
+		return entry
+	}
+	setResponseCacheStatus(ctx, "miss")
+	return nil
+}
+
+// storeResponseCache saves entry without blocking the caller on the persistent backend.
+func storeResponseCache(ctx context.Context, entry *responsecache.Entry) {
+	if entry == nil || entry.Key == "" {
+		return
+	}
+	storeCtx := context.WithoutCancel(ctx)
+	go func() {
+		if err := responsecache.Default().Put(storeCtx, entry); err != nil {
+			log.Warnf("response cache: store entry: %v", err)
+		}
+	}()
+}
+
+// cachedResponseHeaders returns the stored upstream headers when passthrough is enabled.
+func (h *BaseAPIHandler) cachedResponseHeaders(entry *responsecache.Entry) http.Header {
+	if entry == nil || !PassthroughHeadersEnabled(h.Cfg) || entry.Headers == nil {
+		return nil
+	}
+	return entry.Headers.Clone()
+}
+
+// replayCachedStream emits the recorded chunks of a streaming entry in order.
+func replayCachedStream(ctx context.Context, entry *responsecache.Entry) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	dataChan := make(chan []byte)
+	errChan := make(chan *interfaces.ErrorMessage)
+	go func() {
+		defer close(dataChan)
+		defer close(errChan)
+		for _, chunk := range entry.Chunks {
+			select {
+			case <-ctx.Done():
+				return
+			case dataChan <- chunk:
+			}
+		}
+	}()
+	return dataChan, errChan
+}
+
+func setResponseCacheStatus(ctx context.Context, status string) {
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		ginCtx.Header(ResponseCacheStatusHeader, status)
+	}
+}
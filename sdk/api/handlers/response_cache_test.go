@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsecache"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type cacheCountingExecutor struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (e *cacheCountingExecutor) Identifier() string { return "codex" }
+
+func (e *cacheCountingExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	return coreexecutor.Response{Payload: []byte(`{"id":"cmpl-1"}`)}, nil
+}
+
+func (e *cacheCountingExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	ch := make(chan coreexecutor.StreamChunk, 2)
+	ch <- coreexecutor.StreamChunk{Payload: []byte("data: one")}
+	ch <- coreexecutor.StreamChunk{Payload: []byte("data: two")}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *cacheCountingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *cacheCountingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *cacheCountingExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func (e *cacheCountingExecutor) Calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+func newResponseCacheTestHandler(t *testing.T, apiKeys []string) (*BaseAPIHandler, *cacheCountingExecutor) {
+	t.Helper()
+	executor := &cacheCountingExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "cache-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "cache-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	previous := responsecache.Default()
+	responsecache.Register(responsecache.New(time.Hour, 10, nil, ""))
+	t.Cleanup(func() { responsecache.Register(previous) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		ResponseCache: sdkconfig.ResponseCacheConfig{Enable: true, APIKeys: apiKeys},
+	}, manager)
+	return handler, executor
+}
+
+func newResponseCacheTestContext(header string) (context.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if header != "" {
+		ginCtx.Request.Header.Set(ResponseCacheHeader, header)
+	}
+	ginCtx.Set("apiKey", "ci-key")
+	return context.WithValue(context.Background(), "gin", ginCtx), recorder
+}
+
+func waitForCacheEntries(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(responsecache.Default().Entries()) < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d cache entries", want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestExecuteWithAuthManager_ServesRepeatedRequestFromCache(t *testing.T) {
+	handler, executor := newResponseCacheTestHandler(t, []string{"ci-key"})
+
+	ctx, recorder := newResponseCacheTestContext("")
+	body, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "cache-model", []byte(`{"model":"cache-model","messages":[{"role":"user","content":"hi"}]}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if got := recorder.Header().Get(ResponseCacheStatusHeader); got != "miss" {
+		t.Fatalf("cache status = %q, want miss", got)
+	}
+	waitForCacheEntries(t, 1)
+
+	ctx, recorder = newResponseCacheTestContext("")
+	cached, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "cache-model", []byte(`{"messages":[{"content":"hi","role":"user"}],"model":"cache-model"}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if string(cached) != string(body) {
+		t.Fatalf("cached body = %s, want %s", cached, body)
+	}
+	if got := recorder.Header().Get(ResponseCacheStatusHeader); got != "hit" {
+		t.Fatalf("cache status = %q, want hit", got)
+	}
+	if executor.Calls() != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", executor.Calls())
+	}
+
+	ctx, _ = newResponseCacheTestContext("bypass")
+	if _, _, errMsg = handler.ExecuteWithAuthManager(ctx, "openai", "cache-model", []byte(`{"model":"cache-model","messages":[{"role":"user","content":"hi"}]}`), ""); errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if executor.Calls() != 2 {
+		t.Fatalf("expected bypass header to reach upstream, got %d calls", executor.Calls())
+	}
+}
+
+func TestExecuteStreamWithAuthManager_ReplaysCachedChunks(t *testing.T) {
+	handler, executor := newResponseCacheTestHandler(t, nil)
+	payload := []byte(`{"model":"cache-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+
+	collect := func(header string) []string {
+		ctx, _ := newResponseCacheTestContext(header)
+		dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "cache-model", payload, "")
+		var chunks []string
+		for chunk := range dataChan {
+			chunks = append(chunks, string(chunk))
+		}
+		for msg := range errChan {
+			if msg != nil {
+				t.Fatalf("unexpected error: %+v", msg)
+			}
+		}
+		return chunks
+	}
+
+	// Without the opt-in header or an API-key policy the cache is not used.
+	collect("")
+	if len(responsecache.Default().Entries()) != 0 {
+		t.Fatalf("expected no cache entries without opt-in")
+	}
+
+	first := collect("use")
+	waitForCacheEntries(t, 1)
+	second := collect("use")
+	if executor.Calls() != 2 {
+		t.Fatalf("expected 2 upstream calls, got %d", executor.Calls())
+	}
+	if len(second) != 2 || second[0] != first[0] || second[1] != first[1] {
+		t.Fatalf("replayed chunks = %q, want %q", second, first)
+	}
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/shadow"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// shadowClientClosedStatus is recorded when the client went away before the primary response
+// completed, following the nginx convention.
+const shadowClientClosedStatus = 499
+
+// shadowAuthCursor rotates shadow calls across the credentials listed by a rule.
+var shadowAuthCursor atomic.Uint64
+
+// shadowMirror tracks the primary side of a mirrored request. The shadow call runs on its
+// own goroutine; the primary record is written once the handler is done with the response.
+// All methods are safe on a nil receiver so call sites need no mirroring checks.
+type shadowMirror struct {
+	record     shadow.Record
+	recordText bool
+	reqMeta    map[string]any
+	started    time.Time
+
+	mu       sync.Mutex
+	usage    coreusage.Detail
+	output   bytes.Buffer
+	finished bool
+}
+
+// startShadow decides whether the request is mirrored and, if so, launches the shadow call.
+// It returns nil when the request is not mirrored.
+func (h *BaseAPIHandler) startShadow(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, stream bool, reqMeta map[string]any) *shadowMirror {
+	if h == nil || h.Cfg == nil || !h.Cfg.Shadow.Enable || h.AuthManager == nil || ctx == nil {
+		return nil
+	}
+	recorder := shadow.Default()
+	if recorder == nil {
+		return nil
+	}
+	apiKey := ""
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		if v, exists := ginCtx.Get("apiKey"); exists {
+			apiKey = fmt.Sprintf("%v", v)
+		}
+	}
+	rule := shadow.Match(h.Cfg, apiKey, modelName)
+	if rule == nil {
+		return nil
+	}
+	authID := h.pickShadowAuth(rule)
+	if authID == "" {
+		log.Debugf("shadow: no usable credential for rule %q", rule.Name)
+		return nil
+	}
+	if !shadow.Acquire(h.Cfg) {
+		log.Debugf("shadow: concurrency limit reached, skipping mirror for %s", modelName)
+		return nil
+	}
+
+	now := time.Now()
+	mirror := &shadowMirror{
+		record: shadow.Record{
+			ID:        uuid.NewString(),
+			Role:      shadow.RolePrimary,
+			Rule:      rule.Name,
+			Timestamp: now,
+			APIKey:    util.HashAPIKey(apiKey),
+			Handler:   handlerType,
+			Model:     modelName,
+			Stream:    stream,
+		},
+		recordText: rule.RecordText,
+		reqMeta:    reqMeta,
+		started:    now,
+	}
+	if rule.RecordText {
+		mirror.record.Request = string(rawJSON)
+	}
+	shadowRecord := mirror.record
+	shadowRecord.Role = shadow.RoleShadow
+	shadowRecord.Model = strings.TrimSpace(rule.TargetModel)
+	shadowRecord.AuthID = authID
+	shadowRecord.Request = ""
+	go h.runShadow(recorder, shadowRecord, rule.RecordText, bytes.Clone(rawJSON), alt)
+	return mirror
+}
+
+// pickShadowAuth returns the next registered, enabled credential listed by the rule.
+func (h *BaseAPIHandler) pickShadowAuth(rule *config.ShadowRule) string {
+	ids := rule.AuthIDs
+	if len(ids) == 0 {
+		return ""
+	}
+	start := shadowAuthCursor.Add(1)
+	for i := range ids {
+		id := strings.TrimSpace(ids[(start+uint64(i))%uint64(len(ids))])
+		if id == "" {
+			continue
+		}
+		if auth, ok := h.AuthManager.GetByID(id); ok && auth != nil && !auth.Disabled {
+			return id
+		}
+	}
+	return ""
+}
+
+// runShadow executes the mirrored request detached from the client request and records the
+// outcome. The context carries no client state, so request logs, client quotas and the
+// primary's cancellation are unaffected; the pinned credential keeps routing and cooldown
+// state of the primary credentials untouched.
+func (h *BaseAPIHandler) runShadow(recorder shadow.Recorder, record shadow.Record, recordText bool, payload []byte, alt string) {
+	defer shadow.Release()
+	ctx, cancel := context.WithTimeout(context.Background(), shadow.Timeout(h.Cfg))
+	defer cancel()
+
+	defer func() {
+		if errRecord := recorder.Record(context.Background(), record); errRecord != nil {
+			log.Warnf("shadow: failed to write record: %v", errRecord)
+		}
+	}()
+
+	providers, normalizedModel, errMsg := h.getRequestDetails(record.Model)
+	if errMsg != nil {
+		record.Status = errMsg.StatusCode
+		if errMsg.Error != nil {
+			record.Error = errMsg.Error.Error()
+		}
+		return
+	}
+	if len(payload) == 0 {
+		payload = nil
+	}
+	req := coreexecutor.Request{Model: normalizedModel, Payload: payload}
+	opts := coreexecutor.Options{
+		Stream:          record.Stream,
+		Alt:             alt,
+		OriginalRequest: payload,
+		SourceFormat:    sdktranslator.FromString(record.Handler),
+		Metadata: map[string]any{
+			coreexecutor.RequestedModelMetadataKey: normalizedModel,
+			coreexecutor.PinnedAuthMetadataKey:     record.AuthID,
+		},
+	}
+
+	started := time.Now()
+	if !record.Stream {
+		resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+		record.LatencyMs = time.Since(started).Milliseconds()
+		if err != nil {
+			record.Status, record.Error = shadowErrorStatus(err), err.Error()
+			return
+		}
+		record.Status = http.StatusOK
+		setShadowUsage(&record, shadow.Usage(record.Handler, resp.Payload))
+		if recordText {
+			record.Output = string(resp.Payload)
+		}
+		return
+	}
+
+	result, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	if err != nil {
+		record.LatencyMs = time.Since(started).Milliseconds()
+		record.Status, record.Error = shadowErrorStatus(err), err.Error()
+		return
+	}
+	var (
+		detail coreusage.Detail
+		output bytes.Buffer
+	)
+	record.Status = http.StatusOK
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			record.Status, record.Error = shadowErrorStatus(chunk.Err), chunk.Err.Error()
+			continue
+		}
+		if len(chunk.Payload) == 0 {
+			continue
+		}
+		if record.FirstByteMs == 0 {
+			record.FirstByteMs = max(time.Since(started).Milliseconds(), 1)
+		}
+		shadow.StreamUsage(record.Handler, chunk.Payload, &detail)
+		if recordText {
+			output.Write(chunk.Payload)
+		}
+	}
+	record.LatencyMs = time.Since(started).Milliseconds()
+	setShadowUsage(&record, detail)
+	if recordText {
+		record.Output = output.String()
+	}
+}
+
+// observe accounts one streamed chunk of the primary response.
+func (m *shadowMirror) observe(chunk []byte) {
+	if m == nil || len(chunk) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.record.FirstByteMs == 0 {
+		m.record.FirstByteMs = max(time.Since(m.started).Milliseconds(), 1)
+	}
+	shadow.StreamUsage(m.record.Handler, chunk, &m.usage)
+	if m.recordText {
+		m.output.Write(chunk)
+	}
+}
+
+// fail marks the primary response as failed.
+func (m *shadowMirror) fail(status int, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.record.Status = status
+	if err != nil {
+		m.record.Error = err.Error()
+	}
+}
+
+// complete records a finished non-streaming primary response.
+func (m *shadowMirror) complete(payload []byte) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.usage = shadow.Usage(m.record.Handler, payload)
+	if m.recordText {
+		m.output.Write(payload)
+	}
+	m.mu.Unlock()
+	m.finish()
+}
+
+// finish writes the primary record. Later calls are ignored.
+func (m *shadowMirror) finish() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	if m.finished {
+		m.mu.Unlock()
+		return
+	}
+	m.finished = true
+	record := m.record
+	record.LatencyMs = time.Since(m.started).Milliseconds()
+	if record.Status == 0 {
+		record.Status = http.StatusOK
+	}
+	if authID, ok := m.reqMeta[coreexecutor.SelectedAuthMetadataKey].(string); ok {
+		record.AuthID = authID
+	}
+	setShadowUsage(&record, m.usage)
+	if m.recordText {
+		record.Output = m.output.String()
+	}
+	m.mu.Unlock()
+
+	recorder := shadow.Default()
+	if recorder == nil {
+		return
+	}
+	go func() {
+		if errRecord := recorder.Record(context.Background(), record); errRecord != nil {
+			log.Warnf("shadow: failed to write record: %v", errRecord)
+		}
+	}()
+}
+
+func setShadowUsage(record *shadow.Record, detail coreusage.Detail) {
+	record.InputTokens = detail.InputTokens
+	record.OutputTokens = detail.OutputTokens
+	record.ReasoningTokens = detail.ReasoningTokens
+	record.CachedTokens = detail.CachedTokens
+	record.TotalTokens = detail.TotalTokens
+}
+
+func shadowErrorStatus(err error) int {
+	if status := statusFromError(err); status > 0 {
+		return status
+	}
+	return http.StatusInternalServerError
+}
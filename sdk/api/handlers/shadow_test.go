@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/shadow"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type shadowTestExecutor struct {
+	mu      sync.Mutex
+	authIDs map[string]string
+}
+
+func (e *shadowTestExecutor) Identifier() string { return "codex" }
+
+func (e *shadowTestExecutor) Execute(_ context.Context, auth *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.authIDs[req.Model] = auth.ID
+	e.mu.Unlock()
+	if req.Model == "shadow-model" {
+		return coreexecutor.Response{}, &coreauth.Error{Code: "rate_limited", Message: "slow down", HTTPStatus: http.StatusTooManyRequests}
+	}
+	return coreexecutor.Response{Payload: []byte(`{"id":"cmpl-1","usage":{"prompt_tokens":7,"completion_tokens":3,"total_tokens":10}}`)}, nil
+}
+
+func (e *shadowTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *shadowTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *shadowTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *shadowTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+type memoryShadowRecorder struct {
+	mu      sync.Mutex
+	records []shadow.Record
+}
+
+func (r *memoryShadowRecorder) Record(_ context.Context, record shadow.Record) error {
+	r.mu.Lock()
+	r.records = append(r.records, record)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *memoryShadowRecorder) byRole(t *testing.T, want int) map[string]shadow.Record {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r.mu.Lock()
+		if len(r.records) >= want {
+			out := make(map[string]shadow.Record, len(r.records))
+			for _, record := range r.records {
+				out[record.Role] = record
+			}
+			r.mu.Unlock()
+			return out
+		}
+		r.mu.Unlock()
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d shadow records", want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestExecuteWithAuthManager_MirrorsToShadowModel(t *testing.T) {
+	executor := &shadowTestExecutor{authIDs: make(map[string]string)}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	for id, model := range map[string]string{"primary-auth": "primary-model", "shadow-auth": "shadow-model"} {
+		auth := &coreauth.Auth{ID: id, Provider: "codex", Status: coreauth.StatusActive}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register: %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "codex", []*registry.ModelInfo{{ID: model}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	recorder := &memoryShadowRecorder{}
+	shadow.Register(recorder)
+	t.Cleanup(func() { shadow.Register(nil) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Shadow: sdkconfig.ShadowConfig{
+			Enable: true,
+			Rules: []sdkconfig.ShadowRule{{
+				Name:        "compare",
+				APIKeys:     []string{"ci-key"},
+				Models:      []string{"primary-*"},
+				Percent:     100,
+				TargetModel: "shadow-model",
+				AuthIDs:     []string{"shadow-auth"},
+			}},
+		},
+	}, manager)
+
+	ctx, _ := newResponseCacheTestContext("")
+	body, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "primary-model", []byte(`{"model":"primary-model","messages":[{"role":"user","content":"hi"}]}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if len(body) == 0 {
+		t.Fatal("expected primary response body")
+	}
+
+	records := recorder.byRole(t, 2)
+	primary, mirrored := records[shadow.RolePrimary], records[shadow.RoleShadow]
+	if primary.ID == "" || primary.ID != mirrored.ID {
+		t.Fatalf("record ids = %q/%q, want matching pair", primary.ID, mirrored.ID)
+	}
+	if primary.APIKey != util.HashAPIKey("ci-key") || mirrored.APIKey != primary.APIKey {
+		t.Fatalf("record api keys = %q/%q, want the hashed client key", primary.APIKey, mirrored.APIKey)
+	}
+	if primary.Status != http.StatusOK || primary.TotalTokens != 10 || primary.AuthID != "primary-auth" {
+		t.Fatalf("primary record = %+v", primary)
+	}
+	if mirrored.Status != http.StatusTooManyRequests || mirrored.AuthID != "shadow-auth" || mirrored.Model != "shadow-model" {
+		t.Fatalf("shadow record = %+v", mirrored)
+	}
+
+	executor.mu.Lock()
+	shadowAuth := executor.authIDs["shadow-model"]
+	executor.mu.Unlock()
+	if shadowAuth != "shadow-auth" {
+		t.Fatalf("shadow call used auth %q, want shadow-auth", shadowAuth)
+	}
+	if auth, ok := manager.GetByID("primary-auth"); !ok || auth.Unavailable || auth.ModelStates["shadow-model"] != nil {
+		t.Fatalf("primary credential state changed by shadow failure: %+v", auth)
+	}
+}
@@ -1,6 +1,16 @@
 package auth
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
 
 func TestExtractAccessToken(t *testing.T) {
 	t.Parallel()
@@ -78,3 +88,48 @@ func TestExtractAccessToken(t *testing.T) {
 		})
 	}
 }
+
+func TestFileTokenStore_EncryptsMetadataAtRest(t *testing.T) {
+	keyring, err := authcrypt.NewKeyring(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, authcrypt.KeySize)), "")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	authcrypt.Configure(keyring)
+	t.Cleanup(func() { authcrypt.Configure(nil) })
+
+	dir := t.TempDir()
+	store := NewFileTokenStore()
+	store.SetBaseDir(dir)
+	auth := &cliproxyauth.Auth{
+		ID:       "claude-user.json",
+		Provider: "claude",
+		Metadata: map[string]any{"type": "claude", "email": "user@example.com", "access_token": "secret"},
+	}
+	path, err := store.Save(context.Background(), auth)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !authcrypt.IsSealed(raw) || bytes.Contains(raw, []byte("secret")) {
+		t.Fatalf("auth file is not sealed: %s", raw)
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, "codex-legacy.json"), []byte(`{"type":"codex","email":"legacy@example.com"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	auths, err := store.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	emails := map[string]string{}
+	for _, a := range auths {
+		email, _ := a.Metadata["email"].(string)
+		emails[a.Provider] = email
+	}
+	if emails["claude"] != "user@example.com" || emails["codex"] != "legacy@example.com" {
+		t.Fatalf("List emails = %v, want sealed and plaintext records decoded", emails)
+	}
+}
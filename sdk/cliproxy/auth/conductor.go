@@ -96,6 +96,8 @@ type Result struct {
 	Success bool
 	// RetryAfter carries a provider supplied retry hint (e.g. 429 retryDelay).
 	RetryAfter *time.Duration
+	// RateLimit carries the upstream x-ratelimit-* snapshot of a successful response.
+	RateLimit *RateLimitState
 	// Error describes the failure when Success is false.
 	Error *Error
 }
@@ -168,9 +170,19 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// Optional interceptor wrapped around executor calls (see SetExecutionInterceptor).
+	interceptor ExecutionInterceptor
+
+	// Optional check run before each execution candidate (see SetRequestPreflight).
+	preflight RequestPreflight
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 	refreshLoop   *authAutoRefreshLoop
+	refreshStats  refreshCounters
+
+	// perf feeds the latency and least-inflight routing strategies.
+	perf performanceTracker
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -194,12 +206,14 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 	manager.runtimeConfig.Store(&internalconfig.Config{})
 	manager.apiKeyModelAlias.Store(apiKeyModelAliasTable(nil))
 	manager.scheduler = newAuthScheduler(selector)
+	manager.scheduler.perf = &manager.perf
+	bindSelectorPerformance(selector, &manager.perf)
 	return manager
 }
 
 func isBuiltInSelector(selector Selector) bool {
 	switch selector.(type) {
-	case *RoundRobinSelector, *FillFirstSelector:
+	case *RoundRobinSelector, *FillFirstSelector, *LatencyAwareSelector, *LeastInflightSelector:
 		return true
 	default:
 		return false
@@ -335,6 +349,7 @@ func (m *Manager) SetSelector(selector Selector) {
 	if selector == nil {
 		selector = &RoundRobinSelector{}
 	}
+	bindSelectorPerformance(selector, &m.perf)
 	m.mu.Lock()
 	m.selector = selector
 	m.mu.Unlock()
@@ -769,20 +784,30 @@ func readStreamBootstrap(ctx context.Context, ch <-chan cliproxyexecutor.StreamC
 	}
 }
 
-func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, resultModel string, headers http.Header, buffered []cliproxyexecutor.StreamChunk, remaining <-chan cliproxyexecutor.StreamChunk) *cliproxyexecutor.StreamResult {
+func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, resultModel string, headers http.Header, buffered []cliproxyexecutor.StreamChunk, remaining <-chan cliproxyexecutor.StreamChunk, perfModel string, started time.Time, ttft time.Duration) *cliproxyexecutor.StreamResult {
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
 		defer close(out)
 		var failed bool
 		forward := true
+		outcome := perfSuccess
+		defer func() {
+			if !forward && outcome == perfSuccess {
+				outcome = perfAborted
+			}
+			m.perf.end(auth.ID, perfModel, started, ttft, outcome)
+		}()
 		emit := func(chunk cliproxyexecutor.StreamChunk) bool {
 			if chunk.Err != nil && !failed {
 				failed = true
-				rerr := &Error{Message: chunk.Err.Error()}
-				if se, ok := errors.AsType[cliproxyexecutor.StatusError](chunk.Err); ok && se != nil {
-					rerr.HTTPStatus = se.StatusCode()
+				outcome = perfOutcomeForError(ctx, chunk.Err)
+				if !isExecutionRejected(chunk.Err) {
+					rerr := &Error{Message: chunk.Err.Error()}
+					if se, ok := errors.AsType[cliproxyexecutor.StatusError](chunk.Err); ok && se != nil {
+						rerr.HTTPStatus = se.StatusCode()
+					}
+					m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr})
 				}
-				m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr})
 			}
 			if !forward {
 				return false
@@ -812,7 +837,7 @@ func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, re
 			}
 		}
 		if !failed {
-			m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: true})
+			m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: true, RateLimit: ParseRateLimitHeaders(headers, time.Now())})
 		}
 	}()
 	return &cliproxyexecutor.StreamResult{Headers: headers, Chunks: out}
@@ -827,11 +852,22 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 		resultModel := m.stateModelForExecution(auth, routeModel, execModel, pooled)
 		execReq := req
 		execReq.Model = execModel
-		streamResult, errStream := executor.ExecuteStream(ctx, auth, execReq, opts)
+		execReq, errPreflight := m.preflightCandidate(ctx, auth, execReq, opts)
+		if errPreflight != nil {
+			lastErr = errPreflight
+			continue
+		}
+		perfModel := canonicalModelKey(routeModel)
+		started := m.perf.begin(auth.ID, provider, perfModel)
+		streamResult, errStream := m.invokeExecuteStream(ctx, executor, auth, execReq, opts)
 		if errStream != nil {
+			m.perf.end(auth.ID, perfModel, started, 0, perfOutcomeForError(ctx, errStream))
 			if errCtx := ctx.Err(); errCtx != nil {
 				return nil, errCtx
 			}
+			if isExecutionRejected(errStream) {
+				return nil, errStream
+			}
 			rerr := &Error{Message: errStream.Error()}
 			if se, ok := errors.AsType[cliproxyexecutor.StatusError](errStream); ok && se != nil {
 				rerr.HTTPStatus = se.StatusCode()
@@ -847,11 +883,17 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 		}
 
 		buffered, closed, bootstrapErr := readStreamBootstrap(ctx, streamResult.Chunks)
+		ttft := time.Since(started)
 		if bootstrapErr != nil {
+			m.perf.end(auth.ID, perfModel, started, 0, perfOutcomeForError(ctx, bootstrapErr))
 			if errCtx := ctx.Err(); errCtx != nil {
 				discardStreamChunks(streamResult.Chunks)
 				return nil, errCtx
 			}
+			if isExecutionRejected(bootstrapErr) {
+				discardStreamChunks(streamResult.Chunks)
+				return nil, bootstrapErr
+			}
 			if isRequestInvalidError(bootstrapErr) {
 				rerr := &Error{Message: bootstrapErr.Error()}
 				if se, ok := errors.AsType[cliproxyexecutor.StatusError](bootstrapErr); ok && se != nil {
@@ -888,6 +930,7 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 
 		if closed && len(buffered) == 0 {
 			emptyErr := &Error{Code: "empty_stream", Message: "upstream stream closed before first payload", Retryable: true}
+			m.perf.end(auth.ID, perfModel, started, 0, perfFailure)
 			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: emptyErr}
 			m.MarkResult(ctx, result)
 			if idx < len(execModels)-1 {
@@ -903,7 +946,7 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			close(closedCh)
 			remaining = closedCh
 		}
-		return m.wrapStreamResult(ctx, auth.Clone(), provider, resultModel, streamResult.Headers, buffered, remaining), nil
+		return m.wrapStreamResult(ctx, auth.Clone(), provider, resultModel, streamResult.Headers, buffered, remaining, perfModel, started, ttft), nil
 	}
 	if lastErr == nil {
 		lastErr = &Error{Code: "auth_not_found", Message: "no upstream model available"}
@@ -964,6 +1007,14 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 			if entry := resolveVertexAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
 			}
+		case "bedrock":
+			if entry := resolveBedrockAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "azure-openai":
+			if entry := resolveAzureOpenAIAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
 		default:
 			// OpenAI-compat uses config selection from auth.Attributes.
 			providerKey := ""
@@ -1131,6 +1182,9 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	authClone := auth.Clone()
 	m.auths[auth.ID] = authClone
 	m.mu.Unlock()
+	if auth.Disabled || auth.Status == StatusDisabled {
+		m.perf.forget(auth.ID)
+	}
 	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
 	if m.scheduler != nil {
 		m.scheduler.upsertAuth(authClone)
@@ -1173,7 +1227,38 @@ func (m *Manager) Load(ctx context.Context) error {
 
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When the model has a fallback chain configured, eligible failures are retried on the next model.
 func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	resp, err := m.executeModel(ctx, providers, req, opts)
+	chain := m.fallbackChain(req.Model, opts)
+	if len(chain) == 0 {
+		return resp, err
+	}
+	if err != nil && isFallbackEligible(ctx, err) {
+		for _, fallback := range chain {
+			fbProviders, fbReq, fbOpts, ok := m.prepareFallback(fallback, req, opts)
+			if !ok {
+				continue
+			}
+			fbResp, errFallback := m.executeModel(ctx, fbProviders, fbReq, fbOpts)
+			if errFallback == nil {
+				publishServedModel(opts.Metadata, fbOpts.Metadata, fbReq.Model)
+				return fbResp, nil
+			}
+			if !isFallbackEligible(ctx, errFallback) {
+				return fbResp, errFallback
+			}
+			logEntryWithRequestID(ctx).Debugf("fallback model %s failed: %v", fbReq.Model, errFallback)
+		}
+		return resp, err
+	}
+	if err == nil {
+		publishServedModel(opts.Metadata, nil, req.Model)
+	}
+	return resp, err
+}
+
+func (m *Manager) executeModel(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -1235,7 +1320,47 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 
 // ExecuteStream performs a streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When the model has a fallback chain configured, failures before the first byte are retried on the next model.
 func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	result, err := m.executeStreamModel(ctx, providers, req, opts)
+	chain := m.fallbackChain(req.Model, opts)
+	if len(chain) == 0 {
+		return bootstrapErrorResult(result, err)
+	}
+	if err != nil && isFallbackEligible(ctx, err) {
+		for _, fallback := range chain {
+			fbProviders, fbReq, fbOpts, ok := m.prepareFallback(fallback, req, opts)
+			if !ok {
+				continue
+			}
+			fbResult, errFallback := m.executeStreamModel(ctx, fbProviders, fbReq, fbOpts)
+			if errFallback == nil {
+				publishServedModel(opts.Metadata, fbOpts.Metadata, fbReq.Model)
+				return fbResult, nil
+			}
+			if !isFallbackEligible(ctx, errFallback) {
+				return bootstrapErrorResult(fbResult, errFallback)
+			}
+			logEntryWithRequestID(ctx).Debugf("fallback model %s failed: %v", fbReq.Model, errFallback)
+		}
+		return bootstrapErrorResult(result, err)
+	}
+	if err == nil {
+		publishServedModel(opts.Metadata, nil, req.Model)
+	}
+	return bootstrapErrorResult(result, err)
+}
+
+// bootstrapErrorResult converts an error raised before the first stream byte into a
+// stream carrying that error, so callers observe upstream headers and the error chunk.
+func bootstrapErrorResult(result *cliproxyexecutor.StreamResult, err error) (*cliproxyexecutor.StreamResult, error) {
+	if bootstrapErr, ok := errors.AsType[*streamBootstrapError](err); ok && bootstrapErr != nil {
+		return streamErrorResult(bootstrapErr.Headers(), bootstrapErr.cause), nil
+	}
+	return result, err
+}
+
+func (m *Manager) executeStreamModel(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -1249,6 +1374,9 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 		if errStream == nil {
 			return result, nil
 		}
+		if _, ok := errors.AsType[*streamBootstrapError](errStream); ok {
+			return nil, errStream
+		}
 		lastErr = errStream
 		wait, shouldRetry := m.shouldRetryAfterError(errStream, attempt, normalized, req.Model, maxWait)
 		if !shouldRetry {
@@ -1270,6 +1398,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
+	ctx, requiredModalities := withRequiredModalities(ctx, req, opts)
 	tried := make(map[string]struct{})
 	attempted := make(map[string]struct{})
 	var lastErr error
@@ -1300,6 +1429,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		}
 
 		models, pooled := m.preparedExecutionModels(auth, routeModel)
+		models = filterModelsByModality(auth, routeModel, models, requiredModalities)
 		if len(models) == 0 {
 			continue
 		}
@@ -1309,12 +1439,23 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			resultModel := m.stateModelForExecution(auth, routeModel, upstreamModel, pooled)
 			execReq := req
 			execReq.Model = upstreamModel
-			resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+			execReq, errPreflight := m.preflightCandidate(execCtx, auth, execReq, opts)
+			if errPreflight != nil {
+				authErr = errPreflight
+				continue
+			}
+			perfModel := canonicalModelKey(routeModel)
+			started := m.perf.begin(auth.ID, provider, perfModel)
+			resp, errExec := m.invokeExecute(execCtx, executor, auth, execReq, opts)
+			m.perf.end(auth.ID, perfModel, started, 0, perfOutcomeForError(execCtx, errExec))
 			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
 			if errExec != nil {
 				if errCtx := execCtx.Err(); errCtx != nil {
 					return cliproxyexecutor.Response{}, errCtx
 				}
+				if isExecutionRejected(errExec) {
+					return cliproxyexecutor.Response{}, errExec
+				}
 				result.Error = &Error{Message: errExec.Error()}
 				if se, ok := errors.AsType[cliproxyexecutor.StatusError](errExec); ok && se != nil {
 					result.Error.HTTPStatus = se.StatusCode()
@@ -1329,6 +1470,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 				authErr = errExec
 				continue
 			}
+			result.RateLimit = ParseRateLimitHeaders(resp.Headers, time.Now())
 			m.MarkResult(execCtx, result)
 			return resp, nil
 		}
@@ -1426,16 +1568,13 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
+	ctx, requiredModalities := withRequiredModalities(ctx, req, opts)
 	tried := make(map[string]struct{})
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
 		if maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
 			if lastErr != nil {
-				var bootstrapErr *streamBootstrapError
-				if errors.As(lastErr, &bootstrapErr) && bootstrapErr != nil {
-					return streamErrorResult(bootstrapErr.Headers(), bootstrapErr.cause), nil
-				}
 				return nil, lastErr
 			}
 			return nil, &Error{Code: "auth_not_found", Message: "no auth available"}
@@ -1443,10 +1582,6 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
-				var bootstrapErr *streamBootstrapError
-				if errors.As(lastErr, &bootstrapErr) && bootstrapErr != nil {
-					return streamErrorResult(bootstrapErr.Headers(), bootstrapErr.cause), nil
-				}
 				return nil, lastErr
 			}
 			return nil, errPick
@@ -1463,6 +1598,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
 		models, pooled := m.preparedExecutionModels(auth, routeModel)
+		models = filterModelsByModality(auth, routeModel, models, requiredModalities)
 		if len(models) == 0 {
 			continue
 		}
@@ -1606,6 +1742,10 @@ func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) strin
 		upstreamModel = resolveUpstreamModelForCodexAPIKey(cfg, auth, requestedModel)
 	case "vertex":
 		upstreamModel = resolveUpstreamModelForVertexAPIKey(cfg, auth, requestedModel)
+	case "bedrock":
+		upstreamModel = resolveUpstreamModelForBedrockAPIKey(cfg, auth, requestedModel)
+	case "azure-openai":
+		upstreamModel = resolveUpstreamModelForAzureOpenAIAPIKey(cfg, auth, requestedModel)
 	default:
 		upstreamModel = resolveUpstreamModelForOpenAICompatAPIKey(cfg, auth, requestedModel)
 	}
@@ -1690,6 +1830,20 @@ func resolveVertexAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internal
 	return resolveAPIKeyConfig(cfg.VertexCompatAPIKey, auth)
 }
 
+func resolveBedrockAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.BedrockKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.BedrockKey, auth)
+}
+
+func resolveAzureOpenAIAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.AzureOpenAIKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.AzureOpenAIKey, auth)
+}
+
 func resolveUpstreamModelForGeminiAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
 	entry := resolveGeminiAPIKeyConfig(cfg, auth)
 	if entry == nil {
@@ -1722,6 +1876,22 @@ func resolveUpstreamModelForVertexAPIKey(cfg *internalconfig.Config, auth *Auth,
 	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
 }
 
+func resolveUpstreamModelForBedrockAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveBedrockAPIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
+func resolveUpstreamModelForAzureOpenAIAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveAzureOpenAIAPIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
 func resolveUpstreamModelForOpenAICompatAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
 	providerKey := ""
 	compatName := ""
@@ -1923,7 +2093,7 @@ func (m *Manager) shouldRetryAfterError(err error, attempt int, providers []stri
 	if status == http.StatusOK {
 		return 0, false
 	}
-	if isRequestInvalidError(err) {
+	if isRequestInvalidError(err) || isPreflightError(err) {
 		return 0, false
 	}
 	wait, found := m.closestCooldownWait(providers, model, attempt)
@@ -1972,6 +2142,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	clearModelQuota := false
 	setModelQuota := false
 	var authSnapshot *Auth
+	var shared *sharedCooldown
 
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
@@ -1980,7 +2151,12 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		if result.Success {
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
+				wasUnavailable := state.Unavailable
 				resetModelState(state, now)
+				if result.RateLimit != nil {
+					state.Quota.RateLimit = result.RateLimit
+					auth.Quota.RateLimit = result.RateLimit
+				}
 				updateAggregatedAvailability(auth, now)
 				if !hasModelError(auth, now) {
 					auth.LastError = nil
@@ -1990,8 +2166,18 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				auth.UpdatedAt = now
 				shouldResumeModel = true
 				clearModelQuota = true
+				if wasUnavailable {
+					shared = &sharedCooldown{AuthID: auth.ID, Model: result.Model, State: *state.Clone(), Resumed: true}
+				}
 			} else {
+				wasUnavailable := auth.Unavailable
 				clearAuthStateOnSuccess(auth, now)
+				if result.RateLimit != nil {
+					auth.Quota.RateLimit = result.RateLimit
+				}
+				if wasUnavailable {
+					shared = &sharedCooldown{AuthID: auth.ID, State: authLevelState(auth), Resumed: true}
+				}
 			}
 		} else {
 			if result.Model != "" {
@@ -2084,9 +2270,18 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 					auth.Status = StatusError
 					auth.UpdatedAt = now
 					updateAggregatedAvailability(auth, now)
+					if !state.NextRetryAfter.IsZero() {
+						shared = &sharedCooldown{AuthID: auth.ID, Model: result.Model, State: *state.Clone(), QuotaExceeded: setModelQuota}
+						if shouldSuspendModel {
+							shared.SuspendReason = suspendReason
+						}
+					}
 				}
 			} else {
 				applyAuthFailureState(auth, result.Error, result.RetryAfter, now)
+				if !auth.NextRetryAfter.IsZero() {
+					shared = &sharedCooldown{AuthID: auth.ID, State: authLevelState(auth)}
+				}
 			}
 		}
 
@@ -2109,6 +2304,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	} else if shouldSuspendModel {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
+	publishSharedCooldown(shared)
 
 	m.hook.OnResult(ctx, result)
 }
@@ -2401,6 +2597,12 @@ func isRequestInvalidError(err error) bool {
 	if isModelSupportError(err) {
 		return false
 	}
+	if isPreflightError(err) {
+		return false
+	}
+	if _, ok := errors.AsType[*modalityError](err); ok {
+		return true
+	}
 	status := statusCodeFromError(err)
 	switch status {
 	case http.StatusBadRequest:
@@ -2720,6 +2922,8 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 
 func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
+	requiredModalities := requiredModalitiesFromContext(ctx)
+	modalityExcluded := false
 
 	providerSet := make(map[string]struct{}, len(providers))
 	for _, provider := range providers {
@@ -2767,10 +2971,17 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 		if modelKey != "" && !m.authSupportsRouteModel(registryRef, candidate, model) {
 			continue
 		}
+		if !m.authAcceptsModalities(candidate, model, requiredModalities) {
+			modalityExcluded = true
+			continue
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
+		if modalityExcluded {
+			return nil, nil, "", newModalityError(model, requiredModalities, opts.SourceFormat)
+		}
 		return nil, nil, "", &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
 	available, errAvailable := m.availableAuthsForRouteModel(candidates, "mixed", model, time.Now())
@@ -2807,7 +3018,9 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 }
 
 func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
-	if !m.useSchedulerFastPath() {
+	// The scheduler does not know model modalities; requests carrying media are matched
+	// against each candidate's models by the legacy path.
+	if !m.useSchedulerFastPath() || len(requiredModalitiesFromContext(ctx)) > 0 {
 		return m.pickNextMixedLegacy(ctx, providers, model, opts, tried)
 	}
 
@@ -3210,6 +3423,7 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	}
 	log.Debugf("refreshed %s, %s, %v", auth.Provider, auth.ID, err)
 	now := time.Now()
+	m.refreshStats.record(auth.Provider, err == nil)
 	if err != nil {
 		shouldReschedule := false
 		m.mu.Lock()
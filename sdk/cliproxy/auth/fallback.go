@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// fallbackChain returns the configured fallback models for routeModel.
+// Pinned executions (e.g. websocket sessions bound to one credential) never fall back.
+func (m *Manager) fallbackChain(routeModel string, opts cliproxyexecutor.Options) []string {
+	if m == nil || pinnedAuthIDFromMetadata(opts.Metadata) != "" {
+		return nil
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.ModelFallbacks) == 0 {
+		return nil
+	}
+	parsed := thinking.ParseSuffix(routeModel)
+	base := strings.TrimSpace(parsed.ModelName)
+	for _, entry := range cfg.ModelFallbacks {
+		model := strings.TrimSpace(entry.Model)
+		if model == "" || (!strings.EqualFold(model, base) && !strings.EqualFold(model, routeModel)) {
+			continue
+		}
+		chain := make([]string, 0, len(entry.Fallbacks))
+		for _, fallback := range entry.Fallbacks {
+			fallback = strings.TrimSpace(fallback)
+			if fallback == "" || strings.EqualFold(fallback, base) {
+				continue
+			}
+			// Carry the requested thinking suffix over unless the fallback sets its own.
+			if parsed.HasSuffix && !thinking.ParseSuffix(fallback).HasSuffix {
+				fallback = fmt.Sprintf("%s(%s)", fallback, parsed.RawSuffix)
+			}
+			chain = append(chain, fallback)
+		}
+		return chain
+	}
+	return nil
+}
+
+// prepareFallback resolves the providers for a fallback model and builds the request
+// targeting it. The client payload and source format are kept unchanged so executors
+// translate the original request into the fallback provider's format.
+func (m *Manager) prepareFallback(model string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) ([]string, cliproxyexecutor.Request, cliproxyexecutor.Options, bool) {
+	base := strings.TrimSpace(thinking.ParseSuffix(model).ModelName)
+	providers := util.GetProviderName(base)
+	if len(providers) == 0 && base != model {
+		providers = util.GetProviderName(model)
+	}
+	if len(providers) == 0 {
+		return nil, req, opts, false
+	}
+	fbReq := req
+	fbReq.Model = model
+	fbOpts := opts
+	meta := make(map[string]any, len(opts.Metadata)+1)
+	for k, v := range opts.Metadata {
+		meta[k] = v
+	}
+	delete(meta, cliproxyexecutor.SelectedAuthMetadataKey)
+	meta[cliproxyexecutor.RequestedModelMetadataKey] = model
+	fbOpts.Metadata = meta
+	return providers, fbReq, fbOpts, true
+}
+
+// publishServedModel records the model that answered in the caller's metadata and copies
+// the selected auth from the fallback execution metadata when present.
+func publishServedModel(meta, fallbackMeta map[string]any, model string) {
+	if meta == nil {
+		return
+	}
+	meta[cliproxyexecutor.ServedModelMetadataKey] = model
+	if selected, ok := fallbackMeta[cliproxyexecutor.SelectedAuthMetadataKey]; ok {
+		meta[cliproxyexecutor.SelectedAuthMetadataKey] = selected
+	}
+}
+
+// isFallbackEligible reports whether err means the model cannot currently serve the request:
+// every credential is cooling down or unavailable, upstream rate limits were exhausted, or
+// the upstream failed with a 5xx before any bytes were returned to the client.
+func isFallbackEligible(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if ctx != nil && ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if _, ok := errors.AsType[*modelCooldownError](err); ok {
+		return true
+	}
+	if _, ok := errors.AsType[*modalityError](err); ok {
+		return true
+	}
+	if isPreflightError(err) {
+		return true
+	}
+	if authErr, ok := errors.AsType[*Error](err); ok && authErr != nil {
+		switch authErr.Code {
+		case "auth_unavailable", "auth_not_found", "executor_not_found":
+			return true
+		}
+	}
+	if isRequestInvalidError(err) {
+		return false
+	}
+	status := statusCodeFromError(err)
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
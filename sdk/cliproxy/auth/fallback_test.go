@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func newFallbackTestManager(t *testing.T, primary, secondary *openAICompatPoolExecutor) *Manager {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{
+		ModelFallbacks: []internalconfig.ModelFallback{{
+			Model:     "primary-model",
+			Fallbacks: []string{"missing-model", "secondary-model"},
+		}},
+	})
+	reg := registry.GetGlobalRegistry()
+	for _, item := range []struct {
+		executor *openAICompatPoolExecutor
+		model    string
+	}{{primary, "primary-model"}, {secondary, "secondary-model"}} {
+		m.RegisterExecutor(item.executor)
+		auth := &Auth{ID: item.executor.id + "-auth-" + t.Name(), Provider: item.executor.id, Status: StatusActive}
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+		reg.RegisterClient(auth.ID, item.executor.id, []*registry.ModelInfo{{ID: item.model}})
+		authID := auth.ID
+		t.Cleanup(func() { reg.UnregisterClient(authID) })
+	}
+	return m
+}
+
+func TestManagerExecute_FallsBackToNextModelOnUpstreamFailure(t *testing.T) {
+	primary := &openAICompatPoolExecutor{
+		id:            "fallback-primary",
+		executeErrors: map[string]error{"primary-model": &Error{HTTPStatus: http.StatusServiceUnavailable, Message: "overloaded"}},
+	}
+	secondary := &openAICompatPoolExecutor{id: "fallback-secondary"}
+	m := newFallbackTestManager(t, primary, secondary)
+
+	meta := map[string]any{}
+	resp, err := m.Execute(context.Background(), []string{primary.id}, cliproxyexecutor.Request{Model: "primary-model"}, cliproxyexecutor.Options{Metadata: meta})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if string(resp.Payload) != "secondary-model" {
+		t.Fatalf("payload = %q, want secondary-model", resp.Payload)
+	}
+	if got := meta[cliproxyexecutor.ServedModelMetadataKey]; got != "secondary-model" {
+		t.Fatalf("served model = %v, want secondary-model", got)
+	}
+}
+
+func TestManagerExecute_DoesNotFallBackOnInvalidRequest(t *testing.T) {
+	primary := &openAICompatPoolExecutor{
+		id:            "fallback-primary-invalid",
+		executeErrors: map[string]error{"primary-model": &Error{HTTPStatus: http.StatusUnprocessableEntity, Message: "bad input"}},
+	}
+	secondary := &openAICompatPoolExecutor{id: "fallback-secondary-invalid"}
+	m := newFallbackTestManager(t, primary, secondary)
+
+	_, err := m.Execute(context.Background(), []string{primary.id}, cliproxyexecutor.Request{Model: "primary-model"}, cliproxyexecutor.Options{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if calls := secondary.ExecuteModels(); len(calls) != 0 {
+		t.Fatalf("secondary executor called: %v", calls)
+	}
+}
+
+func TestManagerExecuteStream_FallsBackBeforeFirstByte(t *testing.T) {
+	primary := &openAICompatPoolExecutor{
+		id:                "fallback-primary-stream",
+		streamFirstErrors: map[string]error{"primary-model": &Error{HTTPStatus: http.StatusBadGateway, Message: "upstream down"}},
+	}
+	secondary := &openAICompatPoolExecutor{id: "fallback-secondary-stream"}
+	m := newFallbackTestManager(t, primary, secondary)
+
+	meta := map[string]any{}
+	result, err := m.ExecuteStream(context.Background(), []string{primary.id}, cliproxyexecutor.Request{Model: "primary-model"}, cliproxyexecutor.Options{Stream: true, Metadata: meta})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	if got := readOpenAICompatStreamPayload(t, result); got != "secondary-model" {
+		t.Fatalf("stream payload = %q, want secondary-model", got)
+	}
+	if got := meta[cliproxyexecutor.ServedModelMetadataKey]; got != "secondary-model" {
+		t.Fatalf("served model = %v, want secondary-model", got)
+	}
+}
+
+func TestFallbackChainCarriesThinkingSuffix(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{
+		ModelFallbacks: []internalconfig.ModelFallback{{Model: "a", Fallbacks: []string{"b", "c(low)"}}},
+	})
+	chain := m.fallbackChain("a(high)", cliproxyexecutor.Options{})
+	if len(chain) != 2 || chain[0] != "b(high)" || chain[1] != "c(low)" {
+		t.Fatalf("chain = %v", chain)
+	}
+	pinned := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.PinnedAuthMetadataKey: "x"}}
+	if chain = m.fallbackChain("a", pinned); len(chain) != 0 {
+		t.Fatalf("pinned execution should not fall back, got %v", chain)
+	}
+}
+
+type modelPreflight struct {
+	refuse string
+}
+
+func (p modelPreflight) Preflight(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Request, error) {
+	if req.Model == p.refuse {
+		return req, &Error{HTTPStatus: http.StatusBadRequest, Message: "invalid_request_error: prompt is too long"}
+	}
+	req.Payload = []byte("checked")
+	return req, nil
+}
+
+func TestManagerExecute_PreflightRefusalFallsBackWithoutPenalty(t *testing.T) {
+	primary := &openAICompatPoolExecutor{id: "fallback-primary-preflight"}
+	secondary := &openAICompatPoolExecutor{id: "fallback-secondary-preflight"}
+	m := newFallbackTestManager(t, primary, secondary)
+	m.SetRequestPreflight(modelPreflight{refuse: "primary-model"})
+
+	for _, stream := range []bool{false, true} {
+		opts := cliproxyexecutor.Options{Stream: stream, Metadata: map[string]any{}}
+		req := cliproxyexecutor.Request{Model: "primary-model"}
+		if stream {
+			result, err := m.ExecuteStream(context.Background(), []string{primary.id}, req, opts)
+			if err != nil {
+				t.Fatalf("ExecuteStream: %v", err)
+			}
+			if got := readOpenAICompatStreamPayload(t, result); got != "secondary-model" {
+				t.Fatalf("stream payload = %q, want secondary-model", got)
+			}
+		} else {
+			resp, err := m.Execute(context.Background(), []string{primary.id}, req, opts)
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if string(resp.Payload) != "secondary-model" {
+				t.Fatalf("payload = %q, want secondary-model", resp.Payload)
+			}
+		}
+	}
+	if calls := primary.ExecuteModels(); len(calls) != 0 {
+		t.Fatalf("primary executor called: %v", calls)
+	}
+	auth, ok := m.GetByID(primary.id + "-auth-" + t.Name())
+	if !ok || auth.Unavailable || auth.LastError != nil {
+		t.Fatalf("primary auth penalised by a preflight refusal: %+v", auth)
+	}
+
+	m.SetRequestPreflight(modelPreflight{refuse: "secondary-model"})
+	_, err := m.Execute(context.Background(), []string{secondary.id}, cliproxyexecutor.Request{Model: "secondary-model"}, cliproxyexecutor.Options{})
+	if err == nil || statusCodeFromError(err) != http.StatusBadRequest {
+		t.Fatalf("expected the preflight error, got %v", err)
+	}
+}
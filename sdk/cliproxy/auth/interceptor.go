@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// ExecuteFunc performs a single non-streaming upstream call for the selected auth.
+type ExecuteFunc func(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+
+// ExecuteStreamFunc performs a single streaming upstream call for the selected auth.
+type ExecuteStreamFunc func(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error)
+
+// ExecutionInterceptor wraps every ProviderExecutor.Execute and ExecuteStream call issued by
+// the manager, after an auth has been selected. Implementations may rewrite the request,
+// response or stream chunks, or refuse the call by returning a *RejectionError.
+type ExecutionInterceptor interface {
+	InterceptExecute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, next ExecuteFunc) (cliproxyexecutor.Response, error)
+	InterceptExecuteStream(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, next ExecuteStreamFunc) (*cliproxyexecutor.StreamResult, error)
+}
+
+// RejectionError reports that an ExecutionInterceptor refused a request. The manager returns it
+// to the caller as-is: the selected auth is not penalised and no other credential is tried.
+type RejectionError struct {
+	// Err is the reason supplied by the interceptor.
+	Err error
+	// Status is the HTTP status reported to the client. Zero means 403.
+	Status int
+}
+
+// Error implements error.
+func (e *RejectionError) Error() string {
+	if e == nil || e.Err == nil {
+		return "request rejected"
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying reason.
+func (e *RejectionError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// StatusCode implements cliproxyexecutor.StatusError.
+func (e *RejectionError) StatusCode() int {
+	if e == nil || e.Status <= 0 {
+		return http.StatusForbidden
+	}
+	return e.Status
+}
+
+func isExecutionRejected(err error) bool {
+	_, ok := errors.AsType[*RejectionError](err)
+	return ok
+}
+
+// SetExecutionInterceptor installs an interceptor around provider executor calls. Passing nil
+// removes it.
+func (m *Manager) SetExecutionInterceptor(interceptor ExecutionInterceptor) {
+	m.mu.Lock()
+	m.interceptor = interceptor
+	m.mu.Unlock()
+}
+
+func (m *Manager) executionInterceptor() ExecutionInterceptor {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.interceptor
+}
+
+// invokeExecute calls executor.Execute through the installed interceptor, if any.
+func (m *Manager) invokeExecute(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	interceptor := m.executionInterceptor()
+	if interceptor == nil {
+		return executor.Execute(ctx, auth, req, opts)
+	}
+	return interceptor.InterceptExecute(ctx, auth, req, opts, func(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		return executor.Execute(ctx, auth, req, opts)
+	})
+}
+
+// invokeExecuteStream calls executor.ExecuteStream through the installed interceptor, if any.
+func (m *Manager) invokeExecuteStream(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	interceptor := m.executionInterceptor()
+	if interceptor == nil {
+		return executor.ExecuteStream(ctx, auth, req, opts)
+	}
+	return interceptor.InterceptExecuteStream(ctx, auth, req, opts, func(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+		return executor.ExecuteStream(ctx, auth, req, opts)
+	})
+}
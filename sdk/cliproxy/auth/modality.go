@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// Input modalities in the vocabulary of registry.ModelInfo.SupportedInputModalities.
+const (
+	modalityImage = "IMAGE"
+	modalityAudio = "AUDIO"
+	modalityVideo = "VIDEO"
+	modalityPDF   = "PDF"
+)
+
+// requestInputModalities returns the non-text input modalities carried by payload, which is
+// written in the given source format. Text is always assumed and therefore never reported.
+func requestInputModalities(format sdktranslator.Format, payload []byte) []string {
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return nil
+	}
+	found := make(map[string]struct{})
+	root := gjson.ParseBytes(payload)
+	switch format {
+	case sdktranslator.FormatClaude:
+		root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+			collectClaudeModalities(message.Get("content"), found)
+			return true
+		})
+	case sdktranslator.FormatGemini, sdktranslator.FormatGeminiCLI, sdktranslator.FormatAntigravity:
+		if request := root.Get("request"); request.IsObject() {
+			root = request
+		}
+		root.Get("contents").ForEach(func(_, content gjson.Result) bool {
+			collectGeminiModalities(content.Get("parts"), found)
+			return true
+		})
+	case sdktranslator.FormatOpenAIResponse, sdktranslator.FormatCodex:
+		root.Get("input").ForEach(func(_, item gjson.Result) bool {
+			collectOpenAIModalities(item.Get("content"), found)
+			collectOpenAIModalities(item.Get("output"), found)
+			return true
+		})
+	case sdktranslator.FormatOllama:
+		// Ollama attaches base64 images to chat messages or to the generate request itself.
+		images := []gjson.Result{root.Get("images")}
+		root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+			images = append(images, message.Get("images"))
+			return true
+		})
+		for _, list := range images {
+			if list.IsArray() && len(list.Array()) > 0 {
+				addModality(found, modalityImage)
+			}
+		}
+	default:
+		root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+			collectOpenAIModalities(message.Get("content"), found)
+			return true
+		})
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(found))
+	for _, modality := range []string{modalityImage, modalityPDF, modalityAudio, modalityVideo} {
+		if _, ok := found[modality]; ok {
+			out = append(out, modality)
+		}
+	}
+	return out
+}
+
+func collectOpenAIModalities(content gjson.Result, found map[string]struct{}) {
+	if !content.IsArray() {
+		return
+	}
+	content.ForEach(func(_, part gjson.Result) bool {
+		switch part.Get("type").String() {
+		case "image_url":
+			addModality(found, dataURLModality(part.Get("image_url.url").String(), modalityImage))
+		case "input_image":
+			url := part.Get("image_url")
+			if url.IsObject() {
+				url = url.Get("url")
+			}
+			addModality(found, dataURLModality(url.String(), modalityImage))
+		case "input_audio":
+			addModality(found, modalityAudio)
+		case "file":
+			addModality(found, fileModality(part.Get("file.file_data").String(), part.Get("file.filename").String()))
+		case "input_file":
+			addModality(found, fileModality(part.Get("file_data").String(), part.Get("filename").String()))
+		}
+		return true
+	})
+}
+
+func collectClaudeModalities(content gjson.Result, found map[string]struct{}) {
+	if !content.IsArray() {
+		return
+	}
+	content.ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "image":
+			addModality(found, modalityImage)
+		case "document":
+			source := block.Get("source")
+			switch source.Get("type").String() {
+			case "text", "content":
+			case "url":
+				addModality(found, modalityPDF)
+			default:
+				addModality(found, mimeModality(source.Get("media_type").String()))
+			}
+		case "tool_result":
+			collectClaudeModalities(block.Get("content"), found)
+		}
+		return true
+	})
+}
+
+func collectGeminiModalities(parts gjson.Result, found map[string]struct{}) {
+	parts.ForEach(func(_, part gjson.Result) bool {
+		for _, path := range []string{"inlineData.mimeType", "inline_data.mime_type", "fileData.mimeType", "file_data.mime_type"} {
+			if mime := part.Get(path); mime.Exists() {
+				addModality(found, mimeModality(mime.String()))
+			}
+		}
+		return true
+	})
+}
+
+// dataURLModality classifies a data URL by its MIME type. Remote URLs yield fallback.
+func dataURLModality(url, fallback string) string {
+	if mime, ok := strings.CutPrefix(strings.TrimSpace(url), "data:"); ok {
+		if end := strings.IndexAny(mime, ";,"); end >= 0 {
+			mime = mime[:end]
+		}
+		if modality := mimeModality(mime); modality != "" {
+			return modality
+		}
+	}
+	return fallback
+}
+
+// fileModality classifies a file part. File inputs without a recognisable type are PDFs,
+// the only file type the OpenAI APIs accept as model input.
+func fileModality(fileData, filename string) string {
+	if modality := dataURLModality(fileData, ""); modality != "" {
+		return modality
+	}
+	lower := strings.ToLower(filename)
+	if strings.HasSuffix(lower, ".txt") || strings.HasSuffix(lower, ".md") {
+		return ""
+	}
+	return modalityPDF
+}
+
+func mimeModality(mime string) string {
+	mime = strings.ToLower(strings.TrimSpace(mime))
+	switch {
+	case strings.HasPrefix(mime, "image/"):
+		return modalityImage
+	case strings.HasPrefix(mime, "audio/"):
+		return modalityAudio
+	case strings.HasPrefix(mime, "video/"):
+		return modalityVideo
+	case mime == "application/pdf":
+		return modalityPDF
+	default:
+		return ""
+	}
+}
+
+func addModality(found map[string]struct{}, modality string) {
+	if modality != "" {
+		found[modality] = struct{}{}
+	}
+}
+
+// supportsInputModalities reports whether a model declaring supported accepts every required
+// modality. Models without declared modalities are assumed to accept anything.
+func supportsInputModalities(supported, required []string) bool {
+	if len(required) == 0 || len(supported) == 0 {
+		return true
+	}
+	for _, want := range required {
+		ok := false
+		for _, have := range supported {
+			if strings.EqualFold(strings.TrimSpace(have), want) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// inputModalitiesFor returns the declared input modalities of upstreamModel on auth, or nil
+// when they are unknown. The model info auth registered for routeModel is consulted first, so
+// members of an alias pool can differ; the registry's provider-wide info is the fallback.
+func inputModalitiesFor(auth *Auth, routeModel, upstreamModel string) []string {
+	reg := registry.GetGlobalRegistry()
+	upstreamBase := canonicalModelKey(upstreamModel)
+	if info := reg.GetClientModelInfo(auth.ID, canonicalModelKey(routeModel)); info != nil {
+		if modalities, ok := info.UpstreamInputModalities[strings.ToLower(upstreamBase)]; ok {
+			if len(modalities) > 0 {
+				return modalities
+			}
+		} else if len(info.SupportedInputModalities) > 0 {
+			return info.SupportedInputModalities
+		}
+	}
+	if info := reg.GetClientModelInfo(auth.ID, upstreamBase); info != nil && len(info.SupportedInputModalities) > 0 {
+		return info.SupportedInputModalities
+	}
+	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	if info := registry.LookupModelInfo(upstreamBase, provider); info != nil && len(info.SupportedInputModalities) > 0 {
+		return info.SupportedInputModalities
+	}
+	return nil
+}
+
+// filterModelsByModality drops the upstream models of auth that cannot accept the required
+// input modalities.
+func filterModelsByModality(auth *Auth, routeModel string, models, required []string) []string {
+	if len(required) == 0 || len(models) == 0 {
+		return models
+	}
+	out := make([]string, 0, len(models))
+	for _, upstreamModel := range models {
+		if supportsInputModalities(inputModalitiesFor(auth, routeModel, upstreamModel), required) {
+			out = append(out, upstreamModel)
+		}
+	}
+	return out
+}
+
+// authAcceptsModalities reports whether at least one upstream model auth would use for
+// routeModel accepts the required input modalities. Selection uses it to skip auths that
+// cannot serve the request at all.
+func (m *Manager) authAcceptsModalities(auth *Auth, routeModel string, required []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+	requestedModel := m.applyOAuthModelAlias(auth, rewriteModelForAuth(routeModel, auth))
+	models := m.resolveOpenAICompatUpstreamModelPool(auth, requestedModel)
+	if len(models) == 0 {
+		resolved := m.applyAPIKeyModelAlias(auth, requestedModel)
+		if strings.TrimSpace(resolved) == "" {
+			resolved = requestedModel
+		}
+		models = []string{resolved}
+	}
+	return len(filterModelsByModality(auth, routeModel, models, required)) > 0
+}
+
+// requiredModalitiesContextKey carries the non-text input modalities of a request from
+// execution to candidate selection.
+type requiredModalitiesContextKey struct{}
+
+// withRequiredModalities records the input modalities required by req on ctx so candidate
+// selection can skip auths that cannot accept them. It returns the modalities as well.
+func withRequiredModalities(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (context.Context, []string) {
+	required := requestInputModalities(opts.SourceFormat, req.Payload)
+	if len(required) == 0 {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, requiredModalitiesContextKey{}, required), required
+}
+
+func requiredModalitiesFromContext(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	required, _ := ctx.Value(requiredModalitiesContextKey{}).([]string)
+	return required
+}
+
+// modalityError reports that no candidate model accepts the input modalities of a request.
+// Error renders a JSON body in the client's protocol selected by format.
+type modalityError struct {
+	model    string
+	required []string
+	format   sdktranslator.Format
+}
+
+func newModalityError(model string, required []string, format sdktranslator.Format) *modalityError {
+	return &modalityError{model: thinking.ParseSuffix(model).ModelName, required: required, format: format}
+}
+
+func (e *modalityError) Error() string {
+	message := fmt.Sprintf("model %s does not support %s input", e.model, strings.ToLower(strings.Join(e.required, ", ")))
+	return interfaces.InvalidRequestBody(string(e.format), message, "unsupported_modality")
+}
+
+func (e *modalityError) StatusCode() int {
+	return http.StatusBadRequest
+}
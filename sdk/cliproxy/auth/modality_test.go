@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestRequestInputModalities(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  sdktranslator.Format
+		payload string
+		want    string
+	}{
+		{"openai text", sdktranslator.FormatOpenAI, `{"messages":[{"role":"user","content":"hi"}]}`, ""},
+		{"openai image and pdf", sdktranslator.FormatOpenAI, `{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://x/y.png"}},{"type":"file","file":{"file_data":"data:application/pdf;base64,AA=="}}]}]}`, "IMAGE,PDF"},
+		{"responses audio", sdktranslator.FormatOpenAIResponse, `{"input":[{"role":"user","content":[{"type":"input_audio","input_audio":{"data":"AA=="}}]}]}`, "AUDIO"},
+		{"claude tool result image", sdktranslator.FormatClaude, `{"messages":[{"role":"user","content":[{"type":"tool_result","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AA=="}}]},{"type":"document","source":{"type":"text","data":"plain"}}]}]}`, "IMAGE"},
+		{"ollama generate image", sdktranslator.FormatOllama, `{"prompt":"describe","images":["iVBORw0KGgo="]}`, "IMAGE"},
+		{"gemini cli video", sdktranslator.FormatGeminiCLI, `{"request":{"contents":[{"role":"user","parts":[{"fileData":{"mimeType":"video/mp4","fileUri":"gs://x"}}]}]}}`, "VIDEO"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(requestInputModalities(tt.format, []byte(tt.payload)), ",")
+		if got != tt.want {
+			t.Fatalf("%s: modalities = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestManagerExecute_OpenAICompatAliasPoolSkipsTextOnlyUpstreamForImages(t *testing.T) {
+	alias := "vision-pool"
+	executor := &openAICompatPoolExecutor{id: "pool"}
+	m := newOpenAICompatPoolTestManager(t, alias, []internalconfig.OpenAICompatibilityModel{
+		{Name: "text-only", Alias: alias},
+		{Name: "vision", Alias: alias},
+	}, executor)
+	registerPoolModalities(t, alias, map[string][]string{"text-only": {"TEXT"}, "vision": {"TEXT", "IMAGE"}})
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,AA=="}}]}]}`)
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI}
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.Execute(context.Background(), []string{"pool"}, cliproxyexecutor.Request{Model: alias, Payload: payload}, opts); err != nil {
+			t.Fatalf("execute %d: %v", i, err)
+		}
+	}
+	if got := executor.ExecuteModels(); len(got) != 2 || got[0] != "vision" || got[1] != "vision" {
+		t.Fatalf("execute calls = %v, want only vision", got)
+	}
+}
+
+func TestManagerExecuteStream_ModalityUnsupportedReturnsBadRequest(t *testing.T) {
+	alias := "text-pool"
+	executor := &openAICompatPoolExecutor{id: "pool"}
+	m := newOpenAICompatPoolTestManager(t, alias, []internalconfig.OpenAICompatibilityModel{
+		{Name: "text-only", Alias: alias},
+	}, executor)
+	registerPoolModalities(t, alias, map[string][]string{"text-only": {"TEXT"}})
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AA=="}}]}]}`)
+	opts := cliproxyexecutor.Options{Stream: true, SourceFormat: sdktranslator.FormatClaude}
+
+	_, err := m.ExecuteStream(context.Background(), []string{"pool"}, cliproxyexecutor.Request{Model: alias, Payload: payload}, opts)
+	modalityErr, ok := errors.AsType[*modalityError](err)
+	if !ok {
+		t.Fatalf("expected modality error, got %v", err)
+	}
+	if modalityErr.StatusCode() != http.StatusBadRequest || !strings.Contains(err.Error(), `"type":"error"`) {
+		t.Fatalf("unexpected error: %d %s", modalityErr.StatusCode(), err.Error())
+	}
+	if got := executor.StreamModels(); len(got) != 0 {
+		t.Fatalf("stream calls = %v, want none", got)
+	}
+}
+
+func TestManagerExecute_SelectionSkipsAuthWithoutModality(t *testing.T) {
+	executor := &openAICompatPoolExecutor{id: "modality"}
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(executor)
+	reg := registry.GetGlobalRegistry()
+	for id, modalities := range map[string][]string{"text-auth": {"TEXT"}, "image-auth": {"TEXT", "IMAGE"}} {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "modality", Status: StatusActive}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+		reg.RegisterClient(id, "modality", []*registry.ModelInfo{{ID: "shared-model", SupportedInputModalities: modalities}})
+		t.Cleanup(func() { reg.UnregisterClient(id) })
+	}
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://x/y.png"}}]}]}`)
+
+	var selected []string
+	opts := cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FormatOpenAI,
+		Metadata: map[string]any{
+			cliproxyexecutor.SelectedAuthCallbackMetadataKey: func(id string) { selected = append(selected, id) },
+		},
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := m.Execute(context.Background(), []string{"modality"}, cliproxyexecutor.Request{Model: "shared-model", Payload: payload}, opts); err != nil {
+			t.Fatalf("execute %d: %v", i, err)
+		}
+	}
+	if len(selected) != 3 || selected[0] != "image-auth" || selected[1] != "image-auth" || selected[2] != "image-auth" {
+		t.Fatalf("selected auths = %v, want only image-auth", selected)
+	}
+}
+
+// registerPoolModalities registers the alias for the pool test auth with the per-upstream
+// input modalities the service records for an OpenAI-compatible alias pool.
+func registerPoolModalities(t *testing.T, alias string, upstream map[string][]string) {
+	t.Helper()
+	registry.GetGlobalRegistry().RegisterClient("pool-auth-"+t.Name(), "pool", []*registry.ModelInfo{{ID: alias, UpstreamInputModalities: upstream}})
+}
@@ -0,0 +1,412 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	// perfEWMAAlpha is the weight of the newest sample in the latency, TTFT and error averages.
+	perfEWMAAlpha = 0.2
+	// perfDecayHalfLife fades the averages of an idle credential toward zero, so a credential
+	// that was slow or failing a while ago is eventually tried again instead of starving.
+	perfDecayHalfLife = 5 * time.Minute
+	// perfTTFTWeight is the share of time-to-first-token in the responsiveness of credentials
+	// that served streams; the remainder is the request latency, which for streams is also
+	// measured to the first byte so long generations do not read as slow credentials.
+	perfTTFTWeight = 0.5
+	// perfErrorPenaltyMs is the latency, in milliseconds, that a 100% error ratio is worth.
+	perfErrorPenaltyMs = 30000.0
+	// perfStaleAfter drops the stats of an idle auth/model pair. After twelve half-lives the
+	// averages are decayed to nothing, so the entry no longer affects routing.
+	perfStaleAfter = 12 * perfDecayHalfLife
+	// perfPruneInterval bounds how often begin scans for stale entries.
+	perfPruneInterval = 10 * time.Minute
+)
+
+// perfOutcome classifies a finished request for the performance tracker.
+type perfOutcome int
+
+const (
+	perfSuccess perfOutcome = iota
+	perfFailure
+	// perfAborted releases the in-flight slot without recording a sample, used for client
+	// cancellations and invalid requests that say nothing about the credential.
+	perfAborted
+)
+
+type perfKey struct {
+	authID string
+	model  string
+}
+
+// perfStat holds the moving averages of one auth/model pair.
+type perfStat struct {
+	provider       string
+	latencyMs      float64
+	ttftMs         float64
+	errorRate      float64
+	inflight       int
+	latencySamples int64
+	ttftSamples    int64
+	successes      int64
+	failures       int64
+	updatedAt      time.Time
+}
+
+// performanceTracker records per auth/model latency, time-to-first-token, in-flight count and
+// error ratio for the score-based routing strategies.
+type performanceTracker struct {
+	mu         sync.Mutex
+	stats      map[perfKey]*perfStat
+	lastPruned time.Time
+}
+
+// PerformanceScore is a snapshot of the live routing metrics of one credential for one model.
+// Scores are lower-is-better; see LatencyAwareSelector and LeastInflightSelector.
+type PerformanceScore struct {
+	AuthID             string    `json:"auth_id"`
+	Provider           string    `json:"provider"`
+	Model              string    `json:"model"`
+	LatencyMs          float64   `json:"latency_ms"`
+	TTFTMs             float64   `json:"ttft_ms"`
+	ErrorRate          float64   `json:"error_rate"`
+	InFlight           int       `json:"in_flight"`
+	Successes          int64     `json:"successes"`
+	Failures           int64     `json:"failures"`
+	LatencyScore       float64   `json:"latency_score"`
+	LeastInflightScore float64   `json:"least_inflight_score"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// begin marks one request in flight for the auth/model pair and returns its start time.
+func (t *performanceTracker) begin(authID, provider, model string) time.Time {
+	now := time.Now()
+	if t == nil || authID == "" {
+		return now
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.lastPruned) >= perfPruneInterval {
+		t.pruneLocked(now)
+	}
+	stat := t.statLocked(authID, model)
+	if provider != "" {
+		stat.provider = provider
+	}
+	stat.inflight++
+	return now
+}
+
+// end releases the in-flight slot taken by begin and folds the outcome into the averages.
+// ttft is zero for non-streaming requests; for streams it is recorded as the latency sample
+// instead of the full stream duration, which depends on the length of the output.
+func (t *performanceTracker) end(authID, model string, started time.Time, ttft time.Duration, outcome perfOutcome) {
+	if t == nil || authID == "" {
+		return
+	}
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stat := t.statLocked(authID, model)
+	if stat.inflight > 0 {
+		stat.inflight--
+	}
+	if outcome == perfAborted {
+		return
+	}
+	stat.decayLocked(now)
+	if outcome == perfFailure {
+		stat.failures++
+		stat.errorRate = ewma(stat.errorRate, 1, stat.successes+stat.failures)
+		return
+	}
+	stat.successes++
+	stat.errorRate = ewma(stat.errorRate, 0, stat.successes+stat.failures)
+	elapsed := now.Sub(started)
+	if ttft > 0 {
+		elapsed = ttft
+	}
+	stat.latencySamples++
+	stat.latencyMs = ewma(stat.latencyMs, durationMs(elapsed), stat.latencySamples)
+	if ttft > 0 {
+		stat.ttftSamples++
+		stat.ttftMs = ewma(stat.ttftMs, durationMs(ttft), stat.ttftSamples)
+	}
+}
+
+// forget drops every stat of authID, used when the auth is disabled or removed.
+func (t *performanceTracker) forget(authID string) {
+	if t == nil || authID == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.stats {
+		if key.authID == authID {
+			delete(t.stats, key)
+		}
+	}
+}
+
+// pruneLocked drops idle entries that have not recorded a sample for perfStaleAfter, or never
+// recorded one.
+func (t *performanceTracker) pruneLocked(now time.Time) {
+	t.lastPruned = now
+	for key, stat := range t.stats {
+		if stat.inflight == 0 && (stat.updatedAt.IsZero() || now.Sub(stat.updatedAt) >= perfStaleAfter) {
+			delete(t.stats, key)
+		}
+	}
+}
+
+func (t *performanceTracker) statLocked(authID, model string) *perfStat {
+	if t.stats == nil {
+		t.stats = make(map[perfKey]*perfStat)
+	}
+	key := perfKey{authID: authID, model: model}
+	stat, ok := t.stats[key]
+	if !ok {
+		stat = &perfStat{}
+		t.stats[key] = stat
+	}
+	return stat
+}
+
+// latencyScore returns the LatencyAwareSelector score of the auth/model pair.
+func (t *performanceTracker) latencyScore(authID, model string, now time.Time) float64 {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats[perfKey{authID: authID, model: model}].latencyScore(now)
+}
+
+// leastInflightScore returns the LeastInflightSelector score of the auth/model pair.
+func (t *performanceTracker) leastInflightScore(authID, model string, now time.Time) float64 {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats[perfKey{authID: authID, model: model}].leastInflightScore(now)
+}
+
+func (t *performanceTracker) score(strategy schedulerStrategy, authID, model string, now time.Time) float64 {
+	if strategy == schedulerStrategyLeastInflight {
+		return t.leastInflightScore(authID, model, now)
+	}
+	return t.latencyScore(authID, model, now)
+}
+
+func (t *performanceTracker) snapshot(now time.Time) []PerformanceScore {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]PerformanceScore, 0, len(t.stats))
+	for key, stat := range t.stats {
+		factor := stat.decayFactor(now)
+		out = append(out, PerformanceScore{
+			AuthID:             key.authID,
+			Provider:           stat.provider,
+			Model:              key.model,
+			LatencyMs:          stat.latencyMs * factor,
+			TTFTMs:             stat.ttftMs * factor,
+			ErrorRate:          stat.errorRate * factor,
+			InFlight:           stat.inflight,
+			Successes:          stat.successes,
+			Failures:           stat.failures,
+			LatencyScore:       stat.latencyScore(now),
+			LeastInflightScore: stat.leastInflightScore(now),
+			UpdatedAt:          stat.updatedAt,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		if out[i].Model != out[j].Model {
+			return out[i].Model < out[j].Model
+		}
+		return out[i].AuthID < out[j].AuthID
+	})
+	return out
+}
+
+// decayFactor is the weight left on the averages after the time elapsed since the last sample.
+func (s *perfStat) decayFactor(now time.Time) float64 {
+	if s == nil || s.updatedAt.IsZero() {
+		return 1
+	}
+	idle := now.Sub(s.updatedAt)
+	if idle <= 0 {
+		return 1
+	}
+	return math.Exp2(-float64(idle) / float64(perfDecayHalfLife))
+}
+
+// decayLocked applies the idle decay to the stored averages before a new sample is folded in.
+func (s *perfStat) decayLocked(now time.Time) {
+	factor := s.decayFactor(now)
+	s.latencyMs *= factor
+	s.ttftMs *= factor
+	s.errorRate *= factor
+	s.updatedAt = now
+}
+
+// latencyScore weighs responsiveness (latency blended with TTFT when streams were served) plus
+// an error penalty, scaled by the requests already in flight. Credentials without samples
+// score only their in-flight count, so they are tried first but still share concurrent load.
+func (s *perfStat) latencyScore(now time.Time) float64 {
+	if s == nil {
+		return 0
+	}
+	factor := s.decayFactor(now)
+	responsiveness := s.latencyMs
+	if s.ttftSamples > 0 {
+		responsiveness = (1-perfTTFTWeight)*s.latencyMs + perfTTFTWeight*s.ttftMs
+	}
+	return (responsiveness+perfErrorPenaltyMs*s.errorRate)*factor*float64(1+s.inflight) + float64(s.inflight)
+}
+
+// leastInflightScore counts the requests in flight, with the recent error ratio worth up to
+// one more request.
+func (s *perfStat) leastInflightScore(now time.Time) float64 {
+	if s == nil {
+		return 0
+	}
+	return float64(s.inflight) + s.errorRate*s.decayFactor(now)
+}
+
+// ewma folds sample into avg; the first sample seeds the average.
+func ewma(avg, sample float64, samples int64) float64 {
+	if samples <= 1 {
+		return sample
+	}
+	return avg + perfEWMAAlpha*(sample-avg)
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// perfOutcomeForError classifies an execution error. Client cancellations, rejected executions
+// and invalid requests are not held against the credential.
+func perfOutcomeForError(ctx context.Context, err error) perfOutcome {
+	switch {
+	case err == nil:
+		return perfSuccess
+	case ctx != nil && ctx.Err() != nil, isExecutionRejected(err), isRequestInvalidError(err):
+		return perfAborted
+	default:
+		return perfFailure
+	}
+}
+
+// perfModelKey returns the model key scores are tracked under: the canonical route model, or
+// the requested model from metadata when built-in selectors receive no model.
+func perfModelKey(model string, opts cliproxyexecutor.Options) string {
+	if key := canonicalModelKey(model); key != "" {
+		return key
+	}
+	if raw, ok := opts.Metadata[cliproxyexecutor.RequestedModelMetadataKey].(string); ok {
+		return canonicalModelKey(raw)
+	}
+	return ""
+}
+
+// PerformanceScores returns the live latency, TTFT, in-flight and error metrics of every
+// credential/model pair that has served traffic, with the scores both strategies derive.
+func (m *Manager) PerformanceScores() []PerformanceScore {
+	if m == nil {
+		return nil
+	}
+	return m.perf.snapshot(time.Now())
+}
+
+// performanceAware is implemented by selectors that read the manager's performance tracker.
+type performanceAware interface {
+	bindPerformance(tracker *performanceTracker)
+}
+
+// bindSelectorPerformance attaches the tracker to score-based selectors, including ones wrapped
+// as the fallback of a session affinity selector.
+func bindSelectorPerformance(selector Selector, tracker *performanceTracker) {
+	switch s := selector.(type) {
+	case performanceAware:
+		s.bindPerformance(tracker)
+	case *SessionAffinitySelector:
+		if s != nil {
+			bindSelectorPerformance(s.fallback, tracker)
+		}
+	}
+}
+
+// LatencyAwareSelector picks the credential with the lowest weighted score of EWMA latency,
+// time-to-first-token and recent error ratio, scaled by the requests it already has in flight.
+// Ties rotate round-robin.
+type LatencyAwareSelector struct {
+	perf   atomic.Pointer[performanceTracker]
+	cursor atomic.Uint64
+}
+
+// LeastInflightSelector picks the credential with the fewest requests in flight, counting the
+// recent error ratio as up to one extra request. Ties rotate round-robin.
+type LeastInflightSelector struct {
+	perf   atomic.Pointer[performanceTracker]
+	cursor atomic.Uint64
+}
+
+func (s *LatencyAwareSelector) bindPerformance(tracker *performanceTracker) {
+	s.perf.Store(tracker)
+}
+
+func (s *LeastInflightSelector) bindPerformance(tracker *performanceTracker) {
+	s.perf.Store(tracker)
+}
+
+// Pick selects the available auth with the lowest latency score.
+func (s *LatencyAwareSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	return pickByScore(ctx, provider, model, opts, auths, &s.cursor, func(authID, modelKey string, now time.Time) float64 {
+		return s.perf.Load().latencyScore(authID, modelKey, now)
+	})
+}
+
+// Pick selects the available auth with the fewest requests in flight.
+func (s *LeastInflightSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	return pickByScore(ctx, provider, model, opts, auths, &s.cursor, func(authID, modelKey string, now time.Time) float64 {
+		return s.perf.Load().leastInflightScore(authID, modelKey, now)
+	})
+}
+
+func pickByScore(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth, cursor *atomic.Uint64, score func(authID, modelKey string, now time.Time) float64) (*Auth, error) {
+	now := time.Now()
+	available, err := getAvailableAuths(auths, provider, model, now)
+	if err != nil {
+		return nil, err
+	}
+	available = preferCodexWebsocketAuths(ctx, provider, available)
+	modelKey := perfModelKey(model, opts)
+	best := make([]*Auth, 0, len(available))
+	bestScore := 0.0
+	for _, candidate := range available {
+		candidateScore := score(candidate.ID, modelKey, now)
+		switch {
+		case len(best) == 0 || candidateScore < bestScore:
+			best = append(best[:0], candidate)
+			bestScore = candidateScore
+		case candidateScore == bestScore:
+			best = append(best, candidate)
+		}
+	}
+	return best[int((cursor.Add(1)-1)%uint64(len(best)))], nil
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type perfTestExecutor struct {
+	schedulerTestExecutor
+	failFor string
+}
+
+func (e perfTestExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if auth.ID == e.failFor {
+		return cliproxyexecutor.Response{}, &Error{Message: "upstream unavailable", HTTPStatus: http.StatusBadGateway}
+	}
+	return cliproxyexecutor.Response{Payload: []byte("{}")}, nil
+}
+
+func recordPerfSample(tracker *performanceTracker, authID string, latency time.Duration, outcome perfOutcome) {
+	started := tracker.begin(authID, "gemini", "")
+	tracker.end(authID, "", started.Add(-latency), 0, outcome)
+}
+
+func TestSchedulerPick_LatencyPrefersFastestHealthyCredential(t *testing.T) {
+	t.Parallel()
+
+	scheduler := newSchedulerForTest(
+		&LatencyAwareSelector{},
+		&Auth{ID: "slow", Provider: "gemini"},
+		&Auth{ID: "fast", Provider: "gemini"},
+	)
+	tracker := &performanceTracker{}
+	scheduler.perf = tracker
+	recordPerfSample(tracker, "slow", 800*time.Millisecond, perfSuccess)
+	recordPerfSample(tracker, "fast", 100*time.Millisecond, perfSuccess)
+
+	for index := 0; index < 3; index++ {
+		got, errPick := scheduler.pickSingle(context.Background(), "gemini", "", cliproxyexecutor.Options{}, nil)
+		if errPick != nil {
+			t.Fatalf("pickSingle() #%d error = %v", index, errPick)
+		}
+		if got == nil || got.ID != "fast" {
+			t.Fatalf("pickSingle() #%d auth = %v, want fast", index, got)
+		}
+	}
+
+	// A failure outweighs several hundred milliseconds of latency.
+	recordPerfSample(tracker, "fast", 0, perfFailure)
+	got, errPick := scheduler.pickSingle(context.Background(), "gemini", "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil {
+		t.Fatalf("pickSingle() after failure error = %v", errPick)
+	}
+	if got == nil || got.ID != "slow" {
+		t.Fatalf("pickSingle() after failure auth = %v, want slow", got)
+	}
+}
+
+func TestSchedulerPick_LeastInflightSpreadsLoad(t *testing.T) {
+	t.Parallel()
+
+	scheduler := newSchedulerForTest(
+		&LeastInflightSelector{},
+		&Auth{ID: "a", Provider: "gemini"},
+		&Auth{ID: "b", Provider: "gemini"},
+		&Auth{ID: "c", Provider: "gemini"},
+	)
+	tracker := &performanceTracker{}
+	scheduler.perf = tracker
+	tracker.begin("a", "gemini", "")
+	tracker.begin("a", "gemini", "")
+	tracker.begin("c", "gemini", "")
+
+	got, errPick := scheduler.pickSingle(context.Background(), "gemini", "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil {
+		t.Fatalf("pickSingle() error = %v", errPick)
+	}
+	if got == nil || got.ID != "b" {
+		t.Fatalf("pickSingle() auth = %v, want b", got)
+	}
+
+	tracker.begin("b", "gemini", "")
+	tracker.begin("b", "gemini", "")
+	got, errPick = scheduler.pickSingle(context.Background(), "gemini", "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil {
+		t.Fatalf("pickSingle() second error = %v", errPick)
+	}
+	if got == nil || got.ID != "c" {
+		t.Fatalf("pickSingle() second auth = %v, want c", got)
+	}
+}
+
+func TestManager_ExecuteRecordsPerformanceScores(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, &LatencyAwareSelector{}, nil)
+	if manager.scheduler.strategy != schedulerStrategyLatency {
+		t.Fatalf("manager.scheduler.strategy = %v, want %v", manager.scheduler.strategy, schedulerStrategyLatency)
+	}
+	manager.RegisterExecutor(perfTestExecutor{failFor: "perf-bad"})
+	for _, id := range []string{"perf-bad", "perf-good"} {
+		if _, errRegister := manager.Register(context.Background(), &Auth{ID: id, Provider: "test"}); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", id, errRegister)
+		}
+	}
+	registerSchedulerModels(t, "test", "perf-model", "perf-bad", "perf-good")
+
+	if _, errExec := manager.Execute(context.Background(), []string{"test"}, cliproxyexecutor.Request{Model: "perf-model"}, cliproxyexecutor.Options{}); errExec != nil {
+		t.Fatalf("Execute() error = %v", errExec)
+	}
+
+	scores := manager.PerformanceScores()
+	if len(scores) != 2 {
+		t.Fatalf("PerformanceScores() len = %d, want 2: %+v", len(scores), scores)
+	}
+	for _, score := range scores {
+		if score.Model != "perf-model" || score.Provider != "test" || score.InFlight != 0 {
+			t.Fatalf("unexpected score %+v", score)
+		}
+		switch score.AuthID {
+		case "perf-bad":
+			if score.Failures != 1 || score.ErrorRate < 0.99 {
+				t.Fatalf("perf-bad score = %+v, want one failure", score)
+			}
+		case "perf-good":
+			if score.Successes != 1 || score.ErrorRate != 0 {
+				t.Fatalf("perf-good score = %+v, want one success", score)
+			}
+		}
+	}
+}
+
+func TestPerfOutcomeForError_IgnoresClientCancellation(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := perfOutcomeForError(ctx, errors.New("boom")); got != perfAborted {
+		t.Fatalf("perfOutcomeForError(cancelled) = %v, want perfAborted", got)
+	}
+	if got := perfOutcomeForError(context.Background(), errors.New("boom")); got != perfFailure {
+		t.Fatalf("perfOutcomeForError(error) = %v, want perfFailure", got)
+	}
+	if got := perfOutcomeForError(context.Background(), nil); got != perfSuccess {
+		t.Fatalf("perfOutcomeForError(nil) = %v, want perfSuccess", got)
+	}
+}
+
+func TestPerformanceTracker_StreamLatencyIsTimeToFirstByte(t *testing.T) {
+	t.Parallel()
+
+	tracker := &performanceTracker{}
+	started := tracker.begin("stream", "gemini", "m")
+	// A 60s generation whose first byte arrived after 200ms.
+	tracker.end("stream", "m", started.Add(-60*time.Second), 200*time.Millisecond, perfSuccess)
+	scores := tracker.snapshot(time.Now())
+	if len(scores) != 1 || scores[0].LatencyMs > 250 || scores[0].TTFTMs > 250 {
+		t.Fatalf("scores = %+v, want latency and TTFT near 200ms", scores)
+	}
+}
+
+func TestPerformanceTracker_PrunesStaleAndForgottenAuths(t *testing.T) {
+	t.Parallel()
+
+	tracker := &performanceTracker{}
+	recordPerfSample(tracker, "idle", 100*time.Millisecond, perfSuccess)
+	recordPerfSample(tracker, "removed", 100*time.Millisecond, perfSuccess)
+	tracker.forget("removed")
+	if scores := tracker.snapshot(time.Now()); len(scores) != 1 || scores[0].AuthID != "idle" {
+		t.Fatalf("scores after forget = %+v", scores)
+	}
+
+	tracker.mu.Lock()
+	tracker.pruneLocked(time.Now().Add(perfStaleAfter))
+	tracker.mu.Unlock()
+	if scores := tracker.snapshot(time.Now()); len(scores) != 0 {
+		t.Fatalf("stale stats not pruned: %+v", scores)
+	}
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// RequestPreflight checks the request for one execution candidate, the selected auth and the
+// upstream model in req.Model, before it is dispatched, and may return a rewritten request.
+// An error skips the candidate without penalising the auth; when no candidate is left the error
+// is returned to the caller and the model's fallback chain, if any, is tried.
+type RequestPreflight interface {
+	Preflight(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Request, error)
+}
+
+// SetRequestPreflight installs the check run before each execution candidate. Passing nil
+// removes it.
+func (m *Manager) SetRequestPreflight(preflight RequestPreflight) {
+	m.mu.Lock()
+	m.preflight = preflight
+	m.mu.Unlock()
+}
+
+// preflightCandidate runs the installed RequestPreflight, if any, for auth and req.
+func (m *Manager) preflightCandidate(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Request, error) {
+	m.mu.RLock()
+	preflight := m.preflight
+	m.mu.RUnlock()
+	if preflight == nil {
+		return req, nil
+	}
+	out, err := preflight.Preflight(ctx, auth, req, opts)
+	if err != nil {
+		return req, &preflightError{err: err}
+	}
+	return out, nil
+}
+
+// preflightError marks a candidate refused by the RequestPreflight.
+type preflightError struct {
+	err error
+}
+
+func (e *preflightError) Error() string { return e.err.Error() }
+
+func (e *preflightError) Unwrap() error { return e.err }
+
+// StatusCode reports the status of the underlying error, or 400.
+func (e *preflightError) StatusCode() int {
+	if se, ok := errors.AsType[cliproxyexecutor.StatusError](e.err); ok && se != nil {
+		return se.StatusCode()
+	}
+	return http.StatusBadRequest
+}
+
+func isPreflightError(err error) bool {
+	_, ok := errors.AsType[*preflightError](err)
+	return ok
+}
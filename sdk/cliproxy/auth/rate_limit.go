@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseRateLimitHeaders extracts the x-ratelimit-* headers used by OpenAI and Azure OpenAI.
+// Reset values may be Go-style durations ("6m0s", "20ms") or whole seconds. It returns nil
+// when none of the headers are present.
+func ParseRateLimitHeaders(headers http.Header, now time.Time) *RateLimitState {
+	if len(headers) == 0 {
+		return nil
+	}
+	state := RateLimitState{ObservedAt: now}
+	found := false
+	readInt := func(name string, dst *int64) {
+		raw := strings.TrimSpace(headers.Get(name))
+		if raw == "" {
+			return
+		}
+		if v, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			*dst = v
+			found = true
+		}
+	}
+	readReset := func(name string, dst *time.Time) {
+		raw := strings.TrimSpace(headers.Get(name))
+		if raw == "" {
+			return
+		}
+		if d, err := time.ParseDuration(raw); err == nil {
+			*dst = now.Add(d)
+			found = true
+		} else if secs, errFloat := strconv.ParseFloat(raw, 64); errFloat == nil {
+			*dst = now.Add(time.Duration(secs * float64(time.Second)))
+			found = true
+		}
+	}
+	readInt("X-Ratelimit-Limit-Requests", &state.LimitRequests)
+	readInt("X-Ratelimit-Remaining-Requests", &state.RemainingRequests)
+	readReset("X-Ratelimit-Reset-Requests", &state.ResetRequestsAt)
+	readInt("X-Ratelimit-Limit-Tokens", &state.LimitTokens)
+	readInt("X-Ratelimit-Remaining-Tokens", &state.RemainingTokens)
+	readReset("X-Ratelimit-Reset-Tokens", &state.ResetTokensAt)
+	if !found {
+		return nil
+	}
+	return &state
+}
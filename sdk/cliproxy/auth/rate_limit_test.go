@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if ParseRateLimitHeaders(http.Header{"Content-Type": {"application/json"}}, now) != nil {
+		t.Fatalf("expected nil without rate-limit headers")
+	}
+	headers := http.Header{}
+	headers.Set("x-ratelimit-limit-requests", "100")
+	headers.Set("x-ratelimit-remaining-requests", "42")
+	headers.Set("x-ratelimit-remaining-tokens", "9000")
+	headers.Set("x-ratelimit-reset-requests", "6m0s")
+	headers.Set("x-ratelimit-reset-tokens", "2")
+	state := ParseRateLimitHeaders(headers, now)
+	if state == nil || state.LimitRequests != 100 || state.RemainingRequests != 42 || state.RemainingTokens != 9000 {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+	if !state.ResetRequestsAt.Equal(now.Add(6*time.Minute)) || !state.ResetTokensAt.Equal(now.Add(2*time.Second)) {
+		t.Fatalf("unexpected reset times: %+v", state)
+	}
+}
+
+func TestMarkResultRecordsRateLimit(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	auth := &Auth{ID: "azure-1", Provider: "azure-openai"}
+	if _, err := m.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	snapshot := &RateLimitState{RemainingRequests: 7, ObservedAt: time.Now()}
+	m.MarkResult(context.Background(), Result{AuthID: "azure-1", Provider: "azure-openai", Model: "gpt-4o", Success: true, RateLimit: snapshot})
+
+	got, ok := m.GetByID("azure-1")
+	if !ok {
+		t.Fatalf("auth missing")
+	}
+	if got.Quota.RateLimit == nil || got.Quota.RateLimit.RemainingRequests != 7 {
+		t.Fatalf("auth rate limit not recorded: %+v", got.Quota)
+	}
+	if state := got.ModelStates["gpt-4o"]; state == nil || state.Quota.RateLimit == nil {
+		t.Fatalf("model rate limit not recorded: %+v", got.ModelStates)
+	}
+}
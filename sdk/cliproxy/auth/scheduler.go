@@ -18,6 +18,8 @@ const (
 	schedulerStrategyCustom schedulerStrategy = iota
 	schedulerStrategyRoundRobin
 	schedulerStrategyFillFirst
+	schedulerStrategyLatency
+	schedulerStrategyLeastInflight
 )
 
 // scheduledState describes how an auth currently participates in a model shard.
@@ -34,6 +36,7 @@ const (
 type authScheduler struct {
 	mu            sync.Mutex
 	strategy      schedulerStrategy
+	perf          *performanceTracker
 	providers     map[string]*providerScheduler
 	authProviders map[string]string
 	mixedCursors  map[string]int
@@ -178,6 +181,10 @@ func selectorStrategy(selector Selector) schedulerStrategy {
 	switch selector.(type) {
 	case *FillFirstSelector:
 		return schedulerStrategyFillFirst
+	case *LatencyAwareSelector:
+		return schedulerStrategyLatency
+	case *LeastInflightSelector:
+		return schedulerStrategyLeastInflight
 	case nil, *RoundRobinSelector:
 		return schedulerStrategyRoundRobin
 	default:
@@ -270,7 +277,7 @@ func (s *authScheduler) pickSingle(ctx context.Context, provider, model string,
 		}
 		return true
 	}
-	if picked := shard.pickReadyLocked(preferWebsocket, s.strategy, predicate); picked != nil {
+	if picked := shard.pickReadyLocked(preferWebsocket, s.strategy, s.perf, predicate); picked != nil {
 		return picked, nil
 	}
 	return nil, shard.unavailableErrorLocked(provider, model, predicate)
@@ -323,7 +330,7 @@ func (s *authScheduler) pickMixed(ctx context.Context, providers []string, model
 			_, ok := tried[pinnedAuthID]
 			return !ok
 		}
-		if picked := shard.pickReadyLocked(false, s.strategy, predicate); picked != nil {
+		if picked := shard.pickReadyLocked(false, s.strategy, s.perf, predicate); picked != nil {
 			return picked, providerKey, nil
 		}
 		return nil, "", shard.unavailableErrorLocked("mixed", model, predicate)
@@ -363,7 +370,7 @@ func (s *authScheduler) pickMixed(ctx context.Context, providers []string, model
 			if shard == nil {
 				continue
 			}
-			picked := shard.pickReadyAtPriorityLocked(false, bestPriority, s.strategy, predicate)
+			picked := shard.pickReadyAtPriorityLocked(false, bestPriority, s.strategy, s.perf, predicate)
 			if picked != nil {
 				return picked, providerKey, nil
 			}
@@ -371,6 +378,33 @@ func (s *authScheduler) pickMixed(ctx context.Context, providers []string, model
 		return nil, "", s.mixedUnavailableErrorLocked(normalized, model, tried)
 	}
 
+	if s.strategy == schedulerStrategyLatency || s.strategy == schedulerStrategyLeastInflight {
+		// Score-based strategies compare credentials across providers directly: the shard
+		// holding the lowest score at the best priority serves the request.
+		bestShard := -1
+		lowest := 0.0
+		for providerIndex, shard := range candidateShards {
+			if shard == nil {
+				continue
+			}
+			bucket := shard.readyByPriority[bestPriority]
+			if bucket == nil {
+				continue
+			}
+			score, ok := shard.lowestScoreLocked(&bucket.all, s.strategy, s.perf, predicate)
+			if ok && (bestShard < 0 || score < lowest) {
+				bestShard = providerIndex
+				lowest = score
+			}
+		}
+		if bestShard >= 0 {
+			if picked := candidateShards[bestShard].pickReadyAtPriorityLocked(false, bestPriority, s.strategy, s.perf, predicate); picked != nil {
+				return picked, normalized[bestShard], nil
+			}
+		}
+		return nil, "", s.mixedUnavailableErrorLocked(normalized, model, tried)
+	}
+
 	cursorKey := strings.Join(normalized, ",") + ":" + modelKey
 	weights := make([]int, len(normalized))
 	segmentStarts := make([]int, len(normalized))
@@ -417,7 +451,7 @@ func (s *authScheduler) pickMixed(ctx context.Context, providers []string, model
 		if shard == nil {
 			continue
 		}
-		picked := shard.pickReadyAtPriorityLocked(false, bestPriority, schedulerStrategyRoundRobin, predicate)
+		picked := shard.pickReadyAtPriorityLocked(false, bestPriority, schedulerStrategyRoundRobin, nil, predicate)
 		if picked == nil {
 			continue
 		}
@@ -757,7 +791,7 @@ func (m *modelScheduler) promoteExpiredLocked(now time.Time) {
 }
 
 // pickReadyLocked selects the next ready auth from the highest available priority bucket.
-func (m *modelScheduler) pickReadyLocked(preferWebsocket bool, strategy schedulerStrategy, predicate func(*scheduledAuth) bool) *Auth {
+func (m *modelScheduler) pickReadyLocked(preferWebsocket bool, strategy schedulerStrategy, perf *performanceTracker, predicate func(*scheduledAuth) bool) *Auth {
 	if m == nil {
 		return nil
 	}
@@ -766,7 +800,7 @@ func (m *modelScheduler) pickReadyLocked(preferWebsocket bool, strategy schedule
 	if !okPriority {
 		return nil
 	}
-	return m.pickReadyAtPriorityLocked(preferWebsocket, priorityReady, strategy, predicate)
+	return m.pickReadyAtPriorityLocked(preferWebsocket, priorityReady, strategy, perf, predicate)
 }
 
 // highestReadyPriorityLocked returns the highest priority bucket that still has a matching ready auth.
@@ -802,7 +836,7 @@ func (m *modelScheduler) highestReadyPriorityLocked(preferWebsocket bool, predic
 
 // pickReadyAtPriorityLocked selects the next ready auth from a specific priority bucket.
 // The caller must ensure expired entries are already promoted when needed.
-func (m *modelScheduler) pickReadyAtPriorityLocked(preferWebsocket bool, priority int, strategy schedulerStrategy, predicate func(*scheduledAuth) bool) *Auth {
+func (m *modelScheduler) pickReadyAtPriorityLocked(preferWebsocket bool, priority int, strategy schedulerStrategy, perf *performanceTracker, predicate func(*scheduledAuth) bool) *Auth {
 	if m == nil {
 		return nil
 	}
@@ -815,9 +849,12 @@ func (m *modelScheduler) pickReadyAtPriorityLocked(preferWebsocket bool, priorit
 		view = &bucket.ws
 	}
 	var picked *scheduledAuth
-	if strategy == schedulerStrategyFillFirst {
+	switch strategy {
+	case schedulerStrategyFillFirst:
 		picked = view.pickFirst(predicate)
-	} else {
+	case schedulerStrategyLatency, schedulerStrategyLeastInflight:
+		picked = m.pickLowestScoreLocked(view, strategy, perf, predicate)
+	default:
 		picked = view.pickRoundRobin(predicate)
 	}
 	if picked == nil || picked.auth == nil {
@@ -826,6 +863,42 @@ func (m *modelScheduler) pickReadyAtPriorityLocked(preferWebsocket bool, priorit
 	return picked.auth
 }
 
+// pickLowestScoreLocked selects the matching entry with the lowest performance score,
+// rotating round-robin among entries that tie (for example credentials without samples yet).
+func (m *modelScheduler) pickLowestScoreLocked(view *readyView, strategy schedulerStrategy, perf *performanceTracker, predicate func(*scheduledAuth) bool) *scheduledAuth {
+	best, ok := m.lowestScoreLocked(view, strategy, perf, predicate)
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	return view.pickRoundRobin(func(entry *scheduledAuth) bool {
+		if predicate != nil && !predicate(entry) {
+			return false
+		}
+		return entry != nil && entry.auth != nil && perf.score(strategy, entry.auth.ID, m.modelKey, now) <= best
+	})
+}
+
+// lowestScoreLocked returns the lowest performance score among matching entries of view.
+func (m *modelScheduler) lowestScoreLocked(view *readyView, strategy schedulerStrategy, perf *performanceTracker, predicate func(*scheduledAuth) bool) (float64, bool) {
+	if m == nil || view == nil {
+		return 0, false
+	}
+	now := time.Now()
+	best := 0.0
+	found := false
+	for _, entry := range view.flat {
+		if entry == nil || entry.auth == nil || (predicate != nil && !predicate(entry)) {
+			continue
+		}
+		if score := perf.score(strategy, entry.auth.ID, m.modelKey, now); !found || score < best {
+			best = score
+			found = true
+		}
+	}
+	return best, found
+}
+
 func (m *modelScheduler) readyCountAtPriorityLocked(preferWebsocket bool, priority int) int {
 	if m == nil {
 		return 0
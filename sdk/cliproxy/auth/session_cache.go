@@ -1,8 +1,11 @@
 package auth
 
 import (
+	"encoding/json"
 	"sync"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sharedstate"
 )
 
 // sessionEntry stores auth binding with expiration.
@@ -12,11 +15,13 @@ type sessionEntry struct {
 }
 
 // SessionCache provides TTL-based session to auth mapping with automatic cleanup.
+// Bindings are replicated to other proxy replicas when shared state is enabled.
 type SessionCache struct {
-	mu      sync.RWMutex
-	entries map[string]sessionEntry
-	ttl     time.Duration
-	stopCh  chan struct{}
+	mu          sync.RWMutex
+	entries     map[string]sessionEntry
+	ttl         time.Duration
+	stopCh      chan struct{}
+	unsubscribe func()
 }
 
 // NewSessionCache creates a cache with the specified TTL.
@@ -30,6 +35,7 @@ func NewSessionCache(ttl time.Duration) *SessionCache {
 		ttl:     ttl,
 		stopCh:  make(chan struct{}),
 	}
+	c.unsubscribe = sharedstate.Subscribe(sharedstate.KindSession, c.applyShared)
 	go c.cleanupLoop()
 	return c
 }
@@ -73,10 +79,15 @@ func (c *SessionCache) GetAndRefresh(sessionID string) (string, bool) {
 		c.mu.Unlock()
 		return "", false
 	}
-	// Refresh TTL on successful access
+	// Refresh TTL on successful access. Other replicas only need to hear about the refresh
+	// once half the TTL has elapsed, which keeps hot sessions from publishing on every request.
+	republish := entry.expiresAt.Sub(now) < c.ttl/2
 	entry.expiresAt = now.Add(c.ttl)
 	c.entries[sessionID] = entry
 	c.mu.Unlock()
+	if republish {
+		sharedstate.Publish(sharedstate.KindSession, sessionID, entry.authID, entry.expiresAt)
+	}
 	return entry.authID, true
 }
 
@@ -85,12 +96,14 @@ func (c *SessionCache) Set(sessionID, authID string) {
 	if sessionID == "" || authID == "" {
 		return
 	}
+	expiresAt := time.Now().Add(c.ttl)
 	c.mu.Lock()
 	c.entries[sessionID] = sessionEntry{
 		authID:    authID,
-		expiresAt: time.Now().Add(c.ttl),
+		expiresAt: expiresAt,
 	}
 	c.mu.Unlock()
+	sharedstate.Publish(sharedstate.KindSession, sessionID, authID, expiresAt)
 }
 
 // Invalidate removes a specific session binding.
@@ -101,6 +114,7 @@ func (c *SessionCache) Invalidate(sessionID string) {
 	c.mu.Lock()
 	delete(c.entries, sessionID)
 	c.mu.Unlock()
+	sharedstate.Delete(sharedstate.KindSession, sessionID)
 }
 
 // InvalidateAuth removes all sessions bound to a specific auth ID.
@@ -109,13 +123,18 @@ func (c *SessionCache) InvalidateAuth(authID string) {
 	if authID == "" {
 		return
 	}
+	var removed []string
 	c.mu.Lock()
 	for sid, entry := range c.entries {
 		if entry.authID == authID {
 			delete(c.entries, sid)
+			removed = append(removed, sid)
 		}
 	}
 	c.mu.Unlock()
+	for _, sid := range removed {
+		sharedstate.Delete(sharedstate.KindSession, sid)
+	}
 }
 
 // Stop terminates the background cleanup goroutine.
@@ -124,7 +143,27 @@ func (c *SessionCache) Stop() {
 	case <-c.stopCh:
 	default:
 		close(c.stopCh)
+		if c.unsubscribe != nil {
+			c.unsubscribe()
+		}
+	}
+}
+
+// applyShared applies a session binding replicated from another proxy replica.
+func (c *SessionCache) applyShared(entry sharedstate.Entry) {
+	if entry.Deleted {
+		c.mu.Lock()
+		delete(c.entries, entry.Key)
+		c.mu.Unlock()
+		return
 	}
+	var authID string
+	if err := json.Unmarshal(entry.Value, &authID); err != nil || authID == "" {
+		return
+	}
+	c.mu.Lock()
+	c.entries[entry.Key] = sessionEntry{authID: authID, expiresAt: entry.ExpiresAt}
+	c.mu.Unlock()
 }
 
 func (c *SessionCache) cleanupLoop() {
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sharedstate"
+)
+
+// sharedRecoveryTTL is how long a recovery event stays readable by replicas that have not
+// synced yet. Cooldown events expire when the cooldown itself ends.
+const sharedRecoveryTTL = 10 * time.Minute
+
+// sharedCooldown replicates the credential state MarkResult derived from one result, so other
+// replicas stop routing to a credential that has just been rate limited instead of hitting it
+// themselves. Auth-wide state is carried in State with an empty Model.
+type sharedCooldown struct {
+	AuthID        string     `json:"auth_id"`
+	Model         string     `json:"model,omitempty"`
+	State         ModelState `json:"state"`
+	SuspendReason string     `json:"suspend_reason,omitempty"`
+	QuotaExceeded bool       `json:"quota_exceeded,omitempty"`
+	Resumed       bool       `json:"resumed,omitempty"`
+}
+
+// authLevelState captures the auth-wide availability fields in ModelState form.
+func authLevelState(auth *Auth) ModelState {
+	return ModelState{
+		Status:         auth.Status,
+		StatusMessage:  auth.StatusMessage,
+		Unavailable:    auth.Unavailable,
+		NextRetryAfter: auth.NextRetryAfter,
+		LastError:      cloneError(auth.LastError),
+		Quota:          auth.Quota,
+		UpdatedAt:      auth.UpdatedAt,
+	}
+}
+
+func publishSharedCooldown(event *sharedCooldown) {
+	if event == nil || !sharedstate.Enabled() {
+		return
+	}
+	expiresAt := event.State.NextRetryAfter
+	if event.Resumed || expiresAt.IsZero() {
+		expiresAt = time.Now().Add(sharedRecoveryTTL)
+	}
+	sharedstate.Publish(sharedstate.KindCooldown, event.AuthID+"|"+event.Model, event, expiresAt)
+}
+
+// EnableSharedState subscribes the manager to cooldown and recovery events replicated from
+// other proxy replicas. Events are applied to the in-memory state only; they are neither
+// persisted nor reported to hooks, since the replica that observed the result already did.
+// The returned function removes the subscription.
+func (m *Manager) EnableSharedState() func() {
+	if m == nil {
+		return func() {}
+	}
+	return sharedstate.Subscribe(sharedstate.KindCooldown, m.applySharedCooldown)
+}
+
+func (m *Manager) applySharedCooldown(entry sharedstate.Entry) {
+	if entry.Deleted {
+		return
+	}
+	var event sharedCooldown
+	if err := json.Unmarshal(entry.Value, &event); err != nil || event.AuthID == "" {
+		return
+	}
+	now := time.Now()
+
+	m.mu.Lock()
+	auth, ok := m.auths[event.AuthID]
+	if !ok || auth == nil {
+		m.mu.Unlock()
+		return
+	}
+	if event.Model != "" {
+		state := ensureModelState(auth, event.Model)
+		if state.UpdatedAt.After(event.State.UpdatedAt) {
+			m.mu.Unlock()
+			return
+		}
+		*state = event.State
+		if event.State.Unavailable {
+			auth.Status = StatusError
+			auth.LastError = cloneError(event.State.LastError)
+			auth.StatusMessage = event.State.StatusMessage
+		}
+		updateAggregatedAvailability(auth, now)
+		if !event.State.Unavailable && !hasModelError(auth, now) {
+			auth.LastError = nil
+			auth.StatusMessage = ""
+			auth.Status = StatusActive
+		}
+	} else {
+		if auth.UpdatedAt.After(event.State.UpdatedAt) {
+			m.mu.Unlock()
+			return
+		}
+		auth.Status = event.State.Status
+		auth.StatusMessage = event.State.StatusMessage
+		auth.Unavailable = event.State.Unavailable
+		auth.NextRetryAfter = event.State.NextRetryAfter
+		auth.LastError = cloneError(event.State.LastError)
+		auth.Quota = event.State.Quota
+	}
+	if event.State.UpdatedAt.After(auth.UpdatedAt) {
+		auth.UpdatedAt = event.State.UpdatedAt
+	}
+	snapshot := auth.Clone()
+	m.mu.Unlock()
+
+	if m.scheduler != nil {
+		m.scheduler.upsertAuth(snapshot)
+	}
+	if event.Model == "" {
+		return
+	}
+	reg := registry.GetGlobalRegistry()
+	switch {
+	case event.Resumed:
+		reg.ClearModelQuotaExceeded(event.AuthID, event.Model)
+		reg.ResumeClientModel(event.AuthID, event.Model)
+	case event.SuspendReason != "":
+		if event.QuotaExceeded {
+			reg.SetModelQuotaExceeded(event.AuthID, event.Model)
+		}
+		reg.SuspendClientModel(event.AuthID, event.Model, event.SuspendReason)
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sharedstate"
+)
+
+func TestSharedState_CooldownReplicatesToOtherManager(t *testing.T) {
+	backend := sharedstate.NewMemoryBackend()
+	writer := sharedstate.NewReplicator(backend, "replica-a", time.Second)
+	reader := sharedstate.NewReplicator(backend, "replica-b", time.Second)
+	sharedstate.Register(writer)
+	t.Cleanup(func() { sharedstate.Register(nil) })
+
+	ctx := context.Background()
+	source := NewManager(nil, nil, nil)
+	target := NewManager(nil, nil, nil)
+	for _, m := range []*Manager{source, target} {
+		if _, errRegister := m.Register(ctx, &Auth{ID: "shared-auth", Provider: "test"}); errRegister != nil {
+			t.Fatalf("Register error = %v", errRegister)
+		}
+	}
+	unsubscribe := target.EnableSharedState()
+	defer unsubscribe()
+
+	source.MarkResult(ctx, Result{
+		AuthID:     "shared-auth",
+		Provider:   "test",
+		Model:      "test-model",
+		Success:    false,
+		RetryAfter: new(time.Minute),
+		Error:      &Error{HTTPStatus: http.StatusTooManyRequests, Message: "rate limited"},
+	})
+
+	if errSync := writer.Sync(ctx); errSync != nil {
+		t.Fatalf("writer Sync error = %v", errSync)
+	}
+	if errSync := reader.Sync(ctx); errSync != nil {
+		t.Fatalf("reader Sync error = %v", errSync)
+	}
+
+	auth, ok := target.GetByID("shared-auth")
+	if !ok {
+		t.Fatal("auth missing from target manager")
+	}
+	state := auth.ModelStates["test-model"]
+	if state == nil || !state.Unavailable {
+		t.Fatalf("model state = %+v, want unavailable", state)
+	}
+	if !state.Quota.Exceeded {
+		t.Fatalf("quota exceeded = false, want true")
+	}
+	if time.Until(state.NextRetryAfter) <= 0 {
+		t.Fatalf("NextRetryAfter = %v, want future", state.NextRetryAfter)
+	}
+
+	source.MarkResult(ctx, Result{AuthID: "shared-auth", Provider: "test", Model: "test-model", Success: true})
+	if errSync := writer.Sync(ctx); errSync != nil {
+		t.Fatalf("writer Sync error = %v", errSync)
+	}
+	if errSync := reader.Sync(ctx); errSync != nil {
+		t.Fatalf("reader Sync error = %v", errSync)
+	}
+
+	auth, _ = target.GetByID("shared-auth")
+	if state = auth.ModelStates["test-model"]; state == nil || state.Unavailable {
+		t.Fatalf("model state after recovery = %+v, want available", state)
+	}
+}
+
+func TestSharedState_StaleEventIgnored(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	now := time.Now()
+	if _, errRegister := m.Register(context.Background(), &Auth{
+		ID:       "auth-stale",
+		Provider: "test",
+		ModelStates: map[string]*ModelState{
+			"test-model": {Status: StatusActive, UpdatedAt: now},
+		},
+	}); errRegister != nil {
+		t.Fatalf("Register error = %v", errRegister)
+	}
+
+	event := sharedCooldown{
+		AuthID: "auth-stale",
+		Model:  "test-model",
+		State: ModelState{
+			Status:         StatusError,
+			Unavailable:    true,
+			NextRetryAfter: now.Add(time.Minute),
+			UpdatedAt:      now.Add(-time.Minute),
+		},
+	}
+	raw, errMarshal := json.Marshal(event)
+	if errMarshal != nil {
+		t.Fatalf("Marshal error = %v", errMarshal)
+	}
+	m.applySharedCooldown(sharedstate.Entry{Kind: sharedstate.KindCooldown, Key: "auth-stale|test-model", Value: raw})
+
+	auth, _ := m.GetByID("auth-stale")
+	if auth.ModelStates["test-model"].Unavailable {
+		t.Fatal("stale cooldown event overwrote newer local state")
+	}
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SchedulerStat summarizes the scheduling state of one provider/model shard.
+type SchedulerStat struct {
+	Provider string
+	Model    string
+	Ready    int
+	Cooldown int
+	Blocked  int
+	Disabled int
+}
+
+// RefreshStat counts credential refresh outcomes for one provider.
+type RefreshStat struct {
+	Provider string
+	Success  int64
+	Failure  int64
+}
+
+// refreshCounters accumulates refresh outcomes per provider.
+type refreshCounters struct {
+	mu     sync.Mutex
+	counts map[string]*RefreshStat
+}
+
+func (r *refreshCounters) record(provider string, success bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts == nil {
+		r.counts = make(map[string]*RefreshStat)
+	}
+	stat, ok := r.counts[provider]
+	if !ok {
+		stat = &RefreshStat{Provider: provider}
+		r.counts[provider] = stat
+	}
+	if success {
+		stat.Success++
+	} else {
+		stat.Failure++
+	}
+}
+
+func (r *refreshCounters) snapshot() []RefreshStat {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]RefreshStat, 0, len(r.counts))
+	for _, stat := range r.counts {
+		out = append(out, *stat)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
+
+// RefreshStats returns refresh success and failure counts per provider since startup.
+func (m *Manager) RefreshStats() []RefreshStat {
+	if m == nil {
+		return nil
+	}
+	return m.refreshStats.snapshot()
+}
+
+// SchedulerStats returns per provider/model counts of ready, cooling down, blocked and
+// disabled credentials as currently tracked by the scheduler.
+func (m *Manager) SchedulerStats() []SchedulerStat {
+	if m == nil || m.scheduler == nil {
+		return nil
+	}
+	return m.scheduler.stats(time.Now())
+}
+
+// stats counts shard entries by state. Cooldowns that have already expired are
+// reported as ready because the next pick promotes them.
+func (s *authScheduler) stats(now time.Time) []SchedulerStat {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]SchedulerStat, 0)
+	for providerKey, provider := range s.providers {
+		if provider == nil {
+			continue
+		}
+		for modelKey, shard := range provider.modelShards {
+			if shard == nil {
+				continue
+			}
+			stat := SchedulerStat{Provider: providerKey, Model: modelKey}
+			for _, entry := range shard.entries {
+				if entry == nil {
+					continue
+				}
+				switch entry.state {
+				case scheduledStateReady:
+					stat.Ready++
+				case scheduledStateCooldown:
+					if !entry.nextRetryAt.IsZero() && !entry.nextRetryAt.After(now) {
+						stat.Ready++
+					} else {
+						stat.Cooldown++
+					}
+				case scheduledStateBlocked:
+					stat.Blocked++
+				case scheduledStateDisabled:
+					stat.Disabled++
+				}
+			}
+			out = append(out, stat)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].Model < out[j].Model
+	})
+	return out
+}
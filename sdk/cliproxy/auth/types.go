@@ -105,6 +105,21 @@ type QuotaState struct {
 	NextRecoverAt time.Time `json:"next_recover_at"`
 	// BackoffLevel stores the progressive cooldown exponent used for rate limits.
 	BackoffLevel int `json:"backoff_level,omitempty"`
+	// RateLimit holds the latest rate-limit headers reported by the upstream, if any.
+	RateLimit *RateLimitState `json:"rate_limit,omitempty"`
+}
+
+// RateLimitState is a snapshot of the x-ratelimit-* headers returned by an upstream.
+// Fields are zero when the corresponding header was absent.
+type RateLimitState struct {
+	LimitRequests     int64     `json:"limit_requests,omitempty"`
+	RemainingRequests int64     `json:"remaining_requests,omitempty"`
+	ResetRequestsAt   time.Time `json:"reset_requests_at,omitempty"`
+	LimitTokens       int64     `json:"limit_tokens,omitempty"`
+	RemainingTokens   int64     `json:"remaining_tokens,omitempty"`
+	ResetTokensAt     time.Time `json:"reset_tokens_at,omitempty"`
+	// ObservedAt is when the headers were received.
+	ObservedAt time.Time `json:"observed_at"`
 }
 
 // ModelState captures the execution state for a specific model under an auth entry.
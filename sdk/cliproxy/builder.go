@@ -9,11 +9,19 @@ import (
 	"time"
 
 	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
+	jwtaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/jwt_access"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/quota"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/contextguard"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redact"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/pipeline"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 )
 
 // Builder constructs a Service instance with customizable providers.
@@ -49,6 +57,12 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// pipelineHooks run around every provider executor call.
+	pipelineHooks []pipeline.Hook
+
+	// translatorPipeline supplies request/response middleware run around executor calls.
+	translatorPipeline *sdktranslator.Pipeline
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -164,6 +178,25 @@ func (b *Builder) WithPostAuthHook(hook coreauth.PostAuthHook) *Builder {
 	return b
 }
 
+// WithPipelineHooks registers hooks that run around every ProviderExecutor.Execute and
+// ExecuteStream call. Hooks see the selected auth, the request and each stream chunk, and
+// may mutate or reject them through the pipeline.Context.
+func (b *Builder) WithPipelineHooks(hooks ...pipeline.Hook) *Builder {
+	for _, hook := range hooks {
+		if hook != nil {
+			b.pipelineHooks = append(b.pipelineHooks, hook)
+		}
+	}
+	return b
+}
+
+// WithTranslatorPipeline registers a translator pipeline whose UseRequest and UseResponse
+// middleware run around every executor call on the client-format payload.
+func (b *Builder) WithTranslatorPipeline(p *sdktranslator.Pipeline) *Builder {
+	b.translatorPipeline = p
+	return b
+}
+
 // Build validates inputs, applies defaults, and returns a ready-to-run service.
 func (b *Builder) Build() (*Service, error) {
 	if b.cfg == nil {
@@ -199,6 +232,11 @@ func (b *Builder) Build() (*Service, error) {
 	}
 
 	configaccess.Register(&b.cfg.SDKConfig)
+	jwtaccess.Register(&b.cfg.SDKConfig)
+	quota.Configure(&b.cfg.SDKConfig)
+	pricing.Configure(&b.cfg.SDKConfig)
+	redact.Configure(&b.cfg.SDKConfig)
+	contextguard.Configure(&b.cfg.SDKConfig)
 	accessManager.SetProviders(sdkaccess.RegisteredProviders())
 
 	coreManager := b.coreManager
@@ -225,6 +263,10 @@ func (b *Builder) Build() (*Service, error) {
 		switch strategy {
 		case "fill-first", "fillfirst", "ff":
 			selector = &coreauth.FillFirstSelector{}
+		case "latency", "latency-aware", "ewma":
+			selector = &coreauth.LatencyAwareSelector{}
+		case "least-inflight", "leastinflight", "li":
+			selector = &coreauth.LeastInflightSelector{}
 		default:
 			selector = &coreauth.RoundRobinSelector{}
 		}
@@ -243,6 +285,10 @@ func (b *Builder) Build() (*Service, error) {
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
 	coreManager.SetConfig(b.cfg)
 	coreManager.SetOAuthModelAlias(b.cfg.OAuthModelAlias)
+	coreManager.SetRequestPreflight(handlers.ContextGuardPreflight{})
+	if len(b.pipelineHooks) > 0 || b.translatorPipeline != nil {
+		coreManager.SetExecutionInterceptor(pipeline.NewRunner(b.translatorPipeline, b.pipelineHooks...))
+	}
 
 	service := &Service{
 		cfg:            b.cfg,
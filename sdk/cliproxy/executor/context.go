@@ -1,9 +1,14 @@
 package executor
 
-import "context"
+import (
+	"context"
+	"net/http"
+)
 
 type downstreamWebsocketContextKey struct{}
 
+type upstreamInterceptorContextKey struct{}
+
 // WithDownstreamWebsocket marks the current request as coming from a downstream websocket connection.
 func WithDownstreamWebsocket(ctx context.Context) context.Context {
 	if ctx == nil {
@@ -21,3 +26,25 @@ func DownstreamWebsocket(ctx context.Context) bool {
 	enabled, ok := raw.(bool)
 	return ok && enabled
 }
+
+// UpstreamInterceptor inspects an upstream HTTP request right before it is sent. It may modify
+// the request in place; a non-nil error aborts the request and is returned by the transport.
+type UpstreamInterceptor func(req *http.Request) error
+
+// WithUpstreamInterceptor attaches fn to ctx. Executors consult it for every HTTP request they
+// send on behalf of the call.
+func WithUpstreamInterceptor(ctx context.Context, fn UpstreamInterceptor) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, upstreamInterceptorContextKey{}, fn)
+}
+
+// UpstreamInterceptorFrom returns the interceptor attached to ctx, or nil.
+func UpstreamInterceptorFrom(ctx context.Context) UpstreamInterceptor {
+	if ctx == nil {
+		return nil
+	}
+	fn, _ := ctx.Value(upstreamInterceptorContextKey{}).(UpstreamInterceptor)
+	return fn
+}
@@ -19,6 +19,8 @@ const (
 	SelectedAuthCallbackMetadataKey = "selected_auth_callback"
 	// ExecutionSessionMetadataKey identifies a long-lived downstream execution session.
 	ExecutionSessionMetadataKey = "execution_session_id"
+	// ServedModelMetadataKey stores the model that answered when a fallback chain is configured.
+	ServedModelMetadataKey = "served_model"
 )
 
 // Request encapsulates the translated payload that will be sent to a provider executor.
@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -21,6 +22,38 @@ type Context struct {
 	Translator *sdktranslator.Pipeline
 	// HTTPClient allows middleware to customise the outbound transport per request.
 	HTTPClient *http.Client
+	// Response is the executor response seen by AfterExecute hooks. Hooks may modify it in place.
+	Response *cliproxyexecutor.Response
+	// Chunk is the stream chunk seen by OnStreamChunk hooks. Hooks may modify it in place;
+	// a chunk left without payload or error is dropped.
+	Chunk *cliproxyexecutor.StreamChunk
+	// UpstreamPayload is the body of the upstream request seen by BeforeUpstream hooks, already
+	// translated into the provider format. Hooks may replace it.
+	UpstreamPayload []byte
+
+	rejection error
+}
+
+// Reject aborts execution with err. When err implements cliproxyexecutor.StatusError its
+// status code is reported to the client, otherwise 403 is used. Calling Reject from
+// BeforeExecute or BeforeUpstream prevents the upstream call; from OnStreamChunk it
+// terminates the stream and cancels the upstream call.
+func (c *Context) Reject(err error) {
+	if c == nil {
+		return
+	}
+	if err == nil {
+		err = errors.New("request rejected by pipeline hook")
+	}
+	c.rejection = err
+}
+
+// Rejected returns the error passed to Reject, if any.
+func (c *Context) Rejected() error {
+	if c == nil {
+		return nil
+	}
+	return c.rejection
 }
 
 // Hook captures middleware callbacks around execution.
@@ -30,11 +63,20 @@ type Hook interface {
 	OnStreamChunk(ctx context.Context, execCtx *Context, chunk cliproxyexecutor.StreamChunk)
 }
 
+// UpstreamHook is an optional Hook extension invoked right before each HTTP request an executor
+// sends upstream, once the payload has been translated into the provider format. The request
+// body is exposed as execCtx.UpstreamPayload; headers may be changed on req directly. Executors
+// that refresh credentials inline send those requests through the hook as well.
+type UpstreamHook interface {
+	BeforeUpstream(ctx context.Context, execCtx *Context, req *http.Request)
+}
+
 // HookFunc aggregates optional hook implementations.
 type HookFunc struct {
-	Before func(context.Context, *Context)
-	After  func(context.Context, *Context, cliproxyexecutor.Response, error)
-	Stream func(context.Context, *Context, cliproxyexecutor.StreamChunk)
+	Before   func(context.Context, *Context)
+	Upstream func(context.Context, *Context, *http.Request)
+	After    func(context.Context, *Context, cliproxyexecutor.Response, error)
+	Stream   func(context.Context, *Context, cliproxyexecutor.StreamChunk)
 }
 
 // BeforeExecute implements Hook.
@@ -44,6 +86,13 @@ func (h HookFunc) BeforeExecute(ctx context.Context, execCtx *Context) {
 	}
 }
 
+// BeforeUpstream implements UpstreamHook.
+func (h HookFunc) BeforeUpstream(ctx context.Context, execCtx *Context, req *http.Request) {
+	if h.Upstream != nil {
+		h.Upstream(ctx, execCtx, req)
+	}
+}
+
 // AfterExecute implements Hook.
 func (h HookFunc) AfterExecute(ctx context.Context, execCtx *Context, resp cliproxyexecutor.Response, err error) {
 	if h.After != nil {
@@ -0,0 +1,302 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// Runner invokes translator middleware and hooks around provider executor calls.
+// It implements cliproxyauth.ExecutionInterceptor and is installed on the core manager
+// with SetExecutionInterceptor.
+//
+// For each upstream attempt the order is: translator request middleware, BeforeExecute
+// hooks, the executor (which runs BeforeUpstream hooks on the translated request it sends),
+// OnStreamChunk or AfterExecute hooks, translator response middleware.
+type Runner struct {
+	translator *sdktranslator.Pipeline
+	hooks      []Hook
+}
+
+// NewRunner creates a runner for the given translator pipeline (may be nil) and hooks.
+// Hooks run in registration order.
+func NewRunner(translator *sdktranslator.Pipeline, hooks ...Hook) *Runner {
+	r := &Runner{translator: translator}
+	for _, hook := range hooks {
+		if hook != nil {
+			r.hooks = append(r.hooks, hook)
+		}
+	}
+	return r
+}
+
+var _ cliproxyauth.ExecutionInterceptor = (*Runner)(nil)
+
+// InterceptExecute implements cliproxyauth.ExecutionInterceptor.
+func (r *Runner) InterceptExecute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, next cliproxyauth.ExecuteFunc) (cliproxyexecutor.Response, error) {
+	execCtx := r.newContext(auth, req, opts)
+	if err := r.before(ctx, execCtx); err != nil {
+		return r.after(ctx, execCtx, cliproxyexecutor.Response{}, err)
+	}
+	resp, err := next(r.transportContext(ctx, execCtx), execCtx.Request, execCtx.Options)
+	resp, err = r.after(ctx, execCtx, resp, r.upstreamError(execCtx, err))
+	if err != nil {
+		return resp, err
+	}
+	if !r.hasResponseMiddleware() {
+		return resp, nil
+	}
+	env, errProcess := r.translator.ProcessResponse(ctx, sdktranslator.ResponseEnvelope{
+		Format: execCtx.Options.SourceFormat,
+		Model:  execCtx.Request.Model,
+		Body:   resp.Payload,
+	})
+	if errProcess != nil {
+		return cliproxyexecutor.Response{}, rejection(errProcess)
+	}
+	resp.Payload = env.Body
+	return resp, nil
+}
+
+// InterceptExecuteStream implements cliproxyauth.ExecutionInterceptor.
+func (r *Runner) InterceptExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, next cliproxyauth.ExecuteStreamFunc) (*cliproxyexecutor.StreamResult, error) {
+	execCtx := r.newContext(auth, req, opts)
+	if err := r.before(ctx, execCtx); err != nil {
+		_, err = r.after(ctx, execCtx, cliproxyexecutor.Response{}, err)
+		return nil, err
+	}
+	if len(r.hooks) == 0 && !r.hasResponseMiddleware() {
+		return next(ctx, execCtx.Request, execCtx.Options)
+	}
+	upstreamCtx, cancel := context.WithCancel(ctx)
+	result, err := next(r.transportContext(upstreamCtx, execCtx), execCtx.Request, execCtx.Options)
+	if err != nil || result == nil {
+		cancel()
+		_, err = r.after(ctx, execCtx, cliproxyexecutor.Response{}, r.upstreamError(execCtx, err))
+		return result, err
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer cancel()
+		send := func(chunk cliproxyexecutor.StreamChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		var streamErr error
+		abandoned := false
+		for chunk := range result.Chunks {
+			if chunk.Err != nil {
+				streamErr = chunk.Err
+			}
+			chunks, errChunk := r.processChunk(ctx, execCtx, chunk)
+			if errChunk != nil {
+				streamErr = errChunk
+				chunks = append(chunks, cliproxyexecutor.StreamChunk{Err: errChunk})
+			}
+			for _, processed := range chunks {
+				if !send(processed) {
+					abandoned = true
+					break
+				}
+			}
+			if errChunk != nil || abandoned {
+				// Stop the upstream call, then drain so the executor goroutine can exit.
+				cancel()
+				for range result.Chunks {
+				}
+				break
+			}
+		}
+		if _, errAfter := r.after(ctx, execCtx, cliproxyexecutor.Response{Headers: result.Headers}, streamErr); errAfter != nil && streamErr == nil && !abandoned {
+			send(cliproxyexecutor.StreamChunk{Err: errAfter})
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}, nil
+}
+
+func (r *Runner) newContext(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) *Context {
+	return &Context{Request: req, Options: opts, Auth: auth, Translator: r.translator}
+}
+
+// before runs request middleware then BeforeExecute hooks, stopping at the first rejection.
+func (r *Runner) before(ctx context.Context, execCtx *Context) error {
+	if r.hasRequestMiddleware() {
+		env, err := r.translator.ProcessRequest(ctx, sdktranslator.RequestEnvelope{
+			Format: execCtx.Options.SourceFormat,
+			Model:  execCtx.Request.Model,
+			Stream: execCtx.Options.Stream,
+			Body:   execCtx.Request.Payload,
+		})
+		if err != nil {
+			return rejection(err)
+		}
+		execCtx.Request.Payload = env.Body
+	}
+	for _, hook := range r.hooks {
+		hook.BeforeExecute(ctx, execCtx)
+		if err := execCtx.Rejected(); err != nil {
+			return rejection(err)
+		}
+	}
+	return nil
+}
+
+// after runs AfterExecute hooks. Hooks may rewrite execCtx.Response or reject a successful call.
+func (r *Runner) after(ctx context.Context, execCtx *Context, resp cliproxyexecutor.Response, err error) (cliproxyexecutor.Response, error) {
+	if len(r.hooks) == 0 {
+		return resp, err
+	}
+	execCtx.Response = &resp
+	for _, hook := range r.hooks {
+		hook.AfterExecute(ctx, execCtx, resp, err)
+		if errReject := execCtx.Rejected(); errReject != nil && err == nil {
+			err = rejection(errReject)
+			break
+		}
+	}
+	out := *execCtx.Response
+	execCtx.Response = nil
+	return out, err
+}
+
+// processChunk applies OnStreamChunk hooks then response middleware to one upstream chunk.
+// Middleware may split a chunk into several or drop it.
+func (r *Runner) processChunk(ctx context.Context, execCtx *Context, chunk cliproxyexecutor.StreamChunk) ([]cliproxyexecutor.StreamChunk, error) {
+	if len(r.hooks) > 0 {
+		execCtx.Chunk = &chunk
+		for _, hook := range r.hooks {
+			hook.OnStreamChunk(ctx, execCtx, chunk)
+			if err := execCtx.Rejected(); err != nil {
+				execCtx.Chunk = nil
+				return nil, rejection(err)
+			}
+		}
+		execCtx.Chunk = nil
+		if len(chunk.Payload) == 0 && chunk.Err == nil {
+			return nil, nil
+		}
+	}
+	if chunk.Err != nil || !r.hasResponseMiddleware() {
+		return []cliproxyexecutor.StreamChunk{chunk}, nil
+	}
+	env, err := r.translator.ProcessResponse(ctx, sdktranslator.ResponseEnvelope{
+		Format: execCtx.Options.SourceFormat,
+		Model:  execCtx.Request.Model,
+		Stream: true,
+		Body:   chunk.Payload,
+	})
+	if err != nil {
+		return nil, rejection(err)
+	}
+	out := make([]cliproxyexecutor.StreamChunk, 0, len(env.Chunks))
+	for _, payload := range env.Chunks {
+		if len(payload) > 0 {
+			out = append(out, cliproxyexecutor.StreamChunk{Payload: payload})
+		}
+	}
+	return out, nil
+}
+
+// transportContext exposes a hook-supplied HTTP client transport and the BeforeUpstream hooks
+// to executors.
+func (r *Runner) transportContext(ctx context.Context, execCtx *Context) context.Context {
+	if execCtx.HTTPClient != nil && execCtx.HTTPClient.Transport != nil {
+		ctx = context.WithValue(ctx, "cliproxy.roundtripper", execCtx.HTTPClient.Transport)
+	}
+	if intercept := r.upstreamInterceptor(ctx, execCtx); intercept != nil {
+		ctx = cliproxyexecutor.WithUpstreamInterceptor(ctx, intercept)
+	}
+	return ctx
+}
+
+// upstreamInterceptor returns an interceptor running BeforeUpstream hooks on every upstream
+// request of the call, or nil when no hook implements UpstreamHook.
+func (r *Runner) upstreamInterceptor(ctx context.Context, execCtx *Context) cliproxyexecutor.UpstreamInterceptor {
+	var hooks []UpstreamHook
+	for _, hook := range r.hooks {
+		if upstream, ok := hook.(UpstreamHook); ok {
+			hooks = append(hooks, upstream)
+		}
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+	var mu sync.Mutex
+	return func(req *http.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		hadBody := req.Body != nil && req.Body != http.NoBody
+		var payload []byte
+		if hadBody {
+			data, err := io.ReadAll(req.Body)
+			_ = req.Body.Close()
+			if err != nil {
+				return err
+			}
+			payload = data
+		}
+		execCtx.UpstreamPayload = payload
+		defer func() { execCtx.UpstreamPayload = nil }()
+		for _, hook := range hooks {
+			hook.BeforeUpstream(ctx, execCtx, req)
+			if err := execCtx.Rejected(); err != nil {
+				return rejection(err)
+			}
+		}
+		body := execCtx.UpstreamPayload
+		if !hadBody && len(body) == 0 {
+			return nil
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.ContentLength = int64(len(body))
+		return nil
+	}
+}
+
+// upstreamError reports a rejection raised by a BeforeUpstream hook as such, whatever the
+// executor wrapped it in.
+func (r *Runner) upstreamError(execCtx *Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errReject := execCtx.Rejected(); errReject != nil {
+		return rejection(errReject)
+	}
+	return err
+}
+
+func (r *Runner) hasRequestMiddleware() bool {
+	return r.translator != nil && r.translator.HasRequestMiddleware()
+}
+
+func (r *Runner) hasResponseMiddleware() bool {
+	return r.translator != nil && r.translator.HasResponseMiddleware()
+}
+
+// rejection converts a hook or middleware error into a manager-level rejection.
+func rejection(err error) error {
+	if _, ok := errors.AsType[*cliproxyauth.RejectionError](err); ok {
+		return err
+	}
+	status := 0
+	if se, ok := errors.AsType[cliproxyexecutor.StatusError](err); ok && se != nil {
+		status = se.StatusCode()
+	}
+	return &cliproxyauth.RejectionError{Err: err, Status: status}
+}
@@ -0,0 +1,272 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+type echoExecutor struct {
+	id string
+
+	mu       sync.Mutex
+	payloads [][]byte
+}
+
+func (e *echoExecutor) Identifier() string { return e.id }
+
+func (e *echoExecutor) Execute(_ context.Context, _ *cliproxyauth.Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, req.Payload)
+	e.mu.Unlock()
+	return cliproxyexecutor.Response{Payload: append([]byte("echo:"), req.Payload...)}, nil
+}
+
+func (e *echoExecutor) ExecuteStream(_ context.Context, _ *cliproxyauth.Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, req.Payload)
+	e.mu.Unlock()
+	ch := make(chan cliproxyexecutor.StreamChunk, 3)
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("a")}
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("secret")}
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("b")}
+	close(ch)
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *echoExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *echoExecutor) CountTokens(context.Context, *cliproxyauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *echoExecutor) HttpRequest(context.Context, *cliproxyauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *echoExecutor) calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.payloads)
+}
+
+// upstreamExecutor posts a "translated" payload to url through the proxy-aware client.
+type upstreamExecutor struct {
+	echoExecutor
+	url string
+}
+
+func (e *upstreamExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	body := append([]byte("upstream:"), req.Payload...)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	httpResp, err := helps.NewProxyAwareHTTPClient(ctx, nil, auth, 0).Do(httpReq)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+	data, err := io.ReadAll(httpResp.Body)
+	return cliproxyexecutor.Response{Payload: data}, err
+}
+
+// endlessExecutor streams until its context is cancelled, then closes stopped.
+type endlessExecutor struct {
+	echoExecutor
+	stopped chan struct{}
+}
+
+func (e *endlessExecutor) ExecuteStream(ctx context.Context, _ *cliproxyauth.Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(e.stopped)
+		defer close(ch)
+		for {
+			select {
+			case ch <- cliproxyexecutor.StreamChunk{Payload: []byte("x")}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func newRunnerTestManager(t *testing.T, runner *Runner) (*cliproxyauth.Manager, *echoExecutor) {
+	t.Helper()
+	exec := &echoExecutor{id: "pipeline-" + strings.ToLower(t.Name())}
+	return registerRunnerTestExecutor(t, runner, exec), exec
+}
+
+func registerRunnerTestExecutor(t *testing.T, runner *Runner, exec cliproxyauth.ProviderExecutor) *cliproxyauth.Manager {
+	t.Helper()
+	m := cliproxyauth.NewManager(nil, nil, nil)
+	m.RegisterExecutor(exec)
+	m.SetExecutionInterceptor(runner)
+	auth := &cliproxyauth.Auth{ID: exec.Identifier() + "-auth", Provider: exec.Identifier(), Status: cliproxyauth.StatusActive}
+	if _, err := m.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, exec.Identifier(), []*registry.ModelInfo{{ID: "pipeline-model"}})
+	t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+	return m
+}
+
+func TestRunnerMutatesRequestAndResponse(t *testing.T) {
+	translator := sdktranslator.NewPipeline(nil)
+	translator.UseRequest(func(ctx context.Context, req sdktranslator.RequestEnvelope, next sdktranslator.RequestHandler) (sdktranslator.RequestEnvelope, error) {
+		req.Body = append(req.Body, "+mw"...)
+		return next(ctx, req)
+	})
+	var seenAuth string
+	hook := HookFunc{
+		Before: func(_ context.Context, execCtx *Context) {
+			seenAuth = execCtx.Auth.ID
+			execCtx.Request.Payload = append(execCtx.Request.Payload, "+hook"...)
+		},
+		After: func(_ context.Context, execCtx *Context, _ cliproxyexecutor.Response, _ error) {
+			execCtx.Response.Payload = bytes.ToUpper(execCtx.Response.Payload)
+		},
+	}
+	m, exec := newRunnerTestManager(t, NewRunner(translator, hook))
+
+	resp, err := m.Execute(context.Background(), []string{exec.id}, cliproxyexecutor.Request{Model: "pipeline-model", Payload: []byte("in")}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if string(resp.Payload) != "ECHO:IN+MW+HOOK" {
+		t.Fatalf("payload = %q", resp.Payload)
+	}
+	if seenAuth != exec.id+"-auth" {
+		t.Fatalf("hook saw auth %q", seenAuth)
+	}
+}
+
+func TestRunnerRejectSkipsUpstream(t *testing.T) {
+	hook := HookFunc{Before: func(_ context.Context, execCtx *Context) {
+		execCtx.Reject(errors.New("blocked"))
+	}}
+	m, exec := newRunnerTestManager(t, NewRunner(nil, hook))
+
+	_, err := m.Execute(context.Background(), []string{exec.id}, cliproxyexecutor.Request{Model: "pipeline-model"}, cliproxyexecutor.Options{})
+	rejection, ok := errors.AsType[*cliproxyauth.RejectionError](err)
+	if !ok || rejection.StatusCode() != http.StatusForbidden {
+		t.Fatalf("expected 403 rejection, got %v", err)
+	}
+	if exec.calls() != 0 {
+		t.Fatalf("executor called %d times", exec.calls())
+	}
+}
+
+func TestRunnerStreamChunkHooks(t *testing.T) {
+	var after error
+	hook := HookFunc{
+		Stream: func(_ context.Context, execCtx *Context, chunk cliproxyexecutor.StreamChunk) {
+			if string(chunk.Payload) == "secret" {
+				execCtx.Chunk.Payload = nil
+			}
+		},
+		After: func(_ context.Context, _ *Context, _ cliproxyexecutor.Response, err error) { after = err },
+	}
+	m, exec := newRunnerTestManager(t, NewRunner(nil, hook))
+
+	result, err := m.ExecuteStream(context.Background(), []string{exec.id}, cliproxyexecutor.Request{Model: "pipeline-model"}, cliproxyexecutor.Options{Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var got []byte
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		got = append(got, chunk.Payload...)
+	}
+	if string(got) != "ab" {
+		t.Fatalf("stream = %q, want ab", got)
+	}
+	if after != nil {
+		t.Fatalf("AfterExecute saw error %v", after)
+	}
+}
+
+func TestRunnerUpstreamHookSeesTranslatedPayload(t *testing.T) {
+	var gotBody, gotHeader string
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		data, _ := io.ReadAll(r.Body)
+		gotBody, gotHeader = string(data), r.Header.Get("X-Audit")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var seen string
+	hook := HookFunc{Upstream: func(_ context.Context, execCtx *Context, req *http.Request) {
+		seen = string(execCtx.UpstreamPayload)
+		if strings.Contains(seen, "forbidden") {
+			execCtx.Reject(errors.New("blocked"))
+			return
+		}
+		req.Header.Set("X-Audit", execCtx.Auth.ID)
+		execCtx.UpstreamPayload = bytes.ReplaceAll(execCtx.UpstreamPayload, []byte("secret"), []byte("[masked]"))
+	}}
+	exec := &upstreamExecutor{echoExecutor: echoExecutor{id: "pipeline-upstream"}, url: server.URL}
+	m := registerRunnerTestExecutor(t, NewRunner(nil, hook), exec)
+
+	resp, err := m.Execute(context.Background(), []string{exec.id}, cliproxyexecutor.Request{Model: "pipeline-model", Payload: []byte("my secret")}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if string(resp.Payload) != "ok" || seen != "upstream:my secret" {
+		t.Fatalf("payload = %q, hook saw %q", resp.Payload, seen)
+	}
+	if gotBody != "upstream:my [masked]" || gotHeader != exec.id+"-auth" {
+		t.Fatalf("upstream got body %q header %q", gotBody, gotHeader)
+	}
+
+	_, err = m.Execute(context.Background(), []string{exec.id}, cliproxyexecutor.Request{Model: "pipeline-model", Payload: []byte("forbidden")}, cliproxyexecutor.Options{})
+	if _, ok := errors.AsType[*cliproxyauth.RejectionError](err); !ok {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+	if hits != 1 {
+		t.Fatalf("upstream hits = %d, want 1", hits)
+	}
+}
+
+func TestRunnerStreamRejectionCancelsUpstream(t *testing.T) {
+	hook := HookFunc{Stream: func(_ context.Context, execCtx *Context, _ cliproxyexecutor.StreamChunk) {
+		execCtx.Reject(errors.New("blocked"))
+	}}
+	exec := &endlessExecutor{echoExecutor: echoExecutor{id: "pipeline-endless"}, stopped: make(chan struct{})}
+	m := registerRunnerTestExecutor(t, NewRunner(nil, hook), exec)
+
+	result, err := m.ExecuteStream(context.Background(), []string{exec.id}, cliproxyexecutor.Request{Model: "pipeline-model"}, cliproxyexecutor.Options{Stream: true})
+	if err == nil {
+		for range result.Chunks {
+		}
+	} else if _, ok := errors.AsType[*cliproxyauth.RejectionError](err); !ok {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+	select {
+	case <-exec.stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream stream was not cancelled after the rejection")
+	}
+}
@@ -15,7 +15,9 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sharedstate"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -84,6 +86,9 @@ type Service struct {
 	// coreManager handles core authentication and execution.
 	coreManager *coreauth.Manager
 
+	// sharedStateStop removes the core manager's shared state subscription.
+	sharedStateStop func()
+
 	// shutdownOnce ensures shutdown is called only once.
 	shutdownOnce sync.Once
 
@@ -422,6 +427,10 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 		s.coreManager.RegisterExecutor(executor.NewAntigravityExecutor(s.cfg))
 	case "claude":
 		s.coreManager.RegisterExecutor(executor.NewClaudeExecutor(s.cfg))
+	case "bedrock":
+		s.coreManager.RegisterExecutor(executor.NewBedrockExecutor(s.cfg))
+	case "azure-openai":
+		s.coreManager.RegisterExecutor(executor.NewAzureOpenAIExecutor(s.cfg))
 	case "kimi":
 		s.coreManager.RegisterExecutor(executor.NewKimiExecutor(s.cfg))
 	default:
@@ -634,6 +643,10 @@ func (s *Service) Run(ctx context.Context) error {
 			switch strategy {
 			case "fill-first", "fillfirst", "ff":
 				return "fill-first"
+			case "latency", "latency-aware", "ewma":
+				return "latency"
+			case "least-inflight", "leastinflight", "li":
+				return "least-inflight"
 			default:
 				return "round-robin"
 			}
@@ -653,6 +666,10 @@ func (s *Service) Run(ctx context.Context) error {
 			switch nextStrategy {
 			case "fill-first":
 				selector = &coreauth.FillFirstSelector{}
+			case "latency":
+				selector = &coreauth.LatencyAwareSelector{}
+			case "least-inflight":
+				selector = &coreauth.LeastInflightSelector{}
 			default:
 				selector = &coreauth.RoundRobinSelector{}
 			}
@@ -706,6 +723,14 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 	log.Info("file watcher started for config and auth directory changes")
 
+	// Replicate cooldowns and cached state with other replicas when a shared backend is configured.
+	if s.coreManager != nil && sharedstate.Enabled() {
+		s.sharedStateStop = s.coreManager.EnableSharedState()
+		if errShared := sharedstate.Start(ctx); errShared != nil {
+			log.Warnf("shared state initial sync failed: %v", errShared)
+		}
+	}
+
 	// Prefer core auth manager auto refresh if available.
 	if s.coreManager != nil {
 		interval := 15 * time.Minute
@@ -749,6 +774,10 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
 		}
+		sharedstate.Stop()
+		if s.sharedStateStop != nil {
+			s.sharedStateStop()
+		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
 				log.Errorf("failed to stop file watcher: %v", err)
@@ -856,7 +885,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 	var models []*ModelInfo
 	switch provider {
 	case "gemini":
-		models = registry.GetGeminiModels()
+		models = append(registry.GetGeminiModels(), registry.GetGeminiEmbeddingModels()...)
 		if entry := s.resolveConfigGeminiKey(a); entry != nil {
 			if len(entry.Models) > 0 {
 				models = buildGeminiConfigModels(entry)
@@ -868,7 +897,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		models = applyExcludedModels(models, excluded)
 	case "vertex":
 		// Vertex AI Gemini supports the same model identifiers as Gemini.
-		models = registry.GetGeminiVertexModels()
+		models = append(registry.GetGeminiVertexModels(), registry.GetGeminiEmbeddingModels()...)
 		if entry := s.resolveConfigVertexCompatKey(a); entry != nil {
 			if len(entry.Models) > 0 {
 				models = buildVertexCompatConfigModels(entry)
@@ -878,6 +907,24 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			}
 		}
 		models = applyExcludedModels(models, excluded)
+	case "bedrock":
+		// Bedrock model IDs are account and region specific, so only configured models are exposed.
+		if entry := s.resolveConfigBedrockKey(a); entry != nil {
+			models = buildBedrockConfigModels(entry)
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
+		}
+		models = applyExcludedModels(models, excluded)
+	case "azure-openai":
+		// Azure exposes deployments rather than models, so only configured deployments are listed.
+		if entry := s.resolveConfigAzureOpenAIKey(a); entry != nil {
+			models = buildAzureOpenAIConfigModels(entry)
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
+		}
+		models = applyExcludedModels(models, excluded)
 	case "gemini-cli":
 		models = registry.GetGeminiCLIModels()
 		models = applyExcludedModels(models, excluded)
@@ -972,6 +1019,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 					isCompatAuth = true
 					// Convert compatibility models to registry models
 					ms := make([]*ModelInfo, 0, len(compat.Models))
+					byID := make(map[string]*ModelInfo, len(compat.Models))
 					for j := range compat.Models {
 						m := compat.Models[j]
 						// Use alias as model ID, fallback to name if alias is empty
@@ -979,20 +1027,35 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 						if modelID == "" {
 							modelID = m.Name
 						}
+						modalities := append([]string(nil), m.InputModalities...)
+						if len(modalities) == 0 {
+							modalities = staticInputModalities(m.Name)
+						}
 						thinking := m.Thinking
 						if thinking == nil {
 							thinking = &registry.ThinkingSupport{Levels: []string{"low", "medium", "high"}}
 						}
-						ms = append(ms, &ModelInfo{
-							ID:          modelID,
-							Object:      "model",
-							Created:     time.Now().Unix(),
-							OwnedBy:     compat.Name,
-							Type:        "openai-compatibility",
-							DisplayName: modelID,
-							UserDefined: false,
-							Thinking:    thinking,
-						})
+						info := &ModelInfo{
+							ID:                       modelID,
+							Object:                   "model",
+							Created:                  time.Now().Unix(),
+							OwnedBy:                  compat.Name,
+							Type:                     "openai-compatibility",
+							DisplayName:              modelID,
+							UserDefined:              false,
+							Thinking:                 thinking,
+							SupportedInputModalities: modalities,
+						}
+						// Members of an alias pool keep their own modalities on the first entry,
+						// the one the registry stores for the alias.
+						upstreamKey := strings.ToLower(strings.TrimSpace(m.Name))
+						if first := byID[modelID]; first != nil {
+							first.UpstreamInputModalities[upstreamKey] = modalities
+						} else {
+							info.UpstreamInputModalities = map[string][]string{upstreamKey: modalities}
+							byID[modelID] = info
+						}
+						ms = append(ms, info)
 					}
 					// Register and return
 					if len(ms) > 0 {
@@ -1237,7 +1300,7 @@ func applyExcludedModels(models []*ModelInfo, excluded []string) []*ModelInfo {
 		modelID := strings.ToLower(strings.TrimSpace(model.ID))
 		blocked := false
 		for _, pattern := range patterns {
-			if matchWildcard(pattern, modelID) {
+			if util.MatchWildcard(pattern, modelID) {
 				blocked = true
 				break
 			}
@@ -1291,50 +1354,6 @@ func applyModelPrefixes(models []*ModelInfo, prefix string, forceModelPrefix boo
 	return out
 }
 
-// matchWildcard performs case-insensitive wildcard matching where '*' matches any substring.
-func matchWildcard(pattern, value string) bool {
-	if pattern == "" {
-		return false
-	}
-
-	// Fast path for exact match (no wildcard present).
-	if !strings.Contains(pattern, "*") {
-		return pattern == value
-	}
-
-	parts := strings.Split(pattern, "*")
-	// Handle prefix.
-	if prefix := parts[0]; prefix != "" {
-		if !strings.HasPrefix(value, prefix) {
-			return false
-		}
-		value = value[len(prefix):]
-	}
-
-	// Handle suffix.
-	if suffix := parts[len(parts)-1]; suffix != "" {
-		if !strings.HasSuffix(value, suffix) {
-			return false
-		}
-		value = value[:len(value)-len(suffix)]
-	}
-
-	// Handle middle segments in order.
-	for i := 1; i < len(parts)-1; i++ {
-		segment := parts[i]
-		if segment == "" {
-			continue
-		}
-		idx := strings.Index(value, segment)
-		if idx < 0 {
-			return false
-		}
-		value = value[idx+len(segment):]
-	}
-
-	return true
-}
-
 type modelEntry interface {
 	GetName() string
 	GetAlias() string
@@ -1376,8 +1395,9 @@ func buildConfigModels[T modelEntry](models []T, ownedBy, modelType string) []*M
 			UserDefined: true,
 		}
 		if name != "" {
-			if upstream := registry.LookupStaticModelInfo(name); upstream != nil && upstream.Thinking != nil {
+			if upstream := registry.LookupStaticModelInfo(name); upstream != nil {
 				info.Thinking = upstream.Thinking
+				info.SupportedInputModalities = append([]string(nil), upstream.SupportedInputModalities...)
 			}
 		}
 		out = append(out, info)
@@ -1385,6 +1405,63 @@ func buildConfigModels[T modelEntry](models []T, ownedBy, modelType string) []*M
 	return out
 }
 
+// staticInputModalities returns the input modalities of a built-in model definition, or nil
+// when name is not a known model or declares none.
+func staticInputModalities(name string) []string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	if info := registry.LookupStaticModelInfo(name); info != nil && len(info.SupportedInputModalities) > 0 {
+		return append([]string(nil), info.SupportedInputModalities...)
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigBedrockKey(auth *coreauth.Auth) *config.BedrockKey {
+	if auth == nil || auth.Attributes == nil || s.cfg == nil {
+		return nil
+	}
+	attrKey := strings.TrimSpace(auth.Attributes["api_key"])
+	attrBase := strings.TrimSpace(auth.Attributes["base_url"])
+	for i := range s.cfg.BedrockKey {
+		entry := &s.cfg.BedrockKey[i]
+		if entry.GetAPIKey() == attrKey && strings.EqualFold(entry.GetBaseURL(), attrBase) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func buildBedrockConfigModels(entry *config.BedrockKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "amazon-bedrock", "bedrock")
+}
+
+func (s *Service) resolveConfigAzureOpenAIKey(auth *coreauth.Auth) *config.AzureOpenAIKey {
+	if auth == nil || auth.Attributes == nil || s.cfg == nil {
+		return nil
+	}
+	attrKey := strings.TrimSpace(auth.Attributes["api_key"])
+	attrBase := strings.TrimSpace(auth.Attributes["base_url"])
+	for i := range s.cfg.AzureOpenAIKey {
+		entry := &s.cfg.AzureOpenAIKey[i]
+		if entry.APIKey == attrKey && strings.EqualFold(entry.BaseURL, attrBase) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func buildAzureOpenAIConfigModels(entry *config.AzureOpenAIKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "azure-openai", "openai")
+}
+
 func buildVertexCompatConfigModels(entry *config.VertexCompatKey) []*ModelInfo {
 	if entry == nil {
 		return nil
@@ -0,0 +1,52 @@
+package cliproxy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestRegisterModelsForAuth_RecordsAliasPoolInputModalities(t *testing.T) {
+	service := &Service{
+		cfg: &config.Config{
+			OpenAICompatibility: []config.OpenAICompatibility{{
+				Name: "pool",
+				Models: []config.OpenAICompatibilityModel{
+					{Name: "Text-Only", Alias: "vision-pool", InputModalities: []string{"TEXT"}},
+					{Name: "vision", Alias: "vision-pool", InputModalities: []string{"TEXT", "IMAGE"}},
+				},
+			}},
+		},
+	}
+	auth := &coreauth.Auth{
+		ID:       "auth-compat-modalities",
+		Provider: "pool",
+		Status:   coreauth.StatusActive,
+		Attributes: map[string]string{
+			"compat_name":  "pool",
+			"provider_key": "pool",
+		},
+	}
+
+	reg := registry.GetGlobalRegistry()
+	reg.UnregisterClient(auth.ID)
+	t.Cleanup(func() {
+		reg.UnregisterClient(auth.ID)
+	})
+
+	service.registerModelsForAuth(auth)
+
+	info := reg.GetClientModelInfo(auth.ID, "vision-pool")
+	if info == nil {
+		t.Fatal("expected vision-pool to be registered")
+	}
+	if got := strings.Join(info.UpstreamInputModalities["text-only"], ","); got != "TEXT" {
+		t.Fatalf("text-only modalities = %q, want TEXT", got)
+	}
+	if got := strings.Join(info.UpstreamInputModalities["vision"], ","); got != "TEXT,IMAGE" {
+		t.Fatalf("vision modalities = %q, want TEXT,IMAGE", got)
+	}
+}
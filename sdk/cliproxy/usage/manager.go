@@ -29,6 +29,15 @@ type Detail struct {
 	ReasoningTokens int64
 	CachedTokens    int64
 	TotalTokens     int64
+	// CacheCreationTokens counts input tokens written to the prompt cache. Only the Claude
+	// response format reports them, separately from InputTokens and CachedTokens.
+	CacheCreationTokens int64
+	// CachedSeparate reports that CachedTokens are not included in InputTokens, as in the
+	// Claude response format.
+	CachedSeparate bool
+	// ReasoningSeparate reports that ReasoningTokens are not included in OutputTokens, as in
+	// the Gemini response format.
+	ReasoningSeparate bool
 }
 
 // Plugin consumes usage records emitted by the proxy runtime.
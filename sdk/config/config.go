@@ -11,10 +11,25 @@ type SDKConfig = internalconfig.SDKConfig
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type ResponsesStoreConfig = internalconfig.ResponsesStoreConfig
+type ResponseCacheConfig = internalconfig.ResponseCacheConfig
+type BatchConfig = internalconfig.BatchConfig
+type ModelPrice = internalconfig.ModelPrice
+type ClientQuotaConfig = internalconfig.ClientQuotaConfig
+type ClientKeyQuota = internalconfig.ClientKeyQuota
+type RedactionConfig = internalconfig.RedactionConfig
+type RedactionPattern = internalconfig.RedactionPattern
+type RedactionKeyMode = internalconfig.RedactionKeyMode
+type ContextGuardConfig = internalconfig.ContextGuardConfig
+type ContextGuardModel = internalconfig.ContextGuardModel
+type ContextGuardKeyPolicy = internalconfig.ContextGuardKeyPolicy
+type ShadowConfig = internalconfig.ShadowConfig
+type ShadowRule = internalconfig.ShadowRule
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
 type OAuthModelAlias = internalconfig.OAuthModelAlias
+type ModelFallback = internalconfig.ModelFallback
 type PayloadConfig = internalconfig.PayloadConfig
 type PayloadRule = internalconfig.PayloadRule
 type PayloadFilterRule = internalconfig.PayloadFilterRule
@@ -25,6 +40,10 @@ type CodexKey = internalconfig.CodexKey
 type ClaudeKey = internalconfig.ClaudeKey
 type VertexCompatKey = internalconfig.VertexCompatKey
 type VertexCompatModel = internalconfig.VertexCompatModel
+type BedrockKey = internalconfig.BedrockKey
+type BedrockModel = internalconfig.BedrockModel
+type AzureOpenAIKey = internalconfig.AzureOpenAIKey
+type AzureOpenAIModel = internalconfig.AzureOpenAIModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
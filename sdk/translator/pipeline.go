@@ -68,17 +68,7 @@ func (p *Pipeline) TranslateRequest(ctx context.Context, from, to Format, req Re
 		input.Format = to
 		return input, nil
 	}
-
-	handler := terminal
-	for i := len(p.requestMiddleware) - 1; i >= 0; i-- {
-		mw := p.requestMiddleware[i]
-		next := handler
-		handler = func(ctx context.Context, r RequestEnvelope) (RequestEnvelope, error) {
-			return mw(ctx, r, next)
-		}
-	}
-
-	return handler(ctx, req)
+	return p.requestChain(terminal)(ctx, req)
 }
 
 // TranslateResponse applies middleware and registry transformations.
@@ -92,7 +82,53 @@ func (p *Pipeline) TranslateResponse(ctx context.Context, from, to Format, resp
 		input.Format = to
 		return input, nil
 	}
+	return p.responseChain(terminal)(ctx, resp)
+}
 
+// ProcessRequest runs the request middleware without translating between formats.
+func (p *Pipeline) ProcessRequest(ctx context.Context, req RequestEnvelope) (RequestEnvelope, error) {
+	terminal := func(ctx context.Context, input RequestEnvelope) (RequestEnvelope, error) {
+		return input, nil
+	}
+	return p.requestChain(terminal)(ctx, req)
+}
+
+// ProcessResponse runs the response middleware without translating between formats.
+// For streaming envelopes the terminal handler emits Body as the single chunk when no
+// middleware populated Chunks.
+func (p *Pipeline) ProcessResponse(ctx context.Context, resp ResponseEnvelope) (ResponseEnvelope, error) {
+	terminal := func(ctx context.Context, input ResponseEnvelope) (ResponseEnvelope, error) {
+		if input.Stream && input.Chunks == nil {
+			input.Chunks = [][]byte{input.Body}
+		}
+		return input, nil
+	}
+	return p.responseChain(terminal)(ctx, resp)
+}
+
+// HasRequestMiddleware reports whether any request middleware is registered.
+func (p *Pipeline) HasRequestMiddleware() bool {
+	return p != nil && len(p.requestMiddleware) > 0
+}
+
+// HasResponseMiddleware reports whether any response middleware is registered.
+func (p *Pipeline) HasResponseMiddleware() bool {
+	return p != nil && len(p.responseMiddleware) > 0
+}
+
+func (p *Pipeline) requestChain(terminal RequestHandler) RequestHandler {
+	handler := terminal
+	for i := len(p.requestMiddleware) - 1; i >= 0; i-- {
+		mw := p.requestMiddleware[i]
+		next := handler
+		handler = func(ctx context.Context, r RequestEnvelope) (RequestEnvelope, error) {
+			return mw(ctx, r, next)
+		}
+	}
+	return handler
+}
+
+func (p *Pipeline) responseChain(terminal ResponseHandler) ResponseHandler {
 	handler := terminal
 	for i := len(p.responseMiddleware) - 1; i >= 0; i-- {
 		mw := p.responseMiddleware[i]
@@ -101,6 +137,5 @@ func (p *Pipeline) TranslateResponse(ctx context.Context, from, to Format, resp
 			return mw(ctx, r, next)
 		}
 	}
-
-	return handler(ctx, resp)
+	return handler
 }